@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"content-verify-log/config"
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/service"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewBenchCommand() *cobra.Command {
+	var configFilePaths []string
+	var flagCfg config.MigrationConfig
+	var corpus, sink, format string
+	var duration time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "测量迁移流程的端到端吞吐",
+		Long: "以只读方式打开语料 DuckDB（如 genfixtures 生成的文件），按迁移流程分批读取、解析、并发处理并写入，运行指定时长后输出吞吐、单篇处理耗时、内存分配和峰值内存；" +
+			"接受与 migrate 相同的批量、worker 和处理参数，结果可直接用于调整迁移配置。--sink duckdb/parquet 时写入临时文件，结束后删除",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			migrationCfg := mergeMigrationFlags(cmd, cfg.MigrationConfig, &flagCfg, provenance)
+			cfg.MigrationConfig = migrationCfg
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
+			}
+			if _, err := applyLogging(cmd, cfg.LoggingConfig); err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
+
+			format = strings.ToLower(format)
+			if format != "table" && format != "json" {
+				return configError(fmt.Errorf("不支持的输出格式 %q，可选 table/json", format))
+			}
+			if sink != service.BenchSinkDiscard && sink != service.BenchSinkDuckDB && sink != service.BenchSinkParquet {
+				return configError(fmt.Errorf("不支持的写入目标 %q，可选 %s/%s/%s", sink, service.BenchSinkDiscard, service.BenchSinkDuckDB, service.BenchSinkParquet))
+			}
+
+			ctx := cmd.Context()
+			// 语料只读打开，基准测试不会修改其中的数据
+			if err := db.InitDuckDBReadOnly(&config.DuckDBConfig{DBPath: corpus}); err != nil {
+				return connectivityError(fmt.Errorf("打开语料 %s 失败:%w", corpus, err))
+			}
+
+			// 写入 DuckDB 时使用临时文件，避免测量结果受语料文件的锁和大小影响
+			sinkDB := db.GetDuckDB()
+			if sink == service.BenchSinkDuckDB {
+				dir, err := os.MkdirTemp("", "cvl-bench-")
+				if err != nil {
+					return fmt.Errorf("创建临时目录失败:%w", err)
+				}
+				defer os.RemoveAll(dir)
+				sinkDB, err = db.OpenDuckDB(filepath.Join(dir, "bench.duckdb"))
+				if err != nil {
+					return connectivityError(fmt.Errorf("打开临时 DuckDB 失败:%w", err))
+				}
+				defer sinkDB.Close()
+			}
+
+			migrationOpts := newMigrationOptions(migrationCfg)
+			zap.S().Infof("基准测试: 语料 %s, 写入 %s, 时长 %s, 批量 %d, worker %d", corpus, sink, duration, migrationCfg.BatchSize, migrationCfg.Workers)
+			result, err := service.NewMigrationServiceWithDB(migrationOpts, db.GetDuckDB(), sinkDB).Bench(ctx, service.BenchOptions{
+				Duration:  duration,
+				BatchSize: migrationCfg.BatchSize,
+				Sink:      sink,
+			})
+			if err != nil {
+				return withCause(ctx, fmt.Errorf("基准测试失败:%w", err))
+			}
+
+			if format == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(result)
+			}
+			return printBenchResult(cmd, result)
+		},
+	}
+
+	addConfigFlag(cmd, &configFilePaths)
+	addProcessingFlags(cmd, &flagCfg)
+	cmd.Flags().StringVar(&corpus, "corpus", "fixtures.duckdb", "语料 DuckDB 文件，需包含 tbl_verify_content 表")
+	cmd.Flags().DurationVar(&duration, "duration", time.Minute, "运行时长，语料读完后从头循环")
+	cmd.Flags().StringVar(&sink, "sink", service.BenchSinkDiscard, "写入目标：discard 丢弃结果，duckdb 写入临时 DuckDB 文件，parquet 写入临时 Parquet 文件")
+	cmd.Flags().StringVarP(&format, "output", "o", "table", "输出格式：table/json")
+	return cmd
+}
+
+// printBenchResult 以表格输出基准测试结果
+func printBenchResult(cmd *cobra.Command, r *service.BenchResult) error {
+	peakRSS := "-"
+	if r.PeakRSS > 0 {
+		peakRSS = fmt.Sprintf("%.1f MB", float64(r.PeakRSS)/(1<<20))
+	}
+	batchSize := fmt.Sprint(r.BatchSize)
+	if r.BatchSizeMax > 0 {
+		batchSize = fmt.Sprintf("%d（自适应 %d-%d）", r.BatchSize, r.BatchSizeMin, r.BatchSizeMax)
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for _, row := range [][2]string{
+		{"写入目标", r.Sink},
+		{"worker 数", fmt.Sprint(r.Workers)},
+		{"批量大小", batchSize},
+		{"运行时长", r.Elapsed.Round(time.Millisecond).String()},
+		{"语料轮数", fmt.Sprint(r.Passes)},
+		{"处理记录数", fmt.Sprint(r.Rows)},
+		{"无法解析", fmt.Sprint(r.Invalid)},
+		{"应用修正数", fmt.Sprint(r.Corrections)},
+		{"记录/秒", fmt.Sprintf("%.1f", r.RowsPerSec)},
+		{"修正/秒", fmt.Sprintf("%.1f", r.CorrectionsPerSec)},
+		{"单篇耗时 p50", r.LatencyP50.String()},
+		{"单篇耗时 p95", r.LatencyP95.String()},
+		{"单篇耗时 max", r.LatencyMax.String()},
+		{"每篇分配次数", fmt.Sprintf("%.0f", r.AllocsPerDoc)},
+		{"每篇分配字节", fmt.Sprintf("%.0f", r.BytesPerDoc)},
+		{"峰值内存", peakRSS},
+	} {
+		fmt.Fprintf(w, "%s\t%s\n", row[0], row[1])
+	}
+	return w.Flush()
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"content-verify-log/config"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// secretKeyRegex 匹配需要脱敏的配置项名称
+var secretKeyRegex = regexp.MustCompile(`(?i)(password|secret|token|dsn|accesskey)`)
+
+// addConfigFlag 注册可重复指定的 --config 参数及 --init-missing-config
+func addConfigFlag(cmd *cobra.Command, configFilePaths *[]string) {
+	cmd.Flags().StringArrayVarP(configFilePaths, "config", "c", []string{"./etc/config.yaml"}, "配置文件路径，可重复指定，后面的文件深度合并覆盖前面的")
+	cmd.Flags().Bool("init-missing-config", false, "配置文件不存在时写入带注释的默认配置，本次使用默认值和环境变量继续运行")
+}
+
+// flagOverride 命令行参数与配置 key 的对应关系
+type flagOverride struct {
+	flag  string // 参数名
+	key   string // 配置 key，如 migration.batchSize
+	apply func() // 用参数值覆盖合并结果
+}
+
+// applyFlagOverrides 应用显式指定的命令行参数，并在 provenance 中记录来源
+func applyFlagOverrides(cmd *cobra.Command, provenance config.Provenance, overrides []flagOverride) {
+	for _, o := range overrides {
+		if !cmd.Flags().Changed(o.flag) {
+			continue
+		}
+		o.apply()
+		if provenance != nil {
+			provenance.SetFlag(o.key, o.flag)
+		}
+	}
+}
+
+// loadConfig 加载配置并计算每个 key 的来源
+func loadConfig(cmd *cobra.Command, configFilePaths []string) (*config.GlobalConfig, config.Provenance, error) {
+	configFilePaths, err := existingConfigPaths(cmd, configFilePaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := config.TryLoadFromDisk(configFilePaths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	provenance, err := config.LoadProvenance(configFilePaths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, provenance, nil
+}
+
+// existingConfigPaths 返回存在的配置文件路径
+// 指定了 --init-missing-config 时为不存在的路径写入默认配置并跳过该路径，本次以默认值和环境变量运行
+func existingConfigPaths(cmd *cobra.Command, configFilePaths []string) ([]string, error) {
+	initMissing, _ := cmd.Flags().GetBool("init-missing-config")
+	existing := make([]string, 0, len(configFilePaths))
+	for _, path := range configFilePaths {
+		_, err := os.Stat(path)
+		if err == nil {
+			existing = append(existing, path)
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if !initMissing {
+			return nil, fmt.Errorf("配置文件 %s 不存在，可使用 --init-missing-config 写入默认配置并以默认值运行", path)
+		}
+		if err := config.WriteDefaultConfig(path); err != nil {
+			return nil, err
+		}
+		zap.S().Infof("配置文件 %s 不存在，已写入默认配置，本次使用默认值和环境变量", path)
+	}
+	return existing, nil
+}
+
+// validateConfig 校验配置，错误信息附带相关 key 的来源
+func validateConfig(cfg *config.GlobalConfig, provenance config.Provenance) error {
+	errs := cfg.Validate()
+	if len(errs) == 0 {
+		return nil
+	}
+	for i, err := range errs {
+		errs[i] = provenance.Annotate(err)
+	}
+	return errors.Join(errs...)
+}
+
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "查看配置",
+	}
+	cmd.AddCommand(newConfigShowCommand())
+	return cmd
+}
+
+func newConfigShowCommand() *cobra.Command {
+	var configFilePaths []string
+	var redactSecrets bool
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "打印合并后的生效配置及每项的来源",
+		Long:  "按顺序合并默认值、所有 --config 文件、CVL_ 环境变量和日志参数，打印最终生效的配置，并标注每项来自 default/file/env/flag",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			cfg.LoggingConfig = mergeLoggingFlags(cmd, cfg.LoggingConfig, provenance)
+			if err := validateConfig(cfg, provenance); err != nil {
+				zap.S().Warnf("本地配置文件验证错误:%s", err)
+			}
+
+			var node yaml.Node
+			if err := node.Encode(cfg); err != nil {
+				return fmt.Errorf("序列化配置失败:%w", err)
+			}
+			redactSecretRefs(&node, provenance)
+			if redactSecrets {
+				redactNode(&node)
+			}
+
+			var out []byte
+			switch strings.ToLower(format) {
+			case "yaml":
+				annotateNode(&node, provenance)
+				out, err = yaml.Marshal(&node)
+			case "json":
+				var resolved interface{}
+				if err = node.Decode(&resolved); err == nil {
+					out, err = json.MarshalIndent(map[string]interface{}{
+						"config":     resolved,
+						"provenance": provenance,
+					}, "", "  ")
+					out = append(out, '\n')
+				}
+			default:
+				err = fmt.Errorf("不支持的输出格式 %q，可选 yaml/json", format)
+			}
+			if err != nil {
+				return fmt.Errorf("序列化配置失败:%w", err)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), string(out))
+			return nil
+		},
+	}
+
+	addConfigFlag(cmd, &configFilePaths)
+	cmd.Flags().BoolVar(&redactSecrets, "redact-secrets", false, "隐藏密码、令牌、DSN 等敏感配置项的值")
+	cmd.Flags().StringVarP(&format, "output", "o", "yaml", "输出格式：yaml/json")
+	return cmd
+}
+
+// redactNode 将名称匹配 secretKeyRegex 的非空标量值替换为 ******
+func redactNode(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Value != "" && secretKeyRegex.MatchString(key.Value) {
+				value.Value = "******"
+				value.Tag = "!!str"
+				continue
+			}
+			redactNode(value)
+		}
+		return
+	}
+	for _, child := range node.Content {
+		redactNode(child)
+	}
+}
+
+// walkLeaves 遍历所有叶子配置项，path 为 yaml 标签路径（如 migration.taskIds）
+func walkLeaves(node *yaml.Node, prefix string, fn func(path string, key, value *yaml.Node)) {
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			walkLeaves(child, prefix, fn)
+		}
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := key.Value
+		if prefix != "" {
+			path = prefix + "." + key.Value
+		}
+		if value.Kind == yaml.MappingNode {
+			walkLeaves(value, path, fn)
+			continue
+		}
+		fn(path, key, value)
+	}
+}
+
+// redactSecretRefs 隐藏来自密钥引用的值，无论是否指定 --redact-secrets
+func redactSecretRefs(node *yaml.Node, provenance config.Provenance) {
+	walkLeaves(node, "", func(path string, key, value *yaml.Node) {
+		if !provenance.Of(path).Secret {
+			return
+		}
+		*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "******"}
+	})
+}
+
+// redactedJSON 将配置段序列化为单行 JSON 用于日志，来自密钥引用的值会被隐藏
+func redactedJSON(section string, value interface{}, provenance config.Provenance) string {
+	var node yaml.Node
+	if err := node.Encode(map[string]interface{}{section: value}); err != nil {
+		return err.Error()
+	}
+	redactSecretRefs(&node, provenance)
+	var redacted map[string]interface{}
+	if err := node.Decode(&redacted); err != nil {
+		return err.Error()
+	}
+	out, err := json.Marshal(redacted[section])
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// annotateNode 在每个叶子配置项后以行尾注释标注来源
+func annotateNode(node *yaml.Node, provenance config.Provenance) {
+	walkLeaves(node, "", func(path string, key, value *yaml.Node) {
+		comment := provenance.Of(path).String()
+		if value.Kind == yaml.SequenceNode && len(value.Content) == 0 {
+			// 空列表以 [] 输出，注释需挂在值上才会保留
+			value.LineComment = comment
+			return
+		}
+		key.LineComment = comment
+	})
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"content-verify-log/config"
+)
+
+// TestConfigShowLayered config show 打印多个 --config 深度合并后的配置及每项的来源，--redact-secrets 隐藏敏感配置项
+func TestConfigShowLayered(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	prod := filepath.Join(dir, "config.prod.yaml")
+	for path, body := range map[string]string{
+		base: "migration:\n  batchSize: 200\n  taskIds: [b1, b2]\nnotifications:\n  bearerToken: s3cret\nlogging:\n  level: error\n  output: stderr\n",
+		prod: "duckdb:\n  dbPath: /data/prod.duckdb\nmigration:\n  taskIds: [p1]\n",
+	} {
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	root := NewRootCommand()
+	root.SetArgs([]string{"config", "show", "-c", base, "-c", prod, "--redact-secrets", "-o", "json"})
+	root.SetOut(&out)
+	if code := ExitCode(root.ExecuteContext(context.Background())); code != ExitOK {
+		t.Fatalf("退出码 %d", code)
+	}
+
+	var shown struct {
+		Config struct {
+			DuckDB        map[string]interface{} `json:"duckdb"`
+			Migration     map[string]interface{} `json:"migration"`
+			Notifications map[string]interface{} `json:"notifications"`
+		} `json:"config"`
+		Provenance config.Provenance `json:"provenance"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &shown); err != nil {
+		t.Fatalf("输出不是 JSON: %v\n%s", err, out.String())
+	}
+	if got := shown.Config.DuckDB["dbPath"]; got != "/data/prod.duckdb" {
+		t.Errorf("duckdb.dbPath = %v", got)
+	}
+	if got := shown.Config.Migration["batchSize"]; got != 200.0 {
+		t.Errorf("migration.batchSize = %v", got)
+	}
+	if got := shown.Config.Migration["taskIds"]; !reflect.DeepEqual(got, []interface{}{"p1"}) {
+		t.Errorf("migration.taskIds 应被后面的文件整体替换，得到 %v", got)
+	}
+	if got := shown.Config.Notifications["bearerToken"]; got != "******" {
+		t.Errorf("notifications.bearerToken 应被隐藏，得到 %v", got)
+	}
+	if bytes.Contains(out.Bytes(), []byte("s3cret")) {
+		t.Error("输出中不应出现密钥")
+	}
+	for key, want := range map[string]config.Origin{
+		"migration.taskIds":   {Source: config.SourceFile, Detail: prod},
+		"migration.batchSize": {Source: config.SourceFile, Detail: base},
+		"migration.workers":   {Source: config.SourceDefault},
+	} {
+		if got := shown.Provenance.Of(key); got != want {
+			t.Errorf("%s 的来源为 %v，应为 %v", key, got, want)
+		}
+	}
+}
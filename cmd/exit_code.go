@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"content-verify-log/pkg/service"
+	"content-verify-log/pkg/signals"
+)
+
+// 进程退出码，供调度系统区分失败类型
+const (
+	ExitOK           = 0   // 成功
+	ExitError        = 1   // 其他运行时错误
+	ExitConfig       = 2   // 配置错误（文件缺失、解析或校验失败，或要读取的表不存在）
+	ExitConnectivity = 3   // 无法连接数据库
+	ExitAborted      = 4   // 迁移因失败行的比例超过 migration.maxErrorRate 而中止
+	ExitInterrupted  = 130 // 收到退出信号而中断
+)
+
+// exitError 携带退出码的错误
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// configError 标记配置错误
+func configError(err error) error {
+	return &exitError{code: ExitConfig, err: err}
+}
+
+// connectivityError 标记数据库连接错误
+func connectivityError(err error) error {
+	return &exitError{code: ExitConnectivity, err: err}
+}
+
+// ExitCode 返回错误对应的退出码；中断优先于其他分类，因为中断时的错误往往只是取消的连带结果
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, signals.ErrShutdownSignal) || errors.Is(err, context.Canceled) {
+		return ExitInterrupted
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	if errors.Is(err, service.ErrAborted) {
+		return ExitAborted
+	}
+	// 表不存在通常是表名写错或尚未迁移，与配置错误同样需要修改参数后重试
+	if errors.Is(err, service.ErrTableNotFound) {
+		return ExitConfig
+	}
+	return ExitError
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"content-verify-log/config"
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/service"
+	"content-verify-log/pkg/util"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewExportCommand() *cobra.Command {
+	var configFilePaths []string
+	var flagCfg config.OutputConfig
+	var table string
+	var countOnly bool
+	var resume bool
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出处理结果",
+		Long:  "以只读方式打开 DuckDB，按 output 配置将处理结果表导出为 parquet/csv/jsonl 文件；--dir 为 s3:// 或 oss:// 地址时导出后上传到对象存储",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			cfg.OutputConfig = mergeOutputFlags(cmd, cfg.OutputConfig, &flagCfg, provenance)
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
+			}
+			if _, err := applyLogging(cmd, cfg.LoggingConfig); err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
+
+			if cfg.DuckDBConfig == nil {
+				return configError(errors.New("DuckDB 配置未设置"))
+			}
+			if table == "" && cfg.MigrationConfig != nil {
+				table = cfg.MigrationConfig.TargetTable
+			}
+
+			if resume && (util.IsObjectURL(cfg.OutputConfig.Dir) || cfg.OutputConfig.Format == config.OutputFormatParquet || cfg.OutputConfig.RolloverSize != "") {
+				return configError(errors.New("--resume 只支持导出到本地目录、不拆分的 csv/jsonl 格式"))
+			}
+
+			ctx := cmd.Context()
+			// 导出只读取数据，以只读方式打开以免与正在写入的进程冲突
+			if err := db.InitDuckDBReadOnly(cfg.DuckDBConfig); err != nil {
+				return connectivityError(fmt.Errorf("DuckDB 连接错误:%w", err))
+			}
+			if countOnly {
+				count, err := service.NewExportService(newExportOptions(cfg.OutputConfig)).Count(ctx, table)
+				if err != nil {
+					return withCause(ctx, fmt.Errorf("统计失败:%w", err))
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), count)
+				return nil
+			}
+			if resume {
+				return exportResumable(ctx, cfg.OutputConfig, table)
+			}
+			if err := exportTable(ctx, cfg.OutputConfig, table); err != nil {
+				return withCause(ctx, fmt.Errorf("导出失败:%w", err))
+			}
+			return nil
+		},
+	}
+
+	defaults := config.NewDefaultOutputConfig()
+	addConfigFlag(cmd, &configFilePaths)
+	cmd.Flags().StringVar(&table, "table", "", "要导出的表，默认使用 migration.targetTable")
+	cmd.Flags().StringVar(&flagCfg.Dir, "dir", defaults.Dir, "导出目录，可为 s3://bucket/prefix 或 oss://bucket/prefix")
+	cmd.Flags().StringVar(&flagCfg.Format, "format", defaults.Format, "导出格式：parquet/csv/jsonl")
+	cmd.Flags().StringVar(&flagCfg.RolloverSize, "rollover-size", "", "单个文件的大小上限，如 256MB")
+	cmd.Flags().BoolVar(&flagCfg.Gzip, "gzip", false, "csv/jsonl 使用 gzip 压缩")
+	cmd.Flags().BoolVar(&flagCfg.Manifest, "manifest", false, "导出完成后在导出目录写入描述输出的 manifest.json")
+	cmd.Flags().BoolVar(&countOnly, "count-only", false, "只输出将导出的行数，不写入文件")
+	cmd.Flags().BoolVar(&resume, "resume", false, "按 id 分块导出并在输出文件旁记录进度，中断后加同样的参数再次运行时从上次的位置继续（仅 csv/jsonl）")
+	return cmd
+}
+
+// mergeOutputFlags 以配置文件（缺省时为默认值）为基础，用显式指定的命令行参数覆盖
+func mergeOutputFlags(cmd *cobra.Command, fileCfg *config.OutputConfig, flagCfg *config.OutputConfig, provenance config.Provenance) *config.OutputConfig {
+	merged := config.NewDefaultOutputConfig()
+	if fileCfg != nil {
+		*merged = *fileCfg
+	}
+
+	applyFlagOverrides(cmd, provenance, []flagOverride{
+		{flag: "dir", key: "output.dir", apply: func() { merged.Dir = flagCfg.Dir }},
+		{flag: "format", key: "output.format", apply: func() { merged.Format = flagCfg.Format }},
+		{flag: "rollover-size", key: "output.rolloverSize", apply: func() { merged.RolloverSize = flagCfg.RolloverSize }},
+		{flag: "gzip", key: "output.gzip", apply: func() { merged.Gzip = flagCfg.Gzip }},
+		{flag: "manifest", key: "output.manifest", apply: func() { merged.Manifest = flagCfg.Manifest }},
+	})
+	return merged
+}
+
+// newExportOptions 将导出配置转换为服务层选项，配置需已通过校验
+func newExportOptions(cfg *config.OutputConfig) service.ExportOptions {
+	opts := service.ExportOptions{
+		Dir:      cfg.Dir,
+		Format:   cfg.Format,
+		Gzip:     cfg.Gzip,
+		Manifest: cfg.Manifest,
+	}
+	if cfg.RolloverSize != "" {
+		opts.RolloverBytes, _ = util.ParseByteSize(cfg.RolloverSize)
+	}
+	if cfg.CSV != nil {
+		opts.CSVDelimiter = cfg.CSV.Delimiter
+		opts.ExcelBOM = cfg.CSV.ExcelBOM
+	}
+	if cfg.Parquet != nil {
+		opts.ParquetCompression = cfg.Parquet.Compression
+	}
+	if cfg.ObjectStore != nil {
+		opts.ObjectStore = service.ObjectStoreOptions{
+			Endpoint: cfg.ObjectStore.Endpoint,
+			Region:   cfg.ObjectStore.Region,
+			Bucket:   cfg.ObjectStore.Bucket,
+		}
+	}
+	return opts
+}
+
+// exportTable 按导出配置导出表，migrate 的 --export 也复用该流程，保证两个入口行为一致
+func exportTable(ctx context.Context, cfg *config.OutputConfig, table string) error {
+	result, err := service.NewExportService(newExportOptions(cfg)).Export(ctx, table)
+	if err != nil {
+		return err
+	}
+	zap.S().Infof("导出完成: %d 行, %d 个文件", result.Rows, len(result.Files))
+	if len(result.Objects) > 0 {
+		for i, object := range result.Objects {
+			zap.S().Infof("  %s（对象 %s, %d 字节）", result.Files[i], object.Key, object.Size)
+		}
+	} else {
+		for _, file := range result.Files {
+			zap.S().Infof("  %s", file)
+		}
+	}
+	if result.Manifest != "" {
+		zap.S().Infof("清单已写入 %s", result.Manifest)
+	}
+	return nil
+}
+
+// exportResumable 可续传导出，中断时保留已导出的部分和进度文件，再次运行时继续
+func exportResumable(ctx context.Context, cfg *config.OutputConfig, table string) error {
+	opts := newExportOptions(cfg)
+	opts.Resume = true
+	result, err := service.NewExportService(opts).Export(ctx, table)
+	if err != nil {
+		return withCause(ctx, fmt.Errorf("导出失败（加 --resume 再次运行可从中断处继续）:%w", err))
+	}
+	zap.S().Infof("导出完成: %d 行, 已写入 %s", result.Rows, result.Files[0])
+	if result.Manifest != "" {
+		zap.S().Infof("清单已写入 %s", result.Manifest)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/service"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewExportCorrectionsCommand() *cobra.Command {
+	var configFilePaths []string
+	var table, out string
+	var explanationMaxLength int
+
+	cmd := &cobra.Command{
+		Use:   "export-corrections",
+		Short: "导出已应用的修正",
+		Long:  "以只读方式打开 DuckDB，将处理结果表中已应用的修正逐条导出为 CSV（pid, position, error_word, correct_word, type_name, context, explanation），便于在表格中审阅",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
+			}
+			if _, err := applyLogging(cmd, cfg.LoggingConfig); err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
+
+			if out == "" {
+				return configError(errors.New("必须用 --out 指定输出文件（- 表示标准输出）"))
+			}
+			if explanationMaxLength < 0 {
+				return configError(errors.New("--explanation-max-length 不能为负数"))
+			}
+			if cfg.DuckDBConfig == nil {
+				return configError(errors.New("DuckDB 配置未设置"))
+			}
+			if table == "" && cfg.MigrationConfig != nil {
+				table = cfg.MigrationConfig.TargetTable
+			}
+
+			ctx := cmd.Context()
+			if err := db.InitDuckDBReadOnly(cfg.DuckDBConfig); err != nil {
+				return connectivityError(fmt.Errorf("DuckDB 连接错误:%w", err))
+			}
+
+			// 分隔符和 BOM 沿用 output.csv 配置
+			exportOpts := newExportOptions(cfg.OutputConfig)
+			exportOpts.ExplanationMaxLength = explanationMaxLength
+			exportService := service.NewExportService(exportOpts)
+			if out == "-" {
+				count, err := exportService.ExportCorrections(ctx, table, cmd.OutOrStdout())
+				if err != nil {
+					return withCause(ctx, fmt.Errorf("导出修正失败:%w", err))
+				}
+				zap.S().Infof("导出完成: %d 条修正", count)
+				return nil
+			}
+			count, err := exportCorrectionsFile(ctx, exportService, table, out)
+			if err != nil {
+				return withCause(ctx, fmt.Errorf("导出修正失败:%w", err))
+			}
+			zap.S().Infof("导出完成: %d 条修正, 已写入 %s", count, out)
+			return nil
+		},
+	}
+
+	addConfigFlag(cmd, &configFilePaths)
+	cmd.Flags().StringVar(&table, "table", "", "要导出的表，默认使用 migration.targetTable")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "输出的 CSV 文件，- 表示标准输出")
+	cmd.Flags().IntVar(&explanationMaxLength, "explanation-max-length", 0, "explanation 列的最大字符数，超出部分截断并以 … 标注，0 表示不截断")
+	return cmd
+}
+
+// exportCorrectionsFile 导出到文件，失败或中断时删除写了一半的文件
+func exportCorrectionsFile(ctx context.Context, exportService *service.ExportService, table, path string) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	count, err := exportService.ExportCorrections(ctx, table, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return 0, err
+	}
+	return count, nil
+}
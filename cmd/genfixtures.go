@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/fixture"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewGenFixturesCommand() *cobra.Command {
+	opts := fixture.NewDefaultOptions()
+	var out string
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "genfixtures",
+		Short: "生成合成测试数据",
+		Long: "按指定的长度分布、错误密度、错误标记和非常规写法比例，用固定随机种子合成新旧两种格式的文章，" +
+			"写入 DuckDB 的 tbl_verify_content 表（--out 以 .duckdb 结尾）或 JSONL 文件（--out 以 .jsonl 结尾），用于测试和性能评估",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return configError(err)
+			}
+			start := time.Now()
+			gen := fixture.NewGenerator(opts)
+
+			var count int
+			switch strings.ToLower(filepath.Ext(out)) {
+			case ".duckdb", ".db":
+				duckDB, err := db.OpenDuckDB(out)
+				if err != nil {
+					return connectivityError(fmt.Errorf("打开 DuckDB %s 失败:%w", out, err))
+				}
+				defer duckDB.Close()
+				ctx := cmd.Context()
+				count, err = fixture.WriteDuckDB(ctx, duckDB, gen, overwrite)
+				if err != nil {
+					return withCause(ctx, fmt.Errorf("写入合成数据失败:%w", err))
+				}
+			case ".jsonl":
+				flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+				if overwrite {
+					flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+				}
+				f, err := os.OpenFile(out, flags, 0644)
+				if err != nil {
+					return fmt.Errorf("创建文件失败（已存在时可使用 --overwrite）:%w", err)
+				}
+				count, err = fixture.WriteJSONL(f, gen)
+				if closeErr := f.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					return fmt.Errorf("写入合成数据失败:%w", err)
+				}
+			default:
+				return configError(fmt.Errorf("不支持的输出文件 %q，扩展名应为 .duckdb 或 .jsonl", out))
+			}
+
+			zap.S().Infof("已生成 %d 行合成数据到 %s（种子 %d），耗时 %s", count, out, opts.Seed, time.Since(start))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "fixtures.duckdb", "输出文件，.duckdb 写入 tbl_verify_content 表，.jsonl 每行一条记录")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "输出的表或文件已存在时覆盖")
+	cmd.Flags().IntVar(&opts.Rows, "rows", opts.Rows, "生成的行数")
+	cmd.Flags().Uint64Var(&opts.Seed, "seed", opts.Seed, "随机种子，相同参数和种子生成相同的数据")
+	cmd.Flags().IntVar(&opts.Tasks, "tasks", opts.Tasks, "任务 ID 的数量")
+	cmd.Flags().Float64Var(&opts.NewFormatRatio, "new-format-ratio", opts.NewFormatRatio, "新格式（replace_text + checklist）所占比例")
+	cmd.Flags().IntVar(&opts.MinLength, "min-length", opts.MinLength, "每篇文章可见文本的最少字符数")
+	cmd.Flags().IntVar(&opts.MaxLength, "max-length", opts.MaxLength, "每篇文章可见文本的最多字符数")
+	cmd.Flags().Float64Var(&opts.ErrorDensity, "error-density", opts.ErrorDensity, "每 100 个字符的平均错误数")
+	cmd.Flags().Float64Var(&opts.MarkerRate, "marker-rate", opts.MarkerRate, "错误词带错误标记 span 的比例")
+	cmd.Flags().Float64Var(&opts.QuirkRate, "quirk-rate", opts.QuirkRate, "非常规写法（数字写成字符串、数组写成 JSON 字符串、HTML 实体等）的比例")
+	cmd.Flags().Float64Var(&opts.InvalidRate, "invalid-rate", opts.InvalidRate, "无法解析的行所占比例")
+	return cmd
+}
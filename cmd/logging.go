@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"content-verify-log/config"
+	"content-verify-log/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+// addLoggingFlags 添加日志相关的全局参数，优先级高于配置文件
+func addLoggingFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("log-level", "", "日志级别 debug/info/warn/error（覆盖配置文件）")
+	cmd.PersistentFlags().String("log-format", "", "日志格式 console/json（覆盖配置文件）")
+	cmd.PersistentFlags().String("log-output", "", "日志输出 stdout/stderr 或文件路径（覆盖配置文件）")
+}
+
+// mergeLoggingFlags 以配置文件（缺省时为默认值）为基础，用显式指定的日志参数覆盖
+func mergeLoggingFlags(cmd *cobra.Command, fileCfg *config.LoggingConfig, provenance config.Provenance) *config.LoggingConfig {
+	cfg := config.NewDefaultLoggingConfig()
+	if fileCfg != nil {
+		*cfg = *fileCfg
+	}
+	stringFlag := func(flag string, target *string) func() {
+		return func() { *target, _ = cmd.Flags().GetString(flag) }
+	}
+	applyFlagOverrides(cmd, provenance, []flagOverride{
+		{flag: "log-level", key: "logging.level", apply: stringFlag("log-level", &cfg.Level)},
+		{flag: "log-format", key: "logging.format", apply: stringFlag("log-format", &cfg.Format)},
+		{flag: "log-output", key: "logging.output", apply: stringFlag("log-output", &cfg.Output)},
+	})
+	return cfg
+}
+
+// initLogging 按默认值和日志参数初始化全局 logger，在读取配置文件之前执行，保证加载配置时的错误也能输出
+// 返回的函数用于退出前刷新缓冲
+func initLogging(cmd *cobra.Command) (func(), error) {
+	cfg := mergeLoggingFlags(cmd, nil, nil)
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return log.Init(cfg.Level, cfg.Format, cfg.Output)
+}
+
+// applyLogging 合并配置文件与命令行参数中的日志配置，重新构建并替换全局 logger
+// 返回合并后的配置
+func applyLogging(cmd *cobra.Command, fileCfg *config.LoggingConfig) (*config.LoggingConfig, error) {
+	cfg := mergeLoggingFlags(cmd, fileCfg, nil)
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if _, err := log.Init(cfg.Level, cfg.Format, cfg.Output); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// withCause 上下文已取消时在错误后附加取消原因，区分收到退出信号和超时
+func withCause(ctx context.Context, err error) error {
+	cause := context.Cause(ctx)
+	if cause == nil || errors.Is(err, cause) {
+		return err
+	}
+	return fmt.Errorf("%w（%w）", err, cause)
+}
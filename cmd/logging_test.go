@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"content-verify-log/config"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// TestApplyLogging 解析配置文件中的 logging 段并构建全局 logger，显式指定的命令行参数覆盖配置文件
+func TestApplyLogging(t *testing.T) {
+	before := zap.L()
+	t.Cleanup(func() { zap.ReplaceGlobals(before) })
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "cvl.log")
+	cfgPath := filepath.Join(dir, "config.yaml")
+	body := "logging:\n  level: warn\n  format: json\n  output: " + logPath + "\n  rowDiagnostics: false\n"
+	if err := os.WriteFile(cfgPath, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileCfg, err := config.TryLoadFromDisk(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		want  config.LoggingConfig
+		lines int // 分别以 debug、info、warn 级别各输出一行后写入文件的行数
+	}{
+		{name: "file", want: config.LoggingConfig{Level: "warn", Format: "json", Output: logPath}, lines: 1},
+		{name: "level flag", args: []string{"--log-level", "debug"}, want: config.LoggingConfig{Level: "debug", Format: "json", Output: logPath}, lines: 3},
+		{name: "format flag", args: []string{"--log-format", "console", "--log-level", "info"}, want: config.LoggingConfig{Level: "info", Format: "console", Output: logPath}, lines: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			cmd := &cobra.Command{}
+			addLoggingFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			got, err := applyLogging(cmd, fileCfg.LoggingConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("合并后的配置为 %+v，应为 %+v", *got, tt.want)
+			}
+
+			zap.S().Debug("debug")
+			zap.S().Info("info")
+			zap.S().Warn("warn")
+			_ = zap.L().Sync()
+			b, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+			if len(lines) != tt.lines {
+				t.Fatalf("应输出 %d 行，得到 %q", tt.lines, b)
+			}
+			var entry map[string]interface{}
+			isJSON := json.Unmarshal([]byte(lines[0]), &entry) == nil
+			if isJSON != (tt.want.Format == "json") {
+				t.Errorf("输出格式应为 %s: %q", tt.want.Format, lines[0])
+			}
+			if strings.Contains(string(b), "\x1b[") {
+				t.Errorf("输出到文件时不应使用颜色: %q", b)
+			}
+		})
+	}
+}
+
+// TestApplyLoggingInvalid 配置文件或命令行参数中的日志配置不合法时返回错误，不替换全局 logger
+func TestApplyLoggingInvalid(t *testing.T) {
+	before := zap.L()
+	t.Cleanup(func() { zap.ReplaceGlobals(before) })
+
+	tests := []struct {
+		name string
+		file config.LoggingConfig
+		args []string
+	}{
+		{name: "file level", file: config.LoggingConfig{Level: "verbose", Format: "json", Output: "stderr"}},
+		{name: "file format", file: config.LoggingConfig{Level: "info", Format: "xml", Output: "stderr"}},
+		{name: "flag level", file: config.LoggingConfig{Level: "info", Format: "json", Output: "stderr"}, args: []string{"--log-level", "verbose"}},
+		{name: "flag output", file: config.LoggingConfig{Level: "info", Format: "json", Output: "stderr"}, args: []string{"--log-output", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addLoggingFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := applyLogging(cmd, &tt.file); err == nil {
+				t.Error("应返回错误")
+			}
+			if zap.L() != before {
+				t.Error("不应替换全局 logger")
+			}
+		})
+	}
+}
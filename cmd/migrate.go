@@ -1,67 +1,325 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 
 	"content-verify-log/config"
 	"content-verify-log/pkg/db"
 	"content-verify-log/pkg/service"
-	"content-verify-log/pkg/signals"
+	"content-verify-log/pkg/util"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 func NewMigrateCommand() *cobra.Command {
-	var configFilePath string
-	var batchSize int
+	var configFilePaths []string
+	var flagCfg config.MigrationConfig
+	var printSQL bool
+	var shadowConfig string
+	var progressBar bool
 
 	cmd := &cobra.Command{
 		Use:   "migrate",
 		Short: "处理 DuckDB 中的数据",
 		Long:  "从 DuckDB 的 tbl_verify_content 表读取数据，解析 JSON 内容，处理后存储到同一数据库的 processed_content 表",
-		Run: func(cmd *cobra.Command, args []string) {
-			cfg, err := config.TryLoadFromDisk(configFilePath)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
 			if err != nil {
-				zap.S().Errorf("读取本地配置文件错误:%s", err.Error())
-				return
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
 			}
-			if errs := cfg.Validate(); len(errs) > 0 {
-				zap.S().Errorf("本地配置文件验证错误:%s", errors.Join(errs...))
-				return
+			// 命令行参数覆盖配置文件，合并后统一校验
+			migrationCfg := mergeMigrationFlags(cmd, cfg.MigrationConfig, &flagCfg, provenance)
+			cfg.MigrationConfig = migrationCfg
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
 			}
+			loggingCfg, err := applyLogging(cmd, cfg.LoggingConfig)
+			if err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
 
-			if cfg.DuckDBConfig == nil {
-				zap.S().Error("DuckDB 配置未设置")
-				return
+			migrationOpts := newMigrationOptions(migrationCfg)
+			migrationOpts.RowDiagnostics = loggingCfg.RowDiagnostics
+			if shadowConfig != "" {
+				shadow, err := loadShadowProcessorOptions(cmd, configFilePaths, shadowConfig, &flagCfg)
+				if err != nil {
+					return configError(fmt.Errorf("影子配置错误:%w", err))
+				}
+				migrationOpts.Shadow = shadow
+			}
+			if progressBar {
+				migrationOpts.Progress = newProgressReporter(os.Stdout)
+			}
+
+			// 仅打印将要执行的 SQL，不连接数据库
+			if printSQL {
+				sqlText, err := service.NewMigrationService(migrationOpts).ExplainSQL(migrationCfg.BatchSize)
+				if err != nil {
+					return fmt.Errorf("生成 SQL 失败:%w", err)
+				}
+				fmt.Print(sqlText)
+				return nil
 			}
 
-			ctx := signals.SetupSignalHandler()
+			migrationOpts.Settings = redactedJSON("migration", migrationCfg, provenance)
+			zap.S().Infof("迁移配置: %s", migrationOpts.Settings)
 
-			// 初始化 DuckDB
-			if err := db.InitDuckDB(cfg.DuckDBConfig); err != nil {
-				zap.S().Errorf("DuckDB 连接错误:%s", err.Error())
-				return
+			if cfg.DuckDBConfig == nil {
+				return configError(errors.New("DuckDB 配置未设置"))
 			}
+			if cfg.DuckDBConfig.ReadOnly {
+				return configError(errors.New("迁移需要写入 DuckDB，不能使用只读模式 (duckdb.readOnly)"))
+			}
+
+			ctx := cmd.Context()
+			migrationService := service.NewMigrationService(migrationOpts)
 
-			// 执行迁移
-			migrationService := service.NewMigrationService()
-			if err := migrationService.MigrateToDuckDB(ctx, batchSize); err != nil {
-				zap.S().Errorf("迁移失败:%s", err.Error())
-				return
+			if err := db.InitDuckDB(cfg.DuckDBConfig); err != nil {
+				err = connectivityError(fmt.Errorf("DuckDB 连接错误:%w", err))
+				notifyRunFinished(ctx, cfg.NotificationConfig, nil, err)
+				return err
 			}
 
-			// 显示统计信息
-			count, err := migrationService.GetProcessedContentCount(ctx)
-			if err != nil {
-				zap.S().Warnf("获取统计信息失败:%s", err.Error())
-			} else {
-				zap.S().Infof("DuckDB 中已处理的内容数量: %d", count)
+			if migrationCfg.Schedule != "" {
+				// 已在配置校验时检查过格式
+				schedule, _ := util.ParseCronSchedule(migrationCfg.Schedule)
+				return runSchedule(ctx, schedule, func(ctx context.Context) error {
+					return runMigration(ctx, cfg, migrationService)
+				})
 			}
+			return runMigration(ctx, cfg, migrationService)
 		},
 	}
 
-	cmd.Flags().StringVarP(&configFilePath, "config", "c", "./etc/config.yaml", "配置文件路径")
-	cmd.Flags().IntVarP(&batchSize, "batch-size", "b", 100, "批量处理大小")
+	defaults := config.NewDefaultMigrationConfig()
+	addConfigFlag(cmd, &configFilePaths)
+	addProcessingFlags(cmd, &flagCfg)
+	cmd.Flags().IntVar(&flagCfg.QueueDepth, "queue-depth", defaults.QueueDepth, "读取、处理、写入之间每个队列最多缓冲的批数")
+	cmd.Flags().StringVar(&flagCfg.TargetTable, "table", "", "目标表名（只允许字母、数字和下划线）")
+	cmd.Flags().StringVar(&flagCfg.RunLabel, "label", "", "运行标签，设置后目标表追加 run_label 列，所有行的值为该标签")
+	cmd.Flags().StringVar(&flagCfg.TextOnlyDir, "text-only", "", "不写目标表，每篇文章写一个 <id>.txt 到该目录（内容为修改后的文章），目录不存在时创建")
+	cmd.Flags().BoolVar(&flagCfg.TextOriginal, "text-original", false, "--text-only 写原文而不是修改后的文章")
+	cmd.Flags().IntVar(&flagCfg.TextOpenFiles, "text-open-files", defaults.TextOpenFiles, "--text-only 同时打开的文件数上限")
+	cmd.Flags().StringVar(&flagCfg.DuplicateIDs, "duplicate-ids", defaults.DuplicateIDs, "源数据中 ID 重复时的处理方式：skip 保留先写入的记录并计数，upsert 后来的记录替换先写入的")
+	cmd.Flags().Float64Var(&flagCfg.MaxErrorRate, "max-error-rate", 0, "失败行占读取行数的比例上限 [0, 1]，超过时中止迁移、不替换目标表，0 表示不检查")
+	cmd.Flags().BoolVar(&flagCfg.CompactAfter, "compact", false, "迁移成功后压缩 DuckDB 文件")
+	cmd.Flags().BoolVar(&flagCfg.ExportAfter, "export", false, "迁移成功后按 output 配置导出目标表")
+	cmd.Flags().BoolVar(&flagCfg.Manifest, "manifest", false, "迁移成功后在 DuckDB 文件所在目录写入描述输出的 manifest.json，同时使用 --export 时导出目录中同样写入")
+	cmd.Flags().StringVar(&flagCfg.ShardBy, "shard-by", "", "分片方式：task 按任务写入单独的 DuckDB 文件")
+	cmd.Flags().StringVar(&flagCfg.ShardPath, "shard-path", defaults.ShardPath, "分片文件路径模板，{task} 替换为任务 ID")
+	cmd.Flags().StringVar(&flagCfg.PartitionBy, "partition-by", "", "分区方式：month 按源记录创建时间的月份写入 <目标表>_YYYYMM，没有创建时间的写入 <目标表>_unknown")
+	cmd.Flags().StringVar(&flagCfg.Schedule, "schedule", "", "cron 表达式（分 时 日 月 周），如 \"0 2 * * *\"，设置后常驻进程按计划重复迁移")
+	cmd.Flags().BoolVar(&printSQL, "print-sql", false, "仅打印迁移将要执行的 SQL，不执行")
+	cmd.Flags().BoolVar(&progressBar, "progress-bar", false, "标准输出是终端时显示进度条，否则每 10 秒输出一行进度日志")
+	cmd.Flags().StringVar(&shadowConfig, "shadow-config", "", "影子处理器的配置文件，叠加在 --config 之上；设置后用它处理同一批输入并与写入的结果逐字段比较，不一致的样本写入 shadow_diffs 表")
 	return cmd
 }
+
+// loadShadowProcessorOptions 将影子配置文件叠加在 --config 指定的文件之上，命令行参数同样生效，
+// 返回影子处理器的选项；要对比的处理选项应只写在影子配置文件中
+func loadShadowProcessorOptions(cmd *cobra.Command, configFilePaths []string, shadowConfig string, flagCfg *config.MigrationConfig) (*service.ProcessorOptions, error) {
+	paths, err := existingConfigPaths(cmd, configFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.TryLoadFromDisk(append(paths, shadowConfig)...)
+	if err != nil {
+		return nil, err
+	}
+	shadowCfg := mergeMigrationFlags(cmd, cfg.MigrationConfig, flagCfg, nil)
+	if errs := shadowCfg.Validate(); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	opts := newMigrationOptions(shadowCfg).Processor
+	return &opts, nil
+}
+
+// runMigration 执行一次迁移，结束（包括失败和中断）时发送通知，成功后按配置导出和压缩
+func runMigration(ctx context.Context, cfg *config.GlobalConfig, migrationService *service.MigrationService) error {
+	migrationCfg := cfg.MigrationConfig
+	var runErr error
+	if err := migrationService.MigrateToDuckDB(ctx, migrationCfg.BatchSize); err != nil {
+		runErr = withCause(ctx, fmt.Errorf("迁移失败:%w", err))
+	}
+	notifyRunFinished(ctx, cfg.NotificationConfig, migrationService.RunSummary(), runErr)
+	if runErr != nil {
+		return runErr
+	}
+
+	// 显示统计信息；分片或分区时结果写入各分片文件或分区表，已在提交时输出每个的写入数量
+	if migrationCfg.ShardBy == "" && migrationCfg.PartitionBy == "" && migrationCfg.TextOnlyDir == "" {
+		count, err := migrationService.GetProcessedContentCount(ctx)
+		if err != nil {
+			zap.S().Warnf("获取统计信息失败:%s", err.Error())
+		} else {
+			zap.S().Infof("DuckDB 中已处理的内容数量: %d", count)
+		}
+	}
+
+	if migrationCfg.Manifest {
+		manifest := migrationService.Manifest(cfg.DuckDBConfig.DBPath)
+		if path, err := service.WriteManifest(filepath.Dir(cfg.DuckDBConfig.DBPath), manifest); err != nil {
+			zap.S().Warnf("写入清单失败:%s", err)
+		} else {
+			zap.S().Infof("清单已写入 %s", path)
+		}
+	}
+
+	if migrationCfg.ExportAfter {
+		outputCfg := *cfg.OutputConfig
+		outputCfg.Manifest = outputCfg.Manifest || migrationCfg.Manifest
+		if err := exportTable(ctx, &outputCfg, migrationCfg.TargetTable); err != nil {
+			zap.S().Warnf("导出失败:%s", withCause(ctx, err))
+		}
+	}
+
+	if migrationCfg.CompactAfter {
+		if err := compactDuckDB(ctx, cfg.DuckDBConfig.DBPath); err != nil {
+			zap.S().Warnf("压缩失败:%s", withCause(ctx, err))
+		}
+	}
+	return nil
+}
+
+// addProcessingFlags 注册读取和处理相关的迁移参数，migrate 和 bench 共用，便于基准测试结果直接用于迁移
+func addProcessingFlags(cmd *cobra.Command, flagCfg *config.MigrationConfig) {
+	defaults := config.NewDefaultMigrationConfig()
+	cmd.Flags().IntVarP(&flagCfg.BatchSize, "batch-size", "b", defaults.BatchSize, "批量处理大小")
+	cmd.Flags().IntVarP(&flagCfg.Workers, "workers", "w", defaults.Workers, "并发处理的 worker 数")
+	cmd.Flags().StringVar(&flagCfg.IngestMode, "ingest-mode", defaults.IngestMode, "写入方式：insert 逐行插入，appender 按批通过 Appender 追加，copy 按批写入临时文件后批量读入，arrow 按批构建 Arrow record batch 后读入")
+	cmd.Flags().StringVar(&flagCfg.MemoryBudget, "memory-budget", "", "单批原文字节数上限，如 512MB，设置后按文档大小自适应调整批量")
+	cmd.Flags().StringSliceVar(&flagCfg.TaskIDs, "task-id", nil, "只迁移指定任务（可重复），为空表示全部")
+	cmd.Flags().StringSliceVar(&flagCfg.ExcludeTaskIDs, "exclude-task-id", nil, "不迁移指定任务（可重复），可与 --task-id 同时使用")
+	cmd.Flags().IntSliceVar(&flagCfg.IncludeTypes, "include-type", nil, "仅应用指定错误类型的修正（可重复）")
+	cmd.Flags().IntSliceVar(&flagCfg.ExcludeTypes, "exclude-type", nil, "不应用指定错误类型的修正（可重复）")
+	cmd.Flags().IntVar(&flagCfg.MinErrorLevel, "min-error-level", 0, "只应用错误级别不低于该值的修正，0 表示不限制")
+	cmd.Flags().BoolVar(&flagCfg.DedupCorrections, "dedup-corrections", defaults.DedupCorrections, "位置、错误词和建议词都相同的重复修正项只应用第一项，其余记为 duplicate")
+	cmd.Flags().BoolVar(&flagCfg.OnlyErrors, "only-errors", false, "只写入实际应用了修正的记录（has_errors 为 true）")
+	cmd.Flags().BoolVar(&flagCfg.ValidateInput, "validate-input", false, "处理前校验输入格式，违例记为 SCHEMA_VIOLATION")
+	cmd.Flags().BoolVar(&flagCfg.NormalizeText, "normalize-text", false, "统一换行符为 \\n 并移除零宽字符")
+	cmd.Flags().StringVar(&flagCfg.NormalizeQuotes, "normalize-quotes", defaults.NormalizeQuotes, "引号规范化：off 不处理，match 比较错误词时忽略弯引号/直引号的差异，all 同时将存储的文本统一为直引号")
+	cmd.Flags().Lookup("normalize-quotes").NoOptDefVal = "match"
+	cmd.Flags().BoolVar(&flagCfg.KeepHTML, "keep-html", false, "原文和修改后的文章保留 HTML，只移除错误标记")
+	cmd.Flags().StringSliceVar(&flagCfg.PreserveTags, "preserve-tag", nil, "清洗 HTML 时原样保留的标签名（可重复），如 sup、sub、del")
+	cmd.Flags().BoolVar(&flagCfg.ImageAltText, "image-alt-text", false, "清洗 HTML 时将 alt 非空的图片替换为 \"[图: alt]\"")
+	cmd.Flags().BoolVar(&flagCfg.LinkURLs, "link-urls", false, "清洗 HTML 时在链接文字后附上 \" (地址)\"，javascript:、data: 地址不附加")
+	cmd.Flags().StringSliceVar(&flagCfg.MarkerClasses, "marker-class", defaults.MarkerClasses, "新格式中作为错误标记移除的 span class（可重复）")
+	cmd.Flags().StringVar(&flagCfg.MarkerClassPrefix, "marker-class-prefix", "", "class 以该前缀开头的 span 同样作为错误标记移除，如 jdt_")
+	cmd.Flags().StringSliceVar(&flagCfg.KeepMarkerClasses, "keep-marker-class", nil, "不作为错误标记移除的 span class（可重复），优先于 --marker-class 和 --marker-class-prefix")
+	cmd.Flags().IntVar(&flagCfg.SearchWindow, "search-window", defaults.SearchWindow, "位置与错误词不一致时在前后多少个字符内查找错误词，0 表示不查找")
+	cmd.Flags().StringVar(&flagCfg.PositionFormat, "position-format", defaults.PositionFormat, "新格式 position 的表示方式：offset 平铺偏移量，line-col 同时换算 {\"line\",\"col\"} 形式的位置")
+	cmd.Flags().IntVar(&flagCfg.ContextWindow, "context-window", 0, "为已应用的修正在明细中记录前后各多少个字符的上下文，0 表示不记录")
+	cmd.Flags().Float64Var(&flagCfg.MinLengthRatio, "min-length-ratio", 0, "修改后的文章与原文字符数之比的下限，低于时记为 SUSPICIOUS_MODIFICATION，0 表示不检查")
+	cmd.Flags().Float64Var(&flagCfg.MaxLengthRatio, "max-length-ratio", 0, "修改后的文章与原文字符数之比的上限，高于时记为 SUSPICIOUS_MODIFICATION，0 表示不检查")
+	cmd.Flags().BoolVar(&flagCfg.KeepOriginalOnSuspicious, "keep-original-on-suspicious", false, "长度之比超出范围时修改后的文章保留为原文")
+	cmd.Flags().BoolVar(&flagCfg.TagNeedsReview, "tag-needs-review", false, "在 needs_review 列标记需要人工复核的记录")
+	cmd.Flags().Float64Var(&flagCfg.ReviewSkipRatio, "review-skip-ratio", defaults.ReviewSkipRatio, "跳过的修正占比超过该值时需要复核 [0, 1]")
+	cmd.Flags().StringVar(&flagCfg.SourceEncoding, "source-encoding", defaults.SourceEncoding, "源 content 编码：utf-8/gbk/gb18030，非 UTF-8 的行按该编码转换，为 utf-8 时按 GB18030 尝试")
+	cmd.Flags().StringVar(&flagCfg.MaxJSONSize, "max-json-size", defaults.MaxJSONSize, "源内容 JSON 的最大字节数，超出的行不解析，写入死信表")
+	cmd.Flags().IntVar(&flagCfg.MaxJSONDepth, "max-json-depth", defaults.MaxJSONDepth, "源内容 JSON 的最大嵌套层数")
+	cmd.Flags().BoolVar(&flagCfg.SalvageTruncated, "salvage-truncated", false, "源内容不是合法 JSON（通常被截断）时提取 checkresultstr/replace_text 等已知字段尽量处理，结果记为 TRUNCATED_JSON_SALVAGED")
+	cmd.Flags().BoolVar(&flagCfg.SkipContentHash, "skip-content-hash", false, "跳过源内容 SHA-256 计算")
+	cmd.Flags().BoolVar(&flagCfg.ContentHashNFC, "content-hash-nfc", false, "计算 content_hash 前做 Unicode NFC 规范化")
+}
+
+// mergeMigrationFlags 以配置文件（缺省时为默认值）为基础，用显式指定的命令行参数覆盖
+func mergeMigrationFlags(cmd *cobra.Command, fileCfg *config.MigrationConfig, flagCfg *config.MigrationConfig, provenance config.Provenance) *config.MigrationConfig {
+	merged := config.NewDefaultMigrationConfig()
+	if fileCfg != nil {
+		*merged = *fileCfg
+	}
+
+	applyFlagOverrides(cmd, provenance, []flagOverride{
+		{flag: "batch-size", key: "migration.batchSize", apply: func() { merged.BatchSize = flagCfg.BatchSize }},
+		{flag: "workers", key: "migration.workers", apply: func() { merged.Workers = flagCfg.Workers }},
+		{flag: "memory-budget", key: "migration.memoryBudget", apply: func() { merged.MemoryBudget = flagCfg.MemoryBudget }},
+		{flag: "queue-depth", key: "migration.queueDepth", apply: func() { merged.QueueDepth = flagCfg.QueueDepth }},
+		{flag: "ingest-mode", key: "migration.ingestMode", apply: func() { merged.IngestMode = flagCfg.IngestMode }},
+		{flag: "duplicate-ids", key: "migration.duplicateIds", apply: func() { merged.DuplicateIDs = flagCfg.DuplicateIDs }},
+		{flag: "task-id", key: "migration.taskIds", apply: func() { merged.TaskIDs = flagCfg.TaskIDs }},
+		{flag: "exclude-task-id", key: "migration.excludeTaskIds", apply: func() { merged.ExcludeTaskIDs = flagCfg.ExcludeTaskIDs }},
+		{flag: "table", key: "migration.targetTable", apply: func() { merged.TargetTable = flagCfg.TargetTable }},
+		{flag: "label", key: "migration.runLabel", apply: func() { merged.RunLabel = flagCfg.RunLabel }},
+		{flag: "text-only", key: "migration.textOnlyDir", apply: func() { merged.TextOnlyDir = flagCfg.TextOnlyDir }},
+		{flag: "text-original", key: "migration.textOriginal", apply: func() { merged.TextOriginal = flagCfg.TextOriginal }},
+		{flag: "text-open-files", key: "migration.textOpenFiles", apply: func() { merged.TextOpenFiles = flagCfg.TextOpenFiles }},
+		{flag: "include-type", key: "migration.includeTypes", apply: func() { merged.IncludeTypes = flagCfg.IncludeTypes }},
+		{flag: "exclude-type", key: "migration.excludeTypes", apply: func() { merged.ExcludeTypes = flagCfg.ExcludeTypes }},
+		{flag: "min-error-level", key: "migration.minErrorLevel", apply: func() { merged.MinErrorLevel = flagCfg.MinErrorLevel }},
+		{flag: "dedup-corrections", key: "migration.dedupCorrections", apply: func() { merged.DedupCorrections = flagCfg.DedupCorrections }},
+		{flag: "only-errors", key: "migration.onlyErrors", apply: func() { merged.OnlyErrors = flagCfg.OnlyErrors }},
+		{flag: "validate-input", key: "migration.validateInput", apply: func() { merged.ValidateInput = flagCfg.ValidateInput }},
+		{flag: "normalize-text", key: "migration.normalizeText", apply: func() { merged.NormalizeText = flagCfg.NormalizeText }},
+		{flag: "normalize-quotes", key: "migration.normalizeQuotes", apply: func() { merged.NormalizeQuotes = flagCfg.NormalizeQuotes }},
+		{flag: "keep-html", key: "migration.keepHtml", apply: func() { merged.KeepHTML = flagCfg.KeepHTML }},
+		{flag: "preserve-tag", key: "migration.preserveTags", apply: func() { merged.PreserveTags = flagCfg.PreserveTags }},
+		{flag: "image-alt-text", key: "migration.imageAltText", apply: func() { merged.ImageAltText = flagCfg.ImageAltText }},
+		{flag: "link-urls", key: "migration.linkUrls", apply: func() { merged.LinkURLs = flagCfg.LinkURLs }},
+		{flag: "marker-class", key: "migration.markerClasses", apply: func() { merged.MarkerClasses = flagCfg.MarkerClasses }},
+		{flag: "marker-class-prefix", key: "migration.markerClassPrefix", apply: func() { merged.MarkerClassPrefix = flagCfg.MarkerClassPrefix }},
+		{flag: "keep-marker-class", key: "migration.keepMarkerClasses", apply: func() { merged.KeepMarkerClasses = flagCfg.KeepMarkerClasses }},
+		{flag: "search-window", key: "migration.searchWindow", apply: func() { merged.SearchWindow = flagCfg.SearchWindow }},
+		{flag: "position-format", key: "migration.positionFormat", apply: func() { merged.PositionFormat = flagCfg.PositionFormat }},
+		{flag: "max-json-size", key: "migration.maxJsonSize", apply: func() { merged.MaxJSONSize = flagCfg.MaxJSONSize }},
+		{flag: "max-json-depth", key: "migration.maxJsonDepth", apply: func() { merged.MaxJSONDepth = flagCfg.MaxJSONDepth }},
+		{flag: "salvage-truncated", key: "migration.salvageTruncated", apply: func() { merged.SalvageTruncated = flagCfg.SalvageTruncated }},
+		{flag: "max-error-rate", key: "migration.maxErrorRate", apply: func() { merged.MaxErrorRate = flagCfg.MaxErrorRate }},
+		{flag: "context-window", key: "migration.contextWindow", apply: func() { merged.ContextWindow = flagCfg.ContextWindow }},
+		{flag: "min-length-ratio", key: "migration.minLengthRatio", apply: func() { merged.MinLengthRatio = flagCfg.MinLengthRatio }},
+		{flag: "max-length-ratio", key: "migration.maxLengthRatio", apply: func() { merged.MaxLengthRatio = flagCfg.MaxLengthRatio }},
+		{flag: "keep-original-on-suspicious", key: "migration.keepOriginalOnSuspicious", apply: func() { merged.KeepOriginalOnSuspicious = flagCfg.KeepOriginalOnSuspicious }},
+		{flag: "tag-needs-review", key: "migration.tagNeedsReview", apply: func() { merged.TagNeedsReview = flagCfg.TagNeedsReview }},
+		{flag: "review-skip-ratio", key: "migration.reviewSkipRatio", apply: func() { merged.ReviewSkipRatio = flagCfg.ReviewSkipRatio }},
+		{flag: "source-encoding", key: "migration.sourceEncoding", apply: func() { merged.SourceEncoding = flagCfg.SourceEncoding }},
+		{flag: "skip-content-hash", key: "migration.skipContentHash", apply: func() { merged.SkipContentHash = flagCfg.SkipContentHash }},
+		{flag: "content-hash-nfc", key: "migration.contentHashNfc", apply: func() { merged.ContentHashNFC = flagCfg.ContentHashNFC }},
+		{flag: "compact", key: "migration.compactAfter", apply: func() { merged.CompactAfter = flagCfg.CompactAfter }},
+		{flag: "export", key: "migration.exportAfter", apply: func() { merged.ExportAfter = flagCfg.ExportAfter }},
+		{flag: "manifest", key: "migration.manifest", apply: func() { merged.Manifest = flagCfg.Manifest }},
+		{flag: "shard-by", key: "migration.shardBy", apply: func() { merged.ShardBy = flagCfg.ShardBy }},
+		{flag: "schedule", key: "migration.schedule", apply: func() { merged.Schedule = flagCfg.Schedule }},
+		{flag: "shard-path", key: "migration.shardPath", apply: func() { merged.ShardPath = flagCfg.ShardPath }},
+		{flag: "partition-by", key: "migration.partitionBy", apply: func() { merged.PartitionBy = flagCfg.PartitionBy }},
+	})
+	return merged
+}
+
+// newMigrationOptions 将迁移配置转换为服务层选项
+func newMigrationOptions(cfg *config.MigrationConfig) service.MigrationOptions {
+	var memoryBudget int64
+	if cfg.MemoryBudget != "" {
+		// 已在配置校验时检查过格式
+		memoryBudget, _ = util.ParseByteSize(cfg.MemoryBudget)
+	}
+	return service.MigrationOptions{
+		Processor:       service.ProcessorOptionsFromConfig(cfg),
+		SkipContentHash: cfg.SkipContentHash,
+		ContentHashNFC:  cfg.ContentHashNFC,
+		OnlyErrors:      cfg.OnlyErrors,
+		TargetTable:     cfg.TargetTable,
+		RunLabel:        cfg.RunLabel,
+		TextDir:         cfg.TextOnlyDir,
+		TextOriginal:    cfg.TextOriginal,
+		TextOpenFiles:   cfg.TextOpenFiles,
+		TaskIDs:         cfg.TaskIDs,
+		ExcludeTaskIDs:  cfg.ExcludeTaskIDs,
+		Workers:         cfg.Workers,
+		MemoryBudget:    memoryBudget,
+		QueueDepth:      cfg.QueueDepth,
+		IngestMode:      cfg.IngestMode,
+		DuplicateIDs:    cfg.DuplicateIDs,
+		ShardBy:         cfg.ShardBy,
+		ShardPath:       cfg.ShardPath,
+		PartitionBy:     cfg.PartitionBy,
+		SourceEncoding:  cfg.SourceEncoding,
+		MaxErrorRate:    cfg.MaxErrorRate,
+	}
+}
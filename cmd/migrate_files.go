@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"content-verify-log/config"
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/service"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewMigrateFilesCommand() *cobra.Command {
+	var configFilePaths []string
+	var flagCfg config.MigrationConfig
+	var dir, glob string
+	var progressBar bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate-files",
+		Short: "处理目录中的 JSON 文件",
+		Long: "递归读取 --dir 下文件名匹配 --glob 的 JSON 文件，每个文件作为一条源记录，按与 migrate 相同的流程处理后写入 DuckDB 的 processed_content 表。" +
+			"文件可以是带 id、taskId 和 content 字段的对象，也可以直接是 content 本身；id 缺省时取文件名，taskId 缺省时取文件所在的子目录。" +
+			"无法解析的文件写入死信表，无法确定 ID 的文件记为扫描失败",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			migrationCfg := mergeMigrationFlags(cmd, cfg.MigrationConfig, &flagCfg, provenance)
+			cfg.MigrationConfig = migrationCfg
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
+			}
+			loggingCfg, err := applyLogging(cmd, cfg.LoggingConfig)
+			if err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
+
+			if dir == "" {
+				return configError(errors.New("未指定源目录 (--dir)"))
+			}
+			if cfg.DuckDBConfig == nil {
+				return configError(errors.New("DuckDB 配置未设置"))
+			}
+			if cfg.DuckDBConfig.ReadOnly {
+				return configError(errors.New("迁移需要写入 DuckDB，不能使用只读模式 (duckdb.readOnly)"))
+			}
+
+			migrationOpts := newMigrationOptions(migrationCfg)
+			migrationOpts.RowDiagnostics = loggingCfg.RowDiagnostics
+			migrationOpts.SourceDir = dir
+			migrationOpts.SourceGlob = glob
+			if progressBar {
+				migrationOpts.Progress = newProgressReporter(os.Stdout)
+			}
+			migrationOpts.Settings = redactedJSON("migration", migrationCfg, provenance)
+			zap.S().Infof("迁移配置: %s", migrationOpts.Settings)
+			zap.S().Infof("源目录: %s, 文件匹配: %s", dir, glob)
+
+			ctx := cmd.Context()
+			migrationService := service.NewMigrationService(migrationOpts)
+			if err := db.InitDuckDB(cfg.DuckDBConfig); err != nil {
+				err = connectivityError(fmt.Errorf("DuckDB 连接错误:%w", err))
+				notifyRunFinished(ctx, cfg.NotificationConfig, nil, err)
+				return err
+			}
+			return runMigration(ctx, cfg, migrationService)
+		},
+	}
+
+	defaults := config.NewDefaultMigrationConfig()
+	addConfigFlag(cmd, &configFilePaths)
+	addProcessingFlags(cmd, &flagCfg)
+	cmd.Flags().StringVar(&dir, "dir", "", "源目录，递归读取其中的文件")
+	cmd.Flags().StringVar(&glob, "glob", service.DefaultSourceGlob, "参与迁移的文件名模式，按文件名（不含目录）匹配")
+	cmd.Flags().IntVar(&flagCfg.QueueDepth, "queue-depth", defaults.QueueDepth, "读取、处理、写入之间每个队列最多缓冲的批数")
+	cmd.Flags().StringVar(&flagCfg.TargetTable, "table", "", "目标表名（只允许字母、数字和下划线）")
+	cmd.Flags().StringVar(&flagCfg.RunLabel, "label", "", "运行标签，设置后目标表追加 run_label 列，所有行的值为该标签")
+	cmd.Flags().StringVar(&flagCfg.TextOnlyDir, "text-only", "", "不写目标表，每篇文章写一个 <id>.txt 到该目录（内容为修改后的文章），目录不存在时创建")
+	cmd.Flags().BoolVar(&flagCfg.TextOriginal, "text-original", false, "--text-only 写原文而不是修改后的文章")
+	cmd.Flags().IntVar(&flagCfg.TextOpenFiles, "text-open-files", defaults.TextOpenFiles, "--text-only 同时打开的文件数上限")
+	cmd.Flags().StringVar(&flagCfg.DuplicateIDs, "duplicate-ids", defaults.DuplicateIDs, "文件中 ID 重复时的处理方式：skip 保留先写入的记录并计数，upsert 后来的记录替换先写入的")
+	cmd.Flags().Float64Var(&flagCfg.MaxErrorRate, "max-error-rate", 0, "失败行占读取行数的比例上限 [0, 1]，超过时中止迁移、不替换目标表，0 表示不检查")
+	cmd.Flags().BoolVar(&flagCfg.CompactAfter, "compact", false, "迁移成功后压缩 DuckDB 文件")
+	cmd.Flags().BoolVar(&flagCfg.ExportAfter, "export", false, "迁移成功后按 output 配置导出目标表")
+	cmd.Flags().BoolVar(&flagCfg.Manifest, "manifest", false, "迁移成功后在 DuckDB 文件所在目录写入描述输出的 manifest.json，同时使用 --export 时导出目录中同样写入")
+	cmd.Flags().StringVar(&flagCfg.ShardBy, "shard-by", "", "分片方式：task 按任务写入单独的 DuckDB 文件")
+	cmd.Flags().StringVar(&flagCfg.ShardPath, "shard-path", defaults.ShardPath, "分片文件路径模板，{task} 替换为任务 ID")
+	cmd.Flags().StringVar(&flagCfg.PartitionBy, "partition-by", "", "分区方式：month 按文件修改时间的月份写入 <目标表>_YYYYMM")
+	cmd.Flags().BoolVar(&progressBar, "progress-bar", false, "标准输出是终端时显示进度条，否则每 10 秒输出一行进度日志")
+	return cmd
+}
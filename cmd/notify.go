@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+
+	"content-verify-log/config"
+	"content-verify-log/pkg/notify"
+	"content-verify-log/pkg/service"
+	"content-verify-log/pkg/util"
+
+	"go.uber.org/zap"
+)
+
+// 通知事件：迁移结束（含失败和中断），以及因失败行的比例超过 maxErrorRate 中止（单独的事件，便于值班按事件路由）
+const (
+	eventMigrationFinished = "migration.finished"
+	eventMigrationAborted  = "migration.aborted"
+)
+
+// 迁移结束状态
+const (
+	runStatusSucceeded   = "succeeded"
+	runStatusFailed      = "failed"
+	runStatusInterrupted = "interrupted"
+	runStatusAborted     = "aborted"
+)
+
+// runNotification Webhook 请求体，运行摘要的字段展开在顶层；迁移未开始时没有摘要字段
+type runNotification struct {
+	Event       string `json:"event"`
+	Status      string `json:"status"`
+	ExitCode    int    `json:"exit_code"`
+	Error       string `json:"error,omitempty"`
+	ToolVersion string `json:"tool_version"`
+	*service.RunSummary
+}
+
+// notifyRunFinished 按 notifications 配置发送迁移结束通知，runErr 为迁移返回的错误（已分类退出码）
+// 通知失败只记录日志，不改变命令的退出码；中断时 ctx 已取消，请求使用不受取消影响的 context
+func notifyRunFinished(ctx context.Context, cfg *config.NotificationConfig, summary *service.RunSummary, runErr error) {
+	if cfg == nil || cfg.WebhookURL == "" {
+		return
+	}
+	payload := runNotification{
+		Event:       eventMigrationFinished,
+		Status:      runStatusSucceeded,
+		ExitCode:    ExitCode(runErr),
+		ToolVersion: util.GetVersion().Version,
+		RunSummary:  summary,
+	}
+	if runErr != nil {
+		payload.Error = runErr.Error()
+		switch payload.ExitCode {
+		case ExitInterrupted:
+			payload.Status = runStatusInterrupted
+		case ExitAborted:
+			payload.Event, payload.Status = eventMigrationAborted, runStatusAborted
+		default:
+			payload.Status = runStatusFailed
+		}
+	}
+
+	webhook := notify.NewWebhook(notify.WebhookOptions{
+		URL:         cfg.WebhookURL,
+		BearerToken: cfg.BearerToken,
+		Timeout:     cfg.TimeoutDuration(),
+		Retries:     cfg.Retries,
+	})
+	if err := webhook.Send(context.WithoutCancel(ctx), payload); err != nil {
+		zap.S().Warnf("发送迁移结束通知失败（已重试 %d 次）: %v", cfg.Retries, err)
+		return
+	}
+	zap.S().Infof("已发送迁移结束通知: %s %s", payload.Event, payload.Status)
+}
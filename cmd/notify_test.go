@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"content-verify-log/config"
+	"content-verify-log/pkg/service"
+)
+
+// TestNotifyRunFinished 按迁移返回的错误区分事件和状态，超过 maxErrorRate 中止时单独发送 migration.aborted
+func TestNotifyRunFinished(t *testing.T) {
+	tests := []struct {
+		name     string
+		runErr   error
+		event    string
+		status   string
+		exitCode int
+	}{
+		{"succeeded", nil, eventMigrationFinished, runStatusSucceeded, ExitOK},
+		{"failed", errors.New("迁移失败:boom"), eventMigrationFinished, runStatusFailed, ExitError},
+		{"interrupted", fmt.Errorf("迁移失败:%w", context.Canceled), eventMigrationFinished, runStatusInterrupted, ExitInterrupted},
+		{"aborted", fmt.Errorf("迁移失败:%w", service.ErrAborted), eventMigrationAborted, runStatusAborted, ExitAborted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got runNotification
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+			}))
+			defer server.Close()
+
+			cfg := &config.NotificationConfig{WebhookURL: server.URL, Timeout: "5s"}
+			notifyRunFinished(context.Background(), cfg, &service.RunSummary{RunID: "run-1"}, tt.runErr)
+			if got.Event != tt.event || got.Status != tt.status || got.ExitCode != tt.exitCode {
+				t.Errorf("通知为 %s %s %d，应为 %s %s %d", got.Event, got.Status, got.ExitCode, tt.event, tt.status, tt.exitCode)
+			}
+			if got.RunSummary == nil || got.RunID != "run-1" {
+				t.Errorf("通知中缺少运行摘要: %+v", got.RunSummary)
+			}
+			if tt.runErr != nil && got.Error != tt.runErr.Error() {
+				t.Errorf("error 为 %q，应为 %q", got.Error, tt.runErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"content-verify-log/pkg/service"
+
+	"github.com/schollz/progressbar/v3"
+	"go.uber.org/zap"
+	"golang.org/x/term"
+)
+
+// progressLogInterval 标准输出不是终端时输出进度日志的间隔
+const progressLogInterval = 10 * time.Second
+
+// newProgressReporter 创建迁移进度的接收者：out 是终端时显示进度条，否则（如重定向到文件、在调度系统中运行）
+// 每隔 progressLogInterval 输出一行进度日志
+func newProgressReporter(out *os.File) service.ProgressReporter {
+	if term.IsTerminal(int(out.Fd())) {
+		return &barProgress{out: out}
+	}
+	return &logProgress{interval: progressLogInterval}
+}
+
+// barProgress 在终端中显示进度条，每次迁移重新创建
+type barProgress struct {
+	out   *os.File
+	bar   *progressbar.ProgressBar
+	total int64
+	rows  int64
+}
+
+func (p *barProgress) Start(total int64) {
+	p.total, p.rows = total, 0
+	p.bar = progressbar.NewOptions64(total,
+		progressbar.OptionSetWriter(p.out),
+		progressbar.OptionSetDescription("迁移"),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetItsString("条"),
+		progressbar.OptionSetPredictTime(true),
+		progressbar.OptionThrottle(100*time.Millisecond),
+		progressbar.OptionFullWidth(),
+		progressbar.OptionOnCompletion(func() { fmt.Fprintln(p.out) }),
+	)
+}
+
+func (p *barProgress) Add(rows int) {
+	p.rows += int64(rows)
+	_ = p.bar.Add(rows)
+}
+
+// Finish 读完全部记录时补满进度条，失败或中断时停在当前位置
+func (p *barProgress) Finish() {
+	if p.total >= 0 && p.rows >= p.total {
+		_ = p.bar.Finish()
+		return
+	}
+	_ = p.bar.Exit()
+}
+
+// logProgress 定时输出进度日志，用于标准输出不是终端的场景
+type logProgress struct {
+	interval time.Duration
+	start    time.Time
+	lastLog  time.Time
+	total    int64
+	rows     int64
+}
+
+func (p *logProgress) Start(total int64) {
+	p.start, p.lastLog = time.Now(), time.Now()
+	p.total, p.rows = total, 0
+}
+
+func (p *logProgress) Add(rows int) {
+	p.rows += int64(rows)
+	if time.Since(p.lastLog) < p.interval {
+		return
+	}
+	p.lastLog = time.Now()
+	elapsed := time.Since(p.start)
+	rate := float64(p.rows) / elapsed.Seconds()
+	if p.total <= 0 {
+		zap.S().Infof("进度: 已读取 %d 条, %.0f 条/秒", p.rows, rate)
+		return
+	}
+	remaining := "未知"
+	if rate > 0 {
+		left := max(p.total-p.rows, 0)
+		remaining = time.Duration(float64(left) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+	zap.S().Infof("进度: %d/%d 条 (%.1f%%), %.0f 条/秒, 预计剩余 %s",
+		p.rows, p.total, float64(p.rows)*100/float64(p.total), rate, remaining)
+}
+
+func (p *logProgress) Finish() {}
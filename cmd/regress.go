@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+
+	"content-verify-log/config"
+	"content-verify-log/pkg/regress"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewRegressCommand() *cobra.Command {
+	var configFilePaths []string
+	var corpus string
+	var update bool
+
+	cmd := &cobra.Command{
+		Use:   "regress",
+		Short: "用语料目录对比内容处理结果，发现处理逻辑变更导致的输出漂移",
+		Long: "语料目录中每个 <name>.json 为源表 content 列的原始内容，<name>.golden.json 为期望的处理结果；" +
+			"有 <name>.options.yaml 时按默认配置叠加该文件中的 migration 选项处理，否则按 migration 配置的处理选项处理，逐字段比较并打印不一致的字段。" +
+			"有意修改处理逻辑后使用 --update 重新生成期望结果",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
+			}
+			if _, err := applyLogging(cmd, cfg.LoggingConfig); err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
+
+			migrationCfg := cfg.MigrationConfig
+			if migrationCfg == nil {
+				migrationCfg = config.NewDefaultMigrationConfig()
+			}
+			runner := regress.NewRunner(migrationCfg)
+
+			cases, err := regress.LoadCorpus(corpus)
+			if err != nil {
+				return configError(err)
+			}
+			if len(cases) == 0 {
+				return configError(fmt.Errorf("语料目录 %s 中没有 .json 输入", corpus))
+			}
+
+			if update {
+				for _, c := range cases {
+					if err := runner.Update(c); err != nil {
+						return fmt.Errorf("更新 %s 失败:%w", c.Name, err)
+					}
+				}
+				zap.S().Infof("已重新生成 %d 个期望结果", len(cases))
+				return nil
+			}
+
+			out := cmd.OutOrStdout()
+			failed := 0
+			for _, c := range cases {
+				result, err := runner.Check(c)
+				if err != nil {
+					return fmt.Errorf("处理 %s 失败:%w", c.Name, err)
+				}
+				if result.Passed() {
+					continue
+				}
+				failed++
+				if result.Missing {
+					fmt.Fprintf(out, "--- %s: 缺少期望结果 %s，可使用 --update 生成\n", c.Name, c.GoldenPath)
+					continue
+				}
+				fmt.Fprintf(out, "--- %s: %d 个字段不一致\n", c.Name, len(result.Diffs))
+				for _, diff := range result.Diffs {
+					fmt.Fprintf(out, "  %s\n", diff)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d/%d 个用例与期望结果不一致", failed, len(cases))
+			}
+			zap.S().Infof("%d 个用例全部与期望结果一致", len(cases))
+			return nil
+		},
+	}
+
+	addConfigFlag(cmd, &configFilePaths)
+	cmd.Flags().StringVar(&corpus, "corpus", "./testdata/golden", "语料目录")
+	cmd.Flags().BoolVar(&update, "update", false, "用当前的处理结果覆盖期望结果")
+	return cmd
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"content-verify-log/config"
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/service"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewRetryDeadLetterCommand() *cobra.Command {
+	var configFilePaths []string
+	var ids []int64
+
+	cmd := &cobra.Command{
+		Use:   "retry-dead-letter",
+		Short: "重新处理死信表中的记录",
+		Long:  "从 dead_letter 表读取迁移时无法处理的源记录 ID，按当前配置重新处理；成功的记录写入（或替换）目标表中的同 ID 记录并从死信表删除，再次失败的记录更新失败原因",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
+			}
+			loggingCfg, err := applyLogging(cmd, cfg.LoggingConfig)
+			if err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
+
+			if cfg.DuckDBConfig == nil {
+				return configError(errors.New("DuckDB 配置未设置"))
+			}
+			if cfg.DuckDBConfig.ReadOnly {
+				return configError(errors.New("重新处理需要写入 DuckDB，不能使用只读模式 (duckdb.readOnly)"))
+			}
+
+			migrationCfg := cfg.MigrationConfig
+			if migrationCfg == nil {
+				migrationCfg = config.NewDefaultMigrationConfig()
+			}
+			migrationOpts := newMigrationOptions(migrationCfg)
+			migrationOpts.RowDiagnostics = loggingCfg.RowDiagnostics
+
+			ctx := cmd.Context()
+			if err := db.InitDuckDB(cfg.DuckDBConfig); err != nil {
+				return connectivityError(fmt.Errorf("DuckDB 连接错误:%w", err))
+			}
+
+			if err := service.NewMigrationService(migrationOpts).RetryDeadLetters(ctx, ids); err != nil {
+				return withCause(ctx, fmt.Errorf("重新处理死信失败:%w", err))
+			}
+			return nil
+		},
+	}
+
+	addConfigFlag(cmd, &configFilePaths)
+	cmd.Flags().Int64SliceVar(&ids, "id", nil, "只重新处理指定的源表 ID（可重复），为空表示全部死信")
+	return cmd
+}
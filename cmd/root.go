@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"content-verify-log/config"
+	"content-verify-log/pkg/log"
+	"content-verify-log/pkg/signals"
 	"content-verify-log/pkg/util"
 
 	"github.com/spf13/cobra"
@@ -11,6 +14,9 @@ func NewRootCommand() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "content-verify-log",
 		Short: "内容验证日志处理工具",
+		// 错误由 Execute 统一输出并映射为退出码，运行时失败不打印帮助信息
+		SilenceUsage:  true,
+		SilenceErrors: true,
 		CompletionOptions: cobra.CompletionOptions{
 			DisableDefaultCmd:   true,
 			DisableNoDescFlag:   true,
@@ -19,8 +25,41 @@ func NewRootCommand() *cobra.Command {
 		},
 	}
 
+	addLoggingFlags(rootCmd)
+	// 参数错误同样按配置错误处理
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return configError(err)
+	})
+
+	// 所有子命令执行前先按默认值和日志参数初始化 logger，子命令读取配置文件后再按 logging 配置重新初始化
+	var syncLogger func()
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		sync, err := initLogging(cmd)
+		if err != nil {
+			return configError(err)
+		}
+		syncLogger = sync
+		return nil
+	}
+	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		if syncLogger != nil {
+			syncLogger()
+		}
+	}
+
 	// 添加迁移子命令
 	rootCmd.AddCommand(NewMigrateCommand())
+	rootCmd.AddCommand(NewMigrateFilesCommand())
+	rootCmd.AddCommand(NewCompactCommand())
+	rootCmd.AddCommand(NewExportCommand())
+	rootCmd.AddCommand(NewExportCorrectionsCommand())
+	rootCmd.AddCommand(NewRetryDeadLetterCommand())
+	rootCmd.AddCommand(NewTasksCommand())
+	rootCmd.AddCommand(NewStatsCommand())
+	rootCmd.AddCommand(NewGenFixturesCommand())
+	rootCmd.AddCommand(NewRegressCommand())
+	rootCmd.AddCommand(NewBenchCommand())
+	rootCmd.AddCommand(NewConfigCommand())
 
 	rootCmd.Run = func(cmd *cobra.Command, args []string) {
 		zap.S().Info("使用 'migrate' 子命令进行数据迁移")
@@ -29,3 +68,19 @@ func NewRootCommand() *cobra.Command {
 	rootCmd.Version = util.GetVersion().Version
 	return rootCmd
 }
+
+// Execute 执行命令，输出错误并返回进程退出码
+func Execute() int {
+	// 参数解析失败时 PersistentPreRunE 不会执行，先按默认日志配置初始化，保证错误能输出
+	defaults := config.NewDefaultLoggingConfig()
+	if _, err := log.Init(defaults.Level, defaults.Format, defaults.Output); err != nil {
+		return ExitError
+	}
+	// 收到退出信号时取消命令的上下文，子命令通过 cmd.Context() 获取
+	err := NewRootCommand().ExecuteContext(signals.SetupSignalHandler())
+	if err != nil {
+		zap.S().Error(err)
+		_ = zap.L().Sync()
+	}
+	return ExitCode(err)
+}
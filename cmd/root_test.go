@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"content-verify-log/pkg/service"
+	"content-verify-log/pkg/signals"
+)
+
+// execute 以 ctx 执行根命令，返回退出码
+func execute(ctx context.Context, args ...string) int {
+	root := NewRootCommand()
+	root.SetArgs(args)
+	root.SetOut(io.Discard)
+	return ExitCode(root.ExecuteContext(ctx))
+}
+
+// writeTestConfig 在 dir 中写入使用 dir/source.duckdb 的配置文件，返回其路径
+func writeTestConfig(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "config.yaml")
+	body := fmt.Sprintf("duckdb:\n  dbPath: %s\nlogging:\n  level: error\n  output: stderr\n", filepath.Join(dir, "source.duckdb"))
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestExecuteContextExitCode 按命令的执行结果返回退出码：成功为 0，运行失败为 1，配置错误为 2，
+// 上下文取消（包括收到退出信号）为 130
+func TestExecuteContextExitCode(t *testing.T) {
+	dir := t.TempDir()
+	cfg := writeTestConfig(t, dir)
+	if code := execute(context.Background(), "genfixtures", "--out", filepath.Join(dir, "source.duckdb"), "--rows", "20"); code != ExitOK {
+		t.Fatalf("生成测试数据失败，退出码 %d", code)
+	}
+
+	// 期望结果与处理结果不一致的语料
+	corpus := filepath.Join(dir, "corpus")
+	if err := os.MkdirAll(corpus, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"case.json":        `{"data":{"replace_text":"错字","checklist":[{"word":"错","suggest":["对"],"position":0,"length":1}]}}`,
+		"case.golden.json": `{"modified_text":"不一致"}`,
+	} {
+		if err := os.WriteFile(filepath.Join(corpus, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	signalled, stop := context.WithCancelCause(context.Background())
+	stop(fmt.Errorf("%w: interrupt", signals.ErrShutdownSignal))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		args []string
+		want int
+	}{
+		{"success", context.Background(), []string{"regress", "-c", cfg, "--corpus", "../testdata/golden"}, ExitOK},
+		{"failed", context.Background(), []string{"regress", "-c", cfg, "--corpus", corpus}, ExitError},
+		{"missing config", context.Background(), []string{"migrate", "-c", filepath.Join(dir, "missing.yaml")}, ExitConfig},
+		{"unknown flag", context.Background(), []string{"migrate", "--no-such-flag"}, ExitConfig},
+		{"cancelled", cancelled, []string{"migrate", "-c", cfg}, ExitInterrupted},
+		{"signalled", signalled, []string{"migrate", "-c", cfg}, ExitInterrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := execute(tt.ctx, tt.args...); got != tt.want {
+				t.Errorf("退出码为 %d，应为 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExitCode 错误分类与退出码的对应关系，中断优先于其他分类
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"other", errors.New("boom"), ExitError},
+		{"config", configError(errors.New("bad")), ExitConfig},
+		{"table not found", fmt.Errorf("查询失败:%w", service.ErrTableNotFound), ExitConfig},
+		{"connectivity", connectivityError(errors.New("refused")), ExitConnectivity},
+		{"aborted", fmt.Errorf("迁移失败:%w", service.ErrAborted), ExitAborted},
+		{"cancelled", fmt.Errorf("读取失败:%w", context.Canceled), ExitInterrupted},
+		{"signal wins over connectivity", connectivityError(fmt.Errorf("%w: terminated", signals.ErrShutdownSignal)), ExitInterrupted},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: 退出码为 %d，应为 %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"content-verify-log/pkg/util"
+
+	"go.uber.org/zap"
+)
+
+// 统计跳过的触发次数时最多向后查找的次数，避免每分钟触发的计划在长时间运行后逐分钟计数
+const maxMissedTicks = 1000
+
+// runSchedule 常驻进程，每到计划时间执行一次 run；运行期间到达的触发时间直接跳过，不排队补跑
+// 单次运行失败只记录日志，等待下一次触发；收到退出信号时，等待期间直接退出，运行期间取消本次运行后退出
+func runSchedule(ctx context.Context, schedule *util.CronSchedule, run func(ctx context.Context) error) error {
+	zap.S().Infof("已按计划 %q 启动，进程常驻", schedule)
+	for {
+		next := schedule.Next(time.Now())
+		zap.S().Infof("下一次迁移时间: %s", next.Format(time.DateTime))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			zap.S().Infof("收到退出信号，停止计划迁移")
+			return nil
+		case <-timer.C:
+		}
+
+		zap.S().Infof("开始计划迁移（计划时间 %s）", next.Format(time.DateTime))
+		if err := run(ctx); err != nil {
+			if ExitCode(err) == ExitInterrupted {
+				return err
+			}
+			zap.S().Errorf("计划迁移失败，等待下一次触发: %v", err)
+		}
+		if missed := missedTicks(schedule, next, time.Now()); missed > 0 {
+			zap.S().Warnf("迁移耗时超过计划间隔，跳过了运行期间的 %d 次触发", missed)
+		}
+	}
+}
+
+// missedTicks 返回 start 之后、不晚于 now 的触发次数
+func missedTicks(schedule *util.CronSchedule, start, now time.Time) int {
+	missed := 0
+	for t := schedule.Next(start); !t.IsZero() && !t.After(now) && missed < maxMissedTicks; t = schedule.Next(t) {
+		missed++
+	}
+	return missed
+}
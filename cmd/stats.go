@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/repository"
+	"content-verify-log/pkg/service"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewStatsCommand() *cobra.Command {
+	var configFilePaths []string
+	var table, format string
+	var suspicious, byTask, failed bool
+	var skipRatio float64
+	var limit, offset int
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "统计处理结果表",
+		Long: "以只读方式打开 DuckDB，输出处理结果表的记录数、应用了修正和有错误码的记录数，以及按错误码的记录数；" +
+			"--by-task 时输出每个任务的记录数；--failed 时按 id 顺序分页列出有错误码的记录及其错误原因；" +
+			"--suspicious 时列出需要人工复核的记录：跳过的修正占（已应用 + 跳过）的比例超过 --skip-ratio，或有错误明细却未修改原文，" +
+			"输出 id、任务 ID、修正数量和最常见的跳过原因，用于有针对性地抽查",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
+			}
+			if _, err := applyLogging(cmd, cfg.LoggingConfig); err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
+
+			format = strings.ToLower(format)
+			if format != "table" && format != "json" {
+				return configError(fmt.Errorf("不支持的输出格式 %q，可选 table/json", format))
+			}
+			if cfg.DuckDBConfig == nil {
+				return configError(errors.New("DuckDB 配置未设置"))
+			}
+			if countTrue(suspicious, byTask, failed) > 1 {
+				return configError(errors.New("--suspicious、--by-task 和 --failed 只能指定一个"))
+			}
+			if limit < 1 || offset < 0 {
+				return configError(fmt.Errorf("--limit 必须大于 0、--offset 不能为负数，当前为 %d、%d", limit, offset))
+			}
+			// 阈值默认与迁移时标记 needs_review 使用的一致
+			if !cmd.Flags().Changed("skip-ratio") && cfg.MigrationConfig != nil {
+				skipRatio = cfg.MigrationConfig.ReviewSkipRatio
+			}
+			if skipRatio < 0 || skipRatio > 1 {
+				return configError(fmt.Errorf("--skip-ratio 超出范围 [0, 1]，当前为 %g", skipRatio))
+			}
+			if table == "" && cfg.MigrationConfig != nil {
+				table = cfg.MigrationConfig.TargetTable
+			}
+
+			ctx := cmd.Context()
+			if err := db.InitDuckDBReadOnly(cfg.DuckDBConfig); err != nil {
+				return connectivityError(fmt.Errorf("DuckDB 连接错误:%w", err))
+			}
+
+			migrationService := service.NewMigrationServiceWithDB(service.MigrationOptions{TargetTable: table}, nil, db.GetDuckDB())
+			switch {
+			case byTask:
+				counts, err := migrationService.GetCountsByTask(ctx)
+				if err != nil {
+					return withCause(ctx, err)
+				}
+				if format == "json" {
+					return writeJSON(cmd, counts)
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "TASK ID\tROWS")
+				for _, key := range sortedByCount(counts) {
+					task := key
+					if task == "" {
+						task = "-"
+					}
+					fmt.Fprintf(w, "%s\t%d\n", task, counts[key])
+				}
+				return w.Flush()
+			case failed:
+				records, err := migrationService.ListFailed(ctx, limit, offset)
+				if err != nil {
+					return withCause(ctx, err)
+				}
+				if format == "json" {
+					if records == nil {
+						records = []repository.FailedContent{}
+					}
+					return writeJSON(cmd, records)
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tTASK ID\tERROR CODE\tERROR REASON")
+				for _, r := range records {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.ID, r.TaskID, r.ErrorCode, r.ErrorReason)
+				}
+				return w.Flush()
+			case !suspicious:
+				stats, err := service.GetTableStats(ctx, db.GetDuckDB(), table)
+				if err != nil {
+					return withCause(ctx, err)
+				}
+				codes, err := migrationService.GetCountsByErrorCode(ctx)
+				if err != nil {
+					return withCause(ctx, err)
+				}
+				if format == "json" {
+					return writeJSON(cmd, struct {
+						*service.TableStats
+						ErrorCodes map[string]int64 `json:"error_codes"`
+					}{stats, codes})
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintf(w, "记录数\t%d\n", stats.Rows)
+				fmt.Fprintf(w, "应用了修正\t%d\n", stats.WithCorrections)
+				fmt.Fprintf(w, "有错误码\t%d\n", stats.WithErrorCode)
+				for _, code := range sortedByCount(codes) {
+					fmt.Fprintf(w, "  %s\t%d\n", code, codes[code])
+				}
+				fmt.Fprintf(w, "标记为需复核\t%d\n", stats.NeedsReview)
+				return w.Flush()
+			}
+
+			docs, err := service.ListSuspiciousDocuments(ctx, db.GetDuckDB(), table, skipRatio)
+			if err != nil {
+				return withCause(ctx, err)
+			}
+			if format == "json" {
+				if docs == nil {
+					docs = []service.SuspiciousDocument{}
+				}
+				return writeJSON(cmd, docs)
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTASK ID\tAPPLIED\tSKIPPED\tSKIP RATIO\tDOMINANT SKIP REASON\tUNCHANGED")
+			for _, doc := range docs {
+				reason := doc.DominantSkipReason
+				if reason == "" {
+					reason = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.2f\t%s\t%t\n", doc.ID, doc.TaskID, doc.Applied, doc.Skipped, doc.SkipRatio, reason, doc.Unchanged)
+			}
+			fmt.Fprintf(w, "共 %d 条需要复核（跳过比例阈值 %g）\n", len(docs), skipRatio)
+			return w.Flush()
+		},
+	}
+
+	addConfigFlag(cmd, &configFilePaths)
+	cmd.Flags().StringVar(&table, "table", "", "要统计的表，默认使用 migration.targetTable")
+	cmd.Flags().BoolVar(&suspicious, "suspicious", false, "列出需要人工复核的记录")
+	cmd.Flags().BoolVar(&byTask, "by-task", false, "输出每个任务的记录数")
+	cmd.Flags().BoolVar(&failed, "failed", false, "按 id 顺序列出有错误码的记录")
+	cmd.Flags().IntVar(&limit, "limit", 100, "--failed 时最多列出的记录数")
+	cmd.Flags().IntVar(&offset, "offset", 0, "--failed 时跳过的记录数，用于翻页")
+	cmd.Flags().Float64Var(&skipRatio, "skip-ratio", 0.5, "跳过的修正占比超过该值时需要复核 [0, 1]，默认使用 migration.reviewSkipRatio")
+	cmd.Flags().StringVarP(&format, "output", "o", "table", "输出格式：table/json")
+	return cmd
+}
+
+// countTrue 返回值为 true 的个数
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+// sortedByCount 按数量降序返回 counts 的 key，数量相同时按 key 排序
+func sortedByCount(counts map[string]int64) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// writeJSON 以缩进的 JSON 输出 v
+func writeJSON(cmd *cobra.Command, v interface{}) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/service"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewTasksCommand() *cobra.Command {
+	var configFilePaths []string
+	var includeDeleted bool
+
+	cmd := &cobra.Command{
+		Use:   "tasks",
+		Short: "列出源表中的任务及记录数",
+		Long:  "以只读方式打开 DuckDB，按任务统计 tbl_verify_content 的记录数并按数量降序打印，便于选择 --task-id 和了解数据分布；默认不统计已软删除的记录",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, provenance, err := loadConfig(cmd, configFilePaths)
+			if err != nil {
+				return configError(fmt.Errorf("读取本地配置文件错误:%w", err))
+			}
+			if err := validateConfig(cfg, provenance); err != nil {
+				return configError(fmt.Errorf("本地配置文件验证错误:%w", err))
+			}
+			if _, err := applyLogging(cmd, cfg.LoggingConfig); err != nil {
+				return configError(fmt.Errorf("日志配置错误:%w", err))
+			}
+			defer func() {
+				_ = zap.L().Sync()
+			}()
+
+			if cfg.DuckDBConfig == nil {
+				return configError(errors.New("DuckDB 配置未设置"))
+			}
+			ctx := cmd.Context()
+			if err := db.InitDuckDBReadOnly(cfg.DuckDBConfig); err != nil {
+				return connectivityError(fmt.Errorf("DuckDB 连接错误:%w", err))
+			}
+			tasks, err := service.ListSourceTasks(ctx, db.GetDuckDB(), includeDeleted)
+			if err != nil {
+				return withCause(ctx, err)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TASK ID\tROWS")
+			var total int64
+			for _, task := range tasks {
+				taskID := task.TaskID
+				if !task.Valid {
+					taskID = "(NULL)"
+				}
+				fmt.Fprintf(w, "%s\t%d\n", taskID, task.Rows)
+				total += task.Rows
+			}
+			fmt.Fprintf(w, "共 %d 个任务\t%d\n", len(tasks), total)
+			return w.Flush()
+		},
+	}
+
+	addConfigFlag(cmd, &configFilePaths)
+	cmd.Flags().BoolVar(&includeDeleted, "include-deleted", false, "同时统计 deleted_at 不为空的软删除记录")
+	return cmd
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	_ "embed"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultConfigYAML 带注释的默认配置，取值与 NewDefaultGlobalConfig 一致
+//
+//go:embed default_config.yaml
+var DefaultConfigYAML []byte
+
+// WriteDefaultConfig 将默认配置写入指定路径，目录不存在时创建；文件已存在时返回错误，不会覆盖
+func WriteDefaultConfig(path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.Wrapf(err, "创建配置目录 %s 失败", dir)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "创建配置文件 %s 失败", path)
+	}
+	if _, err := f.Write(DefaultConfigYAML); err != nil {
+		_ = f.Close()
+		return errors.Wrapf(err, "写入配置文件 %s 失败", path)
+	}
+	return f.Close()
+}
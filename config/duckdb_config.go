@@ -8,7 +8,8 @@ import (
 )
 
 type DuckDBConfig struct {
-	DBPath string `json:"dbPath" yaml:"dbPath"` // DuckDB 数据库文件路径
+	DBPath   string `json:"dbPath" yaml:"dbPath"`     // DuckDB 数据库文件路径
+	ReadOnly bool   `json:"readOnly" yaml:"readOnly"` // 以只读方式打开，避免与写入进程的锁冲突
 }
 
 func (d *DuckDBConfig) Validate() []error {
@@ -18,6 +19,14 @@ func (d *DuckDBConfig) Validate() []error {
 		return errs
 	}
 
+	// 只读打开时文件必须已存在，DuckDB 不会为只读连接创建新库
+	if d.ReadOnly {
+		if _, err := os.Stat(d.DBPath); err != nil {
+			errs = append(errs, errors.Errorf("只读打开的 DuckDB 文件不存在: %v", err))
+		}
+		return errs
+	}
+
 	// 确保目录存在
 	dir := filepath.Dir(d.DBPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -34,5 +43,15 @@ func NewDefaultDuckDBConfig() *DuckDBConfig {
 }
 
 func (d *DuckDBConfig) DSN() string {
+	if d.ReadOnly {
+		return d.DBPath + "?access_mode=READ_ONLY"
+	}
 	return d.DBPath
 }
+
+// AsReadOnly 返回只读打开的配置副本，供只读子命令使用
+func (d *DuckDBConfig) AsReadOnly() *DuckDBConfig {
+	cfg := *d
+	cfg.ReadOnly = true
+	return &cfg
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/go-viper/mapstructure/v2"
@@ -15,7 +16,12 @@ type IConfig interface {
 }
 
 type GlobalConfig struct {
-	DuckDBConfig *DuckDBConfig `json:"duckdb" yaml:"duckdb"`
+	DuckDBConfig    *DuckDBConfig    `json:"duckdb" yaml:"duckdb"`
+	MigrationConfig *MigrationConfig `json:"migration" yaml:"migration"`
+	LoggingConfig   *LoggingConfig   `json:"logging" yaml:"logging"`
+	OutputConfig    *OutputConfig    `json:"output" yaml:"output"`
+
+	NotificationConfig *NotificationConfig `json:"notifications" yaml:"notifications"`
 }
 
 func (g *GlobalConfig) Validate() []error {
@@ -25,37 +31,131 @@ func (g *GlobalConfig) Validate() []error {
 			errs = append(errs, es...)
 		}
 	}
+	if g.MigrationConfig != nil {
+		if es := g.MigrationConfig.Validate(); len(es) > 0 {
+			errs = append(errs, es...)
+		}
+	}
+	if g.LoggingConfig != nil {
+		if es := g.LoggingConfig.Validate(); len(es) > 0 {
+			errs = append(errs, es...)
+		}
+	}
+	if g.OutputConfig != nil {
+		if es := g.OutputConfig.Validate(); len(es) > 0 {
+			errs = append(errs, es...)
+		}
+	}
+	if g.NotificationConfig != nil {
+		if es := g.NotificationConfig.Validate(); len(es) > 0 {
+			errs = append(errs, es...)
+		}
+	}
 	return errs
 }
 
 func NewDefaultGlobalConfig() *GlobalConfig {
 	return &GlobalConfig{
-		DuckDBConfig: NewDefaultDuckDBConfig(),
+		DuckDBConfig:    NewDefaultDuckDBConfig(),
+		MigrationConfig: NewDefaultMigrationConfig(),
+		LoggingConfig:   NewDefaultLoggingConfig(),
+		OutputConfig:    NewDefaultOutputConfig(),
+
+		NotificationConfig: NewDefaultNotificationConfig(),
 	}
 }
-func TryLoadFromDisk(configFilePath string) (*GlobalConfig, error) {
-	_, err := os.Stat(configFilePath)
-	if err != nil {
-		return nil, err
+
+// EnvPrefix 环境变量前缀，key 路径中的 . 替换为 _ 并转为大写，如 duckdb.dbPath 对应 CVL_DUCKDB_DBPATH
+const EnvPrefix = "CVL"
+
+// configKeys 按 yaml 标签递归列出配置结构体中的每个叶子 key，如 duckdb.dbPath
+func configKeys(t reflect.Type, prefix string) []string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			keys = append(keys, configKeys(ft, key)...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// EnvName 返回配置 key 对应的环境变量名
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// bindEnvs 绑定配置结构体中的每个 key
+func bindEnvs(v *viper.Viper) error {
+	for _, key := range configKeys(reflect.TypeOf(GlobalConfig{}), "") {
+		if err := v.BindEnv(key); err != nil {
+			return errors.Wrapf(err, "绑定环境变量 %s 失败", key)
+		}
 	}
-	dir, file := filepath.Split(configFilePath)
-	fileType := filepath.Ext(file)
-	viper.AddConfigPath(dir)
-	viper.SetConfigName(strings.TrimSuffix(file, fileType))
-	viper.SetConfigType(strings.TrimPrefix(fileType, "."))
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	if err := viper.ReadInConfig(); err != nil {
-		if errors.As(err, &viper.ConfigFileNotFoundError{}) {
+	return nil
+}
+
+// TryLoadFromDisk 读取一个或多个配置文件，后面的文件按 key 深度合并覆盖前面的文件
+// 映射逐层合并，列表（如 migration.taskIds）整体替换；环境变量优先于所有配置文件
+// 不指定配置文件时只使用默认值和环境变量
+func TryLoadFromDisk(configFilePaths ...string) (*GlobalConfig, error) {
+	for _, configFilePath := range configFilePaths {
+		if _, err := os.Stat(configFilePath); err != nil {
 			return nil, err
 		}
-		return nil, errors.Errorf("解析配置文件错误:%s", err.Error())
+	}
+	fileType := ".yaml"
+	if len(configFilePaths) > 0 {
+		fileType = filepath.Ext(configFilePaths[0])
+	}
+	// 每次加载使用独立的 viper 实例，避免多次加载之间通过全局状态互相影响
+	v := viper.New()
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	// AutomaticEnv 只对已知的 key 生效，文件中没有的嵌套 key 需要显式绑定才能从环境变量读取
+	if err := bindEnvs(v); err != nil {
+		return nil, err
+	}
+	for i, configFilePath := range configFilePaths {
+		v.SetConfigFile(configFilePath)
+		v.SetConfigType(strings.TrimPrefix(filepath.Ext(configFilePath), "."))
+		read := v.MergeInConfig
+		if i == 0 {
+			read = v.ReadInConfig
+		}
+		if err := read(); err != nil {
+			if errors.As(err, &viper.ConfigFileNotFoundError{}) {
+				return nil, err
+			}
+			return nil, errors.Errorf("解析配置文件 %s 错误:%s", configFilePath, err.Error())
+		}
 	}
 	cfg := NewDefaultGlobalConfig()
-	if err := viper.Unmarshal(cfg, func(config *mapstructure.DecoderConfig) {
+	if err := v.Unmarshal(cfg, func(config *mapstructure.DecoderConfig) {
 		config.TagName = strings.TrimPrefix(fileType, ".")
 	}); err != nil {
 		return nil, err
 	}
+	if err := resolveSecretRefs(reflect.ValueOf(cfg), ""); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
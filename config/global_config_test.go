@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// writeConfig 在临时目录中写入配置文件，返回其路径
+func writeConfig(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestTryLoadFromDiskEnv 只有环境变量、只有配置文件和两者混合时各 key 的取值：
+// 环境变量优先于所有配置文件，文件中没有的嵌套 key 同样可以从环境变量设置，空的环境变量不覆盖
+func TestTryLoadFromDiskEnv(t *testing.T) {
+	base := `duckdb:
+  dbPath: /data/file.duckdb
+migration:
+  batchSize: 200
+  taskIds: [f1, f2]
+logging:
+  level: warn
+notifications:
+  webhookUrl: ${env:CVL_TEST_WEBHOOK}
+`
+	override := `migration:
+  batchSize: 300
+`
+	defaults := NewDefaultGlobalConfig()
+
+	tests := []struct {
+		name  string
+		files []string
+		env   map[string]string
+		check func(t *testing.T, cfg *GlobalConfig)
+	}{
+		{
+			name: "env only",
+			env: map[string]string{
+				"CVL_DUCKDB_DBPATH":            "/data/env.duckdb",
+				"CVL_DUCKDB_READONLY":          "true",
+				"CVL_MIGRATION_BATCHSIZE":      "50",
+				"CVL_MIGRATION_TASKIDS":        "e1,e2",
+				"CVL_LOGGING_LEVEL":            "error",
+				"CVL_NOTIFICATIONS_WEBHOOKURL": "https://hooks.example/env",
+			},
+			check: func(t *testing.T, cfg *GlobalConfig) {
+				expect(t, "duckdb.dbPath", cfg.DuckDBConfig.DBPath, "/data/env.duckdb")
+				expect(t, "duckdb.readOnly", cfg.DuckDBConfig.ReadOnly, true)
+				expect(t, "migration.batchSize", cfg.MigrationConfig.BatchSize, 50)
+				expect(t, "migration.taskIds", cfg.MigrationConfig.TaskIDs, []string{"e1", "e2"})
+				expect(t, "logging.level", cfg.LoggingConfig.Level, "error")
+				expect(t, "notifications.webhookUrl", cfg.NotificationConfig.WebhookURL, "https://hooks.example/env")
+				expect(t, "migration.queueDepth", cfg.MigrationConfig.QueueDepth, defaults.MigrationConfig.QueueDepth)
+			},
+		},
+		{
+			name:  "file only",
+			files: []string{base},
+			env:   map[string]string{"CVL_TEST_WEBHOOK": "https://hooks.example/secret"},
+			check: func(t *testing.T, cfg *GlobalConfig) {
+				expect(t, "duckdb.dbPath", cfg.DuckDBConfig.DBPath, "/data/file.duckdb")
+				expect(t, "migration.batchSize", cfg.MigrationConfig.BatchSize, 200)
+				expect(t, "migration.taskIds", cfg.MigrationConfig.TaskIDs, []string{"f1", "f2"})
+				expect(t, "logging.level", cfg.LoggingConfig.Level, "warn")
+				expect(t, "notifications.webhookUrl", cfg.NotificationConfig.WebhookURL, "https://hooks.example/secret")
+				expect(t, "logging.format", cfg.LoggingConfig.Format, defaults.LoggingConfig.Format)
+			},
+		},
+		{
+			name:  "mixed",
+			files: []string{base, override},
+			env: map[string]string{
+				"CVL_TEST_WEBHOOK":         "https://hooks.example/secret",
+				"CVL_MIGRATION_BATCHSIZE":  "75",
+				"CVL_MIGRATION_QUEUEDEPTH": "8",
+				"CVL_LOGGING_LEVEL":        "",
+			},
+			check: func(t *testing.T, cfg *GlobalConfig) {
+				expect(t, "duckdb.dbPath", cfg.DuckDBConfig.DBPath, "/data/file.duckdb")
+				expect(t, "migration.batchSize", cfg.MigrationConfig.BatchSize, 75)
+				expect(t, "migration.queueDepth", cfg.MigrationConfig.QueueDepth, 8)
+				expect(t, "migration.taskIds", cfg.MigrationConfig.TaskIDs, []string{"f1", "f2"})
+				expect(t, "logging.level", cfg.LoggingConfig.Level, "warn")
+			},
+		},
+		{
+			name:  "later file without env",
+			files: []string{base, override},
+			env:   map[string]string{"CVL_TEST_WEBHOOK": "https://hooks.example/secret"},
+			check: func(t *testing.T, cfg *GlobalConfig) {
+				expect(t, "migration.batchSize", cfg.MigrationConfig.BatchSize, 300)
+				expect(t, "duckdb.dbPath", cfg.DuckDBConfig.DBPath, "/data/file.duckdb")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			var paths []string
+			for i, body := range tt.files {
+				paths = append(paths, writeConfig(t, fmt.Sprintf("config%d.yaml", i), body))
+			}
+			cfg, err := TryLoadFromDisk(paths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+// TestTryLoadFromDiskSecretRefUnset 配置中引用的环境变量未设置时返回错误
+func TestTryLoadFromDiskSecretRefUnset(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "notifications:\n  webhookUrl: ${env:CVL_TEST_UNSET}\n")
+	if _, err := TryLoadFromDisk(path); err == nil {
+		t.Fatal("引用的环境变量未设置时应返回错误")
+	}
+}
+
+// TestTryLoadFromDiskParallel 并发加载不同的配置文件，每次加载使用独立的 viper 实例，结果互不影响，也不修改全局 viper
+func TestTryLoadFromDiskParallel(t *testing.T) {
+	const loaders = 16
+	paths := make([]string, loaders)
+	for i := range paths {
+		paths[i] = writeConfig(t, fmt.Sprintf("config%d.yaml", i),
+			fmt.Sprintf("duckdb:\n  dbPath: /data/%d.duckdb\nmigration:\n  batchSize: %d\n  taskIds: [t%d]\n", i, i+1, i))
+	}
+	t.Run("group", func(t *testing.T) {
+		for i, path := range paths {
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				t.Parallel()
+				for n := 0; n < 20; n++ {
+					cfg, err := TryLoadFromDisk(path)
+					if err != nil {
+						t.Fatal(err)
+					}
+					expect(t, "duckdb.dbPath", cfg.DuckDBConfig.DBPath, fmt.Sprintf("/data/%d.duckdb", i))
+					expect(t, "migration.batchSize", cfg.MigrationConfig.BatchSize, i+1)
+					expect(t, "migration.taskIds", cfg.MigrationConfig.TaskIDs, []string{fmt.Sprintf("t%d", i)})
+				}
+			})
+		}
+		// 与按文件加载并发的只使用默认值的加载
+		t.Run("defaults", func(t *testing.T) {
+			t.Parallel()
+			for n := 0; n < 20; n++ {
+				cfg, err := TryLoadFromDisk()
+				if err != nil {
+					t.Fatal(err)
+				}
+				expect(t, "migration.batchSize", cfg.MigrationConfig.BatchSize, NewDefaultMigrationConfig().BatchSize)
+			}
+		})
+	})
+	if used := viper.ConfigFileUsed(); used != "" || len(viper.AllKeys()) != 0 {
+		t.Errorf("不应修改全局 viper，得到配置文件 %q 和 key %v", used, viper.AllKeys())
+	}
+}
+
+// TestConfigKeys 每个叶子 key 都绑定环境变量，嵌套结构体展开，key 不重复
+func TestConfigKeys(t *testing.T) {
+	keys := configKeys(reflect.TypeOf(GlobalConfig{}), "")
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			t.Errorf("key %s 重复", key)
+		}
+		seen[key] = true
+	}
+	for _, key := range []string{"duckdb.dbPath", "duckdb.readOnly", "migration.batchSize", "migration.taskIds", "logging.level", "output.dir", "notifications.webhookUrl"} {
+		if !seen[key] {
+			t.Errorf("缺少 key %s", key)
+		}
+	}
+	for _, key := range []string{"duckdb", "migration", "logging"} {
+		if seen[key] {
+			t.Errorf("%s 是结构体，不应作为叶子 key", key)
+		}
+	}
+	if got := EnvName("duckdb.dbPath"); got != "CVL_DUCKDB_DBPATH" {
+		t.Errorf("EnvName 得到 %s", got)
+	}
+}
+
+func expect(t *testing.T, key string, got, want interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s 为 %v，应为 %v", key, got, want)
+	}
+}
+
+// TestTryLoadFromDiskLayered 后面的文件深度合并覆盖前面的：只覆盖出现的 key，列表整体替换而不是追加，
+// 前面的文件没有的段（如 duckdb）由后面的文件补充；合并结果只取决于文件顺序
+func TestTryLoadFromDiskLayered(t *testing.T) {
+	base := writeConfig(t, "config.yaml", `migration:
+  batchSize: 200
+  taskIds: [b1, b2, b3]
+  preserveTags: [sup]
+logging:
+  level: warn
+  format: json
+`)
+	prod := writeConfig(t, "config.prod.yaml", `duckdb:
+  dbPath: /data/prod.duckdb
+migration:
+  taskIds: [p1]
+  excludeTaskIds: [x1]
+  preserveTags: []
+logging:
+  level: error
+`)
+
+	cfg, err := TryLoadFromDisk(base, prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, "duckdb.dbPath", cfg.DuckDBConfig.DBPath, "/data/prod.duckdb")
+	expect(t, "migration.batchSize", cfg.MigrationConfig.BatchSize, 200)
+	expect(t, "migration.taskIds", cfg.MigrationConfig.TaskIDs, []string{"p1"})
+	expect(t, "migration.excludeTaskIds", cfg.MigrationConfig.ExcludeTaskIDs, []string{"x1"})
+	expect(t, "migration.preserveTags", len(cfg.MigrationConfig.PreserveTags), 0)
+	expect(t, "logging.level", cfg.LoggingConfig.Level, "error")
+	expect(t, "logging.format", cfg.LoggingConfig.Format, "json")
+
+	reversed, err := TryLoadFromDisk(prod, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, "migration.taskIds", reversed.MigrationConfig.TaskIDs, []string{"b1", "b2", "b3"})
+	expect(t, "migration.preserveTags", reversed.MigrationConfig.PreserveTags, []string{"sup"})
+	expect(t, "logging.level", reversed.LoggingConfig.Level, "warn")
+	expect(t, "duckdb.dbPath", reversed.DuckDBConfig.DBPath, "/data/prod.duckdb")
+
+	for i := 0; i < 10; i++ {
+		again, err := TryLoadFromDisk(base, prod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(again, cfg) {
+			t.Fatalf("第 %d 次合并的结果不同", i+2)
+		}
+	}
+}
+
+// TestTryLoadFromDiskEmptySection 配置文件中段为空（null）时不覆盖前面的文件和默认值，不会得到 nil 的段
+func TestTryLoadFromDiskEmptySection(t *testing.T) {
+	base := writeConfig(t, "config.yaml", "duckdb:\n  dbPath: /data/base.duckdb\n")
+	overlay := writeConfig(t, "overlay.yaml", "duckdb:\nlogging:\n")
+	cfg, err := TryLoadFromDisk(base, overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DuckDBConfig == nil || cfg.LoggingConfig == nil {
+		t.Fatalf("段不应为 nil: %+v", cfg)
+	}
+	expect(t, "duckdb.dbPath", cfg.DuckDBConfig.DBPath, "/data/base.duckdb")
+	expect(t, "logging.level", cfg.LoggingConfig.Level, NewDefaultLoggingConfig().Level)
+}
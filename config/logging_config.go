@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggingConfig 日志配置
+type LoggingConfig struct {
+	Level          string `json:"level" yaml:"level"`                   // 日志级别：debug/info/warn/error
+	Format         string `json:"format" yaml:"format"`                 // 输出格式：console/json
+	Output         string `json:"output" yaml:"output"`                 // 输出位置：stdout/stderr 或文件路径
+	RowDiagnostics bool   `json:"rowDiagnostics" yaml:"rowDiagnostics"` // 是否输出逐行的跳过诊断日志（debug 级别）
+}
+
+func (l *LoggingConfig) Validate() []error {
+	var errs = make([]error, 0)
+	if _, err := zapcore.ParseLevel(l.Level); err != nil {
+		errs = append(errs, errors.Errorf("logging.level 不合法: %q，可选 debug/info/warn/error", l.Level))
+	}
+	switch strings.ToLower(l.Format) {
+	case "console", "json":
+	default:
+		errs = append(errs, errors.Errorf("logging.format 不合法: %q，可选 console/json", l.Format))
+	}
+	if l.Output == "" {
+		errs = append(errs, errors.Errorf("logging.output 不能为空"))
+	}
+	return errs
+}
+
+func NewDefaultLoggingConfig() *LoggingConfig {
+	return &LoggingConfig{
+		Level:          "debug",
+		Format:         "console",
+		Output:         "stderr",
+		RowDiagnostics: true,
+	}
+}
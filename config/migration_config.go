@@ -0,0 +1,263 @@
+package config
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+
+	"content-verify-log/pkg/util"
+
+	"github.com/pkg/errors"
+)
+
+// 迁移数值配置的取值上限
+const (
+	MaxBatchSize     = 100000 // 单批读取的最大记录数
+	MaxSearchWindow  = 1000   // 位置修复时的最大查找窗口
+	MaxContextWindow = 500    // 修正上下文的最大字符数
+	MaxJSONDepth     = 10000  // 源内容 JSON 嵌套层数限制的上限
+	MaxQueueDepth    = 64     // 流水线各阶段之间队列的最大批数
+	MaxTextOpenFiles = 1024   // 文本输出时同时打开的最大文件数
+)
+
+// MaxWorkers 返回允许的最大 worker 数（4×CPU 核数），避免并发过高耗尽数据库连接
+func MaxWorkers() int {
+	return 4 * runtime.NumCPU()
+}
+
+// MigrationConfig 迁移相关配置，命令行参数优先于配置文件，配置文件优先于默认值
+type MigrationConfig struct {
+	BatchSize                int      `json:"batchSize" yaml:"batchSize"`                               // 每批读取的记录数
+	Workers                  int      `json:"workers" yaml:"workers"`                                   // 并发处理的 worker 数
+	MemoryBudget             string   `json:"memoryBudget" yaml:"memoryBudget"`                         // 单批原文字节数上限，如 512MB，非空时按文档大小自适应调整批量，为空表示固定批量
+	QueueDepth               int      `json:"queueDepth" yaml:"queueDepth"`                             // 读取、处理、写入之间每个队列最多缓冲的批数
+	IngestMode               string   `json:"ingestMode" yaml:"ingestMode"`                             // 写入方式：insert 逐行插入，appender 按批通过 Appender 追加，copy 按批写入临时文件后批量读入，arrow 按批构建 Arrow record batch 后读入
+	DuplicateIDs             string   `json:"duplicateIds" yaml:"duplicateIds"`                         // 源数据中 ID 重复时的处理方式：skip 保留先写入的记录并计数，upsert 后来的记录替换先写入的
+	TaskIDs                  []string `json:"taskIds" yaml:"taskIds"`                                   // 只迁移这些任务，为空表示全部
+	ExcludeTaskIDs           []string `json:"excludeTaskIds" yaml:"excludeTaskIds"`                     // 不迁移这些任务
+	TargetTable              string   `json:"targetTable" yaml:"targetTable"`                           // 目标表名，为空时使用默认表
+	RunLabel                 string   `json:"runLabel" yaml:"runLabel"`                                 // 运行标签，非空时目标表追加 run_label 列，所有行的值为该标签；为空时没有该列
+	TextOnlyDir              string   `json:"textOnlyDir" yaml:"textOnlyDir"`                           // 非空时不写目标表，每篇文章写一个 <id>.txt 到该目录
+	TextOriginal             bool     `json:"textOriginal" yaml:"textOriginal"`                         // 文本输出写原文而不是修改后的文章
+	TextOpenFiles            int      `json:"textOpenFiles" yaml:"textOpenFiles"`                       // 文本输出时同时打开的文件数上限
+	IncludeTypes             []int    `json:"includeTypes" yaml:"includeTypes"`                         // 仅应用这些错误类型的修正
+	ExcludeTypes             []int    `json:"excludeTypes" yaml:"excludeTypes"`                         // 不应用这些错误类型的修正
+	MinErrorLevel            int      `json:"minErrorLevel" yaml:"minErrorLevel"`                       // 只应用错误级别不低于该值的修正，0 表示不限制
+	DedupCorrections         bool     `json:"dedupCorrections" yaml:"dedupCorrections"`                 // 位置、错误词和建议词都相同的重复修正项只应用第一项
+	OnlyErrors               bool     `json:"onlyErrors" yaml:"onlyErrors"`                             // 只写入实际应用了修正的记录
+	ValidateInput            bool     `json:"validateInput" yaml:"validateInput"`                       // 处理前校验输入格式
+	NormalizeText            bool     `json:"normalizeText" yaml:"normalizeText"`                       // 统一换行符并移除零宽字符
+	NormalizeQuotes          string   `json:"normalizeQuotes" yaml:"normalizeQuotes"`                   // 引号规范化：off 不处理，match 比较错误词时忽略弯引号/直引号的差异，all 同时将存储的文本统一为直引号
+	KeepHTML                 bool     `json:"keepHtml" yaml:"keepHtml"`                                 // 存储的原文和修改后的文章保留 HTML，只移除错误标记
+	PreserveTags             []string `json:"preserveTags" yaml:"preserveTags"`                         // 清洗 HTML 时原样保留的标签名，如 sup、sub、del
+	ImageAltText             bool     `json:"imageAltText" yaml:"imageAltText"`                         // 清洗 HTML 时将 alt 非空的图片替换为 "[图: alt]"
+	LinkURLs                 bool     `json:"linkUrls" yaml:"linkUrls"`                                 // 清洗 HTML 时在链接文字后附上 " (地址)"
+	MarkerClasses            []string `json:"markerClasses" yaml:"markerClasses"`                       // 新格式中作为错误标记移除的 span class
+	MarkerClassPrefix        string   `json:"markerClassPrefix" yaml:"markerClassPrefix"`               // class 以该前缀开头（如 jdt_）的 span 同样作为错误标记移除，为空不按前缀
+	KeepMarkerClasses        []string `json:"keepMarkerClasses" yaml:"keepMarkerClasses"`               // 不作为错误标记移除的 class，优先于 markerClasses 和 markerClassPrefix
+	SearchWindow             int      `json:"searchWindow" yaml:"searchWindow"`                         // 位置与错误词不一致时在前后多少个字符内查找，0 表示不查找
+	PositionFormat           string   `json:"positionFormat" yaml:"positionFormat"`                     // 新格式 position 的表示方式：offset 平铺偏移量，line-col 同时换算 {"line","col"} 形式的位置
+	ContextWindow            int      `json:"contextWindow" yaml:"contextWindow"`                       // 为已应用的修正在明细中记录前后各多少个字符的上下文，0 表示不记录
+	MinLengthRatio           float64  `json:"minLengthRatio" yaml:"minLengthRatio"`                     // 修改后的文章与原文字符数之比的下限，低于时记为 SUSPICIOUS_MODIFICATION，0 表示不检查
+	MaxLengthRatio           float64  `json:"maxLengthRatio" yaml:"maxLengthRatio"`                     // 修改后的文章与原文字符数之比的上限，高于时记为 SUSPICIOUS_MODIFICATION，0 表示不检查
+	KeepOriginalOnSuspicious bool     `json:"keepOriginalOnSuspicious" yaml:"keepOriginalOnSuspicious"` // 长度之比超出范围时修改后的文章保留为原文
+	TagNeedsReview           bool     `json:"tagNeedsReview" yaml:"tagNeedsReview"`                     // 在 needs_review 列标记需要人工复核的记录
+	ReviewSkipRatio          float64  `json:"reviewSkipRatio" yaml:"reviewSkipRatio"`                   // 跳过的修正占比超过该值时需要复核 [0, 1]
+	SourceEncoding           string   `json:"sourceEncoding" yaml:"sourceEncoding"`                     // 源 content 编码：utf-8/gbk/gb18030，非 UTF-8 的行按该编码转换，为 utf-8 时按 GB18030 尝试
+	MaxJSONSize              string   `json:"maxJsonSize" yaml:"maxJsonSize"`                           // 源内容 JSON 的最大字节数，如 64MB，超出的行不解析，写入死信表
+	MaxJSONDepth             int      `json:"maxJsonDepth" yaml:"maxJsonDepth"`                         // 源内容 JSON 的最大嵌套层数
+	SalvageTruncated         bool     `json:"salvageTruncated" yaml:"salvageTruncated"`                 // 源内容不是合法 JSON（通常被截断）时提取 checkresultstr/replace_text 等已知字段尽量处理
+	MaxErrorRate             float64  `json:"maxErrorRate" yaml:"maxErrorRate"`                         // 失败行（扫描失败、无法解析、写入失败）占读取行数的比例上限 [0, 1]，超过时中止迁移，0 表示不检查
+	SkipContentHash          bool     `json:"skipContentHash" yaml:"skipContentHash"`                   // 跳过源内容 SHA-256 计算以节省 CPU
+	ContentHashNFC           bool     `json:"contentHashNfc" yaml:"contentHashNfc"`                     // 计算 content_hash 前做 Unicode NFC 规范化
+	CompactAfter             bool     `json:"compactAfter" yaml:"compactAfter"`                         // 迁移成功后压缩 DuckDB 文件
+	ExportAfter              bool     `json:"exportAfter" yaml:"exportAfter"`                           // 迁移成功后按 output 配置导出目标表
+	Manifest                 bool     `json:"manifest" yaml:"manifest"`                                 // 迁移成功后在 DuckDB 文件所在目录写入 manifest.json，开启 exportAfter 时导出目录中同样写入
+	ShardBy                  string   `json:"shardBy" yaml:"shardBy"`                                   // 分片方式：为空不分片，task 按任务写入单独的 DuckDB 文件
+	ShardPath                string   `json:"shardPath" yaml:"shardPath"`                               // 分片文件路径模板，{task} 替换为任务 ID
+	PartitionBy              string   `json:"partitionBy" yaml:"partitionBy"`                           // 分区方式：为空不分区，month 按源记录创建时间的月份写入 <targetTable>_YYYYMM
+	Schedule                 string   `json:"schedule" yaml:"schedule"`                                 // cron 表达式（分 时 日 月 周），非空时常驻进程按计划重复迁移
+}
+
+// preserveTagPattern preserveTags 中允许的标签名：字母开头，只含字母、数字和连字符
+var preserveTagPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+
+// markerClassPattern markerClasses、keepMarkerClasses 和 markerClassPrefix 中允许的类名：不含空白、引号和尖括号
+var markerClassPattern = regexp.MustCompile(`^[^\s"'<>]+$`)
+
+func (m *MigrationConfig) Validate() []error {
+	var errs = make([]error, 0)
+	if m.BatchSize < 1 || m.BatchSize > MaxBatchSize {
+		errs = append(errs, errors.Errorf("migration.batchSize 超出范围 [1, %d]，当前为 %d", MaxBatchSize, m.BatchSize))
+	}
+	if maxWorkers := MaxWorkers(); m.Workers < 1 || m.Workers > maxWorkers {
+		errs = append(errs, errors.Errorf("migration.workers 超出范围 [1, %d]（4×CPU 核数），当前为 %d", maxWorkers, m.Workers))
+	}
+	if m.QueueDepth < 1 || m.QueueDepth > MaxQueueDepth {
+		errs = append(errs, errors.Errorf("migration.queueDepth 超出范围 [1, %d]，当前为 %d", MaxQueueDepth, m.QueueDepth))
+	}
+	switch m.IngestMode {
+	case "insert", "appender", "copy", "arrow":
+	default:
+		errs = append(errs, errors.Errorf("migration.ingestMode 不合法: %q，可选 insert/appender/copy/arrow", m.IngestMode))
+	}
+	switch m.DuplicateIDs {
+	case "skip", "upsert":
+	default:
+		errs = append(errs, errors.Errorf("migration.duplicateIds 不合法: %q，可选 skip/upsert", m.DuplicateIDs))
+	}
+	if m.MemoryBudget != "" {
+		if size, err := util.ParseByteSize(m.MemoryBudget); err != nil {
+			errs = append(errs, errors.Wrap(err, "migration.memoryBudget"))
+		} else if size < 1 {
+			errs = append(errs, errors.Errorf("migration.memoryBudget 必须大于 0，当前为 %q", m.MemoryBudget))
+		}
+	}
+	if m.MinErrorLevel < 0 {
+		errs = append(errs, errors.Errorf("migration.minErrorLevel 不能为负数，当前为 %d", m.MinErrorLevel))
+	}
+	if m.SearchWindow < 0 || m.SearchWindow > MaxSearchWindow {
+		errs = append(errs, errors.Errorf("migration.searchWindow 超出范围 [0, %d]，当前为 %d", MaxSearchWindow, m.SearchWindow))
+	}
+	for _, tag := range m.PreserveTags {
+		if !preserveTagPattern.MatchString(tag) {
+			errs = append(errs, errors.Errorf("migration.preserveTags 中的标签名不合法: %q，只允许字母开头的字母、数字和连字符", tag))
+		}
+	}
+	for key, classes := range map[string][]string{"markerClasses": m.MarkerClasses, "keepMarkerClasses": m.KeepMarkerClasses} {
+		for _, class := range classes {
+			if !markerClassPattern.MatchString(class) {
+				errs = append(errs, errors.Errorf("migration.%s 中的类名不合法: %q", key, class))
+			}
+		}
+	}
+	if m.MarkerClassPrefix != "" && !markerClassPattern.MatchString(m.MarkerClassPrefix) {
+		errs = append(errs, errors.Errorf("migration.markerClassPrefix 不合法: %q", m.MarkerClassPrefix))
+	}
+	if m.KeepHTML && len(m.PreserveTags) > 0 {
+		errs = append(errs, errors.New("migration.preserveTags 与 migration.keepHtml 不能同时使用：keepHtml 已保留全部 HTML"))
+	}
+	switch m.NormalizeQuotes {
+	case "off", "match", "all":
+	default:
+		errs = append(errs, errors.Errorf("migration.normalizeQuotes 不合法: %q，可选 off/match/all", m.NormalizeQuotes))
+	}
+	switch m.PositionFormat {
+	case "offset", "line-col":
+	default:
+		errs = append(errs, errors.Errorf("migration.positionFormat 不合法: %q，可选 offset/line-col", m.PositionFormat))
+	}
+	if m.ContextWindow < 0 || m.ContextWindow > MaxContextWindow {
+		errs = append(errs, errors.Errorf("migration.contextWindow 超出范围 [0, %d]，当前为 %d", MaxContextWindow, m.ContextWindow))
+	}
+	if m.MinLengthRatio < 0 || m.MinLengthRatio > 1 {
+		errs = append(errs, errors.Errorf("migration.minLengthRatio 超出范围 [0, 1]，当前为 %g", m.MinLengthRatio))
+	}
+	if m.MaxLengthRatio != 0 && m.MaxLengthRatio < 1 {
+		errs = append(errs, errors.Errorf("migration.maxLengthRatio 必须为 0 或不小于 1，当前为 %g", m.MaxLengthRatio))
+	}
+	if m.MaxErrorRate < 0 || m.MaxErrorRate > 1 {
+		errs = append(errs, errors.Errorf("migration.maxErrorRate 超出范围 [0, 1]，当前为 %g", m.MaxErrorRate))
+	}
+	if m.ReviewSkipRatio < 0 || m.ReviewSkipRatio > 1 {
+		errs = append(errs, errors.Errorf("migration.reviewSkipRatio 超出范围 [0, 1]，当前为 %g", m.ReviewSkipRatio))
+	}
+	if size, err := util.ParseByteSize(m.MaxJSONSize); err != nil {
+		errs = append(errs, errors.Wrap(err, "migration.maxJsonSize"))
+	} else if size < 1 {
+		errs = append(errs, errors.Errorf("migration.maxJsonSize 必须大于 0，当前为 %q", m.MaxJSONSize))
+	}
+	if m.MaxJSONDepth < 1 || m.MaxJSONDepth > MaxJSONDepth {
+		errs = append(errs, errors.Errorf("migration.maxJsonDepth 超出范围 [1, %d]，当前为 %d", MaxJSONDepth, m.MaxJSONDepth))
+	}
+	if err := util.ValidateEncoding(m.SourceEncoding); err != nil {
+		errs = append(errs, errors.Wrap(err, "migration.sourceEncoding"))
+	}
+	included := make(map[string]bool, len(m.TaskIDs))
+	for i, taskID := range m.TaskIDs {
+		if taskID == "" {
+			errs = append(errs, errors.Errorf("migration.taskIds[%d] 不能为空", i))
+		}
+		included[taskID] = true
+	}
+	for i, taskID := range m.ExcludeTaskIDs {
+		if taskID == "" {
+			errs = append(errs, errors.Errorf("migration.excludeTaskIds[%d] 不能为空", i))
+		} else if included[taskID] {
+			errs = append(errs, errors.Errorf("任务 %q 同时出现在 migration.taskIds 和 migration.excludeTaskIds 中", taskID))
+		}
+	}
+	switch m.ShardBy {
+	case "":
+	case "task":
+		if !strings.Contains(m.ShardPath, "{task}") {
+			errs = append(errs, errors.Errorf("migration.shardPath 必须包含 {task} 占位符，当前为 %q", m.ShardPath))
+		}
+	default:
+		errs = append(errs, errors.Errorf("migration.shardBy 不合法: %q，可选 task", m.ShardBy))
+	}
+	switch m.PartitionBy {
+	case "":
+	case "month":
+		if m.ShardBy != "" {
+			errs = append(errs, errors.New("migration.partitionBy 不能与 migration.shardBy 同时设置"))
+		}
+		if m.ExportAfter {
+			errs = append(errs, errors.New("migration.partitionBy 不能与 migration.exportAfter 同时设置：分区后没有单一的目标表可导出"))
+		}
+	default:
+		errs = append(errs, errors.Errorf("migration.partitionBy 不合法: %q，可选 month", m.PartitionBy))
+	}
+	if m.TextOpenFiles < 1 || m.TextOpenFiles > MaxTextOpenFiles {
+		errs = append(errs, errors.Errorf("migration.textOpenFiles 超出范围 [1, %d]，当前为 %d", MaxTextOpenFiles, m.TextOpenFiles))
+	}
+	if m.TextOnlyDir != "" {
+		conflicts := []struct {
+			key string
+			set bool
+		}{
+			{"shardBy", m.ShardBy != ""},
+			{"partitionBy", m.PartitionBy != ""},
+			{"runLabel", m.RunLabel != ""},
+			{"exportAfter", m.ExportAfter},
+			{"manifest", m.Manifest},
+		}
+		for _, c := range conflicts {
+			if c.set {
+				errs = append(errs, errors.Errorf("migration.textOnlyDir 不能与 migration.%s 同时设置：文本输出不写目标表", c.key))
+			}
+		}
+	}
+	if m.Schedule != "" {
+		if _, err := util.ParseCronSchedule(m.Schedule); err != nil {
+			errs = append(errs, errors.Wrap(err, "migration.schedule"))
+		}
+	}
+	if m.TargetTable != "" {
+		if _, err := util.SanitizeIdentifier(m.TargetTable); err != nil {
+			errs = append(errs, errors.Wrap(err, "migration.targetTable"))
+		}
+	}
+	return errs
+}
+
+func NewDefaultMigrationConfig() *MigrationConfig {
+	return &MigrationConfig{
+		BatchSize:        100,
+		Workers:          1,
+		QueueDepth:       2,
+		TextOpenFiles:    16,
+		IngestMode:       "insert",
+		DuplicateIDs:     "skip",
+		SearchWindow:     8,
+		DedupCorrections: true,
+		PositionFormat:   "offset",
+		NormalizeQuotes:  "off",
+		MarkerClasses:    []string{"jdt_umold"},
+		ReviewSkipRatio:  0.5,
+		SourceEncoding:   util.EncodingUTF8,
+		MaxJSONSize:      "64MB",
+		MaxJSONDepth:     200,
+		ShardPath:        "./data/shards/{task}.duckdb",
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+// TestMigrationConfigPreserveTags preserveTags 中的标签名只允许字母开头的字母、数字和连字符，且不能与 keepHtml 同时使用
+func TestMigrationConfigPreserveTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		keepHTML bool
+		wantErr  bool
+	}{
+		{name: "valid", tags: []string{"sup", "SUB", "my-tag", "h1"}},
+		{name: "angle bracket", tags: []string{"<sup>"}, wantErr: true},
+		{name: "attribute", tags: []string{"sup onclick=x"}, wantErr: true},
+		{name: "leading digit", tags: []string{"1sup"}, wantErr: true},
+		{name: "empty", tags: []string{""}, wantErr: true},
+		{name: "with keepHtml", tags: []string{"sup"}, keepHTML: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewDefaultMigrationConfig()
+			m.PreserveTags = tt.tags
+			m.KeepHTML = tt.keepHTML
+			if errs := m.Validate(); (len(errs) > 0) != tt.wantErr {
+				t.Errorf("校验结果 %v，应返回错误: %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 重试次数上限，避免迁移结束后长时间卡在通知上
+const MaxNotificationRetries = 10
+
+// NotificationConfig 迁移结束通知配置
+type NotificationConfig struct {
+	WebhookURL  string `json:"webhookUrl" yaml:"webhookUrl"`   // 迁移结束（成功、失败、中断）或因失败行的比例超过 migration.maxErrorRate 中止时 POST 运行摘要的地址，为空表示不通知
+	BearerToken string `json:"bearerToken" yaml:"bearerToken"` // 可选，以 Authorization: Bearer 发送，建议用 ${env:NAME} 引用
+	Timeout     string `json:"timeout" yaml:"timeout"`         // 单次请求超时，如 10s
+	Retries     int    `json:"retries" yaml:"retries"`         // 请求失败后的重试次数
+}
+
+func (n *NotificationConfig) Validate() []error {
+	var errs = make([]error, 0)
+	if n.WebhookURL != "" {
+		if u, err := url.Parse(n.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.Errorf("notifications.webhookUrl 不合法，应为 http:// 或 https:// 地址"))
+		}
+	}
+	if d, err := time.ParseDuration(n.Timeout); err != nil || d <= 0 {
+		errs = append(errs, errors.Errorf("notifications.timeout 不合法: %q，示例: 10s", n.Timeout))
+	}
+	if n.Retries < 0 || n.Retries > MaxNotificationRetries {
+		errs = append(errs, errors.Errorf("notifications.retries 超出范围 [0, %d]: %d", MaxNotificationRetries, n.Retries))
+	}
+	return errs
+}
+
+// TimeoutDuration 返回单次请求超时，配置需已通过校验
+func (n *NotificationConfig) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(n.Timeout)
+	return d
+}
+
+func NewDefaultNotificationConfig() *NotificationConfig {
+	return &NotificationConfig{
+		Timeout: "10s",
+		Retries: 2,
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"content-verify-log/pkg/util"
+
+	"github.com/pkg/errors"
+)
+
+// 导出格式
+const (
+	OutputFormatParquet = "parquet"
+	OutputFormatCSV     = "csv"
+	OutputFormatJSONL   = "jsonl"
+)
+
+// OutputConfig 导出配置，migrate 的导出选项和 export 子命令共用
+type OutputConfig struct {
+	Dir          string               `json:"dir" yaml:"dir"`                   // 导出目录
+	Format       string               `json:"format" yaml:"format"`             // 导出格式：parquet/csv/jsonl
+	RolloverSize string               `json:"rolloverSize" yaml:"rolloverSize"` // 单个文件的大小上限，如 256MB，为空表示不拆分
+	Gzip         bool                 `json:"gzip" yaml:"gzip"`                 // csv/jsonl 是否 gzip 压缩
+	Manifest     bool                 `json:"manifest" yaml:"manifest"`         // 导出完成后在导出目录写入 manifest.json
+	CSV          *CSVOutputConfig     `json:"csv" yaml:"csv"`
+	Parquet      *ParquetOutputConfig `json:"parquet" yaml:"parquet"`
+
+	ObjectStore *ObjectStoreOutputConfig `json:"objectStore" yaml:"objectStore"`
+}
+
+// CSVOutputConfig csv 导出配置
+type CSVOutputConfig struct {
+	Delimiter string `json:"delimiter" yaml:"delimiter"` // 分隔符，单个字符
+	ExcelBOM  bool   `json:"excelBom" yaml:"excelBom"`   // 写入 UTF-8 BOM，便于 Excel 正确识别中文
+}
+
+// ParquetOutputConfig parquet 导出配置
+type ParquetOutputConfig struct {
+	Compression string `json:"compression" yaml:"compression"` // 压缩算法：snappy/zstd/gzip/uncompressed
+}
+
+// ObjectStoreOutputConfig 导出目录为 s3://、oss:// 地址时的对象存储配置
+// 凭证不写入配置文件，从环境变量、~/.aws/credentials 或实例角色按顺序读取
+type ObjectStoreOutputConfig struct {
+	Endpoint string `json:"endpoint" yaml:"endpoint"` // 服务地址，为空时 s3 使用 AWS，oss 使用 oss-<region>.aliyuncs.com；以 http:// 开头时不使用 TLS
+	Region   string `json:"region" yaml:"region"`     // 区域，如 us-east-1、cn-hangzhou
+	Bucket   string `json:"bucket" yaml:"bucket"`     // 导出目录未指定 bucket（如 s3:///exports）时使用
+}
+
+func (o *OutputConfig) Validate() []error {
+	var errs = make([]error, 0)
+	switch o.Format {
+	case OutputFormatParquet, OutputFormatCSV, OutputFormatJSONL:
+	default:
+		errs = append(errs, errors.Errorf("output.format 不合法: %q，可选 parquet/csv/jsonl", o.Format))
+	}
+	if o.RolloverSize != "" {
+		if size, err := util.ParseByteSize(o.RolloverSize); err != nil {
+			errs = append(errs, errors.Wrap(err, "output.rolloverSize"))
+		} else if size <= 0 {
+			errs = append(errs, errors.Errorf("output.rolloverSize 必须大于 0"))
+		}
+	}
+	if o.Gzip && o.Format == OutputFormatParquet {
+		errs = append(errs, errors.Errorf("output.gzip 只适用于 csv/jsonl，parquet 请使用 output.parquet.compression"))
+	}
+	if o.CSV != nil && len([]rune(o.CSV.Delimiter)) != 1 {
+		errs = append(errs, errors.Errorf("output.csv.delimiter 必须是单个字符，当前为 %q", o.CSV.Delimiter))
+	}
+	if o.Parquet != nil {
+		switch strings.ToLower(o.Parquet.Compression) {
+		case "snappy", "zstd", "gzip", "uncompressed":
+		default:
+			errs = append(errs, errors.Errorf("output.parquet.compression 不合法: %q，可选 snappy/zstd/gzip/uncompressed", o.Parquet.Compression))
+		}
+	}
+	if o.Dir == "" {
+		errs = append(errs, errors.Errorf("output.dir 不能为空"))
+	} else if util.IsObjectURL(o.Dir) {
+		errs = append(errs, o.validateObjectStore()...)
+	} else if err := os.MkdirAll(o.Dir, 0755); err != nil {
+		errs = append(errs, errors.Errorf("创建导出目录失败: %v", err))
+	}
+	return errs
+}
+
+// validateObjectStore 校验导出到对象存储时的地址和配置
+func (o *OutputConfig) validateObjectStore() []error {
+	u, err := util.ParseObjectURL(o.Dir)
+	if err != nil {
+		return []error{errors.Wrap(err, "output.dir")}
+	}
+	store := o.ObjectStore
+	if store == nil {
+		store = &ObjectStoreOutputConfig{}
+	}
+	var errs []error
+	if u.Bucket == "" && store.Bucket == "" {
+		errs = append(errs, errors.Errorf("output.dir %q 未指定 bucket，且 output.objectStore.bucket 为空", o.Dir))
+	}
+	if u.Scheme == util.ObjectSchemeOSS && store.Endpoint == "" && store.Region == "" {
+		errs = append(errs, errors.Errorf("导出到 oss:// 时需要设置 output.objectStore.endpoint 或 output.objectStore.region"))
+	}
+	return errs
+}
+
+func NewDefaultOutputConfig() *OutputConfig {
+	return &OutputConfig{
+		Dir:    "./data/export",
+		Format: OutputFormatParquet,
+		CSV: &CSVOutputConfig{
+			Delimiter: ",",
+		},
+		Parquet: &ParquetOutputConfig{
+			Compression: "zstd",
+		},
+		ObjectStore: &ObjectStoreOutputConfig{},
+	}
+}
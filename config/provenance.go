@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// 配置值的来源
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Origin 一个配置 key 的取值来源
+type Origin struct {
+	Source string `json:"source" yaml:"source"`           // default/file/env/flag
+	Detail string `json:"detail,omitempty" yaml:"detail"` // 文件路径、环境变量名或参数名
+	Secret bool   `json:"secret,omitempty" yaml:"secret"` // 取值来自 ${env:...}/${file:...} 密钥引用，展示时必须隐藏
+}
+
+func (o Origin) String() string {
+	s := o.Source
+	if o.Detail != "" {
+		s += ": " + o.Detail
+	}
+	if o.Secret {
+		s += " (密钥引用)"
+	}
+	return s
+}
+
+// Provenance 记录每个配置 key 最终生效值的来源，key 与 yaml 标签路径一致（如 migration.taskIds）
+type Provenance map[string]Origin
+
+// Of 返回 key 的来源，未记录时视为默认值
+func (p Provenance) Of(key string) Origin {
+	if origin, ok := p[key]; ok {
+		return origin
+	}
+	return Origin{Source: SourceDefault}
+}
+
+// SetFlag 记录 key 被命令行参数覆盖
+func (p Provenance) SetFlag(key, flag string) {
+	p[key] = Origin{Source: SourceFlag, Detail: "--" + flag}
+}
+
+// Annotate 在错误信息后附加相关 key 的来源，便于定位是哪一层配置导致校验失败
+func (p Provenance) Annotate(err error) error {
+	msg := err.Error()
+	// 取错误信息中出现的最长 key，避免 output.csv.delimiter 之类被较短的前缀误匹配
+	matched := ""
+	for _, key := range configKeys(reflect.TypeOf(GlobalConfig{}), "") {
+		if len(key) > len(matched) && strings.Contains(msg, key) {
+			matched = key
+		}
+	}
+	if matched == "" {
+		return err
+	}
+	return errors.Errorf("%s (来源 %s)", msg, p.Of(matched))
+}
+
+// LoadProvenance 按与 TryLoadFromDisk 相同的优先级计算每个 key 的来源：环境变量 > 后面的文件 > 前面的文件 > 默认值
+func LoadProvenance(configFilePaths ...string) (Provenance, error) {
+	files := make([]*viper.Viper, 0, len(configFilePaths))
+	for _, configFilePath := range configFilePaths {
+		v := viper.New()
+		v.SetConfigFile(configFilePath)
+		v.SetConfigType(strings.TrimPrefix(filepath.Ext(configFilePath), "."))
+		if err := v.ReadInConfig(); err != nil {
+			return nil, errors.Errorf("解析配置文件 %s 错误:%s", configFilePath, err.Error())
+		}
+		files = append(files, v)
+	}
+
+	provenance := make(Provenance)
+	for _, key := range configKeys(reflect.TypeOf(GlobalConfig{}), "") {
+		if raw, ok := os.LookupEnv(EnvName(key)); ok {
+			provenance[key] = Origin{Source: SourceEnv, Detail: EnvName(key), Secret: IsSecretRef(raw)}
+			continue
+		}
+		for i := len(files) - 1; i >= 0; i-- {
+			if files[i].IsSet(key) {
+				provenance[key] = Origin{Source: SourceFile, Detail: configFilePaths[i], Secret: hasSecretRef(files[i].Get(key))}
+				break
+			}
+		}
+	}
+	return provenance, nil
+}
+
+// hasSecretRef 判断原始配置值（字符串或字符串列表）中是否包含密钥引用
+func hasSecretRef(raw interface{}) bool {
+	switch v := raw.(type) {
+	case string:
+		return IsSecretRef(v)
+	case []interface{}:
+		for _, elem := range v {
+			if s, ok := elem.(string); ok && IsSecretRef(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
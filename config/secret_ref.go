@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// secretRefRegex 匹配整个取值为 ${env:NAME} 或 ${file:/path} 的引用
+var secretRefRegex = regexp.MustCompile(`^\$\{(env|file):([^}]+)\}$`)
+
+// IsSecretRef 判断配置值是否为密钥引用
+func IsSecretRef(value string) bool {
+	return secretRefRegex.MatchString(strings.TrimSpace(value))
+}
+
+// resolveSecretRef 解析单个密钥引用，非引用的值原样返回
+func resolveSecretRef(key, value string) (string, error) {
+	m := secretRefRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return value, nil
+	}
+	switch m[1] {
+	case "env":
+		resolved, ok := os.LookupEnv(m[2])
+		if !ok {
+			return "", errors.Errorf("%s 引用的环境变量 %s 未设置", key, m[2])
+		}
+		return resolved, nil
+	default:
+		content, err := os.ReadFile(m[2])
+		if err != nil {
+			return "", errors.Errorf("%s 引用的文件 %s 读取失败: %v", key, m[2], err)
+		}
+		// 密钥文件通常以换行结尾
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+}
+
+// resolveSecretRefs 按 yaml 标签递归解析配置中所有字符串（含字符串列表）里的密钥引用
+// 在 Validate 之前执行，使非空等校验看到的是真实值
+func resolveSecretRefs(v reflect.Value, prefix string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.String:
+			resolved, err := resolveSecretRef(key, field.String())
+			if err != nil {
+				return err
+			}
+			field.SetString(resolved)
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < field.Len(); j++ {
+				resolved, err := resolveSecretRef(key, field.Index(j).String())
+				if err != nil {
+					return err
+				}
+				field.Index(j).SetString(resolved)
+			}
+		case field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct):
+			if err := resolveSecretRefs(field, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
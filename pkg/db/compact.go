@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// CompactDuckDB 执行 CHECKPOINT，将 WAL 合并回数据库文件并回收已释放的块
+func CompactDuckDB(ctx context.Context) error {
+	if duckDB == nil {
+		return fmt.Errorf("DuckDB 连接未初始化")
+	}
+	if _, err := duckDB.ExecContext(ctx, "CHECKPOINT"); err != nil {
+		return fmt.Errorf("执行 CHECKPOINT 失败: %v", err)
+	}
+	return nil
+}
+
+// DuckDBFileSize 返回数据库文件及其 WAL 文件的总字节数，文件不存在时按 0 计
+func DuckDBFileSize(path string) (int64, error) {
+	var total int64
+	for _, p := range []string{path, path + ".wal"} {
+		info, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("读取文件大小失败: %v", err)
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
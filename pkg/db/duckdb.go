@@ -3,6 +3,9 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 
 	"content-verify-log/config"
@@ -18,23 +21,39 @@ var duckDBOnce sync.Once
 func InitDuckDB(cfg *config.DuckDBConfig) error {
 	var err error
 	duckDBOnce.Do(func() {
-		duckDB, err = sql.Open("duckdb", cfg.DBPath)
+		duckDB, err = Open(cfg)
 		if err != nil {
-			zap.S().Errorf("连接 duckdb 失败: %v", err)
+			zap.S().Errorf("%v", err)
 			return
 		}
-
-		// 测试连接
-		if err = duckDB.Ping(); err != nil {
-			zap.S().Errorf("duckdb 连接测试失败: %v", err)
-			return
-		}
-
-		zap.S().Debug("duckdb 初始化完成...")
+		zap.S().Debugf("duckdb 初始化完成 (只读: %v)...", cfg.ReadOnly)
 	})
 	return err
 }
 
+// Open 按配置打开一个独立的 duckdb 连接，不设置全局连接，由调用方负责关闭；
+// 在其他程序中嵌入处理逻辑时用它创建连接，配合 service.Migrator 使用
+func Open(cfg *config.DuckDBConfig) (*sql.DB, error) {
+	conn, err := sql.Open("duckdb", cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("连接 duckdb 失败: %v", err)
+	}
+	if err := conn.Ping(); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("duckdb 连接测试失败: %v", err)
+	}
+	return conn, nil
+}
+
+// InitDuckDBReadOnly 以只读方式初始化 duckdb 连接，文件必须已存在
+func InitDuckDBReadOnly(cfg *config.DuckDBConfig) error {
+	readOnly := cfg.AsReadOnly()
+	if errs := readOnly.Validate(); len(errs) > 0 {
+		return errs[0]
+	}
+	return InitDuckDB(readOnly)
+}
+
 // GetDuckDB 获取 DuckDB 连接
 func GetDuckDB() *sql.DB {
 	return duckDB
@@ -44,3 +63,19 @@ func GetDuckDB() *sql.DB {
 func GetDuckDBWithContext(ctx context.Context) *sql.DB {
 	return duckDB
 }
+
+// OpenDuckDB 打开独立的 duckdb 连接（如按任务分片的输出文件），目录不存在时自动创建，由调用方负责关闭
+func OpenDuckDB(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %v", err)
+	}
+	conn, err := sql.Open("duckdb", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,325 @@
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 源表时间字段的格式，与迁移读取时 TRY_STRPTIME 使用的格式一致
+const sourceTimeLayout = "02/01/2006 15:04:05.000"
+
+// Options 合成数据的参数，相同的参数和 Seed 生成完全相同的数据
+type Options struct {
+	Rows           int     // 生成的行数
+	Seed           uint64  // 随机种子
+	Tasks          int     // 任务 ID 的数量，行按顺序轮流分配
+	NewFormatRatio float64 // 新格式（replace_text + checklist）所占比例，其余为旧格式
+	MinLength      int     // 每篇文章可见文本的最少字符数
+	MaxLength      int     // 每篇文章可见文本的最多字符数，长度在 [MinLength, MaxLength] 内均匀分布
+	ErrorDensity   float64 // 每 100 个字符的平均错误数
+	MarkerRate     float64 // 错误词带错误标记 span 的比例
+	QuirkRate      float64 // 使用历史数据中出现过的非常规写法的比例（数字写成字符串、数组写成 JSON 字符串、HTML 实体等）
+	InvalidRate    float64 // 无法解析的行（非 JSON、截断的 JSON、缺少 data）所占比例
+}
+
+// Validate 校验参数
+func (o *Options) Validate() error {
+	switch {
+	case o.Rows < 1:
+		return fmt.Errorf("行数必须大于 0，当前为 %d", o.Rows)
+	case o.Tasks < 1:
+		return fmt.Errorf("任务数必须大于 0，当前为 %d", o.Tasks)
+	case o.MinLength < 1 || o.MaxLength < o.MinLength:
+		return fmt.Errorf("文章长度范围不合法: [%d, %d]", o.MinLength, o.MaxLength)
+	case o.ErrorDensity < 0:
+		return fmt.Errorf("错误密度不能为负数，当前为 %v", o.ErrorDensity)
+	}
+	for name, rate := range map[string]float64{
+		"新格式比例": o.NewFormatRatio, "错误标记比例": o.MarkerRate, "非常规写法比例": o.QuirkRate, "无效行比例": o.InvalidRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s必须在 [0, 1] 内，当前为 %v", name, rate)
+		}
+	}
+	return nil
+}
+
+// NewDefaultOptions 返回默认参数
+func NewDefaultOptions() Options {
+	return Options{
+		Rows:           10000,
+		Seed:           1,
+		Tasks:          4,
+		NewFormatRatio: 0.5,
+		MinLength:      200,
+		MaxLength:      3000,
+		ErrorDensity:   0.5,
+		MarkerRate:     0.5,
+		QuirkRate:      0.05,
+		InvalidRate:    0.01,
+	}
+}
+
+// Row 与 tbl_verify_content 结构一致的一行
+type Row struct {
+	ID        int64   `json:"id"`
+	TaskID    string  `json:"taskId"`
+	Content   string  `json:"content"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+	DeletedAt *string `json:"deleted_at"`
+}
+
+// Generator 按顺序生成合成行
+type Generator struct {
+	opts Options
+	rng  *rand.Rand
+	next int64
+	base time.Time
+}
+
+// NewGenerator 创建生成器，参数需先通过 Validate
+func NewGenerator(opts Options) *Generator {
+	return &Generator{
+		opts: opts,
+		rng:  rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15)),
+		next: 1,
+		base: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+	}
+}
+
+// Next 生成下一行，已生成 Rows 行后返回 false
+func (g *Generator) Next() (Row, bool) {
+	if g.next > int64(g.opts.Rows) {
+		return Row{}, false
+	}
+	id := g.next
+	g.next++
+
+	created := g.base.Add(time.Duration(id) * time.Minute)
+	row := Row{
+		ID:        id,
+		TaskID:    fmt.Sprintf("task%04d", (id-1)%int64(g.opts.Tasks)+1),
+		CreatedAt: created.Format(sourceTimeLayout),
+		UpdatedAt: created.Add(time.Hour).Format(sourceTimeLayout),
+	}
+
+	if g.chance(g.opts.InvalidRate) {
+		row.Content = g.invalidContent()
+		return row, true
+	}
+	doc := g.document()
+	var data map[string]interface{}
+	if g.chance(g.opts.NewFormatRatio) {
+		data = g.newFormat(doc)
+	} else {
+		data = g.oldFormat(doc)
+	}
+	row.Content = marshal(map[string]interface{}{"data": data})
+	return row, true
+}
+
+// marshal 序列化为 JSON，不转义 HTML 字符，与源数据的写法一致
+func marshal(v interface{}) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (g *Generator) chance(p float64) bool {
+	return p > 0 && g.rng.Float64() < p
+}
+
+// invalidContent 生成历史数据中出现过的几类无法解析的内容
+func (g *Generator) invalidContent() string {
+	switch g.rng.IntN(3) {
+	case 0:
+		return "not json"
+	case 1:
+		s := marshal(map[string]interface{}{"data": map[string]interface{}{"checkresultstr": "<p>截断的内容</p>"}})
+		return s[:len(s)/2]
+	default:
+		return `{"code":0,"msg":"ok"}`
+	}
+}
+
+// token 文章中的一段文本；typo 不为 nil 时该段为错误词
+type token struct {
+	text  string
+	typo  *typo
+	quirk string // 非常规写法：entity 在后面插入 HTML 实体，strong 用 <strong> 包裹
+}
+
+// paragraph 一个段落的文本片段
+type paragraph []token
+
+// document 生成可见文本长度在 [MinLength, MaxLength] 内的文章，按错误密度插入错误词
+func (g *Generator) document() []paragraph {
+	target := g.opts.MinLength + g.rng.IntN(g.opts.MaxLength-g.opts.MinLength+1)
+	errorRate := g.opts.ErrorDensity / 100
+
+	var doc []paragraph
+	var para paragraph
+	paraLen, length := 0, 0
+	paraTarget := 60 + g.rng.IntN(200)
+	for length < target {
+		var t token
+		if g.chance(errorRate * 2) {
+			// 错误词平均 2 个字符，按字符数折算为按词的概率
+			ty := &typos[g.rng.IntN(len(typos))]
+			t = token{text: ty.wrong, typo: ty}
+		} else {
+			t = token{text: words[g.rng.IntN(len(words))]}
+		}
+		if g.chance(g.opts.QuirkRate) {
+			t.quirk = []string{"entity", "strong"}[g.rng.IntN(2)]
+		}
+		para = append(para, t)
+		n := utf8.RuneCountInString(t.text)
+		paraLen += n
+		length += n
+
+		if g.rng.IntN(8) == 0 {
+			para = append(para, token{text: punctuation[g.rng.IntN(len(punctuation))]})
+			paraLen++
+			length++
+		}
+		if paraLen >= paraTarget {
+			para = append(para, token{text: "。"})
+			doc = append(doc, para)
+			para, paraLen = nil, 0
+			paraTarget = 60 + g.rng.IntN(200)
+		}
+	}
+	if len(para) > 0 {
+		doc = append(doc, append(para, token{text: "。"}))
+	}
+	return doc
+}
+
+// writePlain 写入普通片段，按 quirk 插入实体或 <strong>
+func writePlain(b *strings.Builder, t token) {
+	switch t.quirk {
+	case "strong":
+		b.WriteString("<strong>")
+		b.WriteString(t.text)
+		b.WriteString("</strong>")
+	case "entity":
+		b.WriteString(t.text)
+		b.WriteString("&nbsp;")
+	default:
+		b.WriteString(t.text)
+	}
+}
+
+// oldFormat 生成旧格式：checkresultstr 中错误词可能带黄色背景的错误标记，pos 为错误词在 checkresultstr 中的字节位置
+func (g *Generator) oldFormat(doc []paragraph) map[string]interface{} {
+	var b strings.Builder
+	var corrections []map[string]interface{}
+	for _, para := range doc {
+		b.WriteString("<p>")
+		for _, t := range para {
+			if t.typo == nil {
+				writePlain(&b, t)
+				continue
+			}
+			marked := g.chance(g.opts.MarkerRate)
+			if marked {
+				b.WriteString(`<span style="background-color:yellow;">`)
+			}
+			var pos interface{} = b.Len()
+			if t.quirk != "" {
+				pos = fmt.Sprint(pos)
+			}
+			b.WriteString(t.typo.wrong)
+			if marked {
+				b.WriteString("【" + t.typo.correct + ",错误】</span>")
+			}
+			corrections = append(corrections, map[string]interface{}{
+				"errtype": t.typo.typeID,
+				"errword": t.typo.wrong,
+				"errdesc": t.typo.typeName,
+				"pos":     pos,
+				"level":   t.typo.level,
+				"corword": []string{t.typo.correct},
+			})
+		}
+		b.WriteString("</p>")
+	}
+
+	if corrections == nil {
+		corrections = []map[string]interface{}{}
+	}
+	// checkresultjson 通常是 JSON 字符串，部分历史数据直接是数组
+	var checkResultJSON interface{} = corrections
+	if !g.chance(g.opts.QuirkRate) {
+		checkResultJSON = marshal(corrections)
+	}
+	return map[string]interface{}{
+		"checkresultstr":  b.String(),
+		"checkresultjson": checkResultJSON,
+	}
+}
+
+// newFormat 生成新格式：replace_text 中错误词可能带 jdt_umold 标记，position 为移除标记后的 rune 位置（保留其他 HTML）
+func (g *Generator) newFormat(doc []paragraph) map[string]interface{} {
+	var b strings.Builder
+	runes := 0 // 不含错误标记的 rune 数
+	write := func(s string) {
+		b.WriteString(s)
+		runes += utf8.RuneCountInString(s)
+	}
+	var checklist []map[string]interface{}
+	for _, para := range doc {
+		write("<p>")
+		for _, t := range para {
+			if t.typo == nil {
+				var tmp strings.Builder
+				writePlain(&tmp, t)
+				write(tmp.String())
+				continue
+			}
+			marked := g.chance(g.opts.MarkerRate)
+			if marked {
+				b.WriteString(`<span class="jdt_umold">`)
+			}
+			var position, length interface{} = runes, utf8.RuneCountInString(t.typo.wrong)
+			if t.quirk != "" {
+				position, length = fmt.Sprint(position), fmt.Sprint(length)
+			}
+			write(t.typo.wrong)
+			if marked {
+				b.WriteString("</span>")
+			}
+			checklist = append(checklist, map[string]interface{}{
+				"position":       position,
+				"word":           t.typo.wrong,
+				"length":         length,
+				"suggest":        []string{t.typo.correct},
+				"explanation":    t.typo.typeName,
+				"type":           map[string]interface{}{"id": t.typo.typeID, "name": t.typo.typeName},
+				"um_error_level": t.typo.level,
+			})
+		}
+		write("</p>")
+	}
+
+	if checklist == nil {
+		checklist = []map[string]interface{}{}
+	}
+	// checklist 通常是数组，部分历史数据是 JSON 字符串
+	var checklistValue interface{} = checklist
+	if g.chance(g.opts.QuirkRate) {
+		checklistValue = marshal(checklist)
+	}
+	return map[string]interface{}{
+		"replace_text": b.String(),
+		"checklist":    checklistValue,
+	}
+}
@@ -0,0 +1,138 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+)
+
+// testOptions 测试用的小规模参数
+func testOptions() Options {
+	opts := NewDefaultOptions()
+	opts.Rows = 200
+	opts.MinLength, opts.MaxLength = 50, 300
+	opts.ErrorDensity = 2
+	opts.InvalidRate = 0.1
+	return opts
+}
+
+// generateJSONL 按 opts 生成全部行并返回 JSONL 内容
+func generateJSONL(t *testing.T, opts Options) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	count, err := WriteJSONL(&buf, NewGenerator(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != opts.Rows {
+		t.Fatalf("生成了 %d 行，应为 %d 行", count, opts.Rows)
+	}
+	return buf.Bytes()
+}
+
+// TestGeneratorDeterministic 相同的参数和种子生成完全相同的数据，种子不同时数据不同
+func TestGeneratorDeterministic(t *testing.T) {
+	opts := testOptions()
+	first, second := generateJSONL(t, opts), generateJSONL(t, opts)
+	if !bytes.Equal(first, second) {
+		t.Error("相同种子两次生成的数据不同")
+	}
+	opts.Seed++
+	if bytes.Equal(first, generateJSONL(t, opts)) {
+		t.Error("不同种子生成的数据相同")
+	}
+}
+
+// TestGeneratorRows ID 从 1 连续递增，任务按顺序轮流分配；无效行比例为 0 时每行都是带 data 的 JSON，
+// 新格式的 position 是错误词在移除标记后的 replace_text 中的字符位置
+func TestGeneratorRows(t *testing.T) {
+	opts := testOptions()
+	opts.InvalidRate = 0
+	opts.QuirkRate = 0
+	opts.MarkerRate = 0
+	gen := NewGenerator(opts)
+	var id int64
+	checked := 0
+	for row, ok := gen.Next(); ok; row, ok = gen.Next() {
+		id++
+		if row.ID != id {
+			t.Fatalf("第 %d 行的 ID 为 %d", id, row.ID)
+		}
+		if want := fmt.Sprintf("task%04d", (id-1)%int64(opts.Tasks)+1); row.TaskID != want {
+			t.Errorf("ID %d 的任务为 %s，应为 %s", id, row.TaskID, want)
+		}
+		var content struct {
+			Data struct {
+				ReplaceText string `json:"replace_text"`
+				Checklist   []struct {
+					Position int    `json:"position"`
+					Word     string `json:"word"`
+					Length   int    `json:"length"`
+				} `json:"checklist"`
+				CheckResultStr string `json:"checkresultstr"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(row.Content), &content); err != nil {
+			t.Fatalf("ID %d 的内容不是合法 JSON: %v", id, err)
+		}
+		if content.Data.ReplaceText == "" && content.Data.CheckResultStr == "" {
+			t.Errorf("ID %d 没有正文: %s", id, row.Content)
+		}
+		runes := []rune(content.Data.ReplaceText)
+		for _, item := range content.Data.Checklist {
+			if item.Length != utf8.RuneCountInString(item.Word) {
+				t.Errorf("ID %d: %q 的长度为 %d", id, item.Word, item.Length)
+			}
+			if end := item.Position + item.Length; end > len(runes) || string(runes[item.Position:end]) != item.Word {
+				t.Errorf("ID %d: 位置 %d 处不是错误词 %q", id, item.Position, item.Word)
+			}
+			checked++
+		}
+	}
+	if id != int64(opts.Rows) {
+		t.Errorf("生成了 %d 行，应为 %d 行", id, opts.Rows)
+	}
+	if checked == 0 {
+		t.Error("没有生成新格式的错误词")
+	}
+}
+
+// TestGeneratorInvalidRate 无效行比例为 1 时每行都没有可用的 data
+func TestGeneratorInvalidRate(t *testing.T) {
+	opts := testOptions()
+	opts.InvalidRate = 1
+	gen := NewGenerator(opts)
+	for row, ok := gen.Next(); ok; row, ok = gen.Next() {
+		var content struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(row.Content), &content); err == nil && content.Data != nil {
+			t.Errorf("ID %d 应为无效行: %s", row.ID, row.Content)
+		}
+	}
+}
+
+// TestOptionsValidate 行数、任务数、长度范围和各比例的校验
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+		valid  bool
+	}{
+		{name: "default", modify: func(*Options) {}, valid: true},
+		{name: "no rows", modify: func(o *Options) { o.Rows = 0 }},
+		{name: "no tasks", modify: func(o *Options) { o.Tasks = 0 }},
+		{name: "length range", modify: func(o *Options) { o.MinLength, o.MaxLength = 100, 50 }},
+		{name: "negative density", modify: func(o *Options) { o.ErrorDensity = -1 }},
+		{name: "ratio over 1", modify: func(o *Options) { o.InvalidRate = 1.5 }},
+	}
+	for _, tt := range tests {
+		opts := NewDefaultOptions()
+		tt.modify(&opts)
+		if err := opts.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: 校验返回 %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package fixture
+
+// typo 一对错误词和正确词
+type typo struct {
+	wrong    string
+	correct  string
+	typeID   int
+	typeName string
+	level    int
+}
+
+// typos 合成文章中使用的常见错别字和用词错误
+var typos = []typo{
+	{wrong: "公圆", correct: "公园", typeID: 5, typeName: "错别字", level: 1},
+	{wrong: "我门", correct: "我们", typeID: 5, typeName: "错别字", level: 1},
+	{wrong: "以经", correct: "已经", typeID: 5, typeName: "错别字", level: 1},
+	{wrong: "在见", correct: "再见", typeID: 5, typeName: "错别字", level: 1},
+	{wrong: "布署", correct: "部署", typeID: 5, typeName: "错别字", level: 2},
+	{wrong: "精采", correct: "精彩", typeID: 5, typeName: "错别字", level: 1},
+	{wrong: "按装", correct: "安装", typeID: 5, typeName: "错别字", level: 1},
+	{wrong: "迫不急待", correct: "迫不及待", typeID: 6, typeName: "成语错误", level: 2},
+	{wrong: "一如继往", correct: "一如既往", typeID: 6, typeName: "成语错误", level: 2},
+	{wrong: "再接再励", correct: "再接再厉", typeID: 6, typeName: "成语错误", level: 2},
+	{wrong: "副书纪", correct: "副书记", typeID: 8, typeName: "职务错误", level: 3},
+	{wrong: "国务院办公厅厅", correct: "国务院办公厅", typeID: 9, typeName: "重复", level: 1},
+}
+
+// words 合成文章的正文词汇
+var words = []string{
+	"今天", "我们", "会议", "强调", "要", "进一步", "推进", "工作", "落实", "责任",
+	"发展", "经济", "社会", "建设", "全面", "加强", "管理", "服务", "群众", "基层",
+	"项目", "资金", "安排", "年度", "目标", "任务", "完成", "情况", "汇报", "部门",
+	"单位", "积极", "配合", "有序", "开展", "检查", "整改", "问题", "措施", "制度",
+	"学校", "公园", "城市", "乡村", "环境", "保护", "教育", "医疗", "交通", "文化",
+	"的", "了", "和", "在", "对", "是", "将", "并", "与", "及",
+}
+
+// punctuation 句内标点
+var punctuation = []string{"，", "、", "；", "：", "“", "”"}
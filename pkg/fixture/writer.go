@@ -0,0 +1,74 @@
+package fixture
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SourceTable 合成数据写入的表，结构与 tbl_verify_content 一致
+const SourceTable = "tbl_verify_content"
+
+// WriteDuckDB 将生成器的全部行写入 DuckDB 的 SourceTable，在一个事务中完成
+// overwrite 为 false 且表已存在时返回错误，避免覆盖真实数据
+func WriteDuckDB(ctx context.Context, duckDB *sql.DB, gen *Generator, overwrite bool) (int, error) {
+	tx, err := duckDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if overwrite {
+		if _, err := tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+SourceTable); err != nil {
+			return 0, fmt.Errorf("删除旧表失败: %v", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE `+SourceTable+` (
+	id BIGINT PRIMARY KEY,
+	taskId VARCHAR,
+	content VARCHAR,
+	created_at VARCHAR,
+	updated_at VARCHAR,
+	deleted_at VARCHAR
+)`); err != nil {
+		return 0, fmt.Errorf("创建表 %s 失败（已存在时可使用 --overwrite）: %v", SourceTable, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO "+SourceTable+" VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return 0, fmt.Errorf("准备插入语句失败: %v", err)
+	}
+	defer stmt.Close()
+
+	count := 0
+	for row, ok := gen.Next(); ok; row, ok = gen.Next() {
+		if _, err := stmt.ExecContext(ctx, row.ID, row.TaskID, row.Content, row.CreatedAt, row.UpdatedAt, row.DeletedAt); err != nil {
+			return count, fmt.Errorf("插入第 %d 行失败: %v", row.ID, err)
+		}
+		count++
+	}
+	if err := tx.Commit(); err != nil {
+		return count, fmt.Errorf("提交事务失败: %v", err)
+	}
+	return count, nil
+}
+
+// WriteJSONL 将生成器的全部行按每行一个 JSON 对象写入 w
+func WriteJSONL(w io.Writer, gen *Generator) (int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	count := 0
+	for row, ok := gen.Next(); ok; row, ok = gen.Next() {
+		if err := enc.Encode(row); err != nil {
+			return count, fmt.Errorf("写入第 %d 行失败: %v", row.ID, err)
+		}
+		count++
+	}
+	return count, bw.Flush()
+}
@@ -0,0 +1,86 @@
+package fixture
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+)
+
+// TestWriteDuckDB 写入的行与生成器一致；表已存在时不覆盖则返回错误，覆盖时替换为新数据
+func TestWriteDuckDB(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	ctx := context.Background()
+
+	opts := testOptions()
+	count, err := WriteDuckDB(ctx, db, NewGenerator(opts), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != opts.Rows {
+		t.Fatalf("写入了 %d 行，应为 %d 行", count, opts.Rows)
+	}
+	want, _ := NewGenerator(opts).Next()
+	var got Row
+	if err := db.QueryRow("SELECT id, taskId, content, created_at, updated_at, deleted_at FROM "+SourceTable+" WHERE id = 1").
+		Scan(&got.ID, &got.TaskID, &got.Content, &got.CreatedAt, &got.UpdatedAt, &got.DeletedAt); err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != want.Content || got.TaskID != want.TaskID || got.CreatedAt != want.CreatedAt || got.DeletedAt != nil {
+		t.Errorf("第 1 行为 %+v，应为 %+v", got, want)
+	}
+
+	if _, err := WriteDuckDB(ctx, db, NewGenerator(opts), false); err == nil {
+		t.Error("表已存在且不覆盖时应返回错误")
+	}
+	opts.Rows = 10
+	if _, err := WriteDuckDB(ctx, db, NewGenerator(opts), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("SELECT count(*) FROM " + SourceTable).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != opts.Rows {
+		t.Errorf("覆盖后有 %d 行，应为 %d 行", count, opts.Rows)
+	}
+}
+
+// TestWriteJSONL 每行一个 JSON 对象，字段与 tbl_verify_content 的列名一致，HTML 字符不转义
+func TestWriteJSONL(t *testing.T) {
+	opts := testOptions()
+	opts.Rows = 20
+	var buf bytes.Buffer
+	if _, err := WriteJSONL(&buf, NewGenerator(opts)); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte(`\u003c`)) || !bytes.Contains(buf.Bytes(), []byte(`<p>`)) {
+		t.Error("HTML 字符不应转义")
+	}
+	gen := NewGenerator(opts)
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(nil, 1<<20)
+	lines := 0
+	for scanner.Scan() {
+		var row Row
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("第 %d 行不是合法 JSON: %v", lines+1, err)
+		}
+		want, _ := gen.Next()
+		if row != want {
+			t.Errorf("第 %d 行为 %+v，应为 %+v", lines+1, row, want)
+		}
+		lines++
+	}
+	if lines != opts.Rows {
+		t.Errorf("输出了 %d 行，应为 %d 行", lines, opts.Rows)
+	}
+}
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Init 按级别、格式和输出位置构建 logger 并替换全局 logger，所有命令通过 zap.S() 共用
+// outputPath 为 stdout/stderr 或文件路径；返回的函数用于退出前刷新缓冲
+func Init(level, format, outputPath string) (func(), error) {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	zapCfg := zap.NewProductionConfig()
+	zapCfg.DisableStacktrace = true
+	zapCfg.EncoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(time.DateTime + ".000")
+	zapCfg.Encoding = strings.ToLower(format)
+	zapCfg.Level = zap.NewAtomicLevelAt(lvl)
+	zapCfg.OutputPaths = []string{outputPath}
+	zapCfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	// 只有输出到终端的 console 格式才使用颜色
+	if zapCfg.Encoding == "console" && (outputPath == "stdout" || outputPath == "stderr") {
+		zapCfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	logger, err := zapCfg.Build()
+	if err != nil {
+		return nil, err
+	}
+	zap.ReplaceGlobals(logger)
+	return func() {
+		_ = logger.Sync()
+	}, nil
+}
@@ -0,0 +1,63 @@
+package log
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestInit 按级别和格式构建 logger 并替换全局 logger：json 格式的字段、级别过滤和时间格式
+func TestInit(t *testing.T) {
+	before := zap.L()
+	t.Cleanup(func() { zap.ReplaceGlobals(before) })
+	path := filepath.Join(t.TempDir(), "cvl.log")
+	sync, err := Init("info", "json", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.S().Debugw("不输出", "id", 1)
+	zap.S().Infow("处理完成", "rows", 3, "table", "processed_content")
+	sync()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("info 级别时应只输出 1 行，得到 %q", b)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["level"] != "INFO" || entry["msg"] != "处理完成" || entry["rows"] != 3.0 || entry["table"] != "processed_content" {
+		t.Errorf("字段不符: %v", entry)
+	}
+	if ts, _ := entry["ts"].(string); len(ts) != len("2006-01-02 15:04:05.000") {
+		t.Errorf("时间格式不符: %v", entry["ts"])
+	}
+	if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "log/log_test.go:") {
+		t.Errorf("调用位置不符: %v", entry["caller"])
+	}
+	if _, ok := entry["stacktrace"]; ok {
+		t.Error("不应输出堆栈")
+	}
+}
+
+// TestInitInvalid 级别或格式不合法时返回错误，不替换全局 logger
+func TestInitInvalid(t *testing.T) {
+	before := zap.L()
+	for _, args := range [][3]string{{"verbose", "json", "stderr"}, {"info", "xml", "stderr"}} {
+		if _, err := Init(args[0], args[1], args[2]); err == nil {
+			t.Errorf("%v: 应返回错误", args)
+		}
+	}
+	if zap.L() != before {
+		t.Error("出错时不应替换全局 logger")
+	}
+}
@@ -0,0 +1,116 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Sampler 按原因对高频诊断日志采样：每个原因先输出前 first 次，之后每 every 次输出一次，
+// 并每隔 interval 输出一行各原因的累计次数，避免逐行日志比输出数据还大
+type Sampler struct {
+	first    int64
+	every    int64
+	interval time.Duration
+
+	mu        sync.Mutex
+	totals    map[string]int64 // 各原因的累计次数
+	pending   map[string]int64 // 上次汇总以来的次数
+	lastFlush time.Time
+}
+
+// NewSampler 创建采样器，every 小于 1 时超过 first 次后不再逐条输出，interval 为 0 时不定期汇总
+func NewSampler(first, every int, interval time.Duration) *Sampler {
+	return &Sampler{
+		first:     int64(first),
+		every:     int64(every),
+		interval:  interval,
+		totals:    make(map[string]int64),
+		pending:   make(map[string]int64),
+		lastFlush: time.Now(),
+	}
+}
+
+// Debugf 记录一次 reason 并按采样规则以 debug 级别输出
+func (s *Sampler) Debugf(reason, template string, args ...interface{}) {
+	s.mu.Lock()
+	s.totals[reason]++
+	s.pending[reason]++
+	n := s.totals[reason]
+	flush := s.interval > 0 && time.Since(s.lastFlush) >= s.interval
+	s.mu.Unlock()
+
+	// 跳过 Sampler 自身，日志的调用位置显示为调用方
+	logger := zap.S().WithOptions(zap.AddCallerSkip(1))
+	switch {
+	case n < s.first:
+		logger.Debugf(template, args...)
+	case n == s.first:
+		if s.every > 0 {
+			logger.Debugf(template+"（%s 已出现 %d 次，之后每 %d 次输出一次）", append(args, reason, n, s.every)...)
+		} else {
+			logger.Debugf(template+"（%s 已出现 %d 次，之后不再逐条输出）", append(args, reason, n)...)
+		}
+	case s.every > 0 && (n-s.first)%s.every == 0:
+		logger.Debugf(template+"（%s 第 %d 次）", append(args, reason, n)...)
+	}
+
+	if flush {
+		s.Flush()
+	}
+}
+
+// Flush 输出上次汇总以来各原因的次数，没有新记录时不输出
+func (s *Sampler) Flush() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[string]int64)
+	s.lastFlush = time.Now()
+	s.mu.Unlock()
+
+	if line := summarize(pending); line != "" {
+		zap.S().Infof("逐行诊断（近期）%s", line)
+	}
+}
+
+// LogTotals 输出各原因的累计次数
+func (s *Sampler) LogTotals() {
+	s.mu.Lock()
+	totals := make(map[string]int64, len(s.totals))
+	for reason, n := range s.totals {
+		totals[reason] = n
+	}
+	s.mu.Unlock()
+
+	if line := summarize(totals); line != "" {
+		zap.S().Infof("逐行诊断（累计）%s", line)
+	}
+}
+
+// summarize 按次数从多到少拼接各原因，如 "共 48210 次: content 不是合法 JSON 31200, 缺少 data 字段 9800"
+func summarize(counts map[string]int64) string {
+	if len(counts) == 0 {
+		return ""
+	}
+	reasons := make([]string, 0, len(counts))
+	var total int64
+	for reason, n := range counts {
+		reasons = append(reasons, reason)
+		total += n
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if counts[reasons[i]] != counts[reasons[j]] {
+			return counts[reasons[i]] > counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	parts := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		parts = append(parts, fmt.Sprintf("%s %d", reason, counts[reason]))
+	}
+	return fmt.Sprintf("共 %d 次: %s", total, strings.Join(parts, ", "))
+}
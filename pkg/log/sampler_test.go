@@ -0,0 +1,98 @@
+package log
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observe 用 observer core 替换全局 logger，测试结束时恢复
+func observe(t *testing.T, level zapcore.Level) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(level)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core, zap.AddCaller())))
+	return logs
+}
+
+// TestSamplerDebugf 每个原因先输出前 first 次，之后每 every 次输出一次；调用位置为调用方
+func TestSamplerDebugf(t *testing.T) {
+	logs := observe(t, zapcore.DebugLevel)
+	s := NewSampler(2, 3, 0)
+	for i := 1; i <= 8; i++ {
+		s.Debugf("缺少 data 字段", "文章 ID %d: 缺少 data 字段", i)
+	}
+	s.Debugf("其他", "文章 ID %d: 其他", 100)
+
+	want := []string{
+		"文章 ID 1: 缺少 data 字段",
+		"文章 ID 2: 缺少 data 字段（缺少 data 字段 已出现 2 次，之后每 3 次输出一次）",
+		"文章 ID 5: 缺少 data 字段（缺少 data 字段 第 5 次）",
+		"文章 ID 8: 缺少 data 字段（缺少 data 字段 第 8 次）",
+		"文章 ID 100: 其他",
+	}
+	entries := logs.All()
+	if len(entries) != len(want) {
+		t.Fatalf("应输出 %d 条，得到 %d 条: %v", len(want), len(entries), entries)
+	}
+	for i, entry := range entries {
+		if entry.Message != want[i] {
+			t.Errorf("第 %d 条为 %q，应为 %q", i+1, entry.Message, want[i])
+		}
+		if entry.Level != zapcore.DebugLevel {
+			t.Errorf("第 %d 条级别为 %s，应为 debug", i+1, entry.Level)
+		}
+		if file := filepath.Base(entry.Caller.File); file != "sampler_test.go" {
+			t.Errorf("第 %d 条的调用位置为 %s，应为调用方", i+1, entry.Caller)
+		}
+	}
+}
+
+// TestSamplerEveryZero every 小于 1 时超过 first 次后不再逐条输出
+func TestSamplerEveryZero(t *testing.T) {
+	logs := observe(t, zapcore.DebugLevel)
+	s := NewSampler(1, 0, 0)
+	for i := 0; i < 5; i++ {
+		s.Debugf("r", "m")
+	}
+	if got := logs.Len(); got != 1 || !strings.Contains(logs.All()[0].Message, "之后不再逐条输出") {
+		t.Fatalf("应只输出 1 条，得到 %v", logs.All())
+	}
+}
+
+// TestSamplerTotals Flush 输出上次汇总以来的次数并清零，LogTotals 输出累计次数，均为 info 级别
+func TestSamplerTotals(t *testing.T) {
+	logs := observe(t, zapcore.InfoLevel)
+	s := NewSampler(0, 0, 0)
+	for i := 0; i < 3; i++ {
+		s.Debugf("b", "m")
+	}
+	s.Debugf("a", "m")
+	s.Flush()
+	s.Debugf("a", "m")
+	s.Flush()
+	s.Flush()
+	s.LogTotals()
+
+	want := []string{
+		"逐行诊断（近期）共 4 次: b 3, a 1",
+		"逐行诊断（近期）共 1 次: a 1",
+		"逐行诊断（累计）共 5 次: b 3, a 2",
+	}
+	entries := logs.FilterLevelExact(zapcore.InfoLevel).All()
+	if len(entries) != len(want) {
+		t.Fatalf("应输出 %d 条，得到 %v", len(want), entries)
+	}
+	for i, entry := range entries {
+		if entry.Message != want[i] {
+			t.Errorf("第 %d 条为 %q，应为 %q", i+1, entry.Message, want[i])
+		}
+	}
+	// debug 级别未开启时逐条日志不输出
+	if n := logs.FilterLevelExact(zapcore.DebugLevel).Len(); n != 0 {
+		t.Errorf("不应输出 debug 日志，得到 %d 条", n)
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// utf8BOM 部分源数据以 BOM 开头，哈希前与空白一起去掉
+var utf8BOM = []byte("\ufeff")
+
+// HashOptions content_hash 的规范化选项，相同的选项保证等价内容得到相同的哈希
+type HashOptions struct {
+	NFC bool // 哈希前做 Unicode NFC 规范化，使组合字符的不同编码（如 e + ◌́ 与 é）哈希一致
+}
+
+// ContentHash 返回规范化后的源内容的 SHA-256（十六进制），用于跨数据集关联和去重
+// 规范化依次为：去掉首尾空白和 UTF-8 BOM；开启 NFC 时做 Unicode NFC 规范化。内部的空白和 JSON 键顺序不做处理。
+// 规范化后为空时返回空字符串
+func ContentHash(b []byte, opts HashOptions) string {
+	b = bytes.TrimSpace(b)
+	for bytes.HasPrefix(b, utf8BOM) {
+		b = bytes.TrimSpace(b[len(utf8BOM):])
+	}
+	if len(b) == 0 {
+		return ""
+	}
+	if opts.NFC {
+		b = norm.NFC.Bytes(b)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
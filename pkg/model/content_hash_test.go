@@ -0,0 +1,42 @@
+package model
+
+import "testing"
+
+// TestContentHashStable 首尾空白和 BOM 不影响哈希；开启 NFC 时组合字符的不同编码哈希一致；内容不同时哈希不同
+func TestContentHashStable(t *testing.T) {
+	const raw = `{"data":{"replace_text":"café"}}`
+	tests := []struct {
+		name string
+		a, b string
+		opts HashOptions
+		same bool
+	}{
+		{name: "identical", a: raw, b: raw, same: true},
+		{name: "surrounding whitespace", a: raw, b: " \n\t" + raw + "\r\n", same: true},
+		{name: "bom", a: raw, b: "\ufeff" + raw, same: true},
+		{name: "bom and whitespace", a: raw, b: " \ufeff \ufeff" + raw + " ", same: true},
+		{name: "nfc", a: raw, b: `{"data":{"replace_text":"cafe` + "\u0301" + `"}}`, opts: HashOptions{NFC: true}, same: true},
+		{name: "nfc off", a: raw, b: `{"data":{"replace_text":"cafe` + "\u0301" + `"}}`},
+		{name: "inner whitespace", a: raw, b: `{"data": {"replace_text":"café"}}`},
+		{name: "key order", a: `{"a":1,"b":2}`, b: `{"b":2,"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := ContentHash([]byte(tt.a), tt.opts), ContentHash([]byte(tt.b), tt.opts)
+			if len(a) != 64 {
+				t.Fatalf("哈希应为 64 位十六进制，得到 %q", a)
+			}
+			if (a == b) != tt.same {
+				t.Errorf("%q 与 %q 的哈希相同为 %v，应为 %v", tt.a, tt.b, a == b, tt.same)
+			}
+		})
+	}
+
+	if got := ContentHash([]byte(" \ufeff\n"), HashOptions{}); got != "" {
+		t.Errorf("规范化后为空时应返回空字符串，得到 %q", got)
+	}
+	// 哈希值固定，跨版本、跨数据集可比较
+	if got, want := ContentHash([]byte("abc"), HashOptions{}), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; got != want {
+		t.Errorf("得到 %s，应为 %s", got, want)
+	}
+}
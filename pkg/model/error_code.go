@@ -0,0 +1,19 @@
+package model
+
+// 错误码，写入 error_code 列，便于按类别统计；处理成功时为空
+const (
+	// ErrCodeSchemaViolation 输入内容不符合格式约定（上游数据问题，而非处理逻辑问题）
+	ErrCodeSchemaViolation = "SCHEMA_VIOLATION"
+	// ErrCodeNoExtractableText 源文本非空，但清洗后只剩标签/空白
+	ErrCodeNoExtractableText = "NO_EXTRACTABLE_TEXT"
+	// ErrCodeJSONLimitExceeded 源内容超过 JSON 大小或嵌套层数限制，未解析
+	ErrCodeJSONLimitExceeded = "JSON_LIMIT_EXCEEDED"
+	// ErrCodeEncodingError 源内容不是合法 UTF-8，按 GB18030 等编码转换后仍无法解析
+	ErrCodeEncodingError = "ENCODING_ERROR"
+	// ErrCodeTruncatedJSONSalvaged 源内容不是合法 JSON（通常被截断），从中提取已知字段尽量处理
+	ErrCodeTruncatedJSONSalvaged = "TRUNCATED_JSON_SALVAGED"
+	// ErrCodeSuspiciousModification 修改后的文章与原文长度之比超出允许范围，可能是修正替换出错
+	ErrCodeSuspiciousModification = "SUSPICIOUS_MODIFICATION"
+	// ErrCodeCancelled 批量处理时上下文已取消，记录未处理
+	ErrCodeCancelled = "CANCELLED"
+)
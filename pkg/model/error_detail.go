@@ -0,0 +1,46 @@
+package model
+
+// 源数据格式
+const (
+	SourceFormatOld = "old" // checkresultstr + checkresultjson
+	SourceFormatNew = "new" // replace_text + checklist
+)
+
+// 修正未应用的原因
+const (
+	SkipReasonNoSuggestion   = "no_suggestion"   // 没有建议词
+	SkipReasonEmptyWord      = "empty_word"      // 错误词为空
+	SkipReasonFiltered       = "filtered"        // 被错误类型过滤
+	SkipReasonBelowLevel     = "below_level"     // 错误级别低于 MinErrorLevel
+	SkipReasonOutOfRange     = "out_of_range"    // 位置越界
+	SkipReasonNegativeLength = "negative_length" // 新格式 length 为负，无法确定替换范围
+	SkipReasonMismatch       = "mismatch"        // 位置处的文本与错误词不一致
+	SkipReasonOverlap        = "overlap"         // 与已应用的修正重叠
+	SkipReasonDuplicate      = "duplicate"       // 与前面某项的位置、错误词和建议词都相同，已去重
+	SkipReasonConflict       = "conflict"        // 与另一项的替换区间完全相同、建议词不同，按优先级未被选中
+	SkipReasonNotFound       = "not_found"       // 文中找不到错误词
+	SkipReasonAmbiguous      = "ambiguous"       // 位置未知，错误词出现多处且无法按上下文唯一确定
+	SkipReasonSuspicious     = "suspicious"      // 修改后长度异常，保留原文而撤销
+)
+
+// ErrorDetail 是新旧两种格式共用的错误明细
+// 旧格式的 Correction 与新格式的 ChecklistItem 都被归一化为该结构
+type ErrorDetail struct {
+	Position     int      `json:"position"`              // 在清洗后文本中的位置（rune），无法定位时为 -1
+	Word         string   `json:"word"`                  // 原文中的错误词
+	Suggestions  []string `json:"suggestions"`           // 建议词候选
+	AppliedIndex int      `json:"applied_index"`         // 实际应用的建议词下标，未应用时为 -1
+	TypeID       int      `json:"type_id"`               // 错误类型 ID
+	TypeName     string   `json:"type_name,omitempty"`   // 错误类型名称
+	Level        int      `json:"level"`                 // 错误级别
+	Explanation  string   `json:"explanation,omitempty"` // 错误说明
+	SourceFormat string   `json:"source_format"`         // 来源格式 old/new
+	SkipReason   string   `json:"skip_reason,omitempty"` // 未应用的原因
+	Recovered    bool     `json:"recovered,omitempty"`   // 位置不一致，在附近查找到错误词后应用
+	Context      string   `json:"context,omitempty"`     // 已应用修正前后的上下文，开启 ContextWindow 时填充
+}
+
+// Applied 返回该修正是否已应用
+func (d *ErrorDetail) Applied() bool {
+	return d.AppliedIndex >= 0
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 超出 JSON 限制的错误，可用 errors.Is 判断
+var (
+	ErrJSONTooLarge = errors.New("JSON 超过大小限制")
+	ErrJSONTooDeep  = errors.New("JSON 嵌套层数超过限制")
+)
+
+// JSONLimits 解析 JSON 前检查的限制，避免损坏或恶意的内容在反序列化时耗尽内存；0 表示不限制
+type JSONLimits struct {
+	MaxSize  int64 // 最大字节数
+	MaxDepth int   // 最大嵌套层数（对象和数组）
+}
+
+// DefaultJSONLimits 未显式指定限制时使用的默认值，Scan 也按该限制检查
+var DefaultJSONLimits = JSONLimits{
+	MaxSize:  64 << 20,
+	MaxDepth: 200,
+}
+
+// IsZero 返回是否未设置任何限制
+func (l JSONLimits) IsZero() bool {
+	return l.MaxSize == 0 && l.MaxDepth == 0
+}
+
+// Check 在解析前检查大小和嵌套层数，只扫描一遍字节，不分配内存
+func (l JSONLimits) Check(b []byte) error {
+	if l.MaxSize > 0 && int64(len(b)) > l.MaxSize {
+		return fmt.Errorf("%w: %d 字节，上限 %d 字节", ErrJSONTooLarge, len(b), l.MaxSize)
+	}
+	if l.MaxDepth > 0 && exceedsDepth(b, l.MaxDepth) {
+		return fmt.Errorf("%w: 上限 %d 层", ErrJSONTooDeep, l.MaxDepth)
+	}
+	return nil
+}
+
+// exceedsDepth 判断对象和数组的嵌套层数是否超过 maxDepth，字符串中的括号不计
+func exceedsDepth(b []byte, maxDepth int) bool {
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range b {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return false
+}
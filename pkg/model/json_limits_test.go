@@ -0,0 +1,62 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestJSONLimitsCheck 超过大小或嵌套层数的内容报错，字符串中的括号不计入层数，0 表示不限制
+func TestJSONLimitsCheck(t *testing.T) {
+	deep := strings.Repeat("[", 11) + strings.Repeat("]", 11)
+	tests := []struct {
+		name   string
+		limits JSONLimits
+		in     string
+		want   error
+	}{
+		{name: "within", limits: JSONLimits{MaxSize: 64, MaxDepth: 3}, in: `{"data":{"a":[1]}}`},
+		{name: "too large", limits: JSONLimits{MaxSize: 8}, in: `{"data":{}}`, want: ErrJSONTooLarge},
+		{name: "too deep", limits: JSONLimits{MaxDepth: 10}, in: deep, want: ErrJSONTooDeep},
+		{name: "exact depth", limits: JSONLimits{MaxDepth: 11}, in: deep},
+		{name: "brackets in string", limits: JSONLimits{MaxDepth: 2}, in: `{"a":"[[[{{{\"]]]"}`},
+		{name: "unlimited", in: strings.Repeat("[", 1000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Check([]byte(tt.in))
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("得到 %v，应为 %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseBytesOversized 超出限制的内容不解析：Data 为 nil，Raw 和哈希照常设置，返回可用 errors.Is 判断的错误
+func TestParseBytesOversized(t *testing.T) {
+	payload := []byte(`{"data":{"replace_text":"` + strings.Repeat("字", 1024) + `"}}`)
+	var content JSONContent
+	err := content.ParseBytes(payload, &HashOptions{}, JSONLimits{MaxSize: 1024})
+	if !errors.Is(err, ErrJSONTooLarge) {
+		t.Fatalf("应返回 ErrJSONTooLarge，得到 %v", err)
+	}
+	if content.Data != nil || content.Raw != string(payload) || content.Hash() == "" {
+		t.Errorf("Data=%v len(Raw)=%d hash=%q", content.Data, len(content.Raw), content.Hash())
+	}
+
+	if err := content.ParseBytes(payload, nil, JSONLimits{MaxSize: int64(len(payload))}); err != nil {
+		t.Fatal(err)
+	}
+	if content.Data == nil {
+		t.Error("未超出限制时应解析")
+	}
+
+	// Scan 按默认限制检查
+	nested := strings.Repeat(`{"a":`, DefaultJSONLimits.MaxDepth+1) + "1" + strings.Repeat("}", DefaultJSONLimits.MaxDepth+1)
+	if err := content.Scan(nested); err != nil {
+		t.Fatal(err)
+	}
+	if content.Data != nil || content.Raw != nested {
+		t.Error("超出默认嵌套层数的内容 Scan 时不应解析")
+	}
+}
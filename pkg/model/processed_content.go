@@ -1,12 +1,56 @@
 package model
 
+import "time"
+
 // ProcessedContent 表示处理后的内容，存储到 DuckDB
 type ProcessedContent struct {
 	ID           string `json:"id"`            // UUID
 	OriginalText string `json:"original_text"` // 原文（对应 checkresultstr）
 	ModifiedText string `json:"modified_text"` // 修改后的文章
 	PID          string `json:"pid"`           // 对应 tbl_verify_content 表的 taskId
-	ErrorReason  string `json:"error_reason"`  // 错误原因
+	ErrorReason  string `json:"error_reason"`  // 错误原因，处理成功时为空，写入目标表为 NULL
+	SourceFormat string `json:"source_format"` // 源数据格式 old/new，无法识别时为空
+
+	// 原文、修改后的文章是否已生成：处理在生成文本之前失败时为 false，写入目标表为 NULL；
+	// 为 true 而文本为空表示文档本身没有文字，写入空字符串
+	OriginalTextValid bool `json:"-"`
+	ModifiedTextValid bool `json:"-"`
+
+	ErrorCode       string `json:"error_code,omitempty"`       // 错误码，见 error_code.go
+	ValidationError string `json:"validation_error,omitempty"` // 输入校验的第一个违例（路径: 说明）
+
+	RawSize     int    `json:"raw_size"`     // 源内容字节数
+	ContentHash string `json:"content_hash"` // 源内容 SHA-256，未计算时为空
+
+	ConvertedEncoding string `json:"converted_encoding,omitempty"` // 源内容不是 UTF-8 时解析前转换自的编码，如 gb18030
+
+	HasErrors       bool `json:"has_errors"`       // 是否实际应用了修正
+	CorrectionCount int  `json:"correction_count"` // 实际应用的修正数量（不含跳过/过滤的项）
+	ConflictCount   int  `json:"conflict_count"`   // 替换区间完全相同、建议词不同而未被选中的修正数量
+
+	// 按错误级别（旧格式 level、新格式 um_error_level）统计的错误明细数量，含未应用的项，用于按严重程度排序
+	LevelCounts map[int]int `json:"level_counts"`
+
+	// 清洗源文本时各步骤是否实际修改了文本，用于排查标记未被识别等清洗问题
+	MarkersStripped bool `json:"markers_stripped"` // 移除错误标记改变了文本
+	HTMLStripped    bool `json:"html_stripped"`    // 清洗 HTML 标签/实体改变了文本
+
+	NeedsReview *bool `json:"needs_review,omitempty"` // 修正大多未能应用或未修改原文，需要人工复核；nil 表示迁移时未开启标记
+
+	ToolVersion string `json:"tool_version,omitempty"` // 处理该记录的工具版本，修复处理逻辑后用于找出旧版本处理的记录
+
+	// 时间字段为 nil 表示缺失，序列化为 null 而不是零值时间
+	ProcessedAt     *time.Time `gorm:"column:processed_at" json:"processed_at"`           // 处理时间
+	SourceCreatedAt *time.Time `gorm:"column:source_created_at" json:"source_created_at"` // 源记录创建时间
+	SourceUpdatedAt *time.Time `gorm:"column:source_updated_at" json:"source_updated_at"` // 源记录更新时间
+
+	Details         []ErrorDetail `json:"details"` // 错误明细（新旧格式统一结构）
+	FilteredCount   int           `json:"-"`       // 因错误类型过滤而未应用的修正数量
+	BelowLevelCount int           `json:"-"`       // 因错误级别低于 MinErrorLevel 而未应用的修正数量
+	DuplicateCount  int           `json:"-"`       // 与前面某项完全相同、去重后未应用的修正数量
+
+	RemovedMarkers map[string]int `json:"-"` // 按类名统计的已移除的新格式错误标记 span 数量
+	KeptMarkers    map[string]int `json:"-"` // 按类名统计的未移除的 jdt_ 类 span 数量，用于发现新出现的标注层
 }
 
 // TableName 指定表名
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
 )
@@ -27,6 +28,10 @@ func (VerifyContent) TableName() string {
 type JSONContent struct {
 	Data map[string]interface{} `json:"-"`
 	Raw  string                 `json:"-"`
+	// 解析前转换自的编码（如 gb18030），源内容即为 UTF-8 时为空
+	Encoding string `json:"-"`
+
+	hash string // 规范化后的 Raw 的 SHA-256，见 ContentHash
 }
 
 // Value 实现 driver.Valuer 接口，用于将 JSONContent 存储到数据库
@@ -46,23 +51,33 @@ func (j JSONContent) Value() (driver.Value, error) {
 
 // Scan 实现 sql.Scanner 接口，用于从数据库读取 JSONContent
 func (j *JSONContent) Scan(value interface{}) error {
+	j.hash = ""
+	j.Encoding = ""
 	if value == nil {
 		j.Data = nil
 		j.Raw = ""
 		return nil
 	}
 
+	// 每种输入只做一次拷贝：string 直接引用，[]byte 转换一次
 	var bytes []byte
 	switch v := value.(type) {
 	case []byte:
 		bytes = v
+		j.Raw = string(v)
 	case string:
+		j.Raw = v
 		bytes = []byte(v)
 	default:
 		return nil
 	}
 
-	j.Raw = string(bytes)
+	// 超出默认限制的内容不解析，Data 保持为 nil；
+	// 不是合法 UTF-8 的内容直接解析会得到乱码，同样不解析，由处理时转换编码后再解析
+	if err := DefaultJSONLimits.Check(bytes); err != nil || !utf8.Valid(bytes) {
+		j.Data = nil
+		return nil
+	}
 
 	// 尝试解析 JSON
 	var data map[string]interface{}
@@ -75,9 +90,46 @@ func (j *JSONContent) Scan(value interface{}) error {
 	return nil
 }
 
+// ParseBytes 从驱动返回的字节切片设置内容：转换为 Raw 字符串一次，
+// 直接在原字节上解析 JSON 和按 hash 计算哈希（hash 为 nil 时不计算），避免额外拷贝
+// 超出 limits 或解析失败时 Raw 仍会被设置，Data 为 nil，并返回错误
+func (j *JSONContent) ParseBytes(b []byte, hash *HashOptions, limits JSONLimits) error {
+	j.Raw = string(b)
+	j.Data = nil
+	j.Encoding = ""
+	j.hash = ""
+	if hash != nil {
+		j.hash = ContentHash(b, *hash)
+	}
+	if err := limits.Check(b); err != nil {
+		return err
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	j.Data = data
+	return nil
+}
+
+// SetRaw 只设置原始内容并按 hash 计算哈希（hash 为 nil 时不计算），不解析，Data 为 nil；
+// 用于编码无法识别、不应解析的内容
+func (j *JSONContent) SetRaw(b []byte, hash *HashOptions) {
+	j.Raw = string(b)
+	j.Data = nil
+	j.Encoding = ""
+	j.hash = ""
+	if hash != nil {
+		j.hash = ContentHash(b, *hash)
+	}
+}
+
 // UnmarshalJSON 实现 json.Unmarshaler 接口
 func (j *JSONContent) UnmarshalJSON(data []byte) error {
 	j.Raw = string(data)
+	j.Encoding = ""
+	j.hash = ""
 	var m map[string]interface{}
 	if err := json.Unmarshal(data, &m); err != nil {
 		return err
@@ -106,3 +158,22 @@ func (j *JSONContent) GetParsedContent() map[string]interface{} {
 func (j *JSONContent) GetRawContent() string {
 	return j.Raw
 }
+
+// Size 返回原始 JSON 内容的字节数
+func (j *JSONContent) Size() int {
+	return len(j.Raw)
+}
+
+// ComputeHash 按 opts 计算并缓存原始 JSON 内容的哈希，见 ContentHash，内容为空时返回空字符串
+// 大文档的哈希计算开销较大，调用方可按配置跳过
+func (j *JSONContent) ComputeHash(opts HashOptions) string {
+	if j.hash == "" && j.Raw != "" {
+		j.hash = ContentHash([]byte(j.Raw), opts)
+	}
+	return j.hash
+}
+
+// Hash 返回已计算的内容哈希，未计算时返回空字符串
+func (j *JSONContent) Hash() string {
+	return j.hash
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestJSONContentScan 从驱动读取 content：[]byte 和 string 都保留原文并解析，NULL 清空，
+// 不是合法 JSON 或不是合法 UTF-8 时只保留原文
+func TestJSONContentScan(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name   string
+		value  interface{}
+		raw    string
+		parsed bool
+	}{
+		{"bytes", []byte(`{"data":{"a":1}}`), `{"data":{"a":1}}`, true},
+		{"string", `{"data":{}}`, `{"data":{}}`, true},
+		{"null", nil, "", false},
+		{"invalid json", []byte(`{"data":`), `{"data":`, false},
+		{"invalid utf8", []byte("{\"data\":\"\xb2\xe2\"}"), "{\"data\":\"\xb2\xe2\"}", false},
+	}
+	rows := sqlmock.NewRows([]string{"content"})
+	for _, tt := range tests {
+		rows.AddRow(tt.value)
+	}
+	mock.ExpectQuery("SELECT content").WillReturnRows(rows)
+
+	result, err := db.Query("SELECT content FROM tbl_verify_content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer result.Close()
+	for _, tt := range tests {
+		if !result.Next() {
+			t.Fatalf("%s: 缺少行", tt.name)
+		}
+		// 预置上一次的解析结果，检查 Scan 不会保留
+		var content JSONContent
+		content.Data = map[string]interface{}{"stale": true}
+		if err := result.Scan(&content); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if content.Raw != tt.raw || (content.Data != nil) != tt.parsed {
+			t.Errorf("%s: Raw=%q Data=%v", tt.name, content.Raw, content.Data)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
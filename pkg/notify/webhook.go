@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// 响应体只读取前若干字节用于错误信息
+const maxErrorBody = 512
+
+// WebhookOptions Webhook 选项
+type WebhookOptions struct {
+	URL         string        // 接收通知的地址
+	BearerToken string        // 不为空时以 Authorization: Bearer 发送
+	Timeout     time.Duration // 单次请求超时
+	Retries     int           // 失败后的重试次数
+}
+
+// Webhook 以 JSON POST 发送通知，失败时按 1s、2s、4s... 间隔重试
+type Webhook struct {
+	opts   WebhookOptions
+	client *http.Client
+}
+
+func NewWebhook(opts WebhookOptions) *Webhook {
+	return &Webhook{opts: opts, client: &http.Client{Timeout: opts.Timeout}}
+}
+
+// Send 发送 payload，返回最后一次尝试的错误；2xx 响应视为成功
+func (w *Webhook) Send(ctx context.Context, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化通知失败: %v", err)
+	}
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		err = w.post(ctx, body)
+		if err == nil || attempt >= w.opts.Retries {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (w *Webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.opts.BearerToken)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("响应状态 %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,151 @@
+package regress
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// missing 表示一侧没有该字段
+const missing = "<缺失>"
+
+// Diff 一个不一致的字段
+type Diff struct {
+	Path string // 字段路径，如 details[2].skip_reason
+	Want string // 期望值的 JSON，字段不存在时为 <缺失>
+	Got  string // 实际值的 JSON，字段不存在时为 <缺失>
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%s:\n    期望: %s\n    实际: %s", d.Path, d.Want, d.Got)
+}
+
+// Compare 逐字段比较两段 JSON，返回按路径排序的差异
+func Compare(want, got []byte) ([]Diff, error) {
+	var w, g interface{}
+	if err := json.Unmarshal(want, &w); err != nil {
+		return nil, fmt.Errorf("解析期望结果失败: %v", err)
+	}
+	if err := json.Unmarshal(got, &g); err != nil {
+		return nil, fmt.Errorf("解析实际结果失败: %v", err)
+	}
+	var diffs []Diff
+	compareValue("", w, g, &diffs)
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs, nil
+}
+
+// compareValue 递归比较，对象按 key、数组按下标展开，其余值整体比较
+func compareValue(path string, want, got interface{}, diffs *[]Diff) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		if g, ok := got.(map[string]interface{}); ok {
+			keys := make(map[string]struct{}, len(w)+len(g))
+			for k := range w {
+				keys[k] = struct{}{}
+			}
+			for k := range g {
+				keys[k] = struct{}{}
+			}
+			for k := range keys {
+				wv, wok := w[k]
+				gv, gok := g[k]
+				child := joinPath(path, k)
+				switch {
+				case !wok:
+					*diffs = append(*diffs, Diff{Path: child, Want: missing, Got: encode(gv)})
+				case !gok:
+					*diffs = append(*diffs, Diff{Path: child, Want: encode(wv), Got: missing})
+				default:
+					compareValue(child, wv, gv, diffs)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if g, ok := got.([]interface{}); ok {
+			for i := 0; i < max(len(w), len(g)); i++ {
+				child := path + "[" + strconv.Itoa(i) + "]"
+				switch {
+				case i >= len(w):
+					*diffs = append(*diffs, Diff{Path: child, Want: missing, Got: encode(g[i])})
+				case i >= len(g):
+					*diffs = append(*diffs, Diff{Path: child, Want: encode(w[i]), Got: missing})
+				default:
+					compareValue(child, w[i], g[i], diffs)
+				}
+			}
+			return
+		}
+	}
+	if path == "" {
+		path = "."
+	}
+	ws, wok := want.(string)
+	gs, gok := got.(string)
+	if wok && gok {
+		if ws != gs {
+			*diffs = append(*diffs, stringDiff(path, ws, gs))
+		}
+		return
+	}
+	if we, ge := encode(want), encode(got); we != ge {
+		*diffs = append(*diffs, Diff{Path: path, Want: we, Got: ge})
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// snippetRadius 长文本差异只显示首个不同字符前后的字符数
+const snippetRadius = 40
+
+// encode 将值序列化为单行 JSON，不转义 HTML 字符
+func encode(v interface{}) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// stringDiff 两个长文本只显示首个不同字符附近的片段，便于看出差异所在
+func stringDiff(path, want, got string) Diff {
+	w, g := []rune(want), []rune(got)
+	if len(w) <= 2*snippetRadius && len(g) <= 2*snippetRadius {
+		return Diff{Path: path, Want: encode(want), Got: encode(got)}
+	}
+	at := 0
+	for at < len(w) && at < len(g) && w[at] == g[at] {
+		at++
+	}
+	return Diff{
+		Path: fmt.Sprintf("%s（第 %d 个字符起）", path, at),
+		Want: snippet(w, at),
+		Got:  snippet(g, at),
+	}
+}
+
+// snippet 截取 at 前后各 snippetRadius 个字符，截断处以 … 标注
+func snippet(r []rune, at int) string {
+	start := max(at-snippetRadius, 0)
+	end := min(at+snippetRadius, len(r))
+	s := encode(string(r[start:end]))
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(r) {
+		s += "…"
+	}
+	return s
+}
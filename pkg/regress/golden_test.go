@@ -0,0 +1,88 @@
+package regress
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"content-verify-log/config"
+)
+
+var update = flag.Bool("update", false, "用当前的处理结果覆盖 testdata/golden 中的期望结果")
+
+// goldenDir 仓库中的回归语料
+const goldenDir = "../../testdata/golden"
+
+// TestGolden 按各用例的处理选项（默认配置叠加 <name>.options.yaml）处理 testdata/golden 中的输入并与期望结果比较，
+// 与 etc/config.yaml 无关；有意修改处理逻辑时用 go test ./pkg/regress -update 重新生成
+func TestGolden(t *testing.T) {
+	cases, err := LoadCorpus(goldenDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatalf("%s 中没有用例", goldenDir)
+	}
+	runner := NewRunner(config.NewDefaultMigrationConfig())
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			if *update {
+				if err := runner.Update(c); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			result, err := runner.Check(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Missing {
+				t.Fatalf("缺少期望结果 %s，可使用 -update 生成", c.GoldenPath)
+			}
+			for _, diff := range result.Diffs {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// TestLoadOptions 选项文件叠加在内置默认配置上，不受 Runner 默认选项影响；未知的键报错
+func TestLoadOptions(t *testing.T) {
+	dir := t.TempDir()
+	defaults := config.NewDefaultMigrationConfig()
+	defaults.MinErrorLevel = 3
+
+	missing := Case{Name: "none", OptionsPath: dir + "/none" + optionsSuffix}
+	got, err := LoadOptions(missing, defaults)
+	if err != nil || got != defaults {
+		t.Fatalf("没有选项文件时应返回 defaults，得到 %+v, %v", got, err)
+	}
+
+	withFile := Case{Name: "with", OptionsPath: dir + "/with" + optionsSuffix}
+	writeFile(t, withFile.OptionsPath, "salvageTruncated: true\n")
+	got, err = LoadOptions(withFile, defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.SalvageTruncated || got.MinErrorLevel != 0 {
+		t.Errorf("选项文件应叠加在内置默认配置上，得到 salvageTruncated=%v minErrorLevel=%d", got.SalvageTruncated, got.MinErrorLevel)
+	}
+
+	for name, body := range map[string]string{
+		"unknown": "noSuchKey: 1\n",
+		"invalid": "positionFormat: bogus\n",
+	} {
+		c := Case{Name: name, OptionsPath: dir + "/" + name + optionsSuffix}
+		writeFile(t, c.OptionsPath, body)
+		if _, err := LoadOptions(c, defaults); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
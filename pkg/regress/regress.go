@@ -0,0 +1,166 @@
+package regress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"content-verify-log/config"
+	"content-verify-log/pkg/model"
+	"content-verify-log/pkg/service"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// 输入 foo.json 对应的期望结果文件 foo.golden.json 和处理选项文件 foo.options.yaml 的后缀
+const (
+	goldenSuffix  = ".golden.json"
+	optionsSuffix = ".options.yaml"
+)
+
+// Case 语料目录中的一个用例：输入为源表 content 列的原始内容，期望结果为 ProcessedContent 的 JSON
+type Case struct {
+	Name        string // 用例名，即输入文件名去掉 .json
+	InputPath   string // 输入文件路径
+	GoldenPath  string // 期望结果文件路径，文件可能尚不存在
+	OptionsPath string // 处理选项文件路径，文件不存在时使用 Runner 的默认选项
+}
+
+// LoadCorpus 列出目录中的全部用例，按名称排序
+func LoadCorpus(dir string) ([]Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取语料目录失败: %v", err)
+	}
+	var cases []Case
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasSuffix(name, goldenSuffix) {
+			continue
+		}
+		base := strings.TrimSuffix(name, ".json")
+		cases = append(cases, Case{
+			Name:        base,
+			InputPath:   filepath.Join(dir, name),
+			GoldenPath:  filepath.Join(dir, base+goldenSuffix),
+			OptionsPath: filepath.Join(dir, base+optionsSuffix),
+		})
+	}
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i].Name < cases[j].Name
+	})
+	return cases, nil
+}
+
+// Runner 按用例的处理选项处理语料并与期望结果比较
+type Runner struct {
+	defaults *config.MigrationConfig
+}
+
+// NewRunner 创建比较器。没有处理选项文件的用例按 defaults 中的处理选项和源编码处理，应与迁移时一致
+func NewRunner(defaults *config.MigrationConfig) *Runner {
+	return &Runner{defaults: defaults}
+}
+
+// LoadOptions 返回用例的迁移配置：有处理选项文件时在内置默认配置上叠加该文件（与 Runner 的默认选项无关，
+// 使用例的结果只取决于语料目录本身），否则为 defaults
+func LoadOptions(c Case, defaults *config.MigrationConfig) (*config.MigrationConfig, error) {
+	b, err := os.ReadFile(c.OptionsPath)
+	if os.IsNotExist(err) {
+		return defaults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取处理选项失败: %v", err)
+	}
+	cfg := config.NewDefaultMigrationConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("解析处理选项 %s 失败: %v", c.OptionsPath, err)
+	}
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return nil, fmt.Errorf("处理选项 %s 不合法: %v", c.OptionsPath, errors.Join(errs...))
+	}
+	return cfg, nil
+}
+
+// Process 按迁移的方式处理一个输入，返回稳定的 JSON（去掉处理时间等每次运行都会变化的字段）
+func (r *Runner) Process(c Case) ([]byte, error) {
+	cfg, err := LoadOptions(c, r.defaults)
+	if err != nil {
+		return nil, err
+	}
+	processor := service.NewContentProcessorWithOptions(service.ProcessorOptionsFromConfig(cfg))
+	raw, err := os.ReadFile(c.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取输入失败: %v", err)
+	}
+	content := &model.VerifyContent{TaskID: c.Name}
+	// 解析失败时 Data 为 nil，由 ProcessContent 记录错误原因，与迁移中的处理结果一致
+	_ = service.ParseSourceContent(content, raw, cfg.SourceEncoding, &model.HashOptions{}, processor.JSONLimits())
+	processed := processor.ProcessContent(content)
+	// 处理时间和工具版本每次运行都不同，不参与比较
+	processed.ProcessedAt = nil
+	processed.ToolVersion = ""
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(processed); err != nil {
+		return nil, fmt.Errorf("序列化处理结果失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Result 一个用例的比较结果
+type Result struct {
+	Case    Case
+	Missing bool   // 期望结果文件不存在
+	Diffs   []Diff // 不一致的字段，为空且 Missing 为 false 表示一致
+}
+
+// Passed 返回用例是否与期望结果一致
+func (r *Result) Passed() bool {
+	return !r.Missing && len(r.Diffs) == 0
+}
+
+// Check 处理输入并与期望结果比较
+func (r *Runner) Check(c Case) (*Result, error) {
+	got, err := r.Process(c)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Case: c}
+	want, err := os.ReadFile(c.GoldenPath)
+	if os.IsNotExist(err) {
+		result.Missing = true
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取期望结果失败: %v", err)
+	}
+	result.Diffs, err = Compare(want, got)
+	if err != nil {
+		return nil, fmt.Errorf("比较 %s 失败: %v", c.GoldenPath, err)
+	}
+	return result, nil
+}
+
+// Update 处理输入并覆盖期望结果文件，用于有意修改处理逻辑后重新生成
+func (r *Runner) Update(c Case) error {
+	got, err := r.Process(c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.GoldenPath, got, 0644); err != nil {
+		return fmt.Errorf("写入期望结果失败: %v", err)
+	}
+	return nil
+}
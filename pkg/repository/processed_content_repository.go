@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// FailedContent 目标表中有错误码的记录
+type FailedContent struct {
+	ID          string `json:"id"`
+	TaskID      string `json:"task_id"`
+	ErrorCode   string `json:"error_code"`
+	ErrorReason string `json:"error_reason"`
+}
+
+// ProcessedContentRepository 封装目标表（processed_content）的统计查询
+// 表名由调用方校验，这里直接拼入 SQL
+type ProcessedContentRepository struct {
+	db    *sql.DB
+	table string
+}
+
+// NewProcessedContentRepository 创建目标表仓储
+func NewProcessedContentRepository(db *sql.DB, table string) *ProcessedContentRepository {
+	return &ProcessedContentRepository{db: db, table: table}
+}
+
+// Count 统计记录数量
+func (r *ProcessedContentRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+r.table).Scan(&count); err != nil {
+		return 0, fmt.Errorf("查询数量失败: %v", err)
+	}
+	return count, nil
+}
+
+// CountByErrorCode 按错误码统计记录数量，没有错误码的记录不计
+func (r *ProcessedContentRepository) CountByErrorCode(ctx context.Context) (map[string]int64, error) {
+	return r.countBy(ctx, "error_code", "WHERE error_code IS NOT NULL")
+}
+
+// CountByTask 按任务 ID 统计记录数量，没有任务 ID 的记录计入空字符串
+func (r *ProcessedContentRepository) CountByTask(ctx context.Context) (map[string]int64, error) {
+	return r.countBy(ctx, "COALESCE(pid, '')", "")
+}
+
+// countBy 按表达式 key 分组统计记录数量
+func (r *ProcessedContentRepository) countBy(ctx context.Context, key, where string) (map[string]int64, error) {
+	query := fmt.Sprintf("SELECT %s, COUNT(*) FROM %s %s GROUP BY 1", key, r.table, where)
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("分组统计表 %s 失败: %v", r.table, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var k string
+		var count int64
+		if err := rows.Scan(&k, &count); err != nil {
+			return nil, fmt.Errorf("读取统计结果失败: %v", err)
+		}
+		counts[k] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取统计结果失败: %v", err)
+	}
+	return counts, nil
+}
+
+// ListFailed 按 id 顺序分页查询有错误码的记录，返回跳过前 offset 条后的至多 limit 条
+func (r *ProcessedContentRepository) ListFailed(ctx context.Context, limit, offset int) ([]FailedContent, error) {
+	query := "SELECT id, pid, error_code, error_reason FROM " + r.table +
+		" WHERE error_code IS NOT NULL ORDER BY id LIMIT ? OFFSET ?"
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("查询表 %s 失败: %v", r.table, err)
+	}
+	defer rows.Close()
+
+	var failed []FailedContent
+	for rows.Next() {
+		var f FailedContent
+		var pid, reason sql.NullString
+		if err := rows.Scan(&f.ID, &pid, &f.ErrorCode, &reason); err != nil {
+			return nil, fmt.Errorf("读取记录失败: %v", err)
+		}
+		f.TaskID, f.ErrorReason = pid.String, reason.String
+		failed = append(failed, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取记录失败: %v", err)
+	}
+	return failed, nil
+}
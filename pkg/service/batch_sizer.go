@@ -0,0 +1,47 @@
+package service
+
+// maxAdaptiveBatchSize 自适应批量的上限，与 migration.batchSize 的取值上限一致
+const maxAdaptiveBatchSize = 100000
+
+// batchGrowthDivisor 每批最多增长当前批量的 1/batchGrowthDivisor，缩小则立即生效
+const batchGrowthDivisor = 4
+
+// batchSizer 按单批原文字节数上限自适应调整下一批读取的条数
+// 以上一批中最大的文档估算每条记录的大小：遇到大文档后立即缩小批量，之后逐批恢复，
+// 避免大文档集中出现时一批读入过多内容
+type batchSizer struct {
+	size   int   // 下一批读取的条数
+	budget int64 // 单批原文字节数上限，0 表示固定批量
+
+	minSeen, maxSeen int // 运行中使用过的最小、最大批量
+}
+
+func newBatchSizer(initial int, budget int64) *batchSizer {
+	return &batchSizer{size: initial, budget: budget, minSeen: initial, maxSeen: initial}
+}
+
+// adaptive 返回是否开启自适应
+func (b *batchSizer) adaptive() bool {
+	return b.budget > 0
+}
+
+// next 返回下一批读取的条数
+func (b *batchSizer) next() int {
+	return b.size
+}
+
+// observe 按上一批中最大文档的字节数调整下一批的条数
+func (b *batchSizer) observe(largest int64) {
+	if !b.adaptive() || largest <= 0 {
+		return
+	}
+	fit := b.budget / largest
+	target := int(min(max(fit, 1), maxAdaptiveBatchSize))
+	if target < b.size {
+		b.size = target
+	} else {
+		b.size = min(target, b.size+max(b.size/batchGrowthDivisor, 1))
+	}
+	b.minSeen = min(b.minSeen, b.size)
+	b.maxSeen = max(b.maxSeen, b.size)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// 新格式 position 的表示方式
+const (
+	PositionFormatOffset  = "offset"   // 平铺的偏移量（默认）
+	PositionFormatLineCol = "line-col" // {"line":3,"col":12}，行号和列号都从 1 开始
+)
+
+// ChecklistPosition 新格式的错误位置，兼容平铺的偏移量（数字或字符串编码的数字）和 {"line","col"} 对象
+// 部分新版标注工具按行列报告位置，直接反序列化为 int 会导致整个 checklist 解析失败
+type ChecklistPosition struct {
+	Offset int // 平铺的偏移量，LineCol 为 true 时无意义
+
+	LineCol bool // 是否为行列形式
+	Line    int  // 行号，从 1 开始
+	Col     int  // 列号，从 1 开始，单位与平铺偏移量一致
+}
+
+// UnmarshalJSON 接受 FlexInt 支持的写法和 {"line":3,"col":12} 对象
+func (p *ChecklistPosition) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '{' {
+		var lc struct {
+			Line *FlexInt `json:"line"`
+			Col  *FlexInt `json:"col"`
+		}
+		if err := json.Unmarshal(b, &lc); err != nil {
+			return err
+		}
+		if lc.Line == nil || lc.Col == nil {
+			return fmt.Errorf("行列位置 %s 缺少 line 或 col", b)
+		}
+		*p = ChecklistPosition{LineCol: true, Line: int(*lc.Line), Col: int(*lc.Col)}
+		return nil
+	}
+	var offset FlexInt
+	if err := offset.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	*p = ChecklistPosition{Offset: int(offset)}
+	return nil
+}
+
+// MarshalJSON 按源数据的形式输出
+func (p ChecklistPosition) MarshalJSON() ([]byte, error) {
+	if p.LineCol {
+		return json.Marshal(map[string]int{"line": p.Line, "col": p.Col})
+	}
+	return json.Marshal(p.Offset)
+}
+
+// resolve 返回以 positions 单位表示的平铺偏移量，无法换算时返回 -1（按越界处理）
+// 行列形式只在 format 为 PositionFormatLineCol 时换算：按 runes 中的 \n 划分行，
+// 偏移量为该行行首的偏移量加上 col-1，列超出该行长度时同样按越界处理
+func (p ChecklistPosition) resolve(format string, runes []rune, lines []int, positions PositionMapper) int {
+	if !p.LineCol {
+		return p.Offset
+	}
+	if format != PositionFormatLineCol || p.Line < 1 || p.Line > len(lines) || p.Col < 1 {
+		return -1
+	}
+	start := lines[p.Line-1]
+	end := len(runes)
+	if p.Line < len(lines) {
+		end = lines[p.Line] - 1 // 不含换行符
+	}
+	if p.Col-1 > positions.Len(string(runes[start:end])) {
+		return -1
+	}
+	return positions.Len(string(runes[:start])) + p.Col - 1
+}
+
+// lineStarts 返回每一行行首在 runes 中的下标
+func lineStarts(runes []rune) []int {
+	starts := []int{0}
+	for i, r := range runes {
+		if r == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
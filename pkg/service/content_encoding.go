@@ -0,0 +1,50 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"content-verify-log/pkg/model"
+	"content-verify-log/pkg/util"
+)
+
+// contentEncodingError 源内容不是合法 UTF-8，转换编码失败或转换后仍无法解析
+type contentEncodingError struct {
+	err error
+}
+
+func (e *contentEncodingError) Error() string {
+	return e.err.Error()
+}
+
+func (e *contentEncodingError) Unwrap() error {
+	return e.err
+}
+
+// ParseSourceContent 将源内容转换为 UTF-8 后解析到 content.Content，迁移和回归比较共用。
+// 不是合法 UTF-8 的内容按 sourceEncoding 转换，sourceEncoding 为 UTF-8 时按 GB18030 尝试；
+// 合法 UTF-8 的内容不转换：含 GBK 双字节字符的内容一定不是合法 UTF-8，而按 GB18030 重新解释合法 UTF-8 不会改变其中的 JSON 结构，
+// 解析时报非法字符的也不会因转换而成功。
+// 使用了转换时 content.Content.Encoding 记录转换前的编码。
+// 非 UTF-8 的内容转换或解析失败时 Raw 保留原始字节、Data 为 nil，返回 *contentEncodingError
+func ParseSourceContent(content *model.VerifyContent, b []byte, sourceEncoding string, hash *model.HashOptions, limits model.JSONLimits) error {
+	decoded, encoding, err := util.SniffToUTF8(b, sourceEncoding)
+	if err != nil {
+		content.Content.SetRaw(b, hash)
+		return &contentEncodingError{err: err}
+	}
+	err = content.Content.ParseBytes(decoded, hash, limits)
+	switch {
+	case err == nil:
+		content.Content.Encoding = encoding
+		return nil
+	case encoding != "":
+		if errors.Is(err, model.ErrJSONTooLarge) || errors.Is(err, model.ErrJSONTooDeep) {
+			return err
+		}
+		content.Content.SetRaw(b, hash)
+		return &contentEncodingError{err: fmt.Errorf("按 %s 转换后 %v", encoding, err)}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"content-verify-log/pkg/model"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// TestParseSourceContentGBK GBK 编码的源内容转换为 UTF-8 后解析并处理，记录转换前的编码；无法解码时返回编码错误并保留原始字节
+func TestParseSourceContentGBK(t *testing.T) {
+	raw := `{"data":{"replace_text":"我门的正文","checklist":[{"word":"我门","position":0,"length":2,"suggest":["我们"]}]}}`
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := &model.VerifyContent{}
+	if err := ParseSourceContent(content, gbk, "gbk", nil, model.JSONLimits{}); err != nil {
+		t.Fatal(err)
+	}
+	if content.Content.Encoding != "gbk" {
+		t.Errorf("编码为 %q，应为 gbk", content.Content.Encoding)
+	}
+	result := NewContentProcessor().ProcessContent(content)
+	if result.ModifiedText != "我们的正文" || result.OriginalText != "我门的正文" {
+		t.Errorf("处理结果: %q %q", result.OriginalText, result.ModifiedText)
+	}
+	if result.ConvertedEncoding != "gbk" {
+		t.Errorf("converted_encoding 为 %q，应为 gbk", result.ConvertedEncoding)
+	}
+
+	content = &model.VerifyContent{}
+	if err := ParseSourceContent(content, []byte(raw), "gbk", nil, model.JSONLimits{}); err != nil {
+		t.Fatal(err)
+	}
+	if content.Content.Encoding != "" {
+		t.Errorf("合法 UTF-8 不应转换，编码为 %q", content.Content.Encoding)
+	}
+
+	bad := append(append([]byte{}, gbk...), 0xff)
+	content = &model.VerifyContent{}
+	err = ParseSourceContent(content, bad, "gbk", nil, model.JSONLimits{})
+	var encodingErr *contentEncodingError
+	if !errors.As(err, &encodingErr) {
+		t.Fatalf("应返回编码错误，得到 %v", err)
+	}
+	if content.Content.GetRawContent() != string(bad) {
+		t.Error("应保留原始字节")
+	}
+}
+
+// TestParseSourceContentHash 哈希基于转换为 UTF-8 并规范化后的内容：同一内容的 GBK 和 UTF-8 编码、首尾空白不同时哈希一致
+func TestParseSourceContentHash(t *testing.T) {
+	raw := `{"data":{"replace_text":"我门的正文"}}`
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := &model.HashOptions{NFC: true}
+	var hashes []string
+	for _, b := range [][]byte{[]byte(raw), gbk, []byte("\n" + raw + "  ")} {
+		content := &model.VerifyContent{}
+		if err := ParseSourceContent(content, b, "", hash, model.JSONLimits{}); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, NewContentProcessor().ProcessContent(content).ContentHash)
+	}
+	if hashes[0] == "" || hashes[1] != hashes[0] || hashes[2] != hashes[0] {
+		t.Errorf("等价内容的哈希应一致: %q", hashes)
+	}
+}
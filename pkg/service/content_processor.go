@@ -7,14 +7,166 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"content-verify-log/pkg/model"
+	"content-verify-log/pkg/util"
 )
 
-type ContentProcessor struct{}
+// 预编译的正则表达式，避免每次调用时重复编译
+var (
+	// 旧格式错误标记：带 background-color:yellow 样式的 span
+	oldFormatErrorSpanRegex = regexp.MustCompile(`<span[^>]*style\s*=\s*["'][^"']*background-color\s*:\s*yellow[^"']*["'][^>]*>.*?</span>`)
+	// 任意 HTML 标签
+	htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+	// 旧格式错误提示文本，如 【<无建议>,错误】
+	errorPromptRegex = regexp.MustCompile(`【[^】]*错误】`)
+)
+
+// toolVersion 写入每条处理结果的工具版本，进程内不变
+var toolVersion = util.GetVersion().Version
+
+type ContentProcessor struct {
+	opts ProcessorOptions
+
+	preserve map[string]bool // 清洗 HTML 时原样保留的标签名，由 PreserveTags 生成
+	markers  markerRule      // 新格式错误标记的判断规则，由 MarkerClasses 等选项生成
+}
+
+// ProcessorOptions 内容处理选项
+type ProcessorOptions struct {
+	IncludeTypes []int // 仅应用这些错误类型（ChecklistItem.Type.ID / Correction.ErrType），为空表示不限制
+	ExcludeTypes []int // 不应用这些错误类型，优先于 IncludeTypes
+	// 只应用错误级别（ChecklistItem.UmErrorLevel / Correction.Level）不低于该值的修正，低于的项记入明细但不应用，0 表示不限制
+	MinErrorLevel int
+	// 位置、错误词和建议词都相同的重复修正项只应用第一项，其余记为 duplicate；
+	// 不去重时重复项会在已替换的位置校验失败，旧格式还会退回全文查找而误替换别处
+	DedupCorrections bool
+
+	ValidateInput bool // 处理前按格式约定校验输入，违例记为 SCHEMA_VIOLATION
+	NormalizeText bool // 清洗 HTML 后统一换行符为 \n 并移除零宽字符
+	// 引号规范化方式 QuotesOff/QuotesMatch/QuotesAll，为空时不规范化；源文本与错误词的引号样式（弯引号/直引号）不一致时用于匹配
+	NormalizeQuotes string
+	KeepHTML        bool // OriginalText/ModifiedText 保留 HTML，只移除错误标记；明细中的位置仍基于清洗后的纯文本
+	// 清洗 HTML 用于存储时原样保留的标签名（如 sup、sub、del），开始和结束标签都保留；KeepHTML 时不起作用
+	// 明细中的位置和上下文仍基于不含这些标签的纯文本
+	PreserveTags []string
+	// 清洗 HTML 时将 alt 非空的 <img> 替换为 "[图: alt]"；明细中的位置和上下文同样基于替换后的文本
+	ImageAltText bool
+	// 清洗 HTML 时在链接文字后附上 " (地址)"，javascript:、vbscript:、data: 地址和页内锚点不附加
+	LinkURLs bool
+
+	SearchWindow int // 新格式位置与错误词不一致时，在原位置前后多少个字符内查找错误词，0 表示不查找
+
+	ContextWindow int // 为已应用的修正提取前后各多少个字符的上下文，0 表示不提取；新格式优先使用 checklist 中的 context
+
+	LengthRatio LengthRatioCheck // 修正后检查修改后的文章与原文的长度之比，未设置时不检查
+
+	TagNeedsReview  bool    // 为结果设置 NeedsReview，标记需要人工复核的记录
+	ReviewSkipRatio float64 // 跳过的修正占比超过该值时需要复核
+
+	JSONLimits model.JSONLimits // 解析源内容前检查的大小和嵌套层数限制，未设置时使用 model.DefaultJSONLimits
+	// 源内容不是合法 JSON（通常是因存储长度限制被截断）时，从中提取 checkresultstr/replace_text 等已知字段尽量处理，
+	// 结果记为 TRUNCATED_JSON_SALVAGED；关闭时这类内容只记录解析失败
+	SalvageTruncated bool
+
+	OldPositions PositionMapper // 旧格式 pos 的单位，未设置时为 BytePositions
+	NewPositions PositionMapper // 新格式 position/length 的单位，未设置时为 RunePositions
+
+	PositionFormat string // 新格式 position 的表示方式，PositionFormatLineCol 时换算 {"line","col"} 形式的位置，否则这类项按越界跳过
+
+	// 新格式中作为错误标记移除的 span class，为空时为 DefaultMarkerClasses；
+	// 标记移除后 checklist 的 position 基于移除后的文本，因此增加类名会改变含这类 span 的文档的位置基准
+	MarkerClasses     []string
+	MarkerClassPrefix string   // class 以该前缀开头（如 "jdt_"）的 span 同样作为错误标记移除，为空时不按前缀
+	KeepMarkerClasses []string // 不作为错误标记移除的 class，优先于 MarkerClasses 和 MarkerClassPrefix
+}
+
+// NewContentProcessor 创建内容处理器，不传选项时使用默认行为
+func NewContentProcessor(opts ...Option) *ContentProcessor {
+	p := &ContentProcessor{}
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+	p.preserve = preserveSet(p.opts.PreserveTags)
+	p.markers = newMarkerRule(p.opts)
+	return p
+}
+
+// NewContentProcessorWithOptions 使用指定选项创建内容处理器
+func NewContentProcessorWithOptions(opts ProcessorOptions) *ContentProcessor {
+	return NewContentProcessor(WithOptions(opts))
+}
+
+// JSONLimits 返回解析源内容时使用的限制
+func (p *ContentProcessor) JSONLimits() model.JSONLimits {
+	if p.opts.JSONLimits.IsZero() {
+		return model.DefaultJSONLimits
+	}
+	return p.opts.JSONLimits
+}
+
+// positions 返回该来源格式使用的位置单位
+func (p *ContentProcessor) positions(sourceFormat string) PositionMapper {
+	if sourceFormat == model.SourceFormatOld {
+		if p.opts.OldPositions != nil {
+			return p.opts.OldPositions
+		}
+		return BytePositions
+	}
+	if p.opts.NewPositions != nil {
+		return p.opts.NewPositions
+	}
+	return RunePositions
+}
+
+// typeAllowed 判断错误类型是否允许应用到修改后的文章
+func (p *ContentProcessor) typeAllowed(typeID int) bool {
+	for _, t := range p.opts.ExcludeTypes {
+		if t == typeID {
+			return false
+		}
+	}
+	if len(p.opts.IncludeTypes) == 0 {
+		return true
+	}
+	for _, t := range p.opts.IncludeTypes {
+		if t == typeID {
+			return true
+		}
+	}
+	return false
+}
+
+// filterReason 返回修正项因配置而不应用的原因：错误类型被过滤为 filtered，级别低于 MinErrorLevel 为 below_level，应用时为空
+func (p *ContentProcessor) filterReason(typeID, level int) string {
+	if !p.typeAllowed(typeID) {
+		return model.SkipReasonFiltered
+	}
+	if level < p.opts.MinErrorLevel {
+		return model.SkipReasonBelowLevel
+	}
+	return ""
+}
+
+// countFiltered 按原因计入因配置而未应用的修正数
+func countFiltered(result *model.ProcessedContent, reason string) {
+	if result == nil {
+		return
+	}
+	if reason == model.SkipReasonBelowLevel {
+		result.BelowLevelCount++
+	} else {
+		result.FilteredCount++
+	}
+}
 
-func NewContentProcessor() *ContentProcessor {
-	return &ContentProcessor{}
+// countDuplicate 计入去重后未应用的修正数
+func countDuplicate(result *model.ProcessedContent) {
+	if result != nil {
+		result.DuplicateCount++
+	}
 }
 
 // ProcessContent 处理验证内容，提取并处理 JSON 数据
@@ -23,8 +175,80 @@ func NewContentProcessor() *ContentProcessor {
 // 2. 新格式：replace_text + checklist
 // 即使处理失败也会返回结果，错误原因记录在 ErrorReason 字段中
 func (p *ContentProcessor) ProcessContent(verifyContent *model.VerifyContent) *model.ProcessedContent {
+	result := p.processContent(verifyContent)
+	p.checkLengthRatio(result)
+
+	// 以实际应用的修正为准，而不是 checklist/checkresultjson 中的条目数
+	for i := range result.Details {
+		if result.Details[i].Applied() {
+			result.CorrectionCount++
+		} else if result.Details[i].SkipReason == model.SkipReasonConflict {
+			result.ConflictCount++
+		}
+	}
+	result.LevelCounts = levelCounts(result.Details)
+	if p.opts.ContextWindow > 0 {
+		fillContexts(result, p.plainOriginalText(result), p.opts.ContextWindow)
+	}
+	result.HasErrors = result.CorrectionCount > 0
+	if p.opts.TagNeedsReview {
+		p.tagNeedsReview(result)
+	}
+	return result
+}
+
+// plainOriginalText 返回清洗后的纯文本原文，明细中的位置基于该文本
+func (p *ContentProcessor) plainOriginalText(result *model.ProcessedContent) string {
+	if p.opts.KeepHTML {
+		return p.toPlainText(result.OriginalText)
+	}
+	return p.removePreservedTags(result.OriginalText)
+}
+
+// fillContexts 为没有上下文的已应用修正从清洗后的原文 text 中截取前后 window 个字符
+func fillContexts(result *model.ProcessedContent, text string, window int) {
+	var runes []rune
+	for i := range result.Details {
+		detail := &result.Details[i]
+		if !detail.Applied() || detail.Context != "" || detail.Position < 0 {
+			continue
+		}
+		if runes == nil {
+			runes = []rune(text)
+		}
+		if detail.Position > len(runes) {
+			continue
+		}
+		start := max(detail.Position-window, 0)
+		end := min(detail.Position+utf8.RuneCountInString(detail.Word)+window, len(runes))
+		detail.Context = string(runes[start:end])
+	}
+}
+
+// levelCounts 按错误级别统计错误明细数量（含未应用的项），没有明细时返回 nil
+func levelCounts(details []model.ErrorDetail) map[int]int {
+	if len(details) == 0 {
+		return nil
+	}
+	counts := make(map[int]int)
+	for i := range details {
+		counts[details[i].Level]++
+	}
+	return counts
+}
+
+// processContent 按格式分派处理
+func (p *ContentProcessor) processContent(verifyContent *model.VerifyContent) *model.ProcessedContent {
+	processedAt := time.Now()
 	result := &model.ProcessedContent{
-		PID: verifyContent.TaskID,
+		PID:               verifyContent.TaskID,
+		RawSize:           verifyContent.Content.Size(),
+		ContentHash:       verifyContent.Content.Hash(),
+		ConvertedEncoding: verifyContent.Content.Encoding,
+		ProcessedAt:       &processedAt,
+		ToolVersion:       toolVersion,
+		SourceCreatedAt:   timePtr(verifyContent.CreatedAt),
+		SourceUpdatedAt:   timePtr(verifyContent.UpdatedAt),
 	}
 
 	// 解析 JSON
@@ -36,7 +260,30 @@ func (p *ContentProcessor) ProcessContent(verifyContent *model.VerifyContent) *m
 			result.ErrorReason = "内容为空"
 			return result
 		}
-		if err := json.Unmarshal([]byte(raw), &jsonData); err != nil {
+		// 超出限制的内容不尝试解析，避免反序列化时耗尽内存
+		if err := p.JSONLimits().Check([]byte(raw)); err != nil {
+			result.ErrorCode = model.ErrCodeJSONLimitExceeded
+			result.ErrorReason = fmt.Sprintf("JSON 超出限制: %v", err)
+			return result
+		}
+		// 不是合法 UTF-8 的内容（如旧导出程序写入的 GBK）按 GB18030 转换后再解析
+		if !utf8.ValidString(raw) {
+			decoded, encoding, err := util.SniffToUTF8([]byte(raw), util.EncodingUTF8)
+			if err == nil {
+				err = json.Unmarshal(decoded, &jsonData)
+			}
+			if err != nil {
+				result.ErrorCode = model.ErrCodeEncodingError
+				result.ErrorReason = fmt.Sprintf("content 不是合法的 UTF-8，转换编码后仍无法解析: %v", err)
+				return result
+			}
+			result.ConvertedEncoding = encoding
+		} else if err := json.Unmarshal([]byte(raw), &jsonData); err != nil {
+			if p.opts.SalvageTruncated {
+				if dataObj, ok := salvageTruncated(raw); ok {
+					return p.processSalvaged(dataObj, err, result)
+				}
+			}
 			result.ErrorReason = fmt.Sprintf("JSON 解析失败: %v", err)
 			return result
 		}
@@ -44,9 +291,11 @@ func (p *ContentProcessor) ProcessContent(verifyContent *model.VerifyContent) *m
 
 	// 检查是否有 data 字段包装（根据实际 JSON 结构）
 	var dataObj map[string]interface{}
+	pathPrefix := ""
 	if data, exists := jsonData["data"]; exists {
 		if dataMap, ok := data.(map[string]interface{}); ok {
 			dataObj = dataMap
+			pathPrefix = "data."
 		} else {
 			dataObj = jsonData
 		}
@@ -54,8 +303,18 @@ func (p *ContentProcessor) ProcessContent(verifyContent *model.VerifyContent) *m
 		dataObj = jsonData
 	}
 
-	// 检测格式：新格式有 replace_text 字段
-	if replaceText, exists := dataObj["replace_text"].(string); exists && replaceText != "" {
+	// 校验输入格式，区分“上游数据不合法”和“处理逻辑有问题”
+	if p.opts.ValidateInput {
+		if violation := validateInput(dataObj, pathPrefix); violation != nil {
+			result.ErrorCode = model.ErrCodeSchemaViolation
+			result.ValidationError = violation.Error()
+			result.ErrorReason = "输入不符合格式约定"
+			return result
+		}
+	}
+
+	result.SourceFormat = DetectFormat(dataObj)
+	if result.SourceFormat == model.SourceFormatNew {
 		return p.processNewFormat(dataObj, result)
 	}
 
@@ -63,6 +322,34 @@ func (p *ContentProcessor) ProcessContent(verifyContent *model.VerifyContent) *m
 	return p.processOldFormat(dataObj, result)
 }
 
+// processSalvaged 处理从无法解析的 JSON 中提取的字段，parseErr 为原来的解析错误。
+// 没有完整的修正列表时只有原文（新格式的原文即 replace_text 清洗后的文本）；处理本身的错误码（如 NO_EXTRACTABLE_TEXT）优先
+func (p *ContentProcessor) processSalvaged(dataObj map[string]interface{}, parseErr error, result *model.ProcessedContent) *model.ProcessedContent {
+	result.SourceFormat = DetectFormat(dataObj)
+	if result.SourceFormat == model.SourceFormatNew {
+		result = p.processNewFormat(dataObj, result)
+	} else {
+		result = p.processOldFormat(dataObj, result)
+	}
+	if result.ErrorCode == "" {
+		result.ErrorCode = model.ErrCodeTruncatedJSONSalvaged
+	}
+	reason := fmt.Sprintf("从截断的 JSON 中提取字段处理（%v）", parseErr)
+	if result.ErrorReason != "" {
+		reason += "; " + result.ErrorReason
+	}
+	result.ErrorReason = reason
+	return result
+}
+
+// DetectFormat 检测 data 对象的格式：有非空 replace_text 字段为新格式，否则按旧格式处理
+func DetectFormat(dataObj map[string]interface{}) string {
+	if replaceText, exists := dataObj["replace_text"].(string); exists && replaceText != "" {
+		return model.SourceFormatNew
+	}
+	return model.SourceFormatOld
+}
+
 // processOldFormat 处理旧格式（checkresultstr + checkresultjson）
 func (p *ContentProcessor) processOldFormat(dataObj map[string]interface{}, result *model.ProcessedContent) *model.ProcessedContent {
 	// 提取 checkresultstr（原文，包含错误标记的 HTML）
@@ -80,10 +367,17 @@ func (p *ContentProcessor) processOldFormat(dataObj map[string]interface{}, resu
 		}
 	}
 
-	// 移除错误标记的 HTML，保留原文 HTML
-	originalText := p.stripErrorMarkers(originalTextWithErrorMarkers, "old")
-	// 清洗所有 HTML 标签用于存储
-	result.OriginalText = p.stripHTML(originalText)
+	// 上游整体转义过的原文先解码一次，之后的标记清洗和 pos 都基于解码后的文本
+	if escapedMarkup(originalTextWithErrorMarkers) {
+		originalTextWithErrorMarkers = html.UnescapeString(originalTextWithErrorMarkers)
+	}
+
+	// 移除错误标记的 HTML 后清洗所有 HTML 标签用于存储（开启 KeepHTML 时保留）
+	_, result.OriginalText = p.cleanSource(originalTextWithErrorMarkers, "old", result)
+	result.OriginalTextValid = true
+	if p.markNoExtractableText(originalTextWithErrorMarkers, result) {
+		return result
+	}
 
 	// 提取 checkresultjson（错误信息）
 	checkResultJSON, ok := dataObj["checkresultjson"]
@@ -91,12 +385,12 @@ func (p *ContentProcessor) processOldFormat(dataObj map[string]interface{}, resu
 	// 检查 checkresultjson 是否为空（nil 或空字符串）
 	if checkResultJSON == nil {
 		result.ErrorReason = "checkresultjson为空"
-		// ModifiedText 保持为空
+		// ModifiedText 保持为 NULL
 		return result
 	}
 	if str, ok := checkResultJSON.(string); ok && str == "" {
 		result.ErrorReason = "checkresultjson为空"
-		// ModifiedText 保持为空
+		// ModifiedText 保持为 NULL
 		return result
 	}
 
@@ -104,12 +398,16 @@ func (p *ContentProcessor) processOldFormat(dataObj map[string]interface{}, resu
 	modifiedText, err := p.applyCorrections(originalTextWithErrorMarkers, checkResultJSON, result)
 	if err != nil {
 		result.ErrorReason = fmt.Sprintf("应用修正失败: %v", err)
-		// ModifiedText 保持为空
+		// ModifiedText 保持为 NULL
 		return result
 	}
 
-	// 清洗所有 HTML 标签用于存储
-	result.ModifiedText = p.stripHTML(modifiedText)
+	// 清洗所有 HTML 标签用于存储；保留 HTML 时错误标记仍在修正后的文本中，需要先移除
+	if p.opts.KeepHTML {
+		modifiedText = p.stripErrorMarkers(modifiedText, "old")
+	}
+	result.ModifiedText = p.toStoredText(modifiedText)
+	result.ModifiedTextValid = true
 	return result
 }
 
@@ -127,31 +425,36 @@ func (p *ContentProcessor) processNewFormat(dataObj map[string]interface{}, resu
 	if !ok {
 		result.ErrorReason = "未找到 checklist 字段"
 		// 即使没有 checklist，也可以处理 replace_text
-		// 移除错误标记的html标签
-		cleanedText := p.stripErrorMarkers(replaceText, "new")
-		//清洗原文的html标签
-		result.ModifiedText = p.stripHTML(cleanedText)
+		// 移除错误标记的html标签，清洗原文的html标签
+		_, result.ModifiedText = p.cleanSource(replaceText, "new", result)
 		result.OriginalText = result.ModifiedText
+		result.OriginalTextValid, result.ModifiedTextValid = true, true
+		p.markNoExtractableText(replaceText, result)
 		return result
 	}
 
-	// 移除错误标记，保留原文 HTML
-	cleanedReplaceText := p.stripErrorMarkers(replaceText, "new")
-	// 对原文清洗所有 HTML 标签用于存储
-	result.OriginalText = p.stripHTML(cleanedReplaceText)
+	// 移除错误标记，保留原文 HTML；对原文清洗所有 HTML 标签用于存储
+	cleanedReplaceText, originalText := p.cleanSource(replaceText, "new", result)
+	result.OriginalText = originalText
+	result.OriginalTextValid = true
+	if p.markNoExtractableText(replaceText, result) {
+		return result
+	}
 
 	// 根据 replace_text 和 checklist组成修改后的文章
-	modifiedText, err := p.applyChecklistFixes(cleanedReplaceText, checklist)
+	modifiedText, err := p.applyChecklistFixes(cleanedReplaceText, checklist, result)
 	if err != nil {
 		result.ErrorReason = fmt.Sprintf("提取原文失败: %v", err)
 		// 如果提取失败
 		result.ModifiedText = result.OriginalText
+		result.ModifiedTextValid = true
 		return result
 	}
 
 	// 移除错误标记后清洗 HTML
 	cleanedModifiedText := p.stripErrorMarkers(modifiedText, "new")
-	result.ModifiedText = p.stripHTML(cleanedModifiedText)
+	result.ModifiedText = p.toStoredText(cleanedModifiedText)
+	result.ModifiedTextValid = true
 
 	// 检查是否有错误
 	checklistArray, ok := checklist.([]interface{})
@@ -176,7 +479,7 @@ func (p *ContentProcessor) processNewFormat(dataObj map[string]interface{}, resu
 }
 
 // applyChecklistFixes 从新格式的 replace_text 和 checklist 中提取原文
-func (p *ContentProcessor) applyChecklistFixes(replaceText string, checklist interface{}) (string, error) {
+func (p *ContentProcessor) applyChecklistFixes(replaceText string, checklist interface{}, result *model.ProcessedContent) (string, error) {
 	// ⚠️ 不立即解码 HTML，position 基于原始文本
 	originalText := replaceText
 
@@ -210,39 +513,187 @@ func (p *ContentProcessor) applyChecklistFixes(replaceText string, checklist int
 		// 没有错误，移除错误标记后返回
 		return originalText, nil
 	}
+	return p.applyChecklistItems(originalText, checklistItems, result), nil
+}
 
-	// 按 position 从后往前排序，避免替换影响后续位置
-	sort.Slice(checklistItems, func(i, j int) bool {
-		return checklistItems[i].Position > checklistItems[j].Position
-	})
+// ApplyChecklist 在调用方提供的文本上应用新格式的错误项，返回修正后的文本，不需要 VerifyContent 外层结构
+// position/length 按处理器的选项（NewPositions、PositionFormat）解释，基于 text 本身；
+// 错误类型过滤、附近查找等选项同样生效，未应用的项直接跳过。text 不是合法的 UTF-8 时返回错误
+func (p *ContentProcessor) ApplyChecklist(text string, items []ChecklistItem) (string, error) {
+	if !utf8.ValidString(text) {
+		return text, fmt.Errorf("文本不是合法的 UTF-8")
+	}
+	if len(items) == 0 {
+		return text, nil
+	}
+	return p.applyChecklistItems(text, items, nil), nil
+}
 
+// applyChecklistItems 按 checklistItems 修正 originalText，明细记录到 result（为 nil 时不记录）
+func (p *ContentProcessor) applyChecklistItems(originalText string, checklistItems []ChecklistItem, result *model.ProcessedContent) string {
 	runes := []rune(originalText)
+	positions := p.positions(model.SourceFormatNew)
+
+	// 行列形式的位置先换算为平铺偏移量，排序和应用都使用换算后的值
+	flat := make([]int, len(checklistItems))
+	var lines []int
+	for i := range checklistItems {
+		if checklistItems[i].Position.LineCol && lines == nil {
+			lines = lineStarts(runes)
+		}
+		flat[i] = checklistItems[i].Position.resolve(p.opts.PositionFormat, runes, lines, positions)
+	}
+
+	// 按 position 从后往前应用，避免替换影响后续位置；只对下标排序，保留原始顺序供审计输出
+	position := func(i int) int { return flat[i] }
+	order := checklistApplyOrder(checklistItems, flat)
+	firstDetail := detailCount(result)
+
+	var duplicates map[int]bool
+	if p.opts.DedupCorrections {
+		keys := make([]spanCandidate, 0, len(checklistItems))
+		for i, item := range checklistItems {
+			if len(item.Suggest) > 0 {
+				keys = append(keys, spanCandidate{index: i, pos: flat[i], length: int(item.Length), word: item.Word, suggestion: item.Suggest[0]})
+			}
+		}
+		duplicates = duplicateCandidates(keys)
+	}
+
+	// 同一错误词在同一区间有多个不同建议词时只保留一项；插入（长度为 0）按列出的顺序依次插入，不算冲突
+	candidates := make([]spanCandidate, 0, len(checklistItems))
+	for i, item := range checklistItems {
+		if flat[i] < 0 || item.Length <= 0 || len(item.Suggest) == 0 || p.filterReason(item.Type.ID, item.UmErrorLevel) != "" || duplicates[i] {
+			continue
+		}
+		candidates = append(candidates, spanCandidate{
+			index: i, pos: flat[i], length: int(item.Length), word: item.Word,
+			suggestion: item.Suggest[0], level: item.UmErrorLevel, typeID: item.Type.ID,
+		})
+	}
+	conflicts := spanConflicts(candidates)
+
+	// 清洗后位置映射，首次记录已应用的修正时计算
+	var offsets []int
+	// 用于查找错误词的文本，开启引号规范化时为规范化后的副本，首次查找时计算
+	var matchRunes []rune
+	// 从后往前应用：cursor 之后的文本已处理完毕，pieces 逆序保存替换后的片段，
+	// 最后一次性拼接，避免每次替换都拷贝整个尾部
+	cursor := len(runes)
+	var pieces []string
+
+	for _, i := range order {
+		item := checklistItems[i]
+		detail := model.ErrorDetail{
+			Position:     -1,
+			Word:         item.Word,
+			Suggestions:  item.Suggest,
+			AppliedIndex: -1,
+			TypeID:       item.Type.ID,
+			TypeName:     item.Type.Name,
+			Level:        item.UmErrorLevel,
+			Explanation:  item.Explanation,
+			SourceFormat: model.SourceFormatNew,
+		}
 
-	for _, item := range checklistItems {
 		if len(item.Suggest) == 0 {
+			detail.SkipReason = model.SkipReasonNoSuggestion
+			addErrorDetail(result, detail)
+			continue
+		}
+
+		// 按错误类型和级别过滤，被过滤的项只计数不应用
+		if reason := p.filterReason(item.Type.ID, item.UmErrorLevel); reason != "" {
+			countFiltered(result, reason)
+			detail.SkipReason = reason
+			addErrorDetail(result, detail)
 			continue
 		}
 
-		start := item.Position
-		end := start + item.Length
+		if duplicates[i] {
+			countDuplicate(result)
+			detail.SkipReason = model.SkipReasonDuplicate
+			addErrorDetail(result, detail)
+			continue
+		}
 
-		// 边界保护
-		if start < 0 || end > len(runes) {
+		if conflicts[i] {
+			detail.SkipReason = model.SkipReasonConflict
+			addErrorDetail(result, detail)
 			continue
 		}
 
-		// 校验原文内容，确保不误替换
-		originalWord := string(runes[start:end])
-		if originalWord != item.Word {
+		// 长度为负时无法确定替换范围，单独记录原因以便与位置越界区分
+		if item.Length < 0 {
+			detail.SkipReason = model.SkipReasonNegativeLength
+			addErrorDetail(result, detail)
 			continue
 		}
 
+		// 换算为 rune 区间，负数位置、超出文本的项不应用；长度为 0 时区间为空，即在 position 处插入建议词
+		start, end, ok := positions.RuneRange(runes, flat[i], int(item.Length))
+		if !ok {
+			detail.SkipReason = model.SkipReasonOutOfRange
+			addErrorDetail(result, detail)
+			continue
+		}
+
+		// 与已应用的修正重叠
+		if end > cursor {
+			detail.SkipReason = model.SkipReasonOverlap
+			addErrorDetail(result, detail)
+			continue
+		}
+
+		// 校验原文内容，确保不误替换；位置略有偏差（如实体解码导致）时在附近窗口内查找错误词
+		originalWord := string(runes[start:end])
+		if !p.sameWord(originalWord, item.Word) {
+			// 插入（长度为 0）的 word 应为空；不为空时与长度矛盾，不在附近查找，以免把插入变成替换
+			if start == end {
+				detail.SkipReason = model.SkipReasonMismatch
+				addErrorDetail(result, detail)
+				continue
+			}
+			if matchRunes == nil {
+				matchRunes = p.matchRunes(runes)
+			}
+			wordRunes := p.matchRunes([]rune(item.Word))
+			found := searchNear(matchRunes, wordRunes, start, p.opts.SearchWindow, cursor)
+			if found < 0 {
+				detail.SkipReason = model.SkipReasonMismatch
+				addErrorDetail(result, detail)
+				continue
+			}
+			start, end = found, found+len(wordRunes)
+			detail.Recovered = true
+		}
+
+		if offsets == nil {
+			offsets = visibleOffsets(runes, "new", p.newTagRenderer(), p.opts.NormalizeText)
+		}
+		detail.Position = offsets[start]
+		detail.AppliedIndex = 0
+		if p.opts.ContextWindow > 0 {
+			detail.Context = item.Context
+		}
+		addErrorDetail(result, detail)
+
 		// 执行替换
-		newRunes := []rune(item.Suggest[0])
-		runes = append(runes[:start], append(newRunes, runes[end:]...)...)
+		pieces = append(pieces, string(runes[end:cursor]), item.Suggest[0])
+		cursor = start
 	}
+	restoreDocumentOrder(result, firstDetail, order, position)
 
-	return string(runes), nil
+	if len(pieces) == 0 {
+		return originalText
+	}
+	var b strings.Builder
+	b.Grow(len(originalText))
+	b.WriteString(string(runes[:cursor]))
+	for i := len(pieces) - 1; i >= 0; i-- {
+		b.WriteString(pieces[i])
+	}
+	return b.String()
 }
 
 // applyCorrections 根据 checkresultjson 将错误词替换回原文（旧格式）
@@ -287,91 +738,430 @@ func (p *ContentProcessor) applyCorrections(originalTextWithMarkers string, chec
 		}
 		return originalTextWithMarkers, nil
 	}
+	return p.applyCorrectionList(originalTextWithMarkers, corrections, result), nil
+}
 
-	// 按位置从后往前排序，避免替换时位置偏移
-	sort.Slice(corrections, func(i, j int) bool {
-		return corrections[i].Pos > corrections[j].Pos
-	})
+// ApplyCorrections 在调用方提供的文本上应用旧格式的修正，返回修正后的文本，不需要 VerifyContent 外层结构
+// pos 按处理器的 OldPositions 解释，基于 text 本身；错误类型过滤等选项同样生效，位置不一致时在全文中查找错误词，
+// 找不到的项直接跳过。text 不是合法的 UTF-8 时返回错误
+func (p *ContentProcessor) ApplyCorrections(text string, corrections []Correction) (string, error) {
+	if !utf8.ValidString(text) {
+		return text, fmt.Errorf("文本不是合法的 UTF-8")
+	}
+	if len(corrections) == 0 {
+		return text, nil
+	}
+	return p.applyCorrectionList(text, corrections, nil), nil
+}
+
+// applyCorrectionList 按 corrections 修正 originalTextWithMarkers，明细记录到 result（为 nil 时不记录）
+func (p *ContentProcessor) applyCorrectionList(originalTextWithMarkers string, corrections []Correction, result *model.ProcessedContent) string {
+	// 按位置从后往前应用，避免替换时位置偏移；只对下标排序，保留原始顺序供审计输出
+	position := func(i int) int { return int(corrections[i].Pos) }
+	order := backToFrontOrder(len(corrections), position)
+	firstDetail := detailCount(result)
 
 	// 在包含错误标记的文本上应用修正
 	// 因为 position 是基于包含错误标记的文本计算的
 	modifiedText := originalTextWithMarkers
 	runes := []rune(modifiedText)
+	positions := p.positions(model.SourceFormatOld)
+
+	var duplicates map[int]bool
+	if p.opts.DedupCorrections {
+		// 位置未知的项按上下文定位，无法判断是否指向同一处，不去重
+		keys := make([]spanCandidate, 0, len(corrections))
+		for i, corr := range corrections {
+			if corr.Pos >= 0 && len(corr.CorWord) > 0 {
+				keys = append(keys, spanCandidate{index: i, pos: int(corr.Pos), word: corr.ErrWord, suggestion: corr.CorWord[0]})
+			}
+		}
+		duplicates = duplicateCandidates(keys)
+	}
 
-	// 辅助函数：将字节位置转换为 rune 位置
-	byteToRunePos := func(text string, bytePos int) int {
-		if bytePos < 0 || bytePos >= len(text) {
-			return -1
+	// 同一错误词在同一位置有多个不同建议词时只保留一项，否则先应用的替换会使后面的项在全文中误匹配
+	candidates := make([]spanCandidate, 0, len(corrections))
+	for i, corr := range corrections {
+		if corr.Pos < 0 || corr.ErrWord == "" || len(corr.CorWord) == 0 || corr.CorWord[0] == "" || p.filterReason(corr.ErrType, corr.Level) != "" || duplicates[i] {
+			continue
 		}
-		runePos := 0
-		byteCount := 0
-		for i, r := range text {
-			if byteCount >= bytePos {
-				return i
-			}
-			byteCount += len(string(r))
-			runePos = i + 1
+		candidates = append(candidates, spanCandidate{
+			index: i, pos: int(corr.Pos), length: positions.Len(corr.ErrWord), word: corr.ErrWord,
+			suggestion: corr.CorWord[0], level: corr.Level, typeID: corr.ErrType,
+		})
+	}
+	conflicts := spanConflicts(candidates)
+	// 清洗后位置映射，按需计算；修正从后往前应用，前缀不变时可复用
+	var offsets []int
+	// 从后往前应用：runes[:cursor] 是尚未修改的前缀，pieces 逆序保存 cursor 之后替换后的片段，
+	// 避免每次替换都拷贝整个文本；需要在全文上查找或替换时先用 flush 拼接为完整的 modifiedText 和 runes
+	cursor := len(runes)
+	var pieces []string
+	runeRange := runeRanges(positions, runes)
+	flush := func() {
+		if len(pieces) == 0 {
+			return
 		}
-		return runePos
+		var b strings.Builder
+		b.Grow(len(originalTextWithMarkers))
+		b.WriteString(string(runes[:cursor]))
+		for i := len(pieces) - 1; i >= 0; i-- {
+			b.WriteString(pieces[i])
+		}
+		modifiedText = b.String()
+		runes = []rune(modifiedText)
+		runeRange = runeRanges(positions, runes)
+		cursor = len(runes)
+		pieces = pieces[:0]
 	}
 
 	// 应用修正
-	for _, corr := range corrections {
+	for _, i := range order {
+		corr := corrections[i]
+		detail := model.ErrorDetail{
+			Position:     -1,
+			Word:         corr.ErrWord,
+			Suggestions:  corr.CorWord,
+			AppliedIndex: -1,
+			TypeID:       corr.ErrType,
+			Level:        corr.Level,
+			Explanation:  corr.ErrDesc,
+			SourceFormat: model.SourceFormatOld,
+		}
+
 		// 获取正确词（corword 是数组，取第一个）
 		if len(corr.CorWord) == 0 || corr.CorWord[0] == "" {
 			// 如果没有正确词，跳过
+			detail.SkipReason = model.SkipReasonNoSuggestion
+			addErrorDetail(result, detail)
 			continue
 		}
 
 		correctWord := corr.CorWord[0]
 
 		if corr.ErrWord == "" {
+			detail.SkipReason = model.SkipReasonEmptyWord
+			addErrorDetail(result, detail)
+			continue
+		}
+
+		// 按错误类型和级别过滤，被过滤的项只计数不应用
+		if reason := p.filterReason(corr.ErrType, corr.Level); reason != "" {
+			countFiltered(result, reason)
+			detail.SkipReason = reason
+			addErrorDetail(result, detail)
+			continue
+		}
+
+		if duplicates[i] {
+			countDuplicate(result)
+			detail.SkipReason = model.SkipReasonDuplicate
+			addErrorDetail(result, detail)
 			continue
 		}
 
-		errWordRunes := []rune(corr.ErrWord)
-		correctWordRunes := []rune(correctWord)
+		if conflicts[i] {
+			detail.SkipReason = model.SkipReasonConflict
+			addErrorDetail(result, detail)
+			continue
+		}
 
 		// 尝试使用位置信息（position 是基于包含错误标记的文本）
 		if corr.Pos >= 0 {
-			// 将字节位置转换为 rune 位置
-			runePos := byteToRunePos(modifiedText, corr.Pos)
+			// 换算为 rune 区间，源数据没有长度，按错误词计算
+			runePos, runeEnd, ok := runeRange(int(corr.Pos), positions.Len(corr.ErrWord))
+			// 区间伸入已替换的部分（或只有在替换后的文本中才不越界）时，在替换后的全文上判断
+			if (!ok || runeEnd > cursor) && len(pieces) > 0 {
+				flush()
+				runePos, runeEnd, ok = runeRange(int(corr.Pos), positions.Len(corr.ErrWord))
+			}
 
-			if runePos >= 0 && runePos+len(errWordRunes) <= len(runes) {
+			if ok {
 				// 提取实际文本进行比较（可能包含错误标记 HTML）
-				actualRunes := runes[runePos : runePos+len(errWordRunes)]
+				actualRunes := runes[runePos:runeEnd]
 				actualText := string(actualRunes)
 
 				// 移除错误标记后比较
 				actualTextCleaned := p.stripErrorMarkers(actualText, "new")
-				if actualTextCleaned == corr.ErrWord || actualText == corr.ErrWord {
+				if p.sameWord(actualTextCleaned, corr.ErrWord) || p.sameWord(actualText, corr.ErrWord) {
+					if offsets == nil {
+						offsets = visibleOffsets(runes, "old", p.newTagRenderer(), p.opts.NormalizeText)
+					}
+					detail.Position = offsets[runePos]
+					detail.AppliedIndex = 0
+					addErrorDetail(result, detail)
 					// 位置匹配，直接替换
-					runes = append(
-						runes[:runePos],
-						append(correctWordRunes, runes[runePos+len(errWordRunes):]...)...,
-					)
-					modifiedText = string(runes)
-					runes = []rune(modifiedText)
+					pieces = append(pieces, string(runes[runeEnd:cursor]), correctWord)
+					cursor = runePos
 					continue
 				}
 			}
 		}
 
+		// 以下在全文上查找错误词
+		flush()
+
+		// 位置未知时不取第一处出现，按上下文唯一确定后才应用
+		if corr.Pos < 0 {
+			if offsets == nil {
+				offsets = visibleOffsets(runes, "old", p.newTagRenderer(), p.opts.NormalizeText)
+			}
+			start, reason := p.locateByContext(runes, offsets, corr)
+			if reason != "" {
+				detail.SkipReason = reason
+				addErrorDetail(result, detail)
+				continue
+			}
+			detail.Position = offsets[start]
+			detail.AppliedIndex = 0
+			addErrorDetail(result, detail)
+			end := start + utf8.RuneCountInString(corr.ErrWord)
+			runes = append(runes[:start], append([]rune(correctWord), runes[end:]...)...)
+			modifiedText = string(runes)
+			cursor = len(runes)
+			runeRange = runeRanges(positions, runes)
+			// 位置未知的项最后应用，替换位置可能在任意处，映射失效
+			offsets = nil
+			continue
+		}
+
 		cleanedText := modifiedText
-		idx := strings.Index(cleanedText, corr.ErrWord)
+		idx, idxEnd := p.indexWord(cleanedText, corr.ErrWord)
 		if idx != -1 {
+			if offsets == nil {
+				offsets = visibleOffsets(runes, "old", p.newTagRenderer(), p.opts.NormalizeText)
+			}
+			detail.Position = offsets[utf8.RuneCountInString(cleanedText[:idx])]
+			detail.AppliedIndex = 0
 			// 找到匹配位置，需要在包含错误标记的文本中找到对应位置
 			// 由于错误标记的存在，需要重新计算位置
 			// 简化处理：在清理后的文本中替换，然后重新添加错误标记（如果有的话）
-			cleanedText = strings.Replace(cleanedText, corr.ErrWord, correctWord, 1)
+			cleanedText = cleanedText[:idx] + correctWord + cleanedText[idxEnd:]
 			// 注意：这里简化处理，实际应该保持错误标记的位置
 			// 但为了简化，我们直接使用清理后的文本
 			modifiedText = cleanedText
 			runes = []rune(modifiedText)
+			cursor = len(runes)
+			runeRange = runeRanges(positions, runes)
+			// 替换位置可能在前缀中，映射失效
+			offsets = nil
+		} else {
+			detail.SkipReason = model.SkipReasonNotFound
+		}
+		addErrorDetail(result, detail)
+	}
+	restoreDocumentOrder(result, firstDetail, order, position)
+
+	flush()
+	return modifiedText
+}
+
+// markNoExtractableText 源文本非空但清洗后没有任何可见文本时（全是标签/空白）标记为 NO_EXTRACTABLE_TEXT，
+// 用于区分真正的空内容和清洗过度，返回是否已标记
+func (p *ContentProcessor) markNoExtractableText(source string, result *model.ProcessedContent) bool {
+	if strings.TrimSpace(source) == "" || strings.TrimSpace(p.plainOriginalText(result)) != "" {
+		return false
+	}
+	result.ErrorCode = model.ErrCodeNoExtractableText
+	result.ErrorReason = "没有可提取的文本"
+	// 文档本身没有文字，修改后的文章同样为空字符串而不是 NULL
+	result.ModifiedTextValid = true
+	return true
+}
+
+// timePtr 将零值时间转换为 nil，用于区分缺失的时间
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// visibleOffsets 计算每个 rune 位置在清洗（移除标签和错误提示、解码实体）后的文本中的位置
+// 返回长度为 len(runes)+1 的切片，offsets[i] 为 runes[:i] 清洗后的 rune 数
+// 单次线性扫描，避免对每个修正项重新清洗整个前缀；实体按解码为一个字符计算，r 渲染的标签按渲染后的字符数计算；
+// normalize 为 true 时与 normalizeText 一致：零宽字符（含解码为零宽字符的实体）不计数，\r\n 按一个字符计算
+func visibleOffsets(runes []rune, flag string, r *tagRenderer, normalize bool) []int {
+	offsets := make([]int, len(runes)+1)
+	count := 0
+	// 与 stripHTML 一致：出现过未闭合的注释后不再按注释查找
+	unterminated := false
+	for i := 0; i < len(runes); {
+		next, visible := i+1, 1
+		switch runes[i] {
+		case '<':
+			k := -1
+			comment := false
+			if !unterminated && hasRunePrefix(runes[i:], commentOpen) {
+				if k = commentEndRunes(runes[i:]); k < 0 {
+					unterminated = true
+				}
+				comment = k >= 0
+			}
+			if k < 0 {
+				k = indexRune(runes[i:], '>', len(runes))
+			}
+			if k > 0 {
+				next, visible = i+k+1, 0
+				if r != nil && !comment {
+					rendered := r.render(html.UnescapeString(string(runes[i : i+k+1])))
+					if normalize {
+						rendered = normalizeText(rendered)
+					}
+					visible = utf8.RuneCountInString(rendered)
+				}
+			}
+		case '&':
+			if k := indexRune(runes[i:], ';', maxEntityLength); k > 1 {
+				next = i + k + 1
+				if normalize {
+					if decoded := []rune(html.UnescapeString(string(runes[i:next]))); len(decoded) == 1 && isZeroWidth(decoded[0]) {
+						visible = 0
+					}
+				}
+			}
+		case '\r':
+			// \r\n 规范化为 \n，由后面的 \n 计数
+			if normalize && i+1 < len(runes) && runes[i+1] == '\n' {
+				visible = 0
+			}
+		case '【':
+			if flag == "old" {
+				if k := indexRune(runes[i:], '】', maxPromptLength); k > 2 && string(runes[i+k-2:i+k]) == "错误" {
+					next, visible = i+k+1, 0
+				}
+			}
+		default:
+			if normalize && isZeroWidth(runes[i]) {
+				visible = 0
+			}
+		}
+		for t := i; t < next; t++ {
+			offsets[t] = count
+		}
+		count += visible
+		i = next
+	}
+	offsets[len(runes)] = count
+	return offsets
+}
+
+// 实体与错误提示的最大查找长度，避免在异常文本上退化为平方复杂度
+const (
+	maxEntityLength = 12
+	maxPromptLength = 64
+)
+
+// indexRune 在 runes 的前 limit 个元素中查找 r，未找到返回 -1
+func indexRune(runes []rune, r rune, limit int) int {
+	if limit > len(runes) {
+		limit = len(runes)
+	}
+	for i := 0; i < limit; i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// addErrorDetail 将错误明细记录到处理结果
+func addErrorDetail(result *model.ProcessedContent, detail model.ErrorDetail) {
+	if result != nil {
+		result.Details = append(result.Details, detail)
+	}
+}
+
+// searchNear 在 center 前后 window 个字符内查找 word，返回离 center 最近的起始位置，
+// 匹配结果必须完整落在 [0, limit) 内，找不到时返回 -1
+func searchNear(runes, word []rune, center, window, limit int) int {
+	if window <= 0 || len(word) == 0 {
+		return -1
+	}
+	matchAt := func(pos int) bool {
+		if pos < 0 || pos+len(word) > limit {
+			return false
+		}
+		for i, r := range word {
+			if runes[pos+i] != r {
+				return false
+			}
+		}
+		return true
+	}
+	for d := 1; d <= window; d++ {
+		if matchAt(center - d) {
+			return center - d
+		}
+		if matchAt(center + d) {
+			return center + d
 		}
 	}
+	return -1
+}
+
+// detailCount 返回当前已记录的错误明细数量
+func detailCount(result *model.ProcessedContent) int {
+	if result == nil {
+		return 0
+	}
+	return len(result.Details)
+}
+
+// backToFrontOrder 返回按源位置从后往前的下标顺序，位置相同时保持原始顺序
+func backToFrontOrder(n int, position func(i int) int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return position(order[a]) > position(order[b])
+	})
+	return order
+}
 
-	return modifiedText, nil
+// checklistApplyOrder 返回新格式错误项按位置从后往前的应用顺序；同一位置上先应用替换再应用插入（长度为 0），
+// 插入按列出的逆序应用，使插入的建议词按列出的顺序出现在被替换的词之前，且不会被当作与替换重叠
+func checklistApplyOrder(items []ChecklistItem, flat []int) []int {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if flat[i] != flat[j] {
+			return flat[i] > flat[j]
+		}
+		insertI, insertJ := items[i].Length == 0, items[j].Length == 0
+		if insertI != insertJ {
+			return insertJ
+		}
+		return insertI && i > j
+	})
+	return order
+}
+
+// restoreDocumentOrder 将按 order 应用顺序记录的明细（从 first 开始，每项一条）
+// 重排为按源位置升序，位置相同时按原始顺序，用于审计输出
+func restoreDocumentOrder(result *model.ProcessedContent, first int, order []int, position func(i int) int) {
+	if result == nil || len(result.Details)-first != len(order) {
+		return
+	}
+	applied := result.Details[first:]
+	byIndex := make([]model.ErrorDetail, len(order))
+	for k, i := range order {
+		byIndex[i] = applied[k]
+	}
+	indexes := make([]int, len(order))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return position(indexes[a]) < position(indexes[b])
+	})
+	for k, i := range indexes {
+		applied[k] = byIndex[i]
+	}
 }
 
 // stripErrorMarkers 移除错误标记的 HTML，保留原文的 HTML 和标签内的文字
@@ -382,8 +1172,13 @@ func (p *ContentProcessor) applyCorrections(originalTextWithMarkers string, chec
 //   - 【<无建议>,错误】等错误提示文本
 //
 // 2. 新格式：
-//   - <span class="jdt_umold" ...> 及其闭合标签（保留标签内的文字）
+//   - <span class="jdt_umold" ...> 及其闭合标签（保留标签内的文字），类名按 MarkerClasses 等选项判断
 func (p *ContentProcessor) stripErrorMarkers(text string, flag string) string {
+	return p.stripErrorMarkersCounting(text, flag, nil)
+}
+
+// stripErrorMarkersCounting 与 stripErrorMarkers 相同，result 不为 nil 时统计新格式错误标记的类名
+func (p *ContentProcessor) stripErrorMarkersCounting(text string, flag string, result *model.ProcessedContent) string {
 	if text == "" {
 		return text
 	}
@@ -391,20 +1186,16 @@ func (p *ContentProcessor) stripErrorMarkers(text string, flag string) string {
 	if flag == "new" {
 		// 1. 移除新格式的错误标记：<span class="jdt_umold" ...>...</span>
 		// 只移除 span 标签本身，保留标签内的所有内容（包括文字和其他 HTML）
-		newFormatErrorRegex := regexp.MustCompile(`<span[^>]*class\s*=\s*["']jdt_umold["'][^>]*>(.*?)</span>`)
-		text = newFormatErrorRegex.ReplaceAllString(text, "$1")
+		text = p.stripMarkerSpans(text, result)
 	}
 	if flag == "old" {
 		// 2. 移除旧格式的错误标记的 span 标签及其内容（带 background-color:yellow 样式）
 		// 使用非贪婪匹配，匹配从 <span style="background-color:yellow;"> 到 </span> 的内容
-		oldFormatErrorSpanRegex := regexp.MustCompile(`<span[^>]*style\s*=\s*["'][^"']*background-color\s*:\s*yellow[^"']*["'][^>]*>.*?</span>`)
 		text = oldFormatErrorSpanRegex.ReplaceAllStringFunc(text, func(match string) string {
 			// 提取 span 标签内的文本内容（移除所有 HTML 标签）
-			innerHTMLRegex := regexp.MustCompile(`<[^>]*>`)
-			innerText := innerHTMLRegex.ReplaceAllString(match, "")
+			innerText := htmlTagRegex.ReplaceAllString(match, "")
 			// 移除错误提示文本
-			errorTextRegex := regexp.MustCompile(`【[^】]*错误】`)
-			innerText = errorTextRegex.ReplaceAllString(innerText, "")
+			innerText = errorPromptRegex.ReplaceAllString(innerText, "")
 			return innerText
 		})
 	}
@@ -412,6 +1203,15 @@ func (p *ContentProcessor) stripErrorMarkers(text string, flag string) string {
 	return text
 }
 
+// escapedMarkup 判断旧格式原文是否被上游整体做过一次 HTML 转义：含转义的 <span，
+// 且转义的 "&lt;" 多于未转义的 '<'（部分行外层仍有未转义的 <p> 等标签）
+func escapedMarkup(text string) bool {
+	if !strings.Contains(strings.ToLower(text), "&lt;span") {
+		return false
+	}
+	return strings.Count(text, "<") < strings.Count(text, "&lt;")
+}
+
 // stripHTML 清洗所有 HTML 标签
 func (p *ContentProcessor) stripHTML(text string) string {
 	if text == "" {
@@ -419,34 +1219,115 @@ func (p *ContentProcessor) stripHTML(text string) string {
 	}
 
 	// 先解码 HTML 实体（如 &lt; 转为 <）
-	decoded := html.UnescapeString(text)
+	return stripTags(html.UnescapeString(text), p.newTagRenderer())
+}
 
-	// 使用正则表达式移除所有 HTML 标签
-	// 匹配 <...> 格式的标签，包括自闭合标签
-	htmlTagRegex := regexp.MustCompile(`<[^>]*>`)
-	cleaned := htmlTagRegex.ReplaceAllString(decoded, "")
+// stripTags 移除已解码文本中的所有标签和注释，r 不为 nil 时标签替换为 r 渲染的文字
+func stripTags(decoded string, r *tagRenderer) string {
+	// 移除所有 <...> 格式的标签，包括自闭合标签（等价于正则 <[^>]*>）；
+	// 注释 <!-- ... -->（含 IE 条件注释）连同内容一起移除，内容中可能有 '>' 或换行
+	// 直接扫描写入预分配的 Builder，避免正则替换产生的中间拷贝
+	first := strings.IndexByte(decoded, '<')
+	if first < 0 {
+		return decoded
+	}
+	var b strings.Builder
+	b.Grow(len(decoded))
+	b.WriteString(decoded[:first])
+	rest := decoded[first:]
+	// 出现过未闭合的注释后，之后的 <!-- 也不会闭合，不再查找，避免平方复杂度
+	unterminated := false
+	for {
+		end := -1
+		comment := false
+		if !unterminated && strings.HasPrefix(rest, commentOpen) {
+			if end = commentEnd(rest); end < 0 {
+				unterminated = true
+			}
+			comment = end >= 0
+		}
+		if end < 0 {
+			end = strings.IndexByte(rest, '>')
+		}
+		if end < 0 {
+			// 没有闭合的 '>'，不构成标签，原样保留
+			b.WriteString(rest)
+			break
+		}
+		if !comment {
+			b.WriteString(r.render(rest[:end+1]))
+		}
+		rest = rest[end+1:]
+		next := strings.IndexByte(rest, '<')
+		if next < 0 {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:next])
+		rest = rest[next:]
+	}
+	return b.String()
+}
+
+// HTML 注释的起止标记
+const (
+	commentOpen  = "<!--"
+	commentClose = "-->"
+)
 
-	return cleaned
+// commentEnd 返回以 <!-- 开头的 s 中注释结束的 '>' 的下标，注释未闭合时返回 -1
+func commentEnd(s string) int {
+	k := strings.Index(s[len(commentOpen):], commentClose)
+	if k < 0 {
+		return -1
+	}
+	return len(commentOpen) + k + len(commentClose) - 1
+}
+
+// commentEndRunes 与 commentEnd 相同，用于 rune 切片，runes 不以 <!-- 开头时返回 -1
+func commentEndRunes(runes []rune) int {
+	if !hasRunePrefix(runes, commentOpen) {
+		return -1
+	}
+	for i := len(commentOpen); i+len(commentClose) <= len(runes); i++ {
+		if hasRunePrefix(runes[i:], commentClose) {
+			return i + len(commentClose) - 1
+		}
+	}
+	return -1
+}
+
+// hasRunePrefix 判断 runes 是否以 ASCII 字符串 prefix 开头
+func hasRunePrefix(runes []rune, prefix string) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i := 0; i < len(prefix); i++ {
+		if runes[i] != rune(prefix[i]) {
+			return false
+		}
+	}
+	return true
 }
 
 // Correction 表示一个修正项（旧格式）
 type Correction struct {
-	ErrType int      `json:"errtype"` // 错误类型
-	ErrWord string   `json:"errword"` // 错误词
-	ErrDesc string   `json:"errdesc"` // 错误描述
-	Pos     int      `json:"pos"`     // 错误位置
-	Level   int      `json:"level"`   // 级别
-	CorWord []string `json:"corword"` // 正确词数组
+	ErrType int         `json:"errtype"` // 错误类型
+	ErrWord string      `json:"errword"` // 错误词
+	ErrDesc string      `json:"errdesc"` // 错误描述
+	Pos     FlexInt     `json:"pos"`     // 错误位置，兼容字符串编码的数字
+	Level   int         `json:"level"`   // 级别
+	CorWord FlexStrings `json:"corword"` // 正确词数组，兼容单个字符串
 }
 
 // ChecklistItem 表示新格式的错误项
 type ChecklistItem struct {
-	Position             int                    `json:"position"`             // 错误位置
+	Position             ChecklistPosition      `json:"position"`             // 错误位置，兼容字符串编码的数字和行列形式
 	Word                 string                 `json:"word"`                 // 错误词
 	WordHtml             string                 `json:"wordHtml"`             // HTML 格式的错误词
 	HtmlWords            []HtmlWord             `json:"htmlWords"`            // HTML 词列表
-	Length               int                    `json:"length"`               // 长度
-	Suggest              []string               `json:"suggest"`              // 建议词数组
+	Length               FlexInt                `json:"length"`               // 长度，兼容字符串编码的数字
+	Suggest              FlexStrings            `json:"suggest"`              // 建议词数组，兼容单个字符串
 	Explanation          string                 `json:"explanation"`          // 解释
 	Type                 ChecklistErrorType     `json:"type"`                 // 错误类型
 	Action               map[string]interface{} `json:"action"`               // 操作
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchDocument 返回 paragraphs 段文字，每段有一处错误词“我门”，以及每处错误词的字节位置和字符位置
+func benchDocument(paragraphs int) (text string, bytePos, runePos []int) {
+	var b strings.Builder
+	runes := 0
+	for i := 0; i < paragraphs; i++ {
+		para := fmt.Sprintf("<p>这是第%d段<b>文字</b>，", i)
+		b.WriteString(para)
+		runes += len([]rune(para))
+		bytePos = append(bytePos, b.Len())
+		runePos = append(runePos, runes)
+		b.WriteString("我门今天去学校。&amp;</p>")
+		runes += len([]rune("我门今天去学校。&amp;</p>"))
+	}
+	return b.String(), bytePos, runePos
+}
+
+// benchmarkSizes 文档的段数，即修正的数量
+var benchmarkSizes = []int{100, 1000, 10000}
+
+// BenchmarkApplyCorrectionsOld 旧格式按位置从后往前应用修正，分配量应随文档长度线性增长
+func BenchmarkApplyCorrectionsOld(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		text, bytePos, _ := benchDocument(n)
+		corrections := make([]Correction, n)
+		for i, pos := range bytePos {
+			corrections[i] = Correction{ErrType: 1, ErrWord: "我门", Pos: FlexInt(pos), CorWord: FlexStrings{"我们"}}
+		}
+		p := NewContentProcessor()
+		b.Run(fmt.Sprintf("corrections=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				if _, err := p.ApplyCorrections(text, corrections); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkApplyCorrectionsNew 新格式按位置从后往前应用修正，用于与旧格式对比
+func BenchmarkApplyCorrectionsNew(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		text, _, runePos := benchDocument(n)
+		items := make([]ChecklistItem, n)
+		for i, pos := range runePos {
+			items[i] = ChecklistItem{Position: ChecklistPosition{Offset: pos}, Word: "我门", Length: 2, Suggest: FlexStrings{"我们"}}
+		}
+		p := NewContentProcessor()
+		b.Run(fmt.Sprintf("corrections=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				if _, err := p.ApplyChecklist(text, items); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"content-verify-log/pkg/model"
+)
+
+// goldenInputs 返回 testdata/golden 中全部输入的原始内容，作为模糊测试的种子
+func goldenInputs(f *testing.F) [][]byte {
+	f.Helper()
+	files, err := filepath.Glob("../../testdata/golden/*.json")
+	if err != nil {
+		f.Fatal(err)
+	}
+	var inputs [][]byte
+	for _, file := range files {
+		if strings.HasSuffix(file, ".golden.json") {
+			continue
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		inputs = append(inputs, b)
+	}
+	if len(inputs) == 0 {
+		f.Fatal("testdata/golden 中没有输入")
+	}
+	return inputs
+}
+
+// goldenData 返回能解析的种子中 data 对象的各字段，key 为字段名
+func goldenData(f *testing.F) []map[string]json.RawMessage {
+	var datas []map[string]json.RawMessage
+	for _, b := range goldenInputs(f) {
+		var doc struct {
+			Data map[string]json.RawMessage `json:"data"`
+		}
+		if json.Unmarshal(b, &doc) == nil && doc.Data != nil {
+			datas = append(datas, doc.Data)
+		}
+	}
+	return datas
+}
+
+// checkProcessed 校验对任意输入都应成立的不变量
+func checkProcessed(t *testing.T, result *model.ProcessedContent) {
+	t.Helper()
+	if !utf8.ValidString(result.ModifiedText) {
+		t.Fatalf("modified_text 不是合法的 UTF-8: %q", result.ModifiedText)
+	}
+	if !utf8.ValidString(result.OriginalText) {
+		t.Fatalf("original_text 不是合法的 UTF-8: %q", result.OriginalText)
+	}
+	for i, detail := range result.Details {
+		if detail.Applied() && detail.AppliedIndex >= len(detail.Suggestions) {
+			t.Fatalf("details[%d].applied_index %d 超出建议词范围 %d", i, detail.AppliedIndex, len(detail.Suggestions))
+		}
+	}
+}
+
+// FuzzProcessRaw 按迁移的方式解析并处理任意的源 content：不 panic，输出文本是合法的 UTF-8
+func FuzzProcessRaw(f *testing.F) {
+	for _, b := range goldenInputs(f) {
+		f.Add(b)
+	}
+	p := NewContentProcessor(WithSearchWindow(8), WithContextWindow(5), WithDedupCorrections(), WithSalvageTruncated())
+	f.Fuzz(func(t *testing.T, b []byte) {
+		content := &model.VerifyContent{}
+		_ = ParseSourceContent(content, b, "", &model.HashOptions{}, p.JSONLimits())
+		checkProcessed(t, p.ProcessContent(content))
+	})
+}
+
+// FuzzParseChecklist 用任意的 replace_text 和 checklist 字符串应用新格式修正：不 panic，
+// 输出是合法的 UTF-8，已应用的项位置在修正前的文本范围内
+func FuzzParseChecklist(f *testing.F) {
+	for _, data := range goldenData(f) {
+		var text string
+		if json.Unmarshal(data["replace_text"], &text) != nil {
+			continue
+		}
+		checklist := string(data["checklist"])
+		// checklist 为字符串编码的 JSON 时取其内容
+		var s string
+		if json.Unmarshal(data["checklist"], &s) == nil {
+			checklist = s
+		}
+		f.Add(text, checklist)
+	}
+	p := NewContentProcessor(WithSearchWindow(8), WithContextWindow(5), WithDedupCorrections())
+	f.Fuzz(func(t *testing.T, text, checklist string) {
+		if !utf8.ValidString(text) {
+			return
+		}
+		result := &model.ProcessedContent{}
+		modified, err := p.applyChecklistFixes(text, checklist, result)
+		if err != nil {
+			return
+		}
+		if !utf8.ValidString(modified) {
+			t.Fatalf("修正后的文本不是合法的 UTF-8: %q", modified)
+		}
+		checkProcessed(t, result)
+		limit := utf8.RuneCountInString(text)
+		for i, detail := range result.Details {
+			if detail.Applied() && (detail.Position < 0 || detail.Position > limit) {
+				t.Fatalf("details[%d].position %d 超出文本范围 [0, %d]", i, detail.Position, limit)
+			}
+		}
+	})
+}
+
+// FuzzStripErrorMarkers 移除任意文本中的新格式错误标记：不 panic，输出是合法的 UTF-8 且不比输入长；
+// 有结束标签的标记都已移除，即对输出再移除一次不再有变化（只剩没有结束标签的标记）
+func FuzzStripErrorMarkers(f *testing.F) {
+	for _, data := range goldenData(f) {
+		var text string
+		if json.Unmarshal(data["replace_text"], &text) == nil {
+			f.Add(text)
+		}
+	}
+	f.Add(`<span class="jdt_umold"><span class="jdt_umold">嵌套</span></span><span class="jdt_umold">未闭合`)
+	f.Add(`<!-- <span class="jdt_umold"> --><span class="x jdt_umold">注释</span>`)
+	p := NewContentProcessorWithOptions(ProcessorOptions{MarkerClassPrefix: "jdt_", KeepMarkerClasses: []string{"jdt_sensitive"}})
+	f.Fuzz(func(t *testing.T, text string) {
+		out := p.stripErrorMarkers(text, model.SourceFormatNew)
+		if utf8.ValidString(text) && !utf8.ValidString(out) {
+			t.Fatalf("输出不是合法的 UTF-8: %q", out)
+		}
+		if len(out) > len(text) {
+			t.Fatalf("输出比输入长: %q -> %q", text, out)
+		}
+		if again := p.stripErrorMarkers(out, model.SourceFormatNew); again != out {
+			t.Fatalf("输出中仍有可配对的错误标记: %q -> %q", out, again)
+		}
+	})
+}
@@ -0,0 +1,528 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// TestApplyCorrectionList 旧格式从后往前拼接替换结果，与逐次替换整个文本的结果一致：
+// 区间伸入已替换的部分时在替换后的文本上比较，位置不一致时在全文中查找，位置未知的项最后应用
+func TestApplyCorrectionList(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		corrections []Correction
+		want        string
+	}{
+		{
+			name: "positions",
+			text: "我门和他门",
+			corrections: []Correction{
+				{ErrWord: "我门", Pos: 0, CorWord: FlexStrings{"我们"}},
+				{ErrWord: "他门", Pos: 9, CorWord: FlexStrings{"他们"}},
+			},
+			want: "我们和他们",
+		},
+		{
+			name: "overlaps replaced text",
+			text: "abcd",
+			corrections: []Correction{
+				{ErrWord: "cd", Pos: 2, CorWord: FlexStrings{"XYZ"}},
+				{ErrWord: "bX", Pos: 1, CorWord: FlexStrings{"Q"}},
+			},
+			want: "aQYZ",
+		},
+		{
+			name: "in range only after replacement",
+			text: "ab",
+			corrections: []Correction{
+				{ErrWord: "b", Pos: 1, CorWord: FlexStrings{"bcd"}},
+				{ErrWord: "bc", Pos: 1, CorWord: FlexStrings{"B"}},
+			},
+			want: "aBd",
+		},
+		{
+			name: "mismatch falls back to search",
+			text: "我门xx他门",
+			corrections: []Correction{
+				{ErrWord: "他门", Pos: 8, CorWord: FlexStrings{"他们"}},
+				{ErrWord: "我门", Pos: 1, CorWord: FlexStrings{"我们"}},
+			},
+			want: "我们xx他们",
+		},
+		{
+			name: "unknown position last",
+			text: "我门xx他门",
+			corrections: []Correction{
+				{ErrWord: "我门", Pos: -1, CorWord: FlexStrings{"我们"}},
+				{ErrWord: "他门", Pos: 8, CorWord: FlexStrings{"他们"}},
+			},
+			want: "我们xx他们",
+		},
+	}
+	p := NewContentProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.ApplyCorrections(tt.text, tt.corrections)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("得到 %q，应为 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTypeFilter 被排除的错误类型不应用到修改后的文章，但计入 filtered；只设置 IncludeTypes 时其他类型都不应用
+func TestTypeFilter(t *testing.T) {
+	const text = "我门和他门"
+	items := []ChecklistItem{
+		{Position: ChecklistPosition{Offset: 0}, Word: "我门", Length: 2, Suggest: FlexStrings{"我们"}, Type: ChecklistErrorType{ID: 1}},
+		{Position: ChecklistPosition{Offset: 3}, Word: "他门", Length: 2, Suggest: FlexStrings{"他们"}, Type: ChecklistErrorType{ID: 2}},
+	}
+	corrections := []Correction{
+		{ErrType: 1, ErrWord: "我门", Pos: 0, CorWord: FlexStrings{"我们"}},
+		{ErrType: 2, ErrWord: "他门", Pos: 9, CorWord: FlexStrings{"他们"}},
+	}
+	tests := []struct {
+		name string
+		opt  Option
+		want string
+	}{
+		{name: "exclude", opt: WithExcludeTypes(2), want: "我们和他门"},
+		{name: "include", opt: WithIncludeTypes(2), want: "我门和他们"},
+		{name: "exclude wins", opt: WithOptions(ProcessorOptions{IncludeTypes: []int{1, 2}, ExcludeTypes: []int{1}}), want: "我门和他们"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewContentProcessor(tt.opt)
+
+			result := &model.ProcessedContent{}
+			if got := p.applyChecklistItems(text, items, result); got != tt.want {
+				t.Errorf("新格式得到 %q，应为 %q", got, tt.want)
+			}
+			if result.FilteredCount != 1 {
+				t.Errorf("新格式应计入 1 个被过滤的修正，得到 %d", result.FilteredCount)
+			}
+
+			result = &model.ProcessedContent{}
+			if got := p.applyCorrectionList(text, corrections, result); got != tt.want {
+				t.Errorf("旧格式得到 %q，应为 %q", got, tt.want)
+			}
+			if result.FilteredCount != 1 {
+				t.Errorf("旧格式应计入 1 个被过滤的修正，得到 %d", result.FilteredCount)
+			}
+		})
+	}
+}
+
+// TestMinErrorLevel 级别低于 MinErrorLevel 的修正不应用，仍保留在明细中并计入 BelowLevelCount
+func TestMinErrorLevel(t *testing.T) {
+	const text = "我门和他门"
+	items := []ChecklistItem{
+		{Position: ChecklistPosition{Offset: 0}, Word: "我门", Length: 2, Suggest: FlexStrings{"我们"}, UmErrorLevel: 1},
+		{Position: ChecklistPosition{Offset: 3}, Word: "他门", Length: 2, Suggest: FlexStrings{"他们"}, UmErrorLevel: 3},
+	}
+	corrections := []Correction{
+		{ErrWord: "我门", Pos: 0, CorWord: FlexStrings{"我们"}, Level: 1},
+		{ErrWord: "他门", Pos: 9, CorWord: FlexStrings{"他们"}, Level: 3},
+	}
+	tests := []struct {
+		level      int
+		want       string
+		belowLevel int
+	}{
+		{level: 0, want: "我们和他们"},
+		{level: 2, want: "我门和他们", belowLevel: 1},
+		{level: 3, want: "我门和他们", belowLevel: 1},
+		{level: 4, want: "我门和他门", belowLevel: 2},
+	}
+	check := func(t *testing.T, format, got string, result *model.ProcessedContent, want string, belowLevel int) {
+		t.Helper()
+		if got != want {
+			t.Errorf("%s得到 %q，应为 %q", format, got, want)
+		}
+		if result.BelowLevelCount != belowLevel {
+			t.Errorf("%s应计入 %d 个级别过低的修正，得到 %d", format, belowLevel, result.BelowLevelCount)
+		}
+		if len(result.Details) != 2 {
+			t.Fatalf("%s未应用的项也应保留在明细中，得到 %d 项", format, len(result.Details))
+		}
+		for _, d := range result.Details {
+			if !d.Applied() && d.SkipReason != model.SkipReasonBelowLevel {
+				t.Errorf("%s%s 未应用的原因为 %q，应为 below_level", format, d.Word, d.SkipReason)
+			}
+		}
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.level), func(t *testing.T) {
+			p := NewContentProcessor(WithMinErrorLevel(tt.level))
+
+			result := &model.ProcessedContent{}
+			check(t, "新格式", p.applyChecklistItems(text, items, result), result, tt.want, tt.belowLevel)
+
+			result = &model.ProcessedContent{}
+			check(t, "旧格式", p.applyCorrectionList(text, corrections, result), result, tt.want, tt.belowLevel)
+		})
+	}
+}
+
+// TestDedupCorrections 开启去重时位置、错误词和建议词都相同的重复项只应用一次，其余记为 duplicate 并计入 DuplicateCount
+func TestDedupCorrections(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{
+			name: "old",
+			raw: `{"data":{"checkresultstr":"我们去公圆玩","checkresultjson":"[` +
+				`{\"errword\":\"公圆\",\"pos\":9,\"corword\":[\"公园\"]},` +
+				`{\"errword\":\"公圆\",\"pos\":9,\"corword\":[\"公园\"]}]"}}`,
+		},
+		{
+			name: "new",
+			raw: `{"data":{"replace_text":"我们去公圆玩","checklist":[` +
+				`{"word":"公圆","position":3,"length":2,"suggest":["公园"]},` +
+				`{"word":"公圆","position":3,"length":2,"suggest":["公园"]}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processJSON(t, NewContentProcessor(WithDedupCorrections()), tt.raw)
+			if result.ModifiedText != "我们去公园玩" {
+				t.Errorf("修改后的文章为 %q", result.ModifiedText)
+			}
+			if result.CorrectionCount != 1 || result.DuplicateCount != 1 {
+				t.Errorf("correction_count=%d duplicate=%d，应都为 1", result.CorrectionCount, result.DuplicateCount)
+			}
+			if len(result.Details) != 2 || result.Details[1].SkipReason != model.SkipReasonDuplicate {
+				t.Errorf("第二项应记为 duplicate: %+v", result.Details)
+			}
+
+			result = processJSON(t, NewContentProcessor(), tt.raw)
+			// 未开启去重时第二项按原有逻辑在已修改的位置上找不到错误词，同样不会重复替换，但不计为重复
+			if result.ModifiedText != "我们去公园玩" || result.DuplicateCount != 0 {
+				t.Errorf("未开启去重时修改后的文章为 %q，duplicate=%d", result.ModifiedText, result.DuplicateCount)
+			}
+			if second := result.Details[1]; second.Applied() || second.SkipReason == model.SkipReasonDuplicate {
+				t.Errorf("未开启去重时第二项不应用也不记为 duplicate: %+v", second)
+			}
+		})
+	}
+}
+
+// TestDetailsDocumentOrder 修正从后往前应用，明细按源位置升序输出，位置相同时保持原始顺序
+func TestDetailsDocumentOrder(t *testing.T) {
+	const text = "aa bb cc"
+	p := NewContentProcessor()
+	wantWords := []string{"aa", "bb", "b", "cc"}
+
+	result := &model.ProcessedContent{}
+	got := p.applyCorrectionList(text, []Correction{
+		{ErrWord: "cc", Pos: 6, CorWord: FlexStrings{"CC"}},
+		{ErrWord: "aa", Pos: 0, CorWord: FlexStrings{"AA"}},
+		{ErrWord: "bb", Pos: 3, CorWord: FlexStrings{"BB"}},
+		{ErrWord: "b", Pos: 3, CorWord: FlexStrings{"B"}},
+	}, result)
+	if got != "AA BB CC" {
+		t.Errorf("旧格式得到 %q", got)
+	}
+	checkDetailWords(t, "旧格式", result.Details, wantWords)
+
+	result = &model.ProcessedContent{}
+	got = p.applyChecklistItems(text, []ChecklistItem{
+		{Position: ChecklistPosition{Offset: 6}, Word: "cc", Length: 2, Suggest: FlexStrings{"CC"}},
+		{Position: ChecklistPosition{Offset: 0}, Word: "aa", Length: 2, Suggest: FlexStrings{"AA"}},
+		{Position: ChecklistPosition{Offset: 3}, Word: "bb", Length: 2, Suggest: FlexStrings{"BB"}},
+		{Position: ChecklistPosition{Offset: 3}, Word: "b", Length: 1, Suggest: FlexStrings{"B"}},
+	}, result)
+	if got != "AA BB CC" {
+		t.Errorf("新格式得到 %q", got)
+	}
+	checkDetailWords(t, "新格式", result.Details, wantWords)
+}
+
+func checkDetailWords(t *testing.T, name string, details []model.ErrorDetail, want []string) {
+	t.Helper()
+	words := make([]string, len(details))
+	for i, d := range details {
+		words[i] = d.Word
+	}
+	if strings.Join(words, ",") != strings.Join(want, ",") {
+		t.Errorf("%s明细顺序为 %v，应为 %v", name, words, want)
+	}
+	// 未应用的项没有清洗后的位置，只比较已应用的项
+	last := -1
+	for _, d := range details {
+		if !d.Applied() {
+			continue
+		}
+		if d.Position < last {
+			t.Errorf("%s明细位置不是升序: %d 在 %d 之后", name, d.Position, last)
+		}
+		last = d.Position
+	}
+}
+
+// processJSON 按迁移的方式解析并处理源 content
+func processJSON(t *testing.T, p *ContentProcessor, raw string) *model.ProcessedContent {
+	t.Helper()
+	content := &model.VerifyContent{}
+	if err := ParseSourceContent(content, []byte(raw), "", nil, p.JSONLimits()); err != nil {
+		t.Fatal(err)
+	}
+	return p.ProcessContent(content)
+}
+
+// TestLevelCounts 两种格式都按错误级别统计明细数量，未应用的项同样计入；没有明细时为 nil
+func TestLevelCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want map[int]int
+	}{
+		{
+			name: "new",
+			raw: `{"data":{"replace_text":"我门和他门","checklist":[
+				{"word":"我门","position":0,"length":2,"suggest":["我们"],"um_error_level":1},
+				{"word":"他门","position":3,"length":2,"suggest":["他们"],"um_error_level":1},
+				{"word":"不存在","position":1,"length":3,"suggest":["x"],"um_error_level":2}]}}`,
+			want: map[int]int{1: 2, 2: 1},
+		},
+		{
+			name: "old",
+			raw:  `{"data":{"checkresultstr":"我门和他门","checkresultjson":"[{\"errword\":\"我门\",\"pos\":0,\"level\":0,\"corword\":[\"我们\"]},{\"errword\":\"他门\",\"pos\":9,\"level\":3,\"corword\":[\"他们\"]}]"}}`,
+			want: map[int]int{0: 1, 3: 1},
+		},
+		{
+			name: "none",
+			raw:  `{"data":{"replace_text":"正文","checklist":[]}}`,
+		},
+	}
+	p := NewContentProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processJSON(t, p, tt.raw)
+			if !reflect.DeepEqual(result.LevelCounts, tt.want) {
+				t.Errorf("level_counts 为 %v，应为 %v", result.LevelCounts, tt.want)
+			}
+		})
+	}
+}
+
+// TestDetailContext 开启 ContextWindow 时已应用的修正带有上下文：新格式优先使用 checklist 中的 context，
+// 否则从清洗后的原文截取前后各 N 个字符，在文本边界处截断；未应用的项和未开启时不填充
+func TestDetailContext(t *testing.T) {
+	newFormat := `{"data":{"replace_text":"<p>今天我门和他门去公园</p>","checklist":[
+		{"word":"我门","position":5,"length":2,"suggest":["我们"],"context":"今天我门和"},
+		{"word":"他门","position":8,"length":2,"suggest":["他们"]},
+		{"word":"不存在","position":1,"length":3,"suggest":["x"]}]}}`
+	oldFormat := `{"data":{"checkresultstr":"我门和他门去公园","checkresultjson":"[{\"errword\":\"我门\",\"pos\":0,\"corword\":[\"我们\"]},{\"errword\":\"公园\",\"pos\":18,\"corword\":[\"公园里\"]}]"}}`
+
+	tests := []struct {
+		name   string
+		raw    string
+		window int
+		want   []string
+	}{
+		{name: "new", raw: newFormat, window: 2, want: []string{"", "今天我门和", "门和他门去公"}},
+		{name: "old", raw: oldFormat, window: 2, want: []string{"我门和他", "门去公园"}},
+		{name: "off", raw: newFormat, want: []string{"", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processJSON(t, NewContentProcessor(WithContextWindow(tt.window)), tt.raw)
+			var got []string
+			for _, d := range result.Details {
+				got = append(got, d.Context)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("上下文为 %q，应为 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestKeepHTML 开启 KeepHTML 时原文和修改后的文章保留原文的 HTML，只移除错误标记；明细中的位置仍基于纯文本
+func TestKeepHTML(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		wantOriginal string
+		wantModified string
+	}{
+		{
+			name:         "new",
+			raw:          `{"data":{"replace_text":"<p>今天<b>我门</b><span class=\"jdt_umold\">去</span>学校</p>","checklist":[{"word":"我门","position":8,"length":2,"suggest":["我们"]}]}}`,
+			wantOriginal: "<p>今天<b>我门</b>去学校</p>",
+			wantModified: "<p>今天<b>我们</b>去学校</p>",
+		},
+		{
+			name:         "old",
+			raw:          `{"data":{"checkresultstr":"<p>今天<span style=\"background-color:yellow;\">我门</span>去<i>学校</i></p>","checkresultjson":"[{\"errword\":\"我门\",\"pos\":48,\"corword\":[\"我们\"]}]"}}`,
+			wantOriginal: "<p>今天我门去<i>学校</i></p>",
+			wantModified: "<p>今天我们去<i>学校</i></p>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processJSON(t, NewContentProcessor(WithKeepHTML()), tt.raw)
+			if result.OriginalText != tt.wantOriginal || result.ModifiedText != tt.wantModified {
+				t.Errorf("原文 %q、修改后 %q，应为 %q、%q", result.OriginalText, result.ModifiedText, tt.wantOriginal, tt.wantModified)
+			}
+			if len(result.Details) != 1 || !result.Details[0].Applied() || result.Details[0].Recovered || result.Details[0].Position != 2 {
+				t.Errorf("明细应为纯文本中位置 2 处已应用的修正: %+v", result.Details)
+			}
+
+			plain := processJSON(t, NewContentProcessor(), tt.raw)
+			if plain.ModifiedText != "今天我们去学校" {
+				t.Errorf("未开启时应清洗 HTML，得到 %q", plain.ModifiedText)
+			}
+		})
+	}
+}
+
+// TestStripHTMLComments 注释连同内容一起移除，内容中可以有 '>' 和换行；IE 条件注释同样移除，未闭合的注释不吞掉之后的全部文本
+func TestStripHTMLComments(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "multi-line with >", in: "<p>前<!-- 注释\n a > b\n --></p>后", want: "前后"},
+		{name: "conditional", in: "前<!--[if IE]><p>只在 IE 中</p><![endif]-->后", want: "前后"},
+		{name: "downlevel revealed", in: "前<![if !IE]>中<![endif]>后", want: "前中后"},
+		{name: "multiple", in: "a<!-- 1 -->b<!-- 2 > -->c", want: "abc"},
+		{name: "entity encoded", in: "前&lt;!-- 注释 &gt; --&gt;后", want: "前后"},
+		{name: "tag in comment", in: "前<!-- <span>不显示</span> -->后", want: "前后"},
+		// 未闭合的注释按普通标签处理，到第一个 '>' 为止
+		{name: "unterminated", in: "前<!-- 未闭合 > 后<b>粗</b>", want: "前 后粗"},
+	}
+	p := NewContentProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.stripHTML(tt.in); got != tt.want {
+				t.Errorf("得到 %q，应为 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestChecklistLength 长度为 0 时在 position 之前插入建议词，同一位置的多个插入按列出的顺序、且在被替换的词之前；
+// 长度为 0 而错误词不为空时按 mismatch 跳过，长度为负时按 negative_length 跳过
+func TestChecklistLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []ChecklistItem
+		want    string
+		reasons []string // 各明细未应用的原因，已应用为空
+	}{
+		{
+			name: "insert",
+			items: []ChecklistItem{
+				{Position: ChecklistPosition{Offset: 2}, Length: 0, Suggest: FlexStrings{"明天"}},
+			},
+			want:    "我们明天去公园",
+			reasons: []string{""},
+		},
+		{
+			name: "inserts before replacement",
+			items: []ChecklistItem{
+				{Position: ChecklistPosition{Offset: 3}, Word: "公园", Length: 2, Suggest: FlexStrings{"学校"}},
+				{Position: ChecklistPosition{Offset: 3}, Length: 0, Suggest: FlexStrings{"新"}},
+				{Position: ChecklistPosition{Offset: 3}, Length: 0, Suggest: FlexStrings{"的"}},
+			},
+			want:    "我们去新的学校",
+			reasons: []string{"", "", ""},
+		},
+		{
+			name: "insert at end",
+			items: []ChecklistItem{
+				{Position: ChecklistPosition{Offset: 5}, Length: 0, Suggest: FlexStrings{"了"}},
+			},
+			want:    "我们去公园了",
+			reasons: []string{""},
+		},
+		{
+			name: "zero length with word",
+			items: []ChecklistItem{
+				{Position: ChecklistPosition{Offset: 3}, Word: "公园", Length: 0, Suggest: FlexStrings{"学校"}},
+			},
+			want:    "我们去公园",
+			reasons: []string{model.SkipReasonMismatch},
+		},
+		{
+			name: "negative",
+			items: []ChecklistItem{
+				{Position: ChecklistPosition{Offset: 3}, Word: "公园", Length: -2, Suggest: FlexStrings{"学校"}},
+				{Position: ChecklistPosition{Offset: 0}, Word: "我们", Length: 2, Suggest: FlexStrings{"你们"}},
+			},
+			want:    "你们去公园",
+			reasons: []string{"", model.SkipReasonNegativeLength},
+		},
+	}
+	p := NewContentProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.ProcessedContent{}
+			if got := p.applyChecklistItems("我们去公园", tt.items, result); got != tt.want {
+				t.Errorf("得到 %q，应为 %q", got, tt.want)
+			}
+			var reasons []string
+			for _, d := range result.Details {
+				reasons = append(reasons, d.SkipReason)
+			}
+			if !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("未应用的原因为 %q，应为 %q", reasons, tt.reasons)
+			}
+		})
+	}
+}
+
+// TestEscapedMarkup 被上游整体转义（外层可能仍有未转义的标签）的旧格式原文先解码一次，
+// 标记清洗和 pos 都基于解码后的文本，结果与未转义的原文一致
+func TestEscapedMarkup(t *testing.T) {
+	const marked = `<p>今天天气很<span style="background-color:yellow;">好【<无建议>,错误】</span>，R&amp;D 部门去公圆玩。</p>`
+	const corrections = `[{"errword":"公圆","pos":111,"corword":["公园"]}]`
+	escaped := html.EscapeString(marked)
+	partial := "<p>" + html.EscapeString(strings.TrimSuffix(strings.TrimPrefix(marked, "<p>"), "</p>")) + "</p>"
+
+	for text, want := range map[string]bool{
+		marked:                        false,
+		escaped:                       true,
+		partial:                       true,
+		"R&amp;D &lt;b&gt;":           false, // 没有转义的 span
+		"<p>&lt;span&gt;</p>":         false, // 转义的 "&lt;" 不多于 '<'
+		"&lt;SPAN&gt;文字&lt;/SPAN&gt;": true,
+	} {
+		if got := escapedMarkup(text); got != want {
+			t.Errorf("escapedMarkup(%q) = %v，应为 %v", text, got, want)
+		}
+	}
+
+	p := NewContentProcessor()
+	process := func(text string) *model.ProcessedContent {
+		raw, err := json.Marshal(map[string]interface{}{"data": map[string]string{"checkresultstr": text, "checkresultjson": corrections}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return processJSON(t, p, string(raw))
+	}
+	want := process(marked)
+	if want.OriginalText != "今天天气很好，R&D 部门去公圆玩。" || want.CorrectionCount != 1 || want.Details[0].Recovered {
+		t.Fatalf("未转义的原文: %q %+v", want.OriginalText, want.Details)
+	}
+	for name, text := range map[string]string{"escaped": escaped, "partial": partial} {
+		got := process(text)
+		if got.OriginalText != want.OriginalText || got.ModifiedText != want.ModifiedText || !reflect.DeepEqual(got.Details, want.Details) {
+			t.Errorf("%s: 原文 %q、修改后 %q、明细 %+v，应与未转义时一致", name, got.OriginalText, got.ModifiedText, got.Details)
+		}
+	}
+}
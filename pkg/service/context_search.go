@@ -0,0 +1,110 @@
+package service
+
+import (
+	"strings"
+
+	"content-verify-log/pkg/model"
+)
+
+// contextMatchThreshold 位置未知的修正项按上下文定位时，候选位置的上下文相似度不低于该值才算匹配
+const contextMatchThreshold = 0.5
+
+// contextHintWindow 比较上下文时错误词前后各取的字符数
+const contextHintWindow = 10
+
+// contextHint 从错误描述中提取错误词前后的文字作为定位线索，各取 contextHintWindow 个字符；
+// 描述中不含错误词，或错误词前后都没有文字时没有线索
+func contextHint(desc, word string) (before, after []rune, ok bool) {
+	i := strings.Index(desc, word)
+	if i < 0 {
+		return nil, nil, false
+	}
+	before, after = []rune(desc[:i]), []rune(desc[i+len(word):])
+	before = before[max(len(before)-contextHintWindow, 0):]
+	after = after[:min(len(after), contextHintWindow)]
+	return before, after, len(before)+len(after) > 0
+}
+
+// contextScore 返回候选位置前后的文字与线索的相似度 [0, 1]：错误词前按末尾、错误词后按开头逐字比较，相同的字符数占线索长度的比例
+func contextScore(hintBefore, hintAfter, before, after []rune) float64 {
+	same := 0
+	for i := 1; i <= min(len(hintBefore), len(before)) && hintBefore[len(hintBefore)-i] == before[len(before)-i]; i++ {
+		same++
+	}
+	for i := 0; i < min(len(hintAfter), len(after)) && hintAfter[i] == after[i]; i++ {
+		same++
+	}
+	return float64(same) / float64(len(hintBefore)+len(hintAfter))
+}
+
+// locateByContext 为位置未知（pos 为负）的修正项在 runes 中定位错误词，返回起始下标；offsets 为 runes 对应的 visibleOffsets。
+// 只考虑清洗后仍可见的出现位置（不在标签内），错误描述中含错误词时以其前后文字为线索：
+// 恰好一处出现且没有线索时采用该处；否则只有相似度不低于 contextMatchThreshold 的候选恰好一个时才采用。
+// 无法定位时返回 -1 及跳过原因：没有出现为 not_found，无法唯一确定为 ambiguous
+func (p *ContentProcessor) locateByContext(runes []rune, offsets []int, corr Correction) (int, string) {
+	word := p.matchRunes([]rune(corr.ErrWord))
+	text := p.matchRunes(runes)
+	plain := []rune(p.stripHTML(string(runes)))
+	if p.opts.NormalizeText {
+		plain = []rune(normalizeText(string(plain)))
+	}
+	plain = p.matchRunes(plain)
+	if len(plain) != offsets[len(runes)] {
+		// 清洗结果与位置映射不一致时无法判断可见性和上下文，不冒险应用
+		return -1, model.SkipReasonAmbiguous
+	}
+
+	var candidates []int
+	for i := 0; i+len(word) <= len(text); i++ {
+		if !equalRunes(text[i:i+len(word)], word) {
+			continue
+		}
+		at := offsets[i]
+		if at+len(word) > len(plain) || !equalRunes(plain[at:at+len(word)], word) {
+			continue
+		}
+		candidates = append(candidates, i)
+	}
+	if len(candidates) == 0 {
+		return -1, model.SkipReasonNotFound
+	}
+
+	hintBefore, hintAfter, ok := contextHint(corr.ErrDesc, corr.ErrWord)
+	if !ok {
+		if len(candidates) == 1 {
+			return candidates[0], ""
+		}
+		return -1, model.SkipReasonAmbiguous
+	}
+	hintBefore, hintAfter = p.matchRunes(hintBefore), p.matchRunes(hintAfter)
+	found := -1
+	for _, i := range candidates {
+		at := offsets[i]
+		before := plain[max(at-len(hintBefore), 0):at]
+		after := plain[at+len(word) : min(at+len(word)+len(hintAfter), len(plain))]
+		if contextScore(hintBefore, hintAfter, before, after) < contextMatchThreshold {
+			continue
+		}
+		if found >= 0 {
+			return -1, model.SkipReasonAmbiguous
+		}
+		found = i
+	}
+	if found < 0 {
+		return -1, model.SkipReasonAmbiguous
+	}
+	return found, ""
+}
+
+// equalRunes 判断两个字符切片是否相同
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
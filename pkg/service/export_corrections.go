@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"content-verify-log/pkg/model"
+
+	"go.uber.org/zap"
+)
+
+// correctionColumns 修正明细 CSV 的表头
+var correctionColumns = []string{"pid", "position", "error_word", "correct_word", "type_name", "context", "explanation"}
+
+// ExportCorrections 将表中已应用的修正逐条写为 CSV（每个修正一行），按 pid、id 排序流式读取，返回写入的修正数
+// 数据来自目标表的 details 列，context 只在迁移时开启 contextWindow 或新格式提供了上下文时才有值；
+// explanation 为旧格式的 errdesc 或新格式的 explanation，超过 ExplanationMaxLength 个字符时截断
+func (s *ExportService) ExportCorrections(ctx context.Context, table string, w io.Writer) (int64, error) {
+	table, duckDB, err := s.prepare(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.opts.ExcelBOM {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return 0, err
+		}
+	}
+	cw := csv.NewWriter(w)
+	if s.opts.CSVDelimiter != "" {
+		// 配置校验保证分隔符为单个字符
+		cw.Comma, _ = utf8.DecodeRuneInString(s.opts.CSVDelimiter)
+	}
+	if err := cw.Write(correctionColumns); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf("SELECT id, pid, details FROM %s WHERE correction_count > 0 ORDER BY pid, id", table)
+	zap.S().Debugf("导出修正 SQL: %s", query)
+	rows, err := duckDB.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("查询表 %s 失败: %v", table, err)
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		var id, pid, details string
+		if err := rows.Scan(&id, &pid, &details); err != nil {
+			return count, fmt.Errorf("读取记录失败: %v", err)
+		}
+		var parsed []model.ErrorDetail
+		if err := json.Unmarshal([]byte(details), &parsed); err != nil {
+			zap.S().Warnf("记录 ID %s 的错误明细不是合法 JSON，已跳过: %v", id, err)
+			continue
+		}
+		for i := range parsed {
+			detail := &parsed[i]
+			if !detail.Applied() || detail.AppliedIndex >= len(detail.Suggestions) {
+				continue
+			}
+			record := []string{
+				pid,
+				strconv.Itoa(detail.Position),
+				detail.Word,
+				detail.Suggestions[detail.AppliedIndex],
+				detail.TypeName,
+				detail.Context,
+				clipExplanation(detail.Explanation, s.opts.ExplanationMaxLength),
+			}
+			if err := cw.Write(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("读取记录失败: %v", err)
+	}
+	cw.Flush()
+	return count, cw.Error()
+}
+
+// clipExplanation 将说明截断为最多 limit 个字符，截断处以 … 标注；limit 为 0 时不截断
+func clipExplanation(s string, limit int) string {
+	if limit <= 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit]) + "…"
+}
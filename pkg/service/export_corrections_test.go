@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// TestExportCorrectionsExplanation 旧格式的 errdesc 和新格式的 explanation 都写入 explanation 列，
+// 超过 ExplanationMaxLength 个字符时截断并以 … 标注
+func TestExportCorrectionsExplanation(t *testing.T) {
+	ctx := context.Background()
+	db := openMemoryDB(t)
+	p := NewContentProcessor()
+	batch := []*model.ProcessedContent{
+		processJSON(t, p, `{"data":{"checkresultstr":"我们去公圆玩","checkresultjson":"[{\"errword\":\"公圆\",\"pos\":9,\"corword\":[\"公园\"],\"errdesc\":\"别字：圆应为园\"}]"}}`),
+		processJSON(t, p, `{"data":{"replace_text":"我门走","checklist":[{"word":"我门","position":0,"length":2,"suggest":["我们"],"explanation":"们字误写"}]}}`),
+	}
+	batch[0].ID, batch[0].PID = "1", "old"
+	batch[1].ID, batch[1].PID = "2", "new"
+	dups, _ := newDuplicateIDs(DuplicateIDSkip)
+	sink, err := openTableSink(ctx, db, "out", IngestInsert, "", dups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures := sink.write(ctx, batch); len(failures) > 0 {
+		t.Fatalf("写入失败: %v", failures)
+	}
+	if err := sink.commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{limit: 0, want: []string{"们字误写", "别字：圆应为园"}},
+		{limit: 2, want: []string{"们字…", "别字…"}},
+		{limit: 4, want: []string{"们字误写", "别字：圆…"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		n, err := NewExportServiceWithDB(ExportOptions{ExplanationMaxLength: tt.limit}, db).ExportCorrections(ctx, "out", &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 2 || len(records) != 3 || !reflect.DeepEqual(records[0], correctionColumns) {
+			t.Fatalf("limit %d: 应导出表头和 2 条修正，得到 %d 条: %q", tt.limit, n, records)
+		}
+		// 按 pid 排序，new 在 old 之前
+		var got []string
+		for _, record := range records[1:] {
+			got = append(got, record[len(record)-1])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("limit %d: explanation 列为 %q，应为 %q", tt.limit, got, tt.want)
+		}
+	}
+}
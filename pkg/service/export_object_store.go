@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"content-verify-log/pkg/util"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.uber.org/zap"
+)
+
+// ObjectStoreOptions 对象存储选项，凭证不在选项中，从环境变量、~/.aws/credentials 或实例角色读取
+type ObjectStoreOptions struct {
+	Endpoint string // 服务地址，为空时 s3 使用 AWS，oss 按 Region 推导；以 http:// 开头时不使用 TLS
+	Region   string // 区域
+	Bucket   string // 导出地址未指定 bucket 时使用
+}
+
+// ExportObject 上传到对象存储的一个对象
+type ExportObject struct {
+	Key  string // 对象键
+	Size int64  // 字节数
+}
+
+// exportToObjectStore 先导出到本地临时目录，再逐个文件上传到对象存储（大文件分片流式上传）
+// 上传失败时客户端中止未完成的分片上传，本次已上传的对象也一并删除，不留下不完整的导出结果；
+// 拆分模式下旧对象在新的导出结果上传完成后才删除
+func (s *ExportService) exportToObjectStore(ctx context.Context, table string) (*ExportResult, error) {
+	dest, err := util.ParseObjectURL(s.opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if dest.Bucket == "" {
+		dest.Bucket = s.opts.ObjectStore.Bucket
+	}
+	if dest.Bucket == "" {
+		return nil, fmt.Errorf("导出地址 %s 未指定 bucket", s.opts.Dir)
+	}
+	table, _, err = s.prepare(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newObjectStoreClient(dest.Scheme, s.opts.ObjectStore)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "cvl-export-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	result, err := s.exportLocal(ctx, table, tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	// 与导出到本地一致，覆盖上一次的导出结果。拆分模式下文件数可能变少，需要删除多余的旧对象：
+	// 先上传到临时前缀，全部成功后再复制到目标键并删除旧对象，上传失败时上一次的导出结果保持不变
+	uploadPrefix := dest.Prefix
+	var oldKeys []string
+	if s.opts.RolloverBytes > 0 {
+		if oldKeys, err = listObjects(ctx, client, dest.Bucket, path.Join(dest.Prefix, table)+"/"); err != nil {
+			return nil, fmt.Errorf("列出旧的导出结果失败: %v", err)
+		}
+		uploadPrefix = path.Join(dest.Prefix, stagingPrefix(table))
+	}
+
+	files := result.Files
+	result.Files = make([]string, 0, len(files))
+	var staged []ExportObject
+	for _, file := range files {
+		rel, err := filepath.Rel(tmp, file)
+		if err != nil {
+			return nil, err
+		}
+		key := path.Join(uploadPrefix, filepath.ToSlash(rel))
+		info, err := client.FPutObject(ctx, dest.Bucket, key, file, minio.PutObjectOptions{})
+		if err != nil {
+			// 客户端用 ctx 中止未完成的分片上传，已取消时中止请求发不出去，这里不受取消影响再清理一次
+			if abortErr := client.RemoveIncompleteUpload(context.WithoutCancel(ctx), dest.Bucket, key); abortErr != nil {
+				zap.S().Warnf("中止 %s 的分片上传失败: %v", key, abortErr)
+			}
+			deleteUploaded(ctx, client, dest.Bucket, staged)
+			return nil, fmt.Errorf("上传 %s 失败: %v", util.ObjectURL{Scheme: dest.Scheme, Bucket: dest.Bucket, Prefix: key}, err)
+		}
+		staged = append(staged, ExportObject{Key: key, Size: info.Size})
+		final := path.Join(dest.Prefix, filepath.ToSlash(rel))
+		result.Objects = append(result.Objects, ExportObject{Key: final, Size: info.Size})
+		result.Files = append(result.Files, util.ObjectURL{Scheme: dest.Scheme, Bucket: dest.Bucket, Prefix: final}.String())
+	}
+
+	if s.opts.RolloverBytes > 0 {
+		if err := promoteStaged(ctx, client, dest.Bucket, staged, result.Objects); err != nil {
+			return nil, err
+		}
+		// 新的导出结果已完整，删除旧对象失败只留下多余的文件，记录日志即可
+		for _, key := range staleKeys(oldKeys, result.Objects) {
+			if err := client.RemoveObject(ctx, dest.Bucket, key, minio.RemoveObjectOptions{}); err != nil {
+				zap.S().Warnf("删除旧的导出对象 %s 失败: %v", key, err)
+			}
+		}
+	}
+
+	if s.opts.Manifest {
+		if err := s.uploadManifest(ctx, client, dest, table, result); err != nil {
+			deleteUploaded(ctx, client, dest.Bucket, result.Objects)
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// uploadManifest 将清单上传到导出前缀下的 manifest.json，不计入 result.Objects
+func (s *ExportService) uploadManifest(ctx context.Context, client *minio.Client, dest *util.ObjectURL, table string, result *ExportResult) error {
+	m, err := s.manifest(ctx, table, result)
+	if err != nil {
+		return err
+	}
+	b, err := marshalManifest(m)
+	if err != nil {
+		return err
+	}
+	key := path.Join(dest.Prefix, ManifestFileName)
+	url := util.ObjectURL{Scheme: dest.Scheme, Bucket: dest.Bucket, Prefix: key}
+	if _, err := client.PutObject(ctx, dest.Bucket, key, bytes.NewReader(b), int64(len(b)), minio.PutObjectOptions{ContentType: "application/json"}); err != nil {
+		return fmt.Errorf("上传清单 %s 失败: %v", url, err)
+	}
+	result.Manifest = url.String()
+	return nil
+}
+
+// newObjectStoreClient 创建 S3 兼容的客户端，oss 同样通过 S3 兼容接口访问
+func newObjectStoreClient(scheme string, opts ObjectStoreOptions) (*minio.Client, error) {
+	endpoint, secure := opts.Endpoint, true
+	if rest, ok := strings.CutPrefix(endpoint, "http://"); ok {
+		endpoint, secure = rest, false
+	} else {
+		endpoint = strings.TrimPrefix(endpoint, "https://")
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	// 凭证按顺序查找：环境变量、~/.aws/credentials、实例角色
+	providers := []credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.EnvMinio{},
+		&credentials.FileAWSCredentials{},
+		&credentials.IAM{},
+	}
+	lookup := minio.BucketLookupAuto
+	switch scheme {
+	case util.ObjectSchemeOSS:
+		if endpoint == "" {
+			endpoint = "oss-" + strings.TrimPrefix(opts.Region, "oss-") + ".aliyuncs.com"
+		}
+		// OSS 只支持虚拟主机风格的访问
+		lookup = minio.BucketLookupDNS
+		providers = append([]credentials.Provider{ossEnvCredentials()}, providers...)
+	default:
+		if endpoint == "" {
+			endpoint = "s3.amazonaws.com"
+		}
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewChainCredentials(providers),
+		Secure:       secure,
+		Region:       opts.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建对象存储客户端失败: %v", err)
+	}
+	return client, nil
+}
+
+// ossEnvCredentials 读取阿里云的标准环境变量，未设置时为匿名，由凭证链继续查找
+func ossEnvCredentials() credentials.Provider {
+	return &credentials.Static{Value: credentials.Value{
+		AccessKeyID:     firstEnv("ALIBABA_CLOUD_ACCESS_KEY_ID", "OSS_ACCESS_KEY_ID"),
+		SecretAccessKey: firstEnv("ALIBABA_CLOUD_ACCESS_KEY_SECRET", "OSS_ACCESS_KEY_SECRET"),
+		SessionToken:    firstEnv("ALIBABA_CLOUD_SECURITY_TOKEN", "OSS_SESSION_TOKEN"),
+		SignerType:      credentials.SignatureV4,
+	}}
+}
+
+// firstEnv 返回第一个非空的环境变量
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// stagingPrefix 拆分模式上传用的临时前缀，与表名目录同级，每次导出不同
+func stagingPrefix(table string) string {
+	return fmt.Sprintf(".%s.uploading-%d", table, time.Now().UnixNano())
+}
+
+// promoteStaged 将临时前缀下的对象在服务端复制到目标键，完成后删除临时对象；
+// 复制失败时删除临时对象和已复制的对象
+func promoteStaged(ctx context.Context, client *minio.Client, bucket string, staged, final []ExportObject) error {
+	defer deleteUploaded(ctx, client, bucket, staged)
+	for i, object := range staged {
+		dst := minio.CopyDestOptions{Bucket: bucket, Object: final[i].Key}
+		if _, err := client.CopyObject(ctx, dst, minio.CopySrcOptions{Bucket: bucket, Object: object.Key}); err != nil {
+			deleteUploaded(ctx, client, bucket, final[:i])
+			return fmt.Errorf("复制 %s 到 %s 失败: %v", object.Key, final[i].Key, err)
+		}
+	}
+	return nil
+}
+
+// listObjects 返回 prefix 下全部对象的键
+func listObjects(ctx context.Context, client *minio.Client, bucket, prefix string) ([]string, error) {
+	var keys []string
+	for object := range client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		keys = append(keys, object.Key)
+	}
+	return keys, nil
+}
+
+// staleKeys 返回旧对象中不在本次导出结果里的键，这些对象在新的导出结果完整后删除
+func staleKeys(old []string, current []ExportObject) []string {
+	keep := make(map[string]bool, len(current))
+	for _, object := range current {
+		keep[object.Key] = true
+	}
+	var stale []string
+	for _, key := range old {
+		if !keep[key] {
+			stale = append(stale, key)
+		}
+	}
+	return stale
+}
+
+// deleteUploaded 删除本次已上传的对象，上传失败或已取消时调用，删除失败只记录日志
+func deleteUploaded(ctx context.Context, client *minio.Client, bucket string, uploaded []ExportObject) {
+	ctx = context.WithoutCancel(ctx)
+	for _, object := range uploaded {
+		if err := client.RemoveObject(ctx, bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			zap.S().Warnf("删除已上传的对象 %s 失败: %v", object.Key, err)
+		}
+	}
+}
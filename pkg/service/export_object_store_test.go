@@ -0,0 +1,27 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestStaleKeys 只删除旧对象中不在本次导出结果里的键，同名的对象已被新结果覆盖
+func TestStaleKeys(t *testing.T) {
+	old := []string{"exports/t/data_0.parquet", "exports/t/data_1.parquet", "exports/t/data_2.parquet"}
+	current := []ExportObject{{Key: "exports/t/data_0.parquet"}, {Key: "exports/t/data_1.parquet"}}
+	if got := staleKeys(old, current); !reflect.DeepEqual(got, []string{"exports/t/data_2.parquet"}) {
+		t.Errorf("staleKeys 为 %v", got)
+	}
+	if got := staleKeys(nil, current); len(got) != 0 {
+		t.Errorf("没有旧对象时 staleKeys 为 %v", got)
+	}
+}
+
+// TestStagingPrefix 临时前缀不在表名目录下，列出旧对象时不会包含本次上传的对象
+func TestStagingPrefix(t *testing.T) {
+	prefix := stagingPrefix("t")
+	if strings.HasPrefix(prefix+"/", "t/") || !strings.HasPrefix(prefix, ".t.uploading-") {
+		t.Errorf("临时前缀为 %s", prefix)
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// resumeChunkRows 可续传导出每次 COPY 的行数，每导出一块记录一次进度
+const resumeChunkRows = 100000
+
+// exportProgress 可续传导出的进度，保存在输出文件旁的 .progress 文件中
+type exportProgress struct {
+	Table  string `json:"table"`
+	Format string `json:"format"`
+	Gzip   bool   `json:"gzip"`
+	LastID string `json:"last_id"` // 已导出的最后一个 id，按 id 排序导出
+	Rows   int64  `json:"rows"`    // 已导出的行数
+	Bytes  int64  `json:"bytes"`   // 已完整写入的字节数，续传时先把输出文件截断到该长度
+}
+
+// matches 判断进度是否属于同一次导出
+func (p *exportProgress) matches(table, format string, gzipped bool) bool {
+	return p.Table == table && p.Format == format && p.Gzip == gzipped
+}
+
+// progressPath 返回输出文件对应的进度文件
+func progressPath(target string) string {
+	return target + ".progress"
+}
+
+// exportResumable 按 id 顺序分块导出并追加到输出文件，每块完成后记录进度；
+// 存在与本次导出一致的进度文件时从上次的 id 之后继续，否则从头导出。全部完成后删除进度文件
+// 只支持 csv/jsonl 单文件导出：parquet 不能追加，拆分模式下文件边界与分块不一致
+func (s *ExportService) exportResumable(ctx context.Context, table, dir string) (*ExportResult, error) {
+	if s.opts.Format == exportFormatParquet || s.opts.RolloverBytes > 0 {
+		return nil, fmt.Errorf("续传导出只支持不拆分的 csv/jsonl 格式")
+	}
+	table, duckDB, err := s.prepare(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	target := s.targetPath(dir, table)
+	sidecar := progressPath(target)
+
+	progress, err := s.resumeProgress(table, target, sidecar)
+	if err != nil {
+		return nil, err
+	}
+	chunk := target + ".chunk"
+	defer os.Remove(chunk)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		lastID, count, err := nextChunk(ctx, duckDB, table, progress)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			break
+		}
+
+		query := fmt.Sprintf("COPY (%s) TO %s (%s)", chunkSelect(table, progress.LastID, lastID), quoteLiteral(chunk), strings.Join(s.chunkCopyOptions(progress.Rows == 0), ", "))
+		zap.S().Debugf("导出 SQL: %s", query)
+		var rows int64
+		if err := duckDB.QueryRowContext(ctx, query).Scan(&rows); err != nil {
+			return nil, fmt.Errorf("导出表 %s 失败: %v", table, err)
+		}
+		size, err := appendFile(target, chunk)
+		if err != nil {
+			return nil, fmt.Errorf("追加到 %s 失败: %v", target, err)
+		}
+
+		progress.LastID = lastID
+		progress.Rows += rows
+		progress.Bytes = size
+		if err := saveExportProgress(sidecar, progress); err != nil {
+			return nil, fmt.Errorf("记录导出进度失败: %v", err)
+		}
+		zap.S().Infof("已导出 %d 行（至 id %s）", progress.Rows, progress.LastID)
+	}
+
+	if err := os.Remove(sidecar); err != nil && !errors.Is(err, os.ErrNotExist) {
+		zap.S().Warnf("删除进度文件 %s 失败: %v", sidecar, err)
+	}
+	return &ExportResult{Files: []string{target}, Rows: progress.Rows}, nil
+}
+
+// resumeProgress 读取可续传的进度并把输出文件截断到已完整写入的位置；
+// 没有进度、进度属于其他导出或输出文件比记录的短时从头开始，创建只含 BOM（如需要）的输出文件
+func (s *ExportService) resumeProgress(table, target, sidecar string) (*exportProgress, error) {
+	progress, err := loadExportProgress(sidecar)
+	if err != nil {
+		zap.S().Warnf("进度文件 %s 无法读取，从头导出: %v", sidecar, err)
+	}
+	if progress != nil && progress.matches(table, s.opts.Format, s.opts.Gzip) {
+		info, err := os.Stat(target)
+		if err == nil && info.Size() >= progress.Bytes {
+			// 截掉中断时写了一半的块
+			if err := os.Truncate(target, progress.Bytes); err != nil {
+				return nil, fmt.Errorf("截断 %s 失败: %v", target, err)
+			}
+			zap.S().Infof("从上次中断处继续导出: 已导出 %d 行（至 id %s）", progress.Rows, progress.LastID)
+			return progress, nil
+		}
+		zap.S().Warnf("输出文件 %s 与进度文件不一致，从头导出", target)
+	}
+
+	progress = &exportProgress{Table: table, Format: s.opts.Format, Gzip: s.opts.Gzip}
+	var prefix []byte
+	if s.opts.Format == exportFormatCSV && s.opts.ExcelBOM {
+		if prefix, err = bomPrefix(s.opts.Gzip); err != nil {
+			return nil, fmt.Errorf("写入 BOM 失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(target, prefix, 0644); err != nil {
+		return nil, fmt.Errorf("创建 %s 失败: %v", target, err)
+	}
+	progress.Bytes = int64(len(prefix))
+	return progress, nil
+}
+
+// nextChunk 返回下一块的最后一个 id 和行数，先确定边界再按边界导出，导出期间新写入的行不影响本块
+func nextChunk(ctx context.Context, duckDB *sql.DB, table string, progress *exportProgress) (string, int64, error) {
+	query := fmt.Sprintf("SELECT max(id), count(*) FROM (SELECT id FROM %s%s ORDER BY id LIMIT %d)", table, afterID(progress.Rows, progress.LastID), resumeChunkRows)
+	var lastID sql.NullString
+	var count int64
+	if err := duckDB.QueryRowContext(ctx, query).Scan(&lastID, &count); err != nil {
+		return "", 0, fmt.Errorf("查询表 %s 失败: %v", table, err)
+	}
+	return lastID.String, count, nil
+}
+
+// afterID 返回只选取 lastID 之后的行的条件，尚未导出任何行时为空
+func afterID(exported int64, lastID string) string {
+	if exported == 0 {
+		return ""
+	}
+	return " WHERE id > " + quoteLiteral(lastID)
+}
+
+// chunkSelect 返回导出 (from, to] 区间的查询，from 为空表示从头开始
+func chunkSelect(table, from, to string) string {
+	where := "id <= " + quoteLiteral(to)
+	if from != "" {
+		where = "id > " + quoteLiteral(from) + " AND " + where
+	}
+	return fmt.Sprintf("SELECT * FROM %s WHERE %s ORDER BY id", table, where)
+}
+
+// chunkCopyOptions 返回分块 COPY 的选项，csv 只在第一块写入表头
+func (s *ExportService) chunkCopyOptions(first bool) []string {
+	options := s.copyOptions()
+	if !first {
+		for i, option := range options {
+			if option == "HEADER" {
+				options[i] = "HEADER false"
+			}
+		}
+	}
+	return options
+}
+
+// appendFile 将 src 的内容追加到 dst 并刷盘，返回 dst 追加后的长度；gzip 成员直接拼接，解压结果等价于整体压缩
+func appendFile(dst, src string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return 0, err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return 0, err
+	}
+	info, err := out.Stat()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// loadExportProgress 读取进度文件，不存在时返回 nil
+func loadExportProgress(path string) (*exportProgress, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var progress exportProgress
+	if err := json.Unmarshal(b, &progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// saveExportProgress 先写临时文件再重命名，中断时不会留下不完整的进度文件
+func saveExportProgress(path string, progress *exportProgress) error {
+	b, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// bomPrefix 返回写在文件开头的 UTF-8 BOM，gzip 文件为只含 BOM 的 gzip 成员
+func bomPrefix(gzipped bool) ([]byte, error) {
+	if !gzipped {
+		return utf8BOM, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(utf8BOM); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/util"
+
+	"go.uber.org/zap"
+)
+
+// 导出格式，与 config.OutputFormat* 一致
+const (
+	exportFormatParquet = "parquet"
+	exportFormatCSV     = "csv"
+	exportFormatJSONL   = "jsonl"
+)
+
+// utf8BOM Excel 依赖 BOM 识别 UTF-8 编码的 csv
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	Dir                string // 导出目录，可为 s3://bucket/prefix 或 oss://bucket/prefix
+	Format             string // parquet/csv/jsonl
+	RolloverBytes      int64  // 单个文件的大小上限，0 表示不拆分
+	Gzip               bool   // csv/jsonl 是否 gzip 压缩
+	CSVDelimiter       string // csv 分隔符
+	ExcelBOM           bool   // csv 写入 UTF-8 BOM
+	ParquetCompression string // parquet 压缩算法
+
+	ObjectStore ObjectStoreOptions // Dir 为对象存储地址时使用
+
+	Resume bool // 分块导出并记录进度，中断后再次导出时从上次的 id 之后继续，只支持本地不拆分的 csv/jsonl
+
+	Manifest bool // 导出完成后在导出目录写入 manifest.json
+
+	ExplanationMaxLength int // export-corrections 中 explanation 列的最大字符数，0 表示不截断
+}
+
+// ExportResult 导出结果
+type ExportResult struct {
+	Files    []string       // 生成的文件，导出到对象存储时为对象地址
+	Rows     int64          // 导出的行数
+	Objects  []ExportObject // 上传的对象，导出到本地时为空
+	Manifest string         // 清单的路径或对象地址，未生成时为空
+}
+
+type ExportService struct {
+	opts ExportOptions
+
+	// 显式注入的数据库，为 nil 时使用全局 DuckDB 连接
+	duckDB *sql.DB
+}
+
+func NewExportService(opts ExportOptions) *ExportService {
+	return &ExportService{opts: opts}
+}
+
+// NewExportServiceWithDB 使用指定的数据库创建导出服务
+func NewExportServiceWithDB(opts ExportOptions, duckDB *sql.DB) *ExportService {
+	s := NewExportService(opts)
+	s.duckDB = duckDB
+	return s
+}
+
+func (s *ExportService) database(ctx context.Context) *sql.DB {
+	if s.duckDB != nil {
+		return s.duckDB
+	}
+	return db.GetDuckDBWithContext(ctx)
+}
+
+// Export 使用 DuckDB COPY 导出整张表；开启拆分时输出到以表名命名的目录，否则输出单个文件
+// 导出目录为对象存储地址时先导出到本地临时目录再上传；开启 Resume 时分块导出并可从中断处继续
+func (s *ExportService) Export(ctx context.Context, table string) (*ExportResult, error) {
+	if util.IsObjectURL(s.opts.Dir) {
+		return s.exportToObjectStore(ctx, table)
+	}
+	var result *ExportResult
+	var err error
+	if s.opts.Resume {
+		result, err = s.exportResumable(ctx, table, s.opts.Dir)
+	} else {
+		result, err = s.exportLocal(ctx, table, s.opts.Dir)
+	}
+	if err != nil || !s.opts.Manifest {
+		return result, err
+	}
+	m, err := s.manifest(ctx, table, result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Manifest, err = WriteManifest(s.opts.Dir, m); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// manifest 生成导出结果的清单，列定义读取自导出的表
+func (s *ExportService) manifest(ctx context.Context, table string, result *ExportResult) (*Manifest, error) {
+	if table == "" {
+		table = defaultTargetTable
+	}
+	columns, err := tableManifestColumns(ctx, s.database(ctx), table)
+	if err != nil {
+		return nil, err
+	}
+	m := newManifest(ManifestKindExport)
+	m.Columns = columns
+	m.Outputs = []ManifestOutput{{Table: table, Format: s.opts.Format, Files: result.Files, Rows: result.Rows}}
+	return m, nil
+}
+
+// exportLocal 导出到本地目录 dir
+func (s *ExportService) exportLocal(ctx context.Context, table, dir string) (*ExportResult, error) {
+	table, duckDB, err := s.prepare(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	target := s.targetPath(dir, table)
+	// 覆盖上一次的导出结果，拆分模式下 DuckDB 要求目标目录为空
+	if err := os.RemoveAll(target); err != nil {
+		return nil, fmt.Errorf("清理旧的导出结果失败: %v", err)
+	}
+
+	query := fmt.Sprintf("COPY (%s) TO %s (%s)", exportSelect(table), quoteLiteral(target), strings.Join(s.copyOptions(), ", "))
+	zap.S().Debugf("导出 SQL: %s", query)
+
+	result := &ExportResult{}
+	if err := duckDB.QueryRowContext(ctx, query).Scan(&result.Rows); err != nil {
+		return nil, fmt.Errorf("导出表 %s 失败: %v", table, err)
+	}
+
+	files, err := outputFiles(target)
+	if err != nil {
+		return nil, err
+	}
+	result.Files = files
+
+	if s.opts.Format == exportFormatCSV && s.opts.ExcelBOM {
+		for _, file := range files {
+			if err := prependBOM(file, s.opts.Gzip); err != nil {
+				return nil, fmt.Errorf("写入 BOM 失败: %v", err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Count 返回 Export 将导出的行数，不写入文件
+func (s *ExportService) Count(ctx context.Context, table string) (int64, error) {
+	table, duckDB, err := s.prepare(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf("SELECT COUNT(*) FROM (%s)", exportSelect(table))
+	zap.S().Debugf("统计 SQL: %s", query)
+
+	var count int64
+	if err := duckDB.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("统计表 %s 的行数失败: %v", table, err)
+	}
+	return count, nil
+}
+
+// prepare 校验表名（为空时使用默认表）并返回连接
+func (s *ExportService) prepare(ctx context.Context, table string) (string, *sql.DB, error) {
+	if table == "" {
+		table = defaultTargetTable
+	}
+	if _, err := util.SanitizeIdentifier(table); err != nil {
+		return "", nil, fmt.Errorf("导出表名不合法: %v", err)
+	}
+	duckDB := s.database(ctx)
+	if duckDB == nil {
+		return "", nil, fmt.Errorf("DuckDB 连接未初始化")
+	}
+	if err := requireTable(ctx, duckDB, table); err != nil {
+		return "", nil, err
+	}
+	return table, duckDB, nil
+}
+
+// exportSelect 返回导出的查询，Export 和 Count 共用，保证统计与导出的范围一致
+func exportSelect(table string) string {
+	return fmt.Sprintf("SELECT * FROM %s ORDER BY id", table)
+}
+
+// targetPath 返回 COPY 的目标路径
+func (s *ExportService) targetPath(dir, table string) string {
+	if s.opts.RolloverBytes > 0 {
+		return filepath.Join(dir, table)
+	}
+	name := table + "." + s.opts.Format
+	if s.opts.Gzip {
+		name += ".gz"
+	}
+	return filepath.Join(dir, name)
+}
+
+// copyOptions 构造 COPY 的选项
+func (s *ExportService) copyOptions() []string {
+	var options []string
+	switch s.opts.Format {
+	case exportFormatParquet:
+		options = append(options, "FORMAT parquet")
+		if s.opts.ParquetCompression != "" {
+			options = append(options, "COMPRESSION "+s.opts.ParquetCompression)
+		}
+	case exportFormatCSV:
+		options = append(options, "FORMAT csv", "HEADER")
+		if s.opts.CSVDelimiter != "" {
+			options = append(options, "DELIMITER "+quoteLiteral(s.opts.CSVDelimiter))
+		}
+	case exportFormatJSONL:
+		// DuckDB 的 json 格式默认每行一个对象
+		options = append(options, "FORMAT json")
+	}
+	if s.opts.Gzip && s.opts.Format != exportFormatParquet {
+		options = append(options, "COMPRESSION gzip")
+	}
+	if s.opts.RolloverBytes > 0 {
+		options = append(options, fmt.Sprintf("FILE_SIZE_BYTES %d", s.opts.RolloverBytes))
+	}
+	return options
+}
+
+// outputFiles 返回导出生成的文件，目标为目录时列出其中的文件
+func outputFiles(target string) ([]string, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出结果失败: %v", err)
+	}
+	if !info.IsDir() {
+		return []string{target}, nil
+	}
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出目录失败: %v", err)
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, filepath.Join(target, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// prependBOM 在文件开头写入 UTF-8 BOM；gzip 文件在前面追加一个只含 BOM 的 gzip 成员，解压结果等价
+func prependBOM(path string, gzipped bool) error {
+	prefix, err := bomPrefix(gzipped)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(prefix); err != nil {
+		dst.Close()
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// quoteLiteral 将字符串转换为 SQL 字符串字面量
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
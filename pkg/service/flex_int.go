@@ -0,0 +1,53 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FlexInt 兼容数字和字符串编码数字（如 "123"）的整数
+// 部分导出数据中的 pos/position/length 是字符串，直接反序列化为 int 会导致整个数组解析失败
+type FlexInt int
+
+// UnmarshalJSON 接受 JSON 数字、字符串编码的数字和 null（按 0 处理）
+func (f *FlexInt) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		*f = 0
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		n, err := parseFlexInt(s)
+		if err != nil {
+			return err
+		}
+		*f = FlexInt(n)
+		return nil
+	}
+	n, err := parseFlexInt(string(b))
+	if err != nil {
+		return err
+	}
+	*f = FlexInt(n)
+	return nil
+}
+
+// parseFlexInt 解析整数，允许小数部分为 0 的写法（如 12.0）
+func parseFlexInt(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != float64(int(f)) {
+		return 0, fmt.Errorf("%q 不是合法的整数", s)
+	}
+	return int(f), nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestFlexIntUnmarshal 接受数字、字符串编码的数字和 null，小数部分不为 0 或不是数字时报错
+func TestFlexIntUnmarshal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FlexInt
+		wantErr bool
+	}{
+		{in: `12`, want: 12},
+		{in: `"12"`, want: 12},
+		{in: `" 12 "`, want: 12},
+		{in: `"-3"`, want: -3},
+		{in: `12.0`, want: 12},
+		{in: `"12.0"`, want: 12},
+		{in: `null`, want: 0},
+		{in: `12.5`, wantErr: true},
+		{in: `"12.5"`, wantErr: true},
+		{in: `"abc"`, wantErr: true},
+		{in: `""`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got FlexInt
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.in, err)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%s: 得到 %d，应为 %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestStringEncodedPositions 旧格式的 pos 和新格式的 position/length 为字符串时整个数组照常解析并应用
+func TestStringEncodedPositions(t *testing.T) {
+	p := NewContentProcessor()
+
+	got, err := p.applyCorrections("我门和他门", `[{"errword":"我门","pos":"0","corword":["我们"]},{"errword":"他门","pos":9,"corword":"他们"}]`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "我们和他们" {
+		t.Errorf("旧格式得到 %q", got)
+	}
+
+	got, err = p.applyChecklistFixes("我门和他门", `[{"word":"我门","position":"0","length":"2","suggest":["我们"]},{"word":"他门","position":3,"length":2,"suggest":["他们"]}]`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "我们和他们" {
+		t.Errorf("新格式得到 %q", got)
+	}
+
+	if _, err := p.applyCorrections("我门", `[{"errword":"我门","pos":"x","corword":["我们"]}]`, nil); err == nil {
+		t.Error("pos 不是数字时应返回错误")
+	}
+}
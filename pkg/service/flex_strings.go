@@ -0,0 +1,37 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// FlexStrings 兼容字符串数组和单个字符串（如 "corword":"正词"）的字符串列表
+// 部分导出数据中的 corword/suggest 是单个字符串，直接反序列化为 []string 会导致整个数组解析失败
+type FlexStrings []string
+
+// UnmarshalJSON 接受字符串数组、单个字符串（转换为只含该字符串的列表，空字符串为空列表）和 null
+func (f *FlexStrings) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		*f = nil
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*f = FlexStrings{}
+		} else {
+			*f = FlexStrings{s}
+		}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*f = list
+	return nil
+}
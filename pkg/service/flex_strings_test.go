@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestFlexStringsUnmarshal 接受字符串数组、单个字符串和 null，空字符串为空列表
+func TestFlexStringsUnmarshal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FlexStrings
+		wantErr bool
+	}{
+		{in: `["正词","备选"]`, want: FlexStrings{"正词", "备选"}},
+		{in: `[]`, want: FlexStrings{}},
+		{in: `"正词"`, want: FlexStrings{"正词"}},
+		{in: `""`, want: FlexStrings{}},
+		{in: `null`, want: nil},
+		{in: `1`, wantErr: true},
+		{in: `[1]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got FlexStrings
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.in, err)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: 得到 %#v，应为 %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestScalarCorword corword/suggest 为单个字符串和数组的项混在同一数组中时都能解析并应用
+func TestScalarCorword(t *testing.T) {
+	var corrections []Correction
+	if err := json.Unmarshal([]byte(`[{"errword":"我门","pos":0,"corword":"我们"},{"errword":"他门","pos":9,"corword":["他们","她们"]}]`), &corrections); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(corrections[0].CorWord, FlexStrings{"我们"}) || !reflect.DeepEqual(corrections[1].CorWord, FlexStrings{"他们", "她们"}) {
+		t.Errorf("corword: %#v %#v", corrections[0].CorWord, corrections[1].CorWord)
+	}
+	p := NewContentProcessor()
+	if got, err := p.ApplyCorrections("我门和他门", corrections); err != nil || got != "我们和他们" {
+		t.Errorf("旧格式得到 %q, %v", got, err)
+	}
+
+	var items []ChecklistItem
+	if err := json.Unmarshal([]byte(`[{"word":"我门","position":0,"length":2,"suggest":"我们"},{"word":"他门","position":3,"length":2,"suggest":["他们"]}]`), &items); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := p.ApplyChecklist("我门和他门", items); err != nil || got != "我们和他们" {
+		t.Errorf("新格式得到 %q, %v", got, err)
+	}
+}
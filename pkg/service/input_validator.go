@@ -0,0 +1,215 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// schemaViolation 描述输入内容中第一个不符合格式约定的位置
+type schemaViolation struct {
+	Path    string // 出错字段的路径，如 data.checklist[2].position
+	Message string // 违例说明
+}
+
+func (v *schemaViolation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// validateInput 按检测到的格式校验 data 对象，返回第一个违例，全部合法时返回 nil
+// prefix 为 data 对象在原始 JSON 中的路径前缀
+func validateInput(dataObj map[string]interface{}, prefix string) *schemaViolation {
+	if _, ok := dataObj["replace_text"]; ok {
+		return validateNewFormat(dataObj, prefix)
+	}
+	return validateOldFormat(dataObj, prefix)
+}
+
+// validateOldFormat 校验旧格式：checkresultstr 为字符串，checkresultjson 为修正项数组（或其 JSON 字符串）
+func validateOldFormat(dataObj map[string]interface{}, prefix string) *schemaViolation {
+	if v := requireString(dataObj, prefix, "checkresultstr", false); v != nil {
+		return v
+	}
+	items, v := requireArray(dataObj, prefix, "checkresultjson")
+	if v != nil {
+		return v
+	}
+	for i, raw := range items {
+		path := fmt.Sprintf("%scheckresultjson[%d]", prefix, i)
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			return &schemaViolation{Path: path, Message: "应为对象"}
+		}
+		itemPrefix := path + "."
+		if v := requireString(item, itemPrefix, "errword", true); v != nil {
+			return v
+		}
+		if v := requireFlexInt(item, itemPrefix, "pos"); v != nil {
+			return v
+		}
+		if v := optionalNumber(item, itemPrefix, "errtype"); v != nil {
+			return v
+		}
+		if v := optionalNumber(item, itemPrefix, "level"); v != nil {
+			return v
+		}
+		if v := optionalFlexStrings(item, itemPrefix, "corword"); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// validateNewFormat 校验新格式：replace_text 为非空字符串，checklist 为错误项数组（或其 JSON 字符串）
+func validateNewFormat(dataObj map[string]interface{}, prefix string) *schemaViolation {
+	if v := requireString(dataObj, prefix, "replace_text", true); v != nil {
+		return v
+	}
+	items, v := requireArray(dataObj, prefix, "checklist")
+	if v != nil {
+		return v
+	}
+	for i, raw := range items {
+		path := fmt.Sprintf("%schecklist[%d]", prefix, i)
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			return &schemaViolation{Path: path, Message: "应为对象"}
+		}
+		itemPrefix := path + "."
+		if v := requirePosition(item, itemPrefix, "position"); v != nil {
+			return v
+		}
+		if v := requireFlexInt(item, itemPrefix, "length"); v != nil {
+			return v
+		}
+		if v := requireString(item, itemPrefix, "word", false); v != nil {
+			return v
+		}
+		if v := optionalFlexStrings(item, itemPrefix, "suggest"); v != nil {
+			return v
+		}
+		if t, exists := item["type"]; exists && t != nil {
+			typeObj, ok := t.(map[string]interface{})
+			if !ok {
+				return &schemaViolation{Path: itemPrefix + "type", Message: "应为对象"}
+			}
+			if v := optionalNumber(typeObj, itemPrefix+"type.", "id"); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func requireString(obj map[string]interface{}, prefix, key string, nonEmpty bool) *schemaViolation {
+	raw, exists := obj[key]
+	if !exists || raw == nil {
+		return &schemaViolation{Path: prefix + key, Message: "缺少必填字段"}
+	}
+	str, ok := raw.(string)
+	if !ok {
+		return &schemaViolation{Path: prefix + key, Message: fmt.Sprintf("应为字符串，实际为 %s", jsonTypeName(raw))}
+	}
+	if nonEmpty && str == "" {
+		return &schemaViolation{Path: prefix + key, Message: "不能为空字符串"}
+	}
+	return nil
+}
+
+// requireArray 读取数组字段，兼容以 JSON 字符串形式存储的数组
+func requireArray(obj map[string]interface{}, prefix, key string) ([]interface{}, *schemaViolation) {
+	raw, exists := obj[key]
+	if !exists || raw == nil {
+		return nil, &schemaViolation{Path: prefix + key, Message: "缺少必填字段"}
+	}
+	switch v := raw.(type) {
+	case []interface{}:
+		return v, nil
+	case string:
+		var arr []interface{}
+		if err := json.Unmarshal([]byte(v), &arr); err != nil {
+			return nil, &schemaViolation{Path: prefix + key, Message: fmt.Sprintf("字符串不是合法的 JSON 数组: %v", err)}
+		}
+		return arr, nil
+	default:
+		return nil, &schemaViolation{Path: prefix + key, Message: fmt.Sprintf("应为数组，实际为 %s", jsonTypeName(raw))}
+	}
+}
+
+func optionalNumber(obj map[string]interface{}, prefix, key string) *schemaViolation {
+	raw, exists := obj[key]
+	if !exists || raw == nil {
+		return nil
+	}
+	if _, ok := raw.(float64); !ok {
+		return &schemaViolation{Path: prefix + key, Message: fmt.Sprintf("应为数字，实际为 %s", jsonTypeName(raw))}
+	}
+	return nil
+}
+
+// requireFlexInt 与 FlexInt 一致，除数字外还接受字符串编码的整数
+func requireFlexInt(obj map[string]interface{}, prefix, key string) *schemaViolation {
+	raw, exists := obj[key]
+	if !exists || raw == nil {
+		return &schemaViolation{Path: prefix + key, Message: "缺少必填字段"}
+	}
+	if str, ok := raw.(string); ok {
+		if _, err := parseFlexInt(str); err != nil {
+			return &schemaViolation{Path: prefix + key, Message: fmt.Sprintf("字符串不是合法的整数: %v", err)}
+		}
+		return nil
+	}
+	return optionalNumber(obj, prefix, key)
+}
+
+// requirePosition 与 ChecklistPosition 一致，除整数外还接受 {"line","col"} 对象
+func requirePosition(obj map[string]interface{}, prefix, key string) *schemaViolation {
+	lc, ok := obj[key].(map[string]interface{})
+	if !ok {
+		return requireFlexInt(obj, prefix, key)
+	}
+	if v := requireFlexInt(lc, prefix+key+".", "line"); v != nil {
+		return v
+	}
+	return requireFlexInt(lc, prefix+key+".", "col")
+}
+
+// optionalFlexStrings 与 FlexStrings 一致，除字符串数组外还接受单个字符串
+func optionalFlexStrings(obj map[string]interface{}, prefix, key string) *schemaViolation {
+	raw, exists := obj[key]
+	if !exists || raw == nil {
+		return nil
+	}
+	if _, ok := raw.(string); ok {
+		return nil
+	}
+	arr, ok := raw.([]interface{})
+	if !ok {
+		return &schemaViolation{Path: prefix + key, Message: fmt.Sprintf("应为字符串或字符串数组，实际为 %s", jsonTypeName(raw))}
+	}
+	for i, elem := range arr {
+		if _, ok := elem.(string); !ok {
+			return &schemaViolation{Path: fmt.Sprintf("%s%s[%d]", prefix, key, i), Message: fmt.Sprintf("应为字符串，实际为 %s", jsonTypeName(elem))}
+		}
+	}
+	return nil
+}
+
+// jsonTypeName 返回 json.Unmarshal 结果值对应的 JSON 类型名
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"content-verify-log/pkg/model"
+)
+
+// LengthRatioCheck 修改后的文章与原文（均为清洗后的纯文本）的字符数之比的允许范围
+// 比例过小或过大通常说明修正替换出错（如错误词为整段文字），而不是正常的错别字修正
+type LengthRatioCheck struct {
+	Min float64 // 最小比例，0 表示不检查下限
+	Max float64 // 最大比例，0 表示不检查上限
+
+	KeepOriginal bool // 超出范围时修改后的文章保留为原文，已应用的修正记为未应用
+}
+
+// enabled 返回是否需要检查
+func (c LengthRatioCheck) enabled() bool {
+	return c.Min > 0 || c.Max > 0
+}
+
+// outOfRange 判断比例是否超出允许范围
+func (c LengthRatioCheck) outOfRange(ratio float64) bool {
+	return (c.Min > 0 && ratio < c.Min) || (c.Max > 0 && ratio > c.Max)
+}
+
+// checkLengthRatio 对应用了修正的结果检查长度之比，超出范围时记为 SUSPICIOUS_MODIFICATION
+// 未应用任何修正或已有错误码的结果不检查：修改后的文章与原文相同或为空并不说明替换出错
+func (p *ContentProcessor) checkLengthRatio(result *model.ProcessedContent) {
+	check := p.opts.LengthRatio
+	if !check.enabled() || result.ErrorCode != "" || !anyApplied(result.Details) {
+		return
+	}
+	original := utf8.RuneCountInString(p.plainOriginalText(result))
+	if original == 0 {
+		return
+	}
+	modified := utf8.RuneCountInString(p.plainModifiedText(result))
+	ratio := float64(modified) / float64(original)
+	if !check.outOfRange(ratio) {
+		return
+	}
+
+	result.ErrorCode = model.ErrCodeSuspiciousModification
+	result.ErrorReason = fmt.Sprintf("修改后长度异常: 原文 %d 字, 修改后 %d 字, 比例 %.2f 超出 %s", original, modified, ratio, check)
+	if !check.KeepOriginal {
+		return
+	}
+	result.ModifiedText = result.OriginalText
+	for i := range result.Details {
+		detail := &result.Details[i]
+		if detail.Applied() {
+			detail.AppliedIndex = -1
+			detail.SkipReason = model.SkipReasonSuspicious
+		}
+	}
+}
+
+// String 返回允许范围的可读形式，如 [0.5, 2]
+func (c LengthRatioCheck) String() string {
+	lower, upper := "0", "∞"
+	if c.Min > 0 {
+		lower = fmt.Sprintf("%g", c.Min)
+	}
+	if c.Max > 0 {
+		upper = fmt.Sprintf("%g", c.Max)
+	}
+	return "[" + lower + ", " + upper + "]"
+}
+
+// plainModifiedText 返回清洗后的纯文本修改后的文章
+func (p *ContentProcessor) plainModifiedText(result *model.ProcessedContent) string {
+	if p.opts.KeepHTML {
+		return p.toPlainText(result.ModifiedText)
+	}
+	return p.removePreservedTags(result.ModifiedText)
+}
+
+// anyApplied 返回是否有已应用的修正
+func anyApplied(details []model.ErrorDetail) bool {
+	for i := range details {
+		if details[i].Applied() {
+			return true
+		}
+	}
+	return false
+}
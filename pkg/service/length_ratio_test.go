@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// TestLengthRatio 错误的修正把大部分文字替换掉时记为可疑修改，开启 KeepOriginal 时保留原文；
+// 正常的修正和未开启检查时不受影响
+func TestLengthRatio(t *testing.T) {
+	// 错误词为整段文字，替换后只剩一个字
+	const broken = `{"data":{"replace_text":"今天我们一起去学校上课","checklist":[{"word":"今天我们一起去学校上课","position":0,"length":11,"suggest":["今"]}]}}`
+	const normal = `{"data":{"replace_text":"今天我门一起去学校上课","checklist":[{"word":"我门","position":2,"length":2,"suggest":["我们"]}]}}`
+
+	tests := []struct {
+		name         string
+		raw          string
+		opt          Option
+		wantCode     string
+		wantModified string
+		wantApplied  bool
+	}{
+		{name: "flagged", raw: broken, opt: WithLengthRatio(0.5, 2, false), wantCode: model.ErrCodeSuspiciousModification, wantModified: "今", wantApplied: true},
+		{name: "keep original", raw: broken, opt: WithLengthRatio(0.5, 2, true), wantCode: model.ErrCodeSuspiciousModification, wantModified: "今天我们一起去学校上课"},
+		{name: "upper only", raw: broken, opt: WithLengthRatio(0, 2, true), wantModified: "今", wantApplied: true},
+		{name: "off", raw: broken, opt: WithLengthRatio(0, 0, true), wantModified: "今", wantApplied: true},
+		{name: "normal", raw: normal, opt: WithLengthRatio(0.5, 2, true), wantModified: "今天我们一起去学校上课", wantApplied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processJSON(t, NewContentProcessor(tt.opt), tt.raw)
+			if result.ErrorCode != tt.wantCode {
+				t.Errorf("错误码为 %q，应为 %q（%s）", result.ErrorCode, tt.wantCode, result.ErrorReason)
+			}
+			if result.ModifiedText != tt.wantModified {
+				t.Errorf("修改后的文章为 %q，应为 %q", result.ModifiedText, tt.wantModified)
+			}
+			if len(result.Details) != 1 {
+				t.Fatalf("应有 1 条明细，得到 %d", len(result.Details))
+			}
+			detail := result.Details[0]
+			if detail.Applied() != tt.wantApplied {
+				t.Errorf("明细已应用为 %v，应为 %v", detail.Applied(), tt.wantApplied)
+			}
+			if !tt.wantApplied && detail.SkipReason != model.SkipReasonSuspicious {
+				t.Errorf("未应用的原因为 %q，应为 %q", detail.SkipReason, model.SkipReasonSuspicious)
+			}
+		})
+	}
+}
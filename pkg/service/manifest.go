@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"content-verify-log/pkg/util"
+)
+
+// ManifestFileName 清单文件名，写在输出旁边
+const ManifestFileName = "manifest.json"
+
+// 清单描述的输出类型
+const (
+	ManifestKindMigrate = "migrate"
+	ManifestKindExport  = "export"
+)
+
+// Manifest 描述一次迁移或导出的输出，供数据目录系统自动采集
+type Manifest struct {
+	Kind         string           `json:"kind"`                    // migrate/export
+	GeneratedAt  time.Time        `json:"generated_at"`            // 生成时间
+	RunID        string           `json:"run_id,omitempty"`        // 迁移的运行 ID，与 migration_runs 一致；导出时为空
+	RunLabel     string           `json:"run_label,omitempty"`     // 迁移的运行标签，未设置或导出时为空
+	ToolVersion  string           `json:"tool_version"`            // 工具版本
+	SourceFilter *ManifestFilter  `json:"source_filter,omitempty"` // 迁移的源数据范围，迁移全部任务或导出时省略
+	Columns      []ManifestColumn `json:"columns"`                 // 列定义，各输出相同
+	Outputs      []ManifestOutput `json:"outputs"`                 // 输出的表或文件
+}
+
+// ManifestFilter 迁移的源数据范围
+type ManifestFilter struct {
+	TaskIDs        []string `json:"task_ids,omitempty"`         // 只迁移这些任务
+	ExcludeTaskIDs []string `json:"exclude_task_ids,omitempty"` // 不迁移这些任务
+}
+
+// ManifestColumn 一列的定义
+type ManifestColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`                  // DuckDB 类型，如 TEXT、BIGINT
+	Description string `json:"description,omitempty"` // 列说明，非本工具生成的列为空
+}
+
+// ManifestOutput 一个输出：迁移时为 DuckDB 文件中的一张表，导出时为一张表导出的文件
+type ManifestOutput struct {
+	Table  string   `json:"table"`
+	Format string   `json:"format"`          // duckdb/parquet/csv/jsonl
+	Path   string   `json:"path,omitempty"`  // 迁移时为 DuckDB 文件路径
+	Files  []string `json:"files,omitempty"` // 导出时生成的文件，导出到对象存储时为对象地址
+	Rows   int64    `json:"rows"`
+}
+
+// manifestFormatDuckDB 迁移输出的格式
+const manifestFormatDuckDB = "duckdb"
+
+// newManifest 创建指定类型的清单，填入生成时间和工具版本
+func newManifest(kind string) *Manifest {
+	return &Manifest{
+		Kind:        kind,
+		GeneratedAt: time.Now(),
+		ToolVersion: util.GetVersion().Version,
+	}
+}
+
+// processedManifestColumns 返回目标表的列定义，与 processedColumns 一致
+func processedManifestColumns() []ManifestColumn {
+	columns := make([]ManifestColumn, len(processedColumns))
+	for i, col := range processedColumns {
+		columns[i] = ManifestColumn{Name: col.Name, Type: canonicalType(col.Type), Description: col.Desc}
+	}
+	return columns
+}
+
+// tableManifestColumns 从 DuckDB 读取表的实际列定义，本工具生成的列附上说明
+func tableManifestColumns(ctx context.Context, duckDB *sql.DB, table string) ([]ManifestColumn, error) {
+	rows, err := duckDB.QueryContext(ctx,
+		`SELECT column_name, data_type FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ?
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, fmt.Errorf("读取表 %s 的列失败: %v", table, err)
+	}
+	defer rows.Close()
+
+	descs := make(map[string]string, len(processedColumns)+1)
+	for _, col := range processedColumns {
+		descs[col.Name] = col.Desc
+	}
+	descs[runLabelColumn.Name] = runLabelColumn.Desc
+	var columns []ManifestColumn
+	for rows.Next() {
+		var col ManifestColumn
+		if err := rows.Scan(&col.Name, &col.Type); err != nil {
+			return nil, fmt.Errorf("读取表 %s 的列失败: %v", table, err)
+		}
+		col.Type = strings.ToUpper(col.Type)
+		col.Description = descs[col.Name]
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取表 %s 的列失败: %v", table, err)
+	}
+	return columns, nil
+}
+
+// marshalManifest 将清单序列化为缩进的 JSON
+func marshalManifest(m *Manifest) ([]byte, error) {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化清单失败: %v", err)
+	}
+	return append(b, '\n'), nil
+}
+
+// WriteManifest 将清单写入 dir 下的 manifest.json，先写临时文件再重命名，读者不会看到写了一半的清单
+func WriteManifest(dir string, m *Manifest) (string, error) {
+	b, err := marshalManifest(m)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, ManifestFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return "", fmt.Errorf("写入清单失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("写入清单失败: %v", err)
+	}
+	return path, nil
+}
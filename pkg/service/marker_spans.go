@@ -0,0 +1,149 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"content-verify-log/pkg/model"
+)
+
+// DefaultMarkerClasses 未设置 MarkerClasses 时作为新格式错误标记移除的 span class
+var DefaultMarkerClasses = []string{"jdt_umold"}
+
+// markerFamilyPrefix 错误标记所属的 class 前缀，这类 class 无论是否移除都会计数，便于了解实际出现了哪些标注层
+const markerFamilyPrefix = "jdt_"
+
+// markerRule 判断新格式中哪些 span 是需要移除的错误标记
+type markerRule struct {
+	classes map[string]bool // 按类名移除
+	prefix  string          // class 以该前缀开头时移除，为空时不按前缀
+	keep    map[string]bool // 不移除的类名，优先于 classes 和 prefix
+}
+
+func newMarkerRule(opts ProcessorOptions) markerRule {
+	classes := opts.MarkerClasses
+	if len(classes) == 0 {
+		classes = DefaultMarkerClasses
+	}
+	rule := markerRule{classes: make(map[string]bool, len(classes)), prefix: opts.MarkerClassPrefix}
+	for _, class := range classes {
+		rule.classes[class] = true
+	}
+	if len(opts.KeepMarkerClasses) > 0 {
+		rule.keep = make(map[string]bool, len(opts.KeepMarkerClasses))
+		for _, class := range opts.KeepMarkerClasses {
+			rule.keep[class] = true
+		}
+	}
+	return rule
+}
+
+// match 按 span 的 class 属性判断是否移除：返回移除时匹配的类名，以及不移除时遇到的 jdt_ 类名，都没有时为空
+// class 中任一类名在 keep 中时整个 span 保留
+func (r markerRule) match(classAttr string) (removed, kept string) {
+	tokens := strings.Fields(classAttr)
+	for _, class := range tokens {
+		if r.keep[class] {
+			return "", familyClass(tokens)
+		}
+	}
+	for _, class := range tokens {
+		if r.classes[class] || (r.prefix != "" && strings.HasPrefix(class, r.prefix)) {
+			return class, ""
+		}
+	}
+	return "", familyClass(tokens)
+}
+
+// familyClass 返回第一个以 jdt_ 开头的类名，没有时返回空
+func familyClass(tokens []string) string {
+	for _, class := range tokens {
+		if strings.HasPrefix(class, markerFamilyPrefix) {
+			return class
+		}
+	}
+	return ""
+}
+
+// countClass 将 class 计入 counts，counts 为 nil 时创建
+func countClass(counts map[string]int, class string) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[class]++
+	return counts
+}
+
+// stripMarkerSpans 移除新格式的错误标记 span（开始标签和与之配对的结束标签），保留其中的文字和其他 HTML；
+// 按标签扫描并配对嵌套的 span，没有结束标签的标记保持原样；注释中的标签不处理。
+// result 不为 nil 时将移除的和遇到但未移除的 jdt_ 类名计入 result.RemovedMarkers、result.KeptMarkers
+func (p *ContentProcessor) stripMarkerSpans(text string, result *model.ProcessedContent) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+	// open 为尚未闭合的 span，drop 为要移除的标签的 [start, end) 区间
+	type span struct {
+		start, end int
+		class      string // 移除时匹配的类名，为空表示不移除
+	}
+	var open []span
+	var drop [][2]int
+	unterminated := false
+	for i := 0; i < len(text); {
+		next := strings.IndexByte(text[i:], '<')
+		if next < 0 {
+			break
+		}
+		i += next
+		rest := text[i:]
+		end := -1
+		if !unterminated && strings.HasPrefix(rest, commentOpen) {
+			if end = commentEnd(rest); end < 0 {
+				unterminated = true
+			} else {
+				i += end + 1
+				continue
+			}
+		}
+		if end = strings.IndexByte(rest, '>'); end < 0 {
+			break
+		}
+		tag := rest[:end+1]
+		if tagName(tag) == "span" {
+			if strings.HasPrefix(tag, "</") {
+				if n := len(open); n > 0 {
+					opened := open[n-1]
+					open = open[:n-1]
+					if opened.class != "" {
+						drop = append(drop, [2]int{opened.start, opened.end}, [2]int{i, i + end + 1})
+						if result != nil {
+							result.RemovedMarkers = countClass(result.RemovedMarkers, opened.class)
+						}
+					}
+				}
+			} else if !strings.HasSuffix(tag, "/>") {
+				removed, kept := p.markers.match(tagAttr(tag, "class"))
+				open = append(open, span{start: i, end: i + end + 1, class: removed})
+				if kept != "" && result != nil {
+					result.KeptMarkers = countClass(result.KeptMarkers, kept)
+				}
+			}
+		}
+		i += end + 1
+	}
+	if len(drop) == 0 {
+		return text
+	}
+
+	// 开始标签在配对时才加入，需按位置排序
+	sort.Slice(drop, func(i, j int) bool { return drop[i][0] < drop[j][0] })
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, r := range drop {
+		b.WriteString(text[last:r[0]])
+		last = r[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
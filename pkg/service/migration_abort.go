@@ -0,0 +1,29 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAborted 迁移因失败行的比例超过 MaxErrorRate 而中止，可用 errors.Is 判断
+var ErrAborted = errors.New("失败行的比例超过阈值，迁移中止")
+
+// errorRateMinRows 读取的行数达到该值后才在每批写入后检查错误率，避免开头几行失败就中止；
+// 迁移结束、替换目标表之前按全部行再检查一次
+const errorRateMinRows = 1000
+
+// checkErrorRate 检查失败行（扫描失败、无法解析、写入失败）占读取行数的比例是否超过 MaxErrorRate，
+// 超过时返回包装 ErrAborted 的错误；final 为 false 时读取的行数不足 errorRateMinRows 不检查
+func (s *MigrationService) checkErrorRate(stats *migrationStats, final bool) error {
+	if s.opts.MaxErrorRate <= 0 || stats.scanned == 0 {
+		return nil
+	}
+	if !final && stats.scanned < errorRateMinRows {
+		return nil
+	}
+	rate := float64(stats.failed) / float64(stats.scanned)
+	if rate <= s.opts.MaxErrorRate {
+		return nil
+	}
+	return fmt.Errorf("%w: 已读取 %d 行，失败 %d 行（%.2f%%），超过 maxErrorRate %g", ErrAborted, stats.scanned, stats.failed, rate*100, s.opts.MaxErrorRate)
+}
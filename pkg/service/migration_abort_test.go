@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestMigrateMaxErrorRate 迁移结束时失败行的比例超过 MaxErrorRate 则返回 ErrAborted，
+// 目标表保持上一次成功迁移的结果，运行日志记为 aborted；恰好等于阈值时不中止
+func TestMigrateMaxErrorRate(t *testing.T) {
+	db := openSourceDB(t, [][]interface{}{
+		{1, "t1", runsSourceContent, nil, nil, nil},
+		{2, "t1", runsSourceContent, nil, nil, nil},
+		{3, "t1", `{"data":`, nil, nil, nil},
+		{4, "t1", `{"data":`, nil, nil, nil},
+	})
+	tests := []struct {
+		name    string
+		rate    float64
+		aborted bool
+	}{
+		{name: "off", rate: 0},
+		{name: "equal", rate: 0.5},
+		{name: "over", rate: 0.25, aborted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 先写入一个只有 id 99 的目标表，中止时应保持不变
+			if _, err := db.Exec("CREATE OR REPLACE TABLE " + defaultTargetTable + " AS SELECT '99' AS id"); err != nil {
+				t.Fatal(err)
+			}
+			s := NewMigrationServiceWithDB(MigrationOptions{MaxErrorRate: tt.rate}, db, db)
+			err := s.MigrateToDuckDB(context.Background(), 2)
+			if errors.Is(err, ErrAborted) != tt.aborted || (err != nil && !tt.aborted) {
+				t.Fatalf("迁移返回 %v", err)
+			}
+			want := []string{"1", "2"}
+			if tt.aborted {
+				want = []string{"99"}
+			}
+			if got := targetIDs(t, s); !reflect.DeepEqual(got, want) {
+				t.Errorf("目标表的 id 为 %v，应为 %v", got, want)
+			}
+			var status string
+			if err := db.QueryRow("SELECT status FROM "+migrationRunsTable+" WHERE run_id = ?", s.RunSummary().RunID).Scan(&status); err != nil {
+				t.Fatal(err)
+			}
+			wantStatus := runStatusSucceeded
+			if tt.aborted {
+				wantStatus = runStatusAborted
+			}
+			if status != wantStatus {
+				t.Errorf("status 为 %q，应为 %q", status, wantStatus)
+			}
+		})
+	}
+}
+
+// TestMigrateMaxErrorRateStopsEarly 读取满 errorRateMinRows 行后按批检查，超过阈值时不再读取剩余的行
+func TestMigrateMaxErrorRateStopsEarly(t *testing.T) {
+	rows := make([][]interface{}, 0, errorRateMinRows*2)
+	for i := 1; i <= errorRateMinRows*2; i++ {
+		rows = append(rows, []interface{}{i, "t1", `{"data":`, nil, nil, nil})
+	}
+	db := openSourceDB(t, rows)
+	s := NewMigrationServiceWithDB(MigrationOptions{MaxErrorRate: 0.1, QueueDepth: 1}, db, db)
+	if err := s.MigrateToDuckDB(context.Background(), 100); !errors.Is(err, ErrAborted) {
+		t.Fatalf("应返回 ErrAborted，得到 %v", err)
+	}
+	if s.lastRun.scanned != errorRateMinRows {
+		t.Errorf("应在读取 %d 行后中止，实际处理了 %d 行", errorRateMinRows, s.lastRun.scanned)
+	}
+}
+
+// TestCheckErrorRate 未满 errorRateMinRows 行时只在结束时检查
+func TestCheckErrorRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64
+		scanned int
+		failed  int
+		final   bool
+		aborted bool
+	}{
+		{name: "off", rate: 0, scanned: 10, failed: 10, final: true},
+		{name: "nothing read", rate: 0.1, final: true},
+		{name: "few rows", rate: 0.1, scanned: 10, failed: 5},
+		{name: "few rows final", rate: 0.1, scanned: 10, failed: 5, final: true, aborted: true},
+		{name: "enough rows", rate: 0.1, scanned: errorRateMinRows, failed: errorRateMinRows/10 + 1, aborted: true},
+		{name: "under", rate: 0.1, scanned: errorRateMinRows, failed: errorRateMinRows / 10},
+	}
+	for _, tt := range tests {
+		s := NewMigrationService(MigrationOptions{MaxErrorRate: tt.rate})
+		stats := &migrationStats{scanned: tt.scanned, failed: tt.failed}
+		if err := s.checkErrorRate(stats, tt.final); errors.Is(err, ErrAborted) != tt.aborted {
+			t.Errorf("%s: 返回 %v", tt.name, err)
+		}
+	}
+}
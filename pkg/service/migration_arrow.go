@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"content-verify-log/pkg/model"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// 处理结果在写入阶段以 Arrow record batch 表示，DuckDB 和 Parquet 写入目标共用同一份列式数据，
+// 不再为每个写入目标分别序列化
+
+// processedArrowSchema 处理结果的 Arrow schema，列的顺序和类型与 processedColumns 一致
+var processedArrowSchema = newProcessedArrowSchema()
+
+func newProcessedArrowSchema() *arrow.Schema {
+	fields := make([]arrow.Field, len(processedColumns))
+	for i, col := range processedColumns {
+		fields[i] = arrow.Field{
+			Name:     col.Name,
+			Type:     arrowType(col.Type),
+			Nullable: !strings.Contains(col.Type, "PRIMARY KEY"),
+		}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowType 返回 DuckDB 列类型对应的 Arrow 类型，TIMESTAMP 不带时区，与 DuckDB 的 TIMESTAMP 一致按 UTC 微秒存储
+func arrowType(sqlType string) arrow.DataType {
+	switch strings.Fields(sqlType)[0] {
+	case "BIGINT":
+		return arrow.PrimitiveTypes.Int64
+	case "INTEGER":
+		return arrow.PrimitiveTypes.Int32
+	case "BOOLEAN":
+		return arrow.FixedWidthTypes.Boolean
+	case "TIMESTAMP":
+		return &arrow.TimestampType{Unit: arrow.Microsecond}
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+// newProcessedRecord 将一批结果构建为 Arrow record batch，值（包括 NULL）与 INSERT 的参数一致，调用方负责 Release
+func newProcessedRecord(batch []*model.ProcessedContent) (arrow.RecordBatch, error) {
+	b := array.NewRecordBuilder(memory.DefaultAllocator, processedArrowSchema)
+	defer b.Release()
+	for _, processed := range batch {
+		row, err := driverValues(processed)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range row {
+			if err := appendArrowValue(b.Field(i), v); err != nil {
+				return nil, fmt.Errorf("记录 ID %s 的 %s: %v", processed.ID, processedColumns[i].Name, err)
+			}
+		}
+	}
+	return b.NewRecordBatch(), nil
+}
+
+// appendArrowValue 将驱动值追加到对应类型的列，nil 追加为 NULL
+func appendArrowValue(fb array.Builder, v driver.Value) error {
+	if v == nil {
+		fb.AppendNull()
+		return nil
+	}
+	switch fb := fb.(type) {
+	case *array.StringBuilder:
+		if s, ok := v.(string); ok {
+			fb.Append(s)
+			return nil
+		}
+	case *array.Int64Builder:
+		if n, ok := toInt64(v); ok {
+			fb.Append(n)
+			return nil
+		}
+	case *array.Int32Builder:
+		if n, ok := toInt64(v); ok {
+			fb.Append(int32(n))
+			return nil
+		}
+	case *array.BooleanBuilder:
+		if b, ok := v.(bool); ok {
+			fb.Append(b)
+			return nil
+		}
+	case *array.TimestampBuilder:
+		if t, ok := v.(time.Time); ok {
+			fb.Append(arrow.Timestamp(t.UnixMicro()))
+			return nil
+		}
+	}
+	return fmt.Errorf("值类型 %T 与列类型 %s 不匹配", v, fb.Type())
+}
+
+func toInt64(v driver.Value) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+// newParquetWriter 创建按 processedArrowSchema 写入 w 的 Parquet 写入器，Close 时同时关闭 w
+func newParquetWriter(w io.Writer) (*pqarrow.FileWriter, error) {
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Zstd))
+	return pqarrow.NewFileWriter(processedArrowSchema, w, props, pqarrow.DefaultWriterProps())
+}
+
+// writeParquetFile 将 record batch 写入新建的 Parquet 文件
+func writeParquetFile(path string, rec arrow.RecordBatch) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w, err := newParquetWriter(f)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := w.Write(rec); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// arrowBatch 将一批结果构建为 record batch 后写入 DuckDB 表，整批原子生效
+func arrowBatch(ctx context.Context, duckDB *sql.DB, table string, batch []*model.ProcessedContent) error {
+	rec, err := newProcessedRecord(batch)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+	return insertArrowRecord(ctx, duckDB, table, rec)
+}
+
+// parquetSink 将处理结果以 Arrow record batch 写入单个 Parquet 文件，commit 时关闭文件，abort 时删除
+type parquetSink struct {
+	path   string
+	writer *pqarrow.FileWriter
+	rows   int64
+}
+
+func openParquetSink(path string) (*parquetSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Parquet 文件失败: %v", err)
+	}
+	w, err := newParquetWriter(f)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("创建 Parquet 写入器失败: %v", err)
+	}
+	return &parquetSink{path: path, writer: w}, nil
+}
+
+func (p *parquetSink) write(_ context.Context, batch []*model.ProcessedContent) []writeFailure {
+	if len(batch) == 0 {
+		return nil
+	}
+	rec, err := newProcessedRecord(batch)
+	if err != nil {
+		return failAll(batch, err)
+	}
+	defer rec.Release()
+	if err := p.writer.Write(rec); err != nil {
+		return failAll(batch, fmt.Errorf("写入 Parquet 失败: %v", err))
+	}
+	p.rows += int64(len(batch))
+	return nil
+}
+
+func (p *parquetSink) commit(context.Context) error {
+	return p.writer.Close()
+}
+
+func (p *parquetSink) abort(context.Context) {
+	_ = p.writer.Close()
+	_ = os.Remove(p.path)
+}
+
+// outputs Parquet 文件不是表，不计入清单
+func (p *parquetSink) outputs() []sinkOutput { return nil }
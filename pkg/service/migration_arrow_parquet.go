@@ -0,0 +1,32 @@
+//go:build !duckdb_arrow
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/apache/arrow-go/v18/arrow"
+)
+
+// insertArrowRecord 将 record batch 写入临时 Parquet 文件后用一条 INSERT 读入
+// 默认构建不包含 DuckDB 的 Arrow 接口，以 -tags duckdb_arrow 构建时改为直接读取 Arrow 内存，见 migration_arrow_view.go
+func insertArrowRecord(ctx context.Context, duckDB *sql.DB, table string, rec arrow.RecordBatch) error {
+	f, err := os.CreateTemp("", "cvl-ingest-*.parquet")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	path := f.Name()
+	_ = f.Close()
+	defer os.Remove(path)
+
+	if err := writeParquetFile(path, rec); err != nil {
+		return fmt.Errorf("写入临时文件 %s 失败: %v", path, err)
+	}
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s BY NAME SELECT * FROM read_parquet(%s)", table, quoteLiteral(path))); err != nil {
+		return fmt.Errorf("读入 Arrow 数据失败: %v", err)
+	}
+	return nil
+}
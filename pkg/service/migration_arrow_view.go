@@ -0,0 +1,56 @@
+//go:build duckdb_arrow
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// insertArrowRecord 将 record batch 注册为 DuckDB 视图后用一条 INSERT 读入，DuckDB 直接读取 Arrow 内存，不经过序列化
+// 需要以 -tags duckdb_arrow 构建
+func insertArrowRecord(ctx context.Context, duckDB *sql.DB, table string, rec arrow.RecordBatch) error {
+	conn, err := duckDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		dc, ok := driverConn.(driver.Conn)
+		if !ok {
+			return fmt.Errorf("连接不支持 Arrow")
+		}
+		ar, err := duckdb.NewArrowFromConn(dc)
+		if err != nil {
+			return fmt.Errorf("创建 Arrow 接口失败: %v", err)
+		}
+		reader, err := array.NewRecordReader(rec.Schema(), []arrow.RecordBatch{rec})
+		if err != nil {
+			return err
+		}
+		defer reader.Release()
+
+		view := table + "_arrow_batch"
+		release, err := ar.RegisterView(reader, view)
+		if err != nil {
+			return fmt.Errorf("注册 Arrow 视图失败: %v", err)
+		}
+		defer release()
+
+		execer, ok := driverConn.(driver.ExecerContext)
+		if !ok {
+			return fmt.Errorf("连接不支持执行语句")
+		}
+		if _, err := execer.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s BY NAME SELECT * FROM %s", table, view), nil); err != nil {
+			return fmt.Errorf("读入 Arrow 数据失败: %v", err)
+		}
+		return nil
+	})
+}
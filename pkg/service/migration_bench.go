@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"content-verify-log/pkg/model"
+	"content-verify-log/pkg/util"
+)
+
+// 基准测试的写入目标
+const (
+	BenchSinkDiscard = "discard" // 处理结果直接丢弃，只测量读取和处理
+	BenchSinkDuckDB  = "duckdb"  // 写入 DuckDB 的临时表，与迁移的写入路径一致
+	BenchSinkParquet = "parquet" // 以 Arrow record batch 写入临时 Parquet 文件
+)
+
+// benchTable 基准测试写入 DuckDB 时使用的表，结束后删除
+const benchTable = "bench_processed"
+
+// BenchOptions 基准测试选项
+type BenchOptions struct {
+	Duration  time.Duration // 运行时长，语料读完后从头循环
+	BatchSize int           // 每批读取的记录数，开启 MemoryBudget 时为初始批量
+	Sink      string        // 写入目标：BenchSinkDiscard/BenchSinkDuckDB/BenchSinkParquet
+}
+
+// BenchResult 基准测试结果
+type BenchResult struct {
+	Sink      string `json:"sink"`
+	Workers   int    `json:"workers"`
+	BatchSize int    `json:"batch_size"`
+
+	// 开启自适应批量时运行中使用过的最小、最大批量
+	BatchSizeMin int `json:"batch_size_min,omitempty"`
+	BatchSizeMax int `json:"batch_size_max,omitempty"`
+
+	Elapsed     time.Duration `json:"elapsed_ns"`
+	Passes      int           `json:"passes"`      // 完整读完语料的次数
+	Rows        int64         `json:"rows"`        // 处理的记录数
+	Invalid     int64         `json:"invalid"`     // 无法解析而未处理的记录数（迁移时写入死信表）
+	Corrections int64         `json:"corrections"` // 实际应用的修正数
+
+	RowsPerSec        float64 `json:"rows_per_sec"`
+	CorrectionsPerSec float64 `json:"corrections_per_sec"`
+
+	// 单篇文档处理耗时（不含读取和写入）
+	LatencyP50 time.Duration `json:"latency_p50_ns"`
+	LatencyP95 time.Duration `json:"latency_p95_ns"`
+	LatencyMax time.Duration `json:"latency_max_ns"`
+
+	// 端到端（读取、解析、处理、写入）平均每篇文档的内存分配
+	AllocsPerDoc float64 `json:"allocs_per_doc"`
+	BytesPerDoc  float64 `json:"bytes_per_doc"`
+
+	PeakRSS int64 `json:"peak_rss_bytes"` // 进程峰值常驻内存，无法获取时为 0
+}
+
+// discardSink 丢弃处理结果
+type discardSink struct{}
+
+func (discardSink) write(context.Context, []*model.ProcessedContent) []writeFailure { return nil }
+func (discardSink) commit(context.Context) error                                    { return nil }
+func (discardSink) abort(context.Context)                                           {}
+func (discardSink) outputs() []sinkOutput                                           { return nil }
+
+// openBenchSink 按选项创建写入目标，写入 DuckDB 时使用临时表，写入 Parquet 时使用临时文件，结束后由调用方 abort 删除
+func (s *MigrationService) openBenchSink(ctx context.Context, sinkName string) (processedSink, error) {
+	switch sinkName {
+	case BenchSinkDiscard:
+		return discardSink{}, nil
+	case BenchSinkDuckDB:
+		targetDB := s.target(ctx)
+		if targetDB == nil {
+			return nil, fmt.Errorf("DuckDB 连接未初始化")
+		}
+		dups, err := newDuplicateIDs(s.opts.DuplicateIDs)
+		if err != nil {
+			return nil, err
+		}
+		return openTableSink(ctx, targetDB, benchTable, s.opts.IngestMode, "", dups)
+	case BenchSinkParquet:
+		f, err := os.CreateTemp("", "cvl-bench-*.parquet")
+		if err != nil {
+			return nil, fmt.Errorf("创建临时文件失败: %v", err)
+		}
+		_ = f.Close()
+		return openParquetSink(f.Name())
+	default:
+		return nil, fmt.Errorf("不支持的写入目标: %q", sinkName)
+	}
+}
+
+// Bench 在源库的语料上按迁移流程（分批读取、解析、并发处理、顺序写入）运行指定时长并统计吞吐和资源占用
+// 语料读完后从头循环；写入 DuckDB 时每轮重建临时表以避免主键冲突
+func (s *MigrationService) Bench(ctx context.Context, opts BenchOptions) (*BenchResult, error) {
+	if opts.BatchSize < 1 {
+		return nil, fmt.Errorf("批量大小必须大于 0，当前为 %d", opts.BatchSize)
+	}
+	if opts.Duration <= 0 {
+		return nil, fmt.Errorf("运行时长必须大于 0，当前为 %s", opts.Duration)
+	}
+	sourceDB := s.source(ctx)
+	if sourceDB == nil {
+		return nil, fmt.Errorf("DuckDB 连接未初始化")
+	}
+	src := &dbSource{db: sourceDB, taskIDs: s.opts.TaskIDs, excludeTaskIDs: s.opts.ExcludeTaskIDs}
+
+	sink, err := s.openBenchSink(ctx, opts.Sink)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		sink.abort(ctx)
+	}()
+
+	result := &BenchResult{Sink: opts.Sink, Workers: max(s.opts.Workers, 1), BatchSize: opts.BatchSize}
+	var latencies []time.Duration
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	sizer := newBatchSizer(opts.BatchSize, s.opts.MemoryBudget)
+	start := time.Now()
+	deadline := start.Add(opts.Duration)
+	offset := 0
+
+	for time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		batch, scanned, _, largest, err := s.readBatch(ctx, src, sizer.next(), offset)
+		if err != nil {
+			return nil, err
+		}
+		// 无法解析的记录只计数，不写入死信表
+		result.Invalid += int64(batch.scanErrors + len(batch.deadRows))
+		contents := batch.contents
+		if scanned == 0 {
+			if offset == 0 {
+				return nil, fmt.Errorf("源表 %s 中没有可读取的记录", sourceTable)
+			}
+			result.Passes++
+			offset = 0
+			if opts.Sink == BenchSinkDuckDB {
+				sink.abort(ctx)
+				if sink, err = s.openBenchSink(ctx, opts.Sink); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		durations := make([]time.Duration, len(contents))
+		results, _ := s.processor.ProcessBatch(ctx, contentPointers(contents), BatchOptions{
+			Workers:  s.opts.Workers,
+			OnResult: func(i int, _ *model.ProcessedContent, took time.Duration) { durations[i] = took },
+		})
+		// 中途取消时部分结果未处理，不计入统计
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		latencies = append(latencies, durations...)
+
+		toWrite := make([]*model.ProcessedContent, 0, len(results))
+		for _, processed := range results {
+			result.Rows++
+			result.Corrections += int64(processed.CorrectionCount)
+			if s.opts.OnlyErrors && !processed.HasErrors {
+				continue
+			}
+			toWrite = append(toWrite, processed)
+		}
+		for _, failure := range sink.write(ctx, toWrite) {
+			// 源数据中 ID 重复的行按迁移时的处理方式跳过，不算失败
+			if !errors.Is(failure.err, errDuplicateID) {
+				return nil, fmt.Errorf("写入记录 ID %s 失败: %v", toWrite[failure.index].ID, failure.err)
+			}
+		}
+		offset += scanned
+		sizer.observe(largest)
+	}
+
+	result.Elapsed = time.Since(start)
+	runtime.ReadMemStats(&after)
+	if seconds := result.Elapsed.Seconds(); seconds > 0 {
+		result.RowsPerSec = float64(result.Rows) / seconds
+		result.CorrectionsPerSec = float64(result.Corrections) / seconds
+	}
+	if result.Rows > 0 {
+		result.AllocsPerDoc = float64(after.Mallocs-before.Mallocs) / float64(result.Rows)
+		result.BytesPerDoc = float64(after.TotalAlloc-before.TotalAlloc) / float64(result.Rows)
+	}
+	result.LatencyP50, result.LatencyP95, result.LatencyMax = latencyPercentiles(latencies)
+	if sizer.adaptive() {
+		result.BatchSizeMin, result.BatchSizeMax = sizer.minSeen, sizer.maxSeen
+	}
+	result.PeakRSS, _ = util.PeakRSS()
+	return result, nil
+}
+
+// latencyPercentiles 返回耗时的 p50、p95 和最大值
+func latencyPercentiles(latencies []time.Duration) (p50, p95, maxLatency time.Duration) {
+	if len(latencies) == 0 {
+		return 0, 0, 0
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	at := func(q float64) time.Duration {
+		return latencies[int(q*float64(len(latencies)-1))]
+	}
+	return at(0.50), at(0.95), latencies[len(latencies)-1]
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"content-verify-log/pkg/model"
+
+	"go.uber.org/zap"
+)
+
+// deadLetterTable 保存无法处理的源记录，供排查和 retry-dead-letter 重新处理
+const deadLetterTable = "dead_letter"
+
+// buildCreateDeadLetterSQL 构造创建死信表的语句，表已存在时保留其中的记录
+func buildCreateDeadLetterSQL() string {
+	return `CREATE TABLE IF NOT EXISTS ` + deadLetterTable + ` (
+	id BIGINT PRIMARY KEY,
+	task_id TEXT,
+	reason TEXT,
+	raw_content BLOB,
+	failed_at TIMESTAMP
+)`
+}
+
+// buildDeleteDeadLetterSQL 构造按源表 ID 删除死信的语句
+func buildDeleteDeadLetterSQL() string {
+	return "DELETE FROM " + deadLetterTable + " WHERE id = ?"
+}
+
+// buildInsertDeadLetterSQL 构造写入死信的语句，需先删除同 ID 的旧记录
+func buildInsertDeadLetterSQL() string {
+	return "INSERT INTO " + deadLetterTable + " (id, task_id, reason, raw_content, failed_at) VALUES (?, ?, ?, ?, ?)"
+}
+
+// inClause 构造 "column IN (?, ?, ...)" 条件及其参数
+func inClause[T any](column string, values []T) (string, []interface{}) {
+	placeholders := make([]string, 0, len(values))
+	args := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		placeholders = append(placeholders, "?")
+		args = append(args, v)
+	}
+	return column + " IN (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// notInClause 构造 column NOT IN (?, ...) 条件及对应参数，values 不能为空
+func notInClause[T any](column string, values []T) (string, []interface{}) {
+	cond, args := inClause(column, values)
+	return strings.Replace(cond, " IN (", " NOT IN (", 1), args
+}
+
+// createDeadLetterTable 确保死信表存在
+func createDeadLetterTable(ctx context.Context, duckDB *sql.DB) error {
+	if _, err := duckDB.ExecContext(ctx, buildCreateDeadLetterSQL()); err != nil {
+		return fmt.Errorf("创建死信表失败: %v", err)
+	}
+	return nil
+}
+
+// clearDeadLetters 确保死信表存在，并删除本次迁移范围内的旧死信，taskIDs 为空时清空全部，excludeTaskIDs 中任务的死信保留
+func clearDeadLetters(ctx context.Context, duckDB *sql.DB, taskIDs, excludeTaskIDs []string) error {
+	if err := createDeadLetterTable(ctx, duckDB); err != nil {
+		return err
+	}
+	query := "DELETE FROM " + deadLetterTable
+	var conds []string
+	var args []interface{}
+	if len(taskIDs) > 0 {
+		cond, in := inClause("task_id", taskIDs)
+		conds = append(conds, cond)
+		args = append(args, in...)
+	}
+	if len(excludeTaskIDs) > 0 {
+		cond, in := notInClause("task_id", excludeTaskIDs)
+		conds = append(conds, cond)
+		args = append(args, in...)
+	}
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	if _, err := duckDB.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("清理死信表失败: %v", err)
+	}
+	return nil
+}
+
+// recordDeadLetter 将无法处理的源记录写入死信表，同一 ID 只保留最近一次失败
+// 写入死信失败只输出警告，不中断迁移
+func (s *MigrationService) recordDeadLetter(ctx context.Context, content *model.VerifyContent, contentJSON []byte, reason string, stats *migrationStats) {
+	stats.deadLettered++
+	duckDB := s.target(ctx)
+	if err := upsertDeadLetter(ctx, duckDB, content, contentJSON, reason); err != nil {
+		zap.S().Warnf("记录 ID %d 写入死信表失败: %v", content.ID, err)
+	}
+}
+
+// upsertDeadLetter 在一个事务中删除同 ID 的旧死信并写入新死信
+func upsertDeadLetter(ctx context.Context, duckDB *sql.DB, content *model.VerifyContent, contentJSON []byte, reason string) error {
+	tx, err := duckDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.ExecContext(ctx, buildDeleteDeadLetterSQL(), content.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, buildInsertDeadLetterSQL(),
+		content.ID, nullString(content.TaskID), reason, contentJSON, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deadLetterIDs 读取死信表中的源表 ID，ids 不为空时只返回其中存在于死信表的 ID
+func deadLetterIDs(ctx context.Context, duckDB *sql.DB, ids []int64) ([]int64, error) {
+	query := "SELECT id FROM " + deadLetterTable
+	var args []interface{}
+	if len(ids) > 0 {
+		var where string
+		where, args = inClause("id", ids)
+		query += " WHERE " + where
+	}
+	query += " ORDER BY id"
+
+	rows, err := duckDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		result = append(result, id)
+	}
+	return result, rows.Err()
+}
+
+// buildSourceByIDsQuery 构造按 ID 读取源表的查询，列与 buildSourceQuery 一致
+func buildSourceByIDsQuery(ids []int64) (string, []interface{}) {
+	where, args := inClause("id", ids)
+	return `SELECT id, taskId, content,
+			TRY_STRPTIME(created_at, '%d/%m/%Y %H:%M:%S.%f') AS created_at,
+			TRY_STRPTIME(updated_at, '%d/%m/%Y %H:%M:%S.%f') AS updated_at,
+			TRY_STRPTIME(deleted_at, '%d/%m/%Y %H:%M:%S.%f') AS deleted_at
+			FROM ` + sourceTable + `
+			WHERE ` + where + `
+			ORDER BY id`, args
+}
+
+// RetryDeadLetters 重新处理死信表中的记录，ids 为空时处理全部死信
+// 处理成功的记录写入（或替换）目标表中的同 ID 记录并从死信表删除；再次失败的记录更新失败原因
+func (s *MigrationService) RetryDeadLetters(ctx context.Context, ids []int64) error {
+	if err := s.validateIdentifiers(); err != nil {
+		return err
+	}
+	if s.opts.ShardBy != ShardByNone {
+		return fmt.Errorf("重新处理死信不支持分片写入 (shardBy=%q)", s.opts.ShardBy)
+	}
+	if s.opts.TextDir != "" {
+		return fmt.Errorf("重新处理死信不支持文本输出")
+	}
+
+	sourceDB := s.source(ctx)
+	targetDB := s.target(ctx)
+	if sourceDB == nil || targetDB == nil {
+		return fmt.Errorf("DuckDB 连接未初始化")
+	}
+
+	if _, err := targetDB.ExecContext(ctx, buildCreateDeadLetterSQL()); err != nil {
+		return fmt.Errorf("创建死信表失败: %v", err)
+	}
+	pending, err := deadLetterIDs(ctx, targetDB, ids)
+	if err != nil {
+		return fmt.Errorf("读取死信表失败: %v", err)
+	}
+	if len(pending) == 0 {
+		zap.S().Info("死信表中没有需要重新处理的记录")
+		return nil
+	}
+	zap.S().Infof("重新处理 %d 条死信", len(pending))
+
+	// 目标表可能尚未创建（如首次迁移全部失败），不存在时创建，已存在时保留其中的记录并校验列
+	if err := ensureDuckDBTable(ctx, targetDB, s.targetTable); err != nil {
+		return err
+	}
+
+	query, args := buildSourceByIDsQuery(pending)
+	rows, err := sourceDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("查询数据失败: %v", err)
+	}
+
+	stats := newMigrationStats()
+	s.diagnostics = newRowDiagnostics()
+	found := make(map[int64]bool, len(pending))
+	var contents []model.VerifyContent
+	for rows.Next() {
+		content, contentJSON, err := scanSourceRow(rows)
+		if err != nil {
+			zap.S().Warnf("扫描记录失败: %v", err)
+			stats.errors++
+			continue
+		}
+		found[int64(content.ID)] = true
+
+		loaded, failure := s.loadContent(content, contentJSON)
+		if failure != nil {
+			s.debugRow(failure.reason, "文章 ID %d: %s，跳过", content.ID, failure)
+			s.recordDeadLetter(ctx, content, contentJSON, failure.String(), stats)
+			continue
+		}
+		if !loaded {
+			// 格式未知的记录在正常迁移中同样会被跳过，不再视为失败
+			s.removeDeadLetter(ctx, content.ID)
+			continue
+		}
+		contents = append(contents, *content)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("读取数据失败: %v", err)
+	}
+
+	for _, id := range pending {
+		if !found[id] {
+			zap.S().Warnf("死信 ID %d 在源表中不存在，保留在死信表中", id)
+		}
+	}
+
+	results := s.processBatch(ctx, contents)
+	for i, content := range contents {
+		result := results[i]
+		stats.recordProcessed(result)
+
+		if s.opts.OnlyErrors && !result.HasErrors {
+			stats.skipped++
+			s.removeDeadLetter(ctx, content.ID)
+			continue
+		}
+
+		if err := replaceProcessed(ctx, targetDB, s.targetTable, result); err != nil {
+			zap.S().Warnf("处理记录 ID %d 失败: %v", content.ID, err)
+			stats.recordWriteError(result)
+			s.recordDeadLetter(ctx, &content, []byte(content.Content.Raw), fmt.Sprintf("写入失败: %v", err), stats)
+			continue
+		}
+		stats.recordWritten(result)
+		s.removeDeadLetter(ctx, content.ID)
+	}
+
+	stats.log()
+	s.logDiagnostics()
+	return nil
+}
+
+// removeDeadLetter 从死信表删除已不再失败的记录
+func (s *MigrationService) removeDeadLetter(ctx context.Context, id uint) {
+	if _, err := s.target(ctx).ExecContext(ctx, buildDeleteDeadLetterSQL(), id); err != nil {
+		zap.S().Warnf("从死信表删除记录 ID %d 失败: %v", id, err)
+	}
+}
+
+// replaceProcessed 在一个事务中删除目标表中同 ID 的记录并写入新的处理结果
+func replaceProcessed(ctx context.Context, duckDB *sql.DB, table string, processed *model.ProcessedContent) error {
+	values, err := processedValues(processed)
+	if err != nil {
+		return err
+	}
+	tx, err := duckDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = ?", processed.ID); err != nil {
+		return fmt.Errorf("删除旧记录失败: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, buildInsertSQL(table), values...); err != nil {
+		return fmt.Errorf("插入数据失败: %v", err)
+	}
+	return tx.Commit()
+}
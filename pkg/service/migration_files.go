@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"content-verify-log/pkg/model"
+)
+
+// DefaultSourceGlob 目录迁移时默认匹配的文件名
+const DefaultSourceGlob = "*.json"
+
+// fileSource 从目录中的 JSON 文件读取源数据，每个文件一条记录，按路径的字典序读取
+type fileSource struct {
+	dir   string
+	files []string
+}
+
+// newFileSource 递归列出 dir 下文件名匹配 glob 的文件，没有匹配的文件时返回错误，避免用空结果替换目标表
+func newFileSource(dir, glob string) (*fileSource, error) {
+	if glob == "" {
+		glob = DefaultSourceGlob
+	}
+	if _, err := filepath.Match(glob, ""); err != nil {
+		return nil, fmt.Errorf("文件匹配模式 %q 不合法: %v", glob, err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取目录 %s 失败: %v", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s 不是目录", dir)
+	}
+
+	var files []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		// 模式已校验过，Match 不会出错
+		if ok, _ := filepath.Match(glob, d.Name()); ok {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历目录 %s 失败: %v", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("目录 %s 中没有匹配 %s 的文件", dir, glob)
+	}
+	return &fileSource{dir: dir, files: files}, nil
+}
+
+func (src *fileSource) scan(ctx context.Context, limit, offset int, fn func(content *model.VerifyContent, contentJSON []byte, err error)) error {
+	if offset >= len(src.files) {
+		return nil
+	}
+	for _, path := range src.files[offset:min(offset+limit, len(src.files))] {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fn(readSourceFile(src.dir, path))
+	}
+	return nil
+}
+
+func (src *fileSource) count(context.Context) (int64, error) {
+	return int64(len(src.files)), nil
+}
+
+// readSourceFile 读取一个源文件。文件可以是带 id、taskId（或 task_id）和 content 字段的对象，content 为字符串或对象；
+// 也可以直接是 content 本身。id 缺省时取文件名（不含扩展名），须为正整数；taskId 缺省时取文件相对 dir 的目录，
+// 直接位于 dir 下的文件为空。创建和更新时间取文件的修改时间。
+// 无法读取或无法确定 ID 的文件返回错误；content 无法解析的文件照常返回，由 loadContent 判定并写入死信表
+func readSourceFile(dir, path string) (*model.VerifyContent, []byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件 %s 失败: %v", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件 %s 失败: %v", path, err)
+	}
+	content := &model.VerifyContent{TaskID: taskIDFromPath(dir, path), CreatedAt: info.ModTime(), UpdatedAt: info.ModTime()}
+	contentJSON := b
+
+	var envelope map[string]json.RawMessage
+	if json.Unmarshal(b, &envelope) == nil && envelope["content"] != nil {
+		if raw, ok := envelope["id"]; ok {
+			var id FlexInt
+			if err := json.Unmarshal(raw, &id); err != nil || id <= 0 {
+				return nil, nil, fmt.Errorf("文件 %s 的 id 不是正整数: %s", path, raw)
+			}
+			content.ID = uint(id)
+		}
+		for _, key := range []string{"taskId", "task_id"} {
+			if raw, ok := envelope[key]; ok {
+				if err := json.Unmarshal(raw, &content.TaskID); err != nil {
+					return nil, nil, fmt.Errorf("文件 %s 的 %s 不是字符串: %v", path, key, err)
+				}
+				break
+			}
+		}
+		if contentJSON, err = envelopeContent(envelope["content"]); err != nil {
+			return nil, nil, fmt.Errorf("文件 %s 的 content 不是合法的字符串: %v", path, err)
+		}
+	}
+	if content.ID == 0 {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		id, err := strconv.ParseUint(name, 10, strconv.IntSize)
+		if err != nil || id == 0 {
+			return nil, nil, fmt.Errorf("文件 %s 没有 id 字段，文件名也不是正整数，无法确定 ID", path)
+		}
+		content.ID = uint(id)
+	}
+	return content, contentJSON, nil
+}
+
+// envelopeContent 返回源文件 content 字段的原文：字符串取其内容，null 返回 nil，对象等其他值原样返回
+func envelopeContent(raw json.RawMessage) ([]byte, error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case bytes.Equal(raw, []byte("null")):
+		return nil, nil
+	case len(raw) > 0 && raw[0] == '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	default:
+		return raw, nil
+	}
+}
+
+// taskIDFromPath 返回文件相对 dir 的目录作为任务 ID，直接位于 dir 下时为空
+func taskIDFromPath(dir, path string) string {
+	rel, err := filepath.Rel(dir, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
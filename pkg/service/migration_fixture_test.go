@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"content-verify-log/pkg/fixture"
+)
+
+// openFixtureDB 返回写入了 genfixtures 合成数据的内存 duckdb 连接
+func openFixtureDB(tb testing.TB, opts fixture.Options) *sql.DB {
+	tb.Helper()
+	if err := opts.Validate(); err != nil {
+		tb.Fatal(err)
+	}
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	tb.Cleanup(func() { db.Close() })
+	if _, err := fixture.WriteDuckDB(context.Background(), db, fixture.NewGenerator(opts), false); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+// fixtureOptions 测试用的小规模合成数据参数
+func fixtureOptions(rows int) fixture.Options {
+	opts := fixture.NewDefaultOptions()
+	opts.Rows = rows
+	opts.MinLength, opts.MaxLength = 100, 800
+	opts.ErrorDensity = 2
+	opts.InvalidRate = 0.05
+	return opts
+}
+
+// fixtureExpected 按生成器的输出计算迁移后应写入和写入死信表的行数：没有 data 的行写入死信表，
+// 错误列表为空的行不符合已知格式、跳过
+func fixtureExpected(t *testing.T, opts fixture.Options) (written, dead int) {
+	t.Helper()
+	gen := fixture.NewGenerator(opts)
+	for row, ok := gen.Next(); ok; row, ok = gen.Next() {
+		var content struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(row.Content), &content); err != nil || content.Data == nil {
+			dead++
+			continue
+		}
+		list, ok := content.Data["checklist"]
+		if !ok {
+			list = content.Data["checkresultjson"]
+		}
+		// 错误列表可能是数组，也可能是 JSON 字符串
+		if str, isString := list.(string); isString {
+			if err := json.Unmarshal([]byte(str), &list); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if items, _ := list.([]interface{}); len(items) > 0 {
+			written++
+		}
+	}
+	return written, dead
+}
+
+// TestMigrateFixture 迁移合成数据：每行都被读取，无法解析的行写入死信表，有错误列表的行全部写入并应用了修正；
+// 相同种子的两次迁移结果相同
+func TestMigrateFixture(t *testing.T) {
+	opts := fixtureOptions(300)
+	wantWritten, wantDead := fixtureExpected(t, opts)
+	if wantDead == 0 {
+		t.Fatal("合成数据中应有无效行")
+	}
+	checksum := func() string {
+		db := openFixtureDB(t, opts)
+		s := NewMigrationServiceWithDB(MigrationOptions{Workers: 4}, db, db)
+		if err := s.MigrateToDuckDB(context.Background(), 50); err != nil {
+			t.Fatal(err)
+		}
+		var dead int
+		if err := db.QueryRow("SELECT count(*) FROM " + deadLetterTable).Scan(&dead); err != nil {
+			t.Fatal(err)
+		}
+		stats := s.lastRun
+		if stats.scanned != opts.Rows || stats.errors != 0 || stats.failed != dead {
+			t.Errorf("读取 %d 行、失败 %d 行、死信 %d 行，错误 %d", stats.scanned, stats.failed, dead, stats.errors)
+		}
+		if stats.processed != wantWritten || dead != wantDead {
+			t.Errorf("写入 %d 行、死信 %d 行，应为 %d 行和 %d 行", stats.processed, dead, wantWritten, wantDead)
+		}
+		var withErrors int
+		var sum sql.NullString
+		if err := db.QueryRow("SELECT count(*) FILTER (WHERE has_errors), md5(string_agg(modified_text, '' ORDER BY id)) FROM "+s.targetTable).
+			Scan(&withErrors, &sum); err != nil {
+			t.Fatal(err)
+		}
+		if withErrors != stats.processed {
+			t.Errorf("%d 行中只有 %d 行应用了修正", stats.processed, withErrors)
+		}
+		return sum.String
+	}
+	first := checksum()
+	if second := checksum(); first != second {
+		t.Errorf("相同种子两次迁移的结果不同: %s %s", first, second)
+	}
+}
+
+// BenchmarkMigrateFixture 按各写入方式迁移 genfixtures 默认参数（行数缩小为 1000）生成的合成数据
+func BenchmarkMigrateFixture(b *testing.B) {
+	opts := fixture.NewDefaultOptions()
+	opts.Rows = 1000
+	db := openFixtureDB(b, opts)
+	for _, mode := range []string{IngestInsert, IngestAppender, IngestCopy, IngestArrow} {
+		b.Run(fmt.Sprintf("ingest=%s", mode), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s := NewMigrationServiceWithDB(MigrationOptions{Workers: 4, IngestMode: mode}, db, db)
+				if err := s.MigrateToDuckDB(context.Background(), 500); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
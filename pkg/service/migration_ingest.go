@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"content-verify-log/pkg/model"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// 写入目标表的方式
+const (
+	IngestInsert   = "insert"   // 逐行 INSERT
+	IngestAppender = "appender" // 每批通过 DuckDB Appender 追加
+	IngestCopy     = "copy"     // 每批写入临时 JSONL 文件后由 DuckDB 批量读入
+	IngestArrow    = "arrow"    // 每批构建为 Arrow record batch 后由 DuckDB 读入
+)
+
+// 读取临时 JSONL 文件时单行大小上限的最小值，与 DuckDB 的默认值一致
+const minIngestObjectSize = 16 << 20
+
+// appendBatch 在一个事务中通过 Appender 追加一批结果，任一行失败时整批回滚
+func appendBatch(ctx context.Context, duckDB *sql.DB, table string, batch []*model.ProcessedContent) error {
+	conn, err := duckDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN TRANSACTION"); err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_, _ = conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK")
+		}
+	}()
+
+	err = conn.Raw(func(driverConn any) error {
+		dc, ok := driverConn.(driver.Conn)
+		if !ok {
+			return fmt.Errorf("连接不支持 Appender")
+		}
+		appender, err := duckdb.NewAppenderFromConn(dc, "", table)
+		if err != nil {
+			return fmt.Errorf("创建 Appender 失败: %v", err)
+		}
+		for _, processed := range batch {
+			row, err := driverValues(processed)
+			if err == nil {
+				err = appender.AppendRow(row...)
+			}
+			if err != nil {
+				_ = appender.Close()
+				return fmt.Errorf("追加记录 ID %s 失败: %v", processed.ID, err)
+			}
+		}
+		return appender.Close()
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	committed = true
+	return nil
+}
+
+// copyBatch 将一批结果写入临时 JSONL 文件，再用一条 INSERT ... SELECT FROM read_json 读入表中，整批原子生效
+// 不使用 COPY FROM 是因为它不支持调整单行大小上限，大文档会超过默认的 16MB
+func copyBatch(ctx context.Context, duckDB *sql.DB, table string, batch []*model.ProcessedContent) error {
+	f, err := os.CreateTemp("", "cvl-ingest-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	largest, err := writeIngestJSONL(f, batch)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入临时文件 %s 失败: %v", f.Name(), err)
+	}
+
+	query := buildReadJSONInsertSQL(table, f.Name(), max(largest+1, minIngestObjectSize))
+	if _, err := duckDB.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("批量读入失败: %v", err)
+	}
+	return nil
+}
+
+// buildReadJSONInsertSQL 构造从 JSONL 文件读入目标表的 SQL，列类型取自 processedColumns，不依赖类型推断
+func buildReadJSONInsertSQL(table, path string, maxObjectSize int) string {
+	columns := make([]string, 0, len(processedColumns))
+	for _, col := range processedColumns {
+		typ := strings.TrimSuffix(col.Type, " PRIMARY KEY")
+		columns = append(columns, fmt.Sprintf("%s: %s", col.Name, quoteLiteral(typ)))
+	}
+	return fmt.Sprintf("INSERT INTO %s BY NAME SELECT * FROM read_json(%s, format = 'newline_delimited', maximum_object_size = %d, columns = {%s})",
+		table, quoteLiteral(path), maxObjectSize, strings.Join(columns, ", "))
+}
+
+// writeIngestJSONL 每行写入一个结果，键为列名，值与 INSERT 的参数一致（NULL 写为 null），返回最长一行的字节数
+func writeIngestJSONL(f *os.File, batch []*model.ProcessedContent) (int, error) {
+	w := bufio.NewWriter(f)
+	largest := 0
+	var line []byte
+	for _, processed := range batch {
+		row, err := driverValues(processed)
+		if err != nil {
+			return 0, err
+		}
+		line = append(line[:0], '{')
+		for i, v := range row {
+			if i > 0 {
+				line = append(line, ',')
+			}
+			line = append(line, '"')
+			line = append(line, processedColumns[i].Name...)
+			line = append(line, '"', ':')
+			if t, ok := v.(time.Time); ok {
+				// DuckDB 的 TIMESTAMP 不带时区，与 INSERT 路径一致按 UTC 存储
+				v = t.UTC().Format("2006-01-02 15:04:05.999999")
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return 0, fmt.Errorf("序列化记录 ID %s 的 %s 失败: %v", processed.ID, processedColumns[i].Name, err)
+			}
+			line = append(line, b...)
+		}
+		line = append(line, '}', '\n')
+		largest = max(largest, len(line))
+		if _, err := w.Write(line); err != nil {
+			return 0, err
+		}
+	}
+	return largest, w.Flush()
+}
+
+// driverValues 将 processedValues 的参数转换为驱动值，sql.NullString 等可空类型转换为值或 nil
+// 逐行插入拒绝无效 UTF-8、在 NUL 处截断文本，而 Appender、JSON 和 Arrow 各有不同的处理；
+// 文本含这两类字符时返回错误，由调用方回退为逐行插入，使各写入方式的结果一致
+func driverValues(processed *model.ProcessedContent) ([]driver.Value, error) {
+	values, err := processedValues(processed)
+	if err != nil {
+		return nil, err
+	}
+	row := make([]driver.Value, len(values))
+	for i, v := range values {
+		if valuer, ok := v.(driver.Valuer); ok {
+			if v, err = valuer.Value(); err != nil {
+				return nil, err
+			}
+		}
+		if s, ok := v.(string); ok && (!utf8.ValidString(s) || strings.IndexByte(s, 0) >= 0) {
+			return nil, fmt.Errorf("记录 ID %s 的 %s 含无效 UTF-8 或 NUL 字符", processed.ID, processedColumns[i].Name)
+		}
+		row[i] = v
+	}
+	return row, nil
+}
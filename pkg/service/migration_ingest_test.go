@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"content-verify-log/pkg/model"
+)
+
+// ingestModes 与 insert 方式对比的批量写入方式
+var ingestModes = []string{IngestAppender, IngestCopy, IngestArrow}
+
+// ingestCases 覆盖各列类型、NULL 和特殊文本的处理结果
+func ingestCases() []*model.ProcessedContent {
+	processedAt := time.Date(2024, 3, 5, 6, 7, 8, 123456000, time.FixedZone("CST", 8*3600))
+	created := time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC)
+	needsReview := true
+	full := &model.ProcessedContent{
+		ID: "full", PID: "task1", SourceFormat: "new",
+		OriginalText: "我门去公圆", OriginalTextValid: true,
+		ModifiedText: "我们去公园", ModifiedTextValid: true,
+		ErrorCode: model.ErrCodeSuspiciousModification, ErrorReason: "修改后的文章与原文字数之比 0.1", ValidationError: "data.checklist[0]: 缺少 word",
+		RawSize: 1234, ContentHash: strings.Repeat("ab", 32), ConvertedEncoding: "gb18030",
+		HasErrors: true, CorrectionCount: 2, ConflictCount: 1,
+		LevelCounts:     map[int]int{1: 2, 3: 1},
+		MarkersStripped: true, HTMLStripped: true, NeedsReview: &needsReview,
+		ToolVersion: "v1.2.3", ProcessedAt: &processedAt, SourceCreatedAt: &created, SourceUpdatedAt: &created,
+		Details: []model.ErrorDetail{{Position: 0, Word: "我门", Suggestions: []string{"我们"}, AppliedIndex: 0, TypeID: 5, Level: 1, SourceFormat: "new", Context: "\"引号\"\n换行"}},
+	}
+	// 只有 ID 和任务 ID，其余可空的列都为 NULL
+	nulls := &model.ProcessedContent{ID: "nulls", PID: "task1"}
+	// 文本已生成但为空，写入空字符串而不是 NULL
+	empty := &model.ProcessedContent{ID: "empty", PID: "", OriginalTextValid: true, ModifiedTextValid: true}
+	special := "第一行\n第二行\r\n\"双引号\" '单引号' \\反斜杠\\ 逗号,制表\t\\N NULL null <p>&amp;</p> 😀"
+	text := &model.ProcessedContent{
+		ID: "text\n\"quoted\",id", PID: "task,\"2\"",
+		OriginalText: special, OriginalTextValid: true,
+		ModifiedText: special + "\n", ModifiedTextValid: true,
+		ErrorReason: "原因含\n换行和\"引号\"",
+	}
+	// JSON 无法与逐行插入一致表示的文本：copy 方式应回退为逐行插入
+	nul := &model.ProcessedContent{ID: "nul", OriginalText: "a\x00b", OriginalTextValid: true}
+	return []*model.ProcessedContent{full, nulls, empty, text, nul}
+}
+
+// writeIngestTable 按写入方式将 batch 写入 table 并提交，返回失败的行下标
+func writeIngestTable(t *testing.T, db *sql.DB, table, mode string, batch []*model.ProcessedContent) []int {
+	t.Helper()
+	ctx := context.Background()
+	dups, _ := newDuplicateIDs(DuplicateIDSkip)
+	sink, err := openTableSink(ctx, db, table, mode, "", dups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed []int
+	for _, f := range sink.write(ctx, batch) {
+		failed = append(failed, f.index)
+	}
+	if err := sink.commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return failed
+}
+
+// tableDiff 返回两个表中不同的行数（NULL 视为相同），列的顺序和类型一致
+func tableDiff(t *testing.T, db *sql.DB, a, b string) int {
+	t.Helper()
+	var n int
+	query := "SELECT count(*) FROM ((SELECT * FROM " + a + " EXCEPT ALL SELECT * FROM " + b + ") UNION ALL (SELECT * FROM " + b + " EXCEPT ALL SELECT * FROM " + a + "))"
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// TestIngestRoundTrip 各批量写入方式写入目标表的结果与逐行插入逐列一致：NULL 与空字符串、
+// 含换行和引号的文本、时间（按 UTC 存储）、JSON 列，以及 copy 方式无法表示时回退为逐行插入的文本
+func TestIngestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		batch []*model.ProcessedContent
+	}{
+		{name: "all", batch: ingestCases()},
+	}
+	for _, c := range ingestCases() {
+		tests = append(tests, struct {
+			name  string
+			batch []*model.ProcessedContent
+		}{name: c.ID, batch: []*model.ProcessedContent{c}})
+	}
+	for _, mode := range ingestModes {
+		for _, tt := range tests {
+			t.Run(mode+"/"+tt.name, func(t *testing.T) {
+				db := openMemoryDB(t)
+				if failed := writeIngestTable(t, db, "want", IngestInsert, tt.batch); len(failed) > 0 {
+					t.Fatalf("逐行插入失败: %v", failed)
+				}
+				if failed := writeIngestTable(t, db, "got", mode, tt.batch); len(failed) > 0 {
+					t.Fatalf("写入失败: %v", failed)
+				}
+				var rows int
+				if err := db.QueryRow("SELECT count(*) FROM got").Scan(&rows); err != nil {
+					t.Fatal(err)
+				}
+				if rows != len(tt.batch) {
+					t.Errorf("写入 %d 行，应为 %d 行", rows, len(tt.batch))
+				}
+				if n := tableDiff(t, db, "want", "got"); n > 0 {
+					t.Errorf("与逐行插入的结果有 %d 行不同", n)
+				}
+			})
+		}
+	}
+}
+
+// TestIngestRejectedBatch 批量写入被拒绝（无效 UTF-8）时回退为逐行插入：只有该行失败、其余行写入，
+// 失败的行写入死信表，与 insert 方式一致
+func TestIngestRejectedBatch(t *testing.T) {
+	for _, mode := range append([]string{IngestInsert}, ingestModes...) {
+		t.Run(mode, func(t *testing.T) {
+			ctx := context.Background()
+			db := openMemoryDB(t)
+			if err := createDeadLetterTable(ctx, db); err != nil {
+				t.Fatal(err)
+			}
+			s := NewMigrationServiceWithDB(MigrationOptions{}, db, db)
+			dups, _ := newDuplicateIDs(DuplicateIDSkip)
+			sink, err := openTableSink(ctx, db, "out", mode, "", dups)
+			if err != nil {
+				t.Fatal(err)
+			}
+			batch := &processedBatch{sourceBatch: &sourceBatch{}}
+			for i, text := range []string{"甲", "乙\xff\xfe", "丙"} {
+				id := uint(i + 1)
+				batch.contents = append(batch.contents, model.VerifyContent{ID: id, TaskID: "t1"})
+				batch.results = append(batch.results, processedRow(fmt.Sprint(id), text))
+			}
+			stats := newMigrationStats()
+			s.writeBatch(ctx, sink, batch, stats)
+			if err := sink.commit(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := targetIDs(t, &MigrationService{targetDB: db, targetTable: "out"}); strings.Join(got, ",") != "1,3" {
+				t.Errorf("目标表的 id 为 %v，应为 [1 3]", got)
+			}
+			var id int
+			var reason string
+			if err := db.QueryRow("SELECT id, reason FROM "+deadLetterTable).Scan(&id, &reason); err != nil {
+				t.Fatal(err)
+			}
+			if id != 2 || !strings.HasPrefix(reason, "写入失败") {
+				t.Errorf("死信为 %d %q，应为 ID 2 的写入失败", id, reason)
+			}
+			if stats.processed != 2 || stats.errors != 1 || stats.failed != 1 || stats.deadLettered != 1 {
+				t.Errorf("写入 %d、错误 %d、失败 %d、死信 %d，应为 2、1、1、1", stats.processed, stats.errors, stats.failed, stats.deadLettered)
+			}
+		})
+	}
+}
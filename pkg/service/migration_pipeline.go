@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"content-verify-log/pkg/model"
+
+	"go.uber.org/zap"
+)
+
+// 迁移流水线：读取 -> 处理 -> 写入，三个阶段由容量为 QueueDepth 的队列连接
+// 写入变慢时写入队列先满，处理阶段阻塞；处理队列随后变满，读取阶段阻塞，
+// 内存中至多同时存在 2×QueueDepth+3 批记录（每个队列 QueueDepth 批，每个阶段正在处理的各 1 批），与输入规模无关
+
+// deadRow 读取阶段发现的无法解析的行，由写入阶段写入死信表
+type deadRow struct {
+	content     *model.VerifyContent
+	contentJSON []byte
+	reason      string
+}
+
+// sourceBatch 读取阶段产出的一批记录
+type sourceBatch struct {
+	contents   []model.VerifyContent
+	deadRows   []deadRow
+	scanErrors int // 扫描失败的行数
+	scanned    int // 从源表读取的行数，包括跳过和无法解析的行
+
+	// 采样时两个队列中等待的批数，用于统计队列深度
+	processQueued int
+	writeQueued   int
+}
+
+// processedBatch 处理阶段产出的一批结果，与 contents 一一对应
+type processedBatch struct {
+	*sourceBatch
+	results []*model.ProcessedContent
+}
+
+// queueStats 队列深度和各阶段因队列已满而阻塞的时间
+type queueStats struct {
+	capacity int
+	batches  int
+
+	processQueuedSum, processQueuedMax int // 处理队列中等待的批数
+	writeQueuedSum, writeQueuedMax     int // 写入队列中等待的批数
+
+	readerBlocked    time.Duration // 读取阶段等待处理队列空位的时间
+	processorBlocked time.Duration // 处理阶段等待写入队列空位的时间
+}
+
+// observe 记录一批到达写入阶段时的队列深度
+func (q *queueStats) observe(batch *sourceBatch) {
+	q.batches++
+	q.processQueuedSum += batch.processQueued
+	q.processQueuedMax = max(q.processQueuedMax, batch.processQueued)
+	q.writeQueuedSum += batch.writeQueued
+	q.writeQueuedMax = max(q.writeQueuedMax, batch.writeQueued)
+}
+
+// log 输出队列深度汇总
+func (q *queueStats) log() {
+	if q.batches == 0 {
+		return
+	}
+	n := float64(q.batches)
+	zap.S().Infof("队列深度（容量 %d 批）: 待处理 平均 %.1f 最大 %d, 待写入 平均 %.1f 最大 %d; 读取等待 %s, 处理等待 %s",
+		q.capacity, float64(q.processQueuedSum)/n, q.processQueuedMax, float64(q.writeQueuedSum)/n, q.writeQueuedMax,
+		q.readerBlocked.Round(time.Millisecond), q.processorBlocked.Round(time.Millisecond))
+}
+
+// runPipeline 按流水线读取、处理全部记录，在调用方的 goroutine 中对每批结果调用 write
+// 写入阶段只有一个 goroutine，统计和死信表只在 write 中修改；读取失败或 write 返回错误时停止流水线并返回该错误
+func (s *MigrationService) runPipeline(ctx context.Context, src contentSource, sizer *batchSizer, queues *queueStats, write func(*processedBatch) error) error {
+	depth := max(s.opts.QueueDepth, 1)
+	queues.capacity = depth
+
+	pipeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	processQueue := make(chan *sourceBatch, depth)
+	writeQueue := make(chan *processedBatch, depth)
+
+	var wg sync.WaitGroup
+	var readErr, writeErr error
+	var readerBlocked, processorBlocked time.Duration
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(processQueue)
+		readerBlocked, readErr = s.readBatches(pipeCtx, src, sizer, processQueue, writeQueue)
+		if readErr != nil {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer close(writeQueue)
+		for batch := range processQueue {
+			processed := &processedBatch{sourceBatch: batch, results: s.processBatch(pipeCtx, batch.contents)}
+			start := time.Now()
+			select {
+			case writeQueue <- processed:
+				processorBlocked += time.Since(start)
+			case <-pipeCtx.Done():
+				return
+			}
+		}
+	}()
+
+	for batch := range writeQueue {
+		// 读取失败或已取消时只排空队列，不再写入，避免把取消导致的写入失败记入死信表
+		if pipeCtx.Err() != nil {
+			continue
+		}
+		queues.observe(batch.sourceBatch)
+		if writeErr = write(batch); writeErr != nil {
+			cancel()
+		}
+	}
+	wg.Wait()
+	queues.readerBlocked, queues.processorBlocked = readerBlocked, processorBlocked
+	if writeErr != nil {
+		return writeErr
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return ctx.Err()
+}
+
+// readBatches 分批读取 src 直到读完，每批发送到 out；out 已满时阻塞
+// 返回等待 out 空位的总时间
+func (s *MigrationService) readBatches(ctx context.Context, src contentSource, sizer *batchSizer, out chan<- *sourceBatch, writeQueue chan *processedBatch) (time.Duration, error) {
+	var blocked time.Duration
+	offset := 0
+	for {
+		limit := sizer.next()
+		batch, scanned, batchBytes, largest, err := s.readBatch(ctx, src, limit, offset)
+		if err != nil {
+			return blocked, err
+		}
+		// 以实际读取的行数判断是否读完，整批都被跳过时仍需继续读取下一批
+		if scanned == 0 {
+			return blocked, nil
+		}
+		offset += scanned
+		if sizer.adaptive() {
+			sizer.observe(largest)
+			if next := sizer.next(); next != limit {
+				zap.S().Infof("批量调整: %d -> %d 条（上一批原文 %d 字节，最大文档 %d 字节）", limit, next, batchBytes, largest)
+			}
+		}
+
+		batch.scanned = scanned
+		batch.processQueued, batch.writeQueued = len(out), len(writeQueue)
+		start := time.Now()
+		select {
+		case out <- batch:
+			blocked += time.Since(start)
+		case <-ctx.Done():
+			return blocked, ctx.Err()
+		}
+	}
+}
+
+// readBatch 从 src 读取一批记录并解析，返回读取的行数、原文总字节数和最大文档的字节数
+func (s *MigrationService) readBatch(ctx context.Context, src contentSource, limit, offset int) (*sourceBatch, int, int64, int64, error) {
+	batch := &sourceBatch{}
+	scanned := 0
+	var batchBytes, largest int64
+	err := src.scan(ctx, limit, offset, func(content *model.VerifyContent, contentJSON []byte, err error) {
+		scanned++
+		if err != nil {
+			zap.S().Warnf("扫描记录失败: %v", err)
+			batch.scanErrors++
+			return
+		}
+		batchBytes += int64(len(contentJSON))
+		largest = max(largest, int64(len(contentJSON)))
+
+		loaded, failure := s.loadContent(content, contentJSON)
+		if failure != nil {
+			// 无法解析的行不会因重跑而成功，写入死信表供排查和 retry-dead-letter 重新处理
+			s.debugRow(failure.reason, "文章 ID %d: %s，跳过", content.ID, failure)
+			batch.deadRows = append(batch.deadRows, deadRow{content: content, contentJSON: contentJSON, reason: failure.String()})
+			return
+		}
+		if !loaded {
+			return
+		}
+		batch.contents = append(batch.contents, *content)
+	})
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	return batch, scanned, batchBytes, largest, nil
+}
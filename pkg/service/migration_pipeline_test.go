@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"content-verify-log/pkg/model"
+)
+
+// countingSource 生成 rows 行已知格式的源数据，记录已读取的批数
+type countingSource struct {
+	rows    int
+	batches atomic.Int64
+	onBatch func(read int64) // 每读取一批后调用，参数为已读取的批数
+}
+
+func (src *countingSource) scan(ctx context.Context, limit, offset int, fn func(content *model.VerifyContent, contentJSON []byte, err error)) error {
+	n := min(limit, src.rows-offset)
+	for i := 0; i < n; i++ {
+		id := offset + i + 1
+		fn(&model.VerifyContent{ID: uint(id), TaskID: "t1"}, []byte(`{"data":{"replace_text":"错字","checklist":[{"word":"错","suggest":["对"],"position":0,"length":1}]}}`), nil)
+	}
+	if n > 0 {
+		read := src.batches.Add(1)
+		if src.onBatch != nil {
+			src.onBatch(read)
+		}
+	}
+	return nil
+}
+
+func (src *countingSource) count(context.Context) (int64, error) { return int64(src.rows), nil }
+
+// blockingSink 每次 write 都阻塞到 release 关闭，模拟变慢的写入
+type blockingSink struct {
+	release chan struct{}
+	batches atomic.Int64
+	rows    atomic.Int64
+}
+
+func (s *blockingSink) write(_ context.Context, batch []*model.ProcessedContent) []writeFailure {
+	<-s.release
+	s.rows.Add(int64(len(batch)))
+	s.batches.Add(1)
+	return nil
+}
+
+func (s *blockingSink) commit(context.Context) error { return nil }
+func (s *blockingSink) abort(context.Context)        {}
+func (s *blockingSink) outputs() []sinkOutput        { return nil }
+
+// waitStable 等待 v 在连续几次采样中不再变化，返回该值
+func waitStable(t *testing.T, v *atomic.Int64) int64 {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	last, stable := v.Load(), 0
+	for stable < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("读取的批数没有停止增长，当前为 %d", v.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+		if cur := v.Load(); cur != last {
+			last, stable = cur, 0
+			continue
+		}
+		stable++
+	}
+	return last
+}
+
+// TestPipelineBoundedMemory 写入阻塞时读取和处理随之阻塞，内存中的批数不超过 2×QueueDepth+3，与输入规模无关；
+// 写入恢复后全部记录照常写入
+func TestPipelineBoundedMemory(t *testing.T) {
+	const batchSize = 2
+	for _, depth := range []int{1, 4} {
+		for _, inputBatches := range []int{100, 1000} {
+			t.Run(fmt.Sprintf("depth=%d/batches=%d", depth, inputBatches), func(t *testing.T) {
+				bound := int64(2*depth + 3)
+				sink := &blockingSink{release: make(chan struct{})}
+				src := &countingSource{rows: inputBatches * batchSize}
+				var maxInFlight atomic.Int64
+				src.onBatch = func(read int64) {
+					inFlight := read - sink.batches.Load()
+					for cur := maxInFlight.Load(); inFlight > cur && !maxInFlight.CompareAndSwap(cur, inFlight); cur = maxInFlight.Load() {
+					}
+				}
+
+				s := NewMigrationService(MigrationOptions{QueueDepth: depth, Workers: 2})
+				ctx := context.Background()
+				var wg sync.WaitGroup
+				var err error
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err = s.runPipeline(ctx, src, newBatchSizer(batchSize, 0), &queueStats{}, func(batch *processedBatch) error {
+						sink.write(ctx, batch.results)
+						return nil
+					})
+				}()
+
+				if read := waitStable(t, &src.batches); read != bound {
+					t.Errorf("写入阻塞时应读取 %d 批后停止，实际读取 %d 批", bound, read)
+				}
+				close(sink.release)
+				wg.Wait()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := sink.rows.Load(); got != int64(src.rows) {
+					t.Errorf("应写入 %d 行，得到 %d", src.rows, got)
+				}
+				if got := maxInFlight.Load(); got > bound {
+					t.Errorf("已读取未写入的批数最多为 %d，超过上限 %d", got, bound)
+				}
+			})
+		}
+	}
+}
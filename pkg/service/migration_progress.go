@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// ProgressReporter 接收迁移进度，由命令行按终端类型选择进度条或定时日志
+// 写入阶段在单个 goroutine 中调用 Add，实现不需要加锁
+type ProgressReporter interface {
+	// Start 在开始读取前调用，total 为本次迁移范围内源数据的行数，无法统计时为 -1
+	Start(total int64)
+	// Add 在每批写入后调用，rows 为该批读取的行数（包括跳过和写入死信表的行）
+	Add(rows int)
+	// Finish 在迁移结束（包括失败和中断）时调用
+	Finish()
+}
+
+// startProgress 统计源数据行数并开始报告进度，统计失败时以未知总数继续
+func (s *MigrationService) startProgress(ctx context.Context, src contentSource) {
+	total, err := src.count(ctx)
+	if err != nil {
+		zap.S().Warnf("统计源数据行数失败，进度不显示总数: %v", err)
+		total = -1
+	}
+	s.opts.Progress.Start(total)
+}
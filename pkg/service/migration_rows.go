@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"content-verify-log/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// contentSource 迁移的源数据，按固定顺序分批读取
+type contentSource interface {
+	// scan 读取从第 offset 行开始的至多 limit 行，对每行调用 fn；无法读取的行 err 不为 nil，计为扫描失败
+	scan(ctx context.Context, limit, offset int, fn func(content *model.VerifyContent, contentJSON []byte, err error)) error
+	// count 返回本次迁移范围内的行数
+	count(ctx context.Context) (int64, error)
+}
+
+// dbSource 从源库的 tbl_verify_content 表按 ID 顺序读取
+// 按 ID 游标分批读取：记录上一批读到的位置，下一批从该位置开始时只读取 ID 更大的行，
+// 不预先读取 ID 列表，也不在每批重新统计或用 OFFSET 跳过已读的行
+type dbSource struct {
+	db             *sql.DB
+	taskIDs        []string
+	excludeTaskIDs []string
+
+	next   int  // 已顺序读取的行数，即下一批的 offset
+	lastID uint // 已读取的最后一行的 ID
+}
+
+// scan 只支持顺序读取：offset 为 0 时从头读取，否则必须等于已读取的行数
+// 查询中途取消时返回 ctx.Err()，与读取阶段其他位置的取消一致，由调用方按中断处理
+func (src *dbSource) scan(ctx context.Context, limit, offset int, fn func(content *model.VerifyContent, contentJSON []byte, err error)) error {
+	if offset != 0 && offset != src.next {
+		return fmt.Errorf("源表只支持按顺序分批读取，offset 应为 %d，当前为 %d", src.next, offset)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	after := offset > 0
+	query, args := buildSourceQuery(src.taskIDs, src.excludeTaskIDs, after)
+	if after {
+		args = append(args, src.lastID)
+	}
+	args = append(args, limit)
+
+	rows, err := src.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("查询数据失败: %v", err)
+	}
+	defer rows.Close()
+	scanned, lastID := 0, src.lastID
+	for rows.Next() {
+		content, contentJSON, err := scanSourceRow(rows)
+		if err == nil {
+			lastID = content.ID
+		} else {
+			// 不知道该行的 ID 就无法确定下一批从哪里开始
+			id, idErr := scanSourceID(rows)
+			if idErr != nil {
+				return fmt.Errorf("读取第 %d 行的 ID 失败: %v", offset+scanned+1, idErr)
+			}
+			lastID = id
+		}
+		scanned++
+		fn(content, contentJSON, err)
+	}
+	if err := rows.Err(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("读取数据失败: %v", err)
+	}
+	src.next, src.lastID = offset+scanned, lastID
+	return nil
+}
+
+func (src *dbSource) count(ctx context.Context) (int64, error) {
+	query, args := buildSourceCountQuery(src.taskIDs, src.excludeTaskIDs)
+	var total int64
+	if err := src.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("查询数量失败: %v", err)
+	}
+	return total, nil
+}
+
+// scanSourceRow 扫描源表的一行，列顺序与 buildSourceQuery 一致
+// 内容以 []byte 返回，避免 NullString 再转 []byte 解析的额外拷贝
+func scanSourceRow(rows *sql.Rows) (*model.VerifyContent, []byte, error) {
+	var content model.VerifyContent
+	var taskID sql.NullString
+	var contentJSON []byte
+	var createdAt, updatedAt, deletedAt sql.NullTime
+
+	if err := rows.Scan(&content.ID, &taskID, &contentJSON, &createdAt, &updatedAt, &deletedAt); err != nil {
+		return nil, nil, err
+	}
+
+	if taskID.Valid {
+		content.TaskID = taskID.String
+	}
+	if createdAt.Valid {
+		content.CreatedAt = createdAt.Time
+	}
+	if updatedAt.Valid {
+		content.UpdatedAt = updatedAt.Time
+	}
+	if deletedAt.Valid {
+		content.DeletedAt = gorm.DeletedAt{Time: deletedAt.Time, Valid: true}
+	}
+	return &content, contentJSON, nil
+}
+
+// scanSourceID 只扫描当前行的 ID，用于该行其他列无法扫描时推进游标
+func scanSourceID(rows *sql.Rows) (uint, error) {
+	var id uint
+	var rest [5]interface{}
+	if err := rows.Scan(&id, &rest[0], &rest[1], &rest[2], &rest[3], &rest[4]); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// rowFailure 源记录无法处理的原因，reason 用于日志采样和汇总，err 为具体错误
+type rowFailure struct {
+	reason string
+	err    error
+}
+
+func (f *rowFailure) String() string {
+	if f.err == nil {
+		return f.reason
+	}
+	return fmt.Sprintf("%s: %v", f.reason, f.err)
+}
+
+// loadContent 解码并解析源内容，判断是否为已知格式
+// 内容无法解析时返回失败原因（应写入死信表）；格式未知时返回 false 且失败原因为 nil（正常跳过）
+func (s *MigrationService) loadContent(content *model.VerifyContent, contentJSON []byte) (bool, *rowFailure) {
+	// contentJSON 必须有效且可解析
+	if contentJSON == nil {
+		return false, &rowFailure{reason: "content 为 NULL"}
+	}
+	// 只解析一次，解析结果保存在 Content.Data 中供 ProcessContent 复用；
+	// 旧数据中部分行是 GBK 等非 UTF-8 编码，解析前统一转换为 UTF-8
+	var hash *model.HashOptions
+	if !s.opts.SkipContentHash {
+		hash = &model.HashOptions{NFC: s.opts.ContentHashNFC}
+	}
+	if err := ParseSourceContent(content, contentJSON, s.opts.SourceEncoding, hash, s.processor.JSONLimits()); err != nil {
+		var encodingErr *contentEncodingError
+		switch {
+		case errors.As(err, &encodingErr):
+			return false, &rowFailure{reason: "content 编码无法识别", err: err}
+		case errors.Is(err, model.ErrJSONTooLarge) || errors.Is(err, model.ErrJSONTooDeep):
+			return false, &rowFailure{reason: "content 超出 JSON 限制", err: err}
+		}
+		// 能提取出正文字段的由 ProcessContent 按截断的 JSON 处理
+		if s.processor.opts.SalvageTruncated {
+			if _, ok := salvageTruncated(content.Content.Raw); ok {
+				return true, nil
+			}
+		}
+		return false, &rowFailure{reason: "content 不是合法 JSON", err: err}
+	}
+	raw := content.Content.Data
+
+	dataIface, ok := raw["data"]
+	if !ok {
+		return false, &rowFailure{reason: "JSON 中没有 data 字段"}
+	}
+
+	data, ok := dataIface.(map[string]interface{})
+	if !ok {
+		return false, &rowFailure{reason: "data 字段不是 map 类型"}
+	}
+
+	if !s.isKnownFormat(content.ID, data) {
+		s.debugRow("不符合任何已知格式", "文章 ID %d: 不符合任何已知格式，跳过", content.ID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// isKnownFormat 检查 data 是否为旧格式（checkresultjson 不为空）或新格式（checklist 不为空）
+func (s *MigrationService) isKnownFormat(id uint, data map[string]interface{}) bool {
+	isOldFormat := false
+	isNewFormat := false
+
+	// 旧格式：checkresultjson 不为空
+	if _, hasCheckResultStr := data["checkresultstr"]; hasCheckResultStr {
+		if checkResultJSONRaw, hasCheckResultJSON := data["checkresultjson"]; hasCheckResultJSON {
+			switch v := checkResultJSONRaw.(type) {
+			case string:
+				var arr []interface{}
+				if err := json.Unmarshal([]byte(v), &arr); err == nil && len(arr) > 0 {
+					isOldFormat = true
+				} else {
+					s.debugRow("checkresultjson 字符串为空或解析失败", "文章 ID %d: checkresultjson 字符串为空或解析失败", id)
+				}
+			case []interface{}:
+				if len(v) > 0 {
+					isOldFormat = true
+				} else {
+					s.debugRow("checkresultjson 数组为空", "文章 ID %d: checkresultjson 数组为空", id)
+				}
+			default:
+				s.debugRow("checkresultjson 类型未知", "文章 ID %d: checkresultjson 类型未知，跳过", id)
+			}
+		} else {
+			s.debugRow("缺少 checkresultjson 字段", "文章 ID %d: 缺少 checkresultjson 字段", id)
+		}
+	}
+
+	// 新格式：checklist 不为空
+	if _, hasReplaceText := data["replace_text"]; hasReplaceText {
+		if checklistRaw, hasChecklist := data["checklist"]; hasChecklist {
+			switch v := checklistRaw.(type) {
+			case string:
+				var arr []interface{}
+				if err := json.Unmarshal([]byte(v), &arr); err == nil && len(arr) > 0 {
+					isNewFormat = true
+				} else {
+					s.debugRow("checklist 字符串为空或解析失败", "文章 ID %d: checklist 字符串为空或解析失败", id)
+				}
+			case []interface{}:
+				if len(v) > 0 {
+					isNewFormat = true
+				} else {
+					s.debugRow("checklist 数组为空", "文章 ID %d: checklist 数组为空", id)
+				}
+			default:
+				s.debugRow("checklist 类型未知", "文章 ID %d: checklist 类型未知，跳过", id)
+			}
+		} else {
+			s.debugRow("缺少 checklist 字段", "文章 ID %d: 缺少 checklist 字段", id)
+		}
+	}
+
+	return isOldFormat || isNewFormat
+}
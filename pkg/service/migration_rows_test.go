@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"content-verify-log/pkg/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// sourceColumns buildSourceQuery 返回的列
+var sourceColumns = []string{"id", "taskId", "content", "created_at", "updated_at", "deleted_at"}
+
+// newSourceMock 返回 sqlmock 连接，测试结束时检查期望的查询都已执行
+func newSourceMock(t *testing.T) (*dbSource, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	return &dbSource{db: db}, mock
+}
+
+// scannedRow scan 回调收到的一行
+type scannedRow struct {
+	id      uint
+	taskID  string
+	content []byte
+	deleted bool
+	err     error
+}
+
+func scanAll(t *testing.T, src *dbSource, limit, offset int) ([]scannedRow, error) {
+	t.Helper()
+	var got []scannedRow
+	err := src.scan(context.Background(), limit, offset, func(content *model.VerifyContent, contentJSON []byte, err error) {
+		if err != nil {
+			got = append(got, scannedRow{err: err})
+			return
+		}
+		got = append(got, scannedRow{id: content.ID, taskID: content.TaskID, content: contentJSON, deleted: content.DeletedAt.Valid})
+	})
+	return got, err
+}
+
+// TestDBSourceScan 按列顺序扫描源表的行：NULL 的 taskId 为空，NULL 的 content 为 nil，软删除的行照常读取
+func TestDBSourceScan(t *testing.T) {
+	src, mock := newSourceMock(t)
+	src.taskIDs = []string{"t1"}
+	deleted := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	query, _ := buildSourceQuery(src.taskIDs, nil, false)
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("t1", 2).
+		WillReturnRows(sqlmock.NewRows(sourceColumns).
+			AddRow(1, "t1", []byte(`{"data":{}}`), nil, nil, nil).
+			AddRow(2, nil, nil, nil, nil, deleted))
+
+	got, err := scanAll(t, src, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("应读取 2 行，得到 %d", len(got))
+	}
+	if got[0].id != 1 || got[0].taskID != "t1" || string(got[0].content) != `{"data":{}}` || got[0].deleted {
+		t.Errorf("第 1 行: %+v", got[0])
+	}
+	if got[1].id != 2 || got[1].taskID != "" || got[1].content != nil || !got[1].deleted {
+		t.Errorf("第 2 行: %+v", got[1])
+	}
+}
+
+// TestDBSourceScanRowError 无法扫描的行以 err 回调，计为扫描失败，不中断读取；
+// 游标仍推进到该行，下一批不会重复读取。连 ID 都无法扫描时返回错误
+func TestDBSourceScanRowError(t *testing.T) {
+	src, mock := newSourceMock(t)
+	mock.ExpectQuery("FROM tbl_verify_content").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(sourceColumns).
+			AddRow(1, "t1", []byte(`{}`), nil, nil, nil).
+			AddRow(2, "t1", []byte(`{}`), "not a time", nil, nil))
+	mock.ExpectQuery("id > ?").
+		WithArgs(2, 2).
+		WillReturnRows(sqlmock.NewRows(sourceColumns).
+			AddRow("not a number", "t1", []byte(`{}`), nil, nil, nil))
+
+	got, err := scanAll(t, src, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].err != nil || got[0].id != 1 || got[1].err == nil {
+		t.Fatalf("第 1 行应正常、第 2 行扫描失败，得到 %+v", got)
+	}
+	if _, err := scanAll(t, src, 2, 2); err == nil {
+		t.Fatal("无法扫描 ID 时应返回错误")
+	}
+}
+
+// sourceRows 返回 ID 从 first 到 last 的已知格式的源数据行
+func sourceRows(first, last int) *sqlmock.Rows {
+	rows := sqlmock.NewRows(sourceColumns)
+	for id := first; id <= last; id++ {
+		rows.AddRow(id, "t1", []byte(`{"data":{"replace_text":"错字","checklist":[{"errword":"错","corword":"对","position":0,"length":1}]}}`), nil, nil, nil)
+	}
+	return rows
+}
+
+// recordingSource 记录读取到的行的 ID
+type recordingSource struct {
+	contentSource
+	ids []string
+}
+
+func (r *recordingSource) scan(ctx context.Context, limit, offset int, fn func(content *model.VerifyContent, contentJSON []byte, err error)) error {
+	return r.contentSource.scan(ctx, limit, offset, func(content *model.VerifyContent, contentJSON []byte, err error) {
+		if content != nil {
+			r.ids = append(r.ids, fmt.Sprint(content.ID))
+		}
+		fn(content, contentJSON, err)
+	})
+}
+
+// TestDBSourceStream 通过流水线分批读取源表：第一批不带游标，之后每批按上一批最后的 ID 读取，不使用 OFFSET；
+// 读到第四批时出错，流水线停止并原样返回该错误
+func TestDBSourceStream(t *testing.T) {
+	db, mock := newSourceMock(t)
+	mock.ExpectQuery(`ORDER BY id\s+LIMIT \?$`).WithArgs(2).WillReturnRows(sourceRows(1, 2))
+	mock.ExpectQuery(`WHERE id > \?`).WithArgs(2, 2).WillReturnRows(sourceRows(3, 4))
+	mock.ExpectQuery(`WHERE id > \?`).WithArgs(4, 2).WillReturnRows(sourceRows(5, 6))
+	mock.ExpectQuery(`WHERE id > \?`).WithArgs(6, 2).WillReturnRows(sourceRows(7, 8).RowError(1, errors.New("connection reset")))
+
+	src := &recordingSource{contentSource: db}
+	s := NewMigrationService(MigrationOptions{QueueDepth: 1})
+	err := s.runPipeline(context.Background(), src, newBatchSizer(2, 0), &queueStats{}, func(*processedBatch) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("应返回读取错误，得到 %v", err)
+	}
+	if want := "1 2 3 4 5 6 7"; strings.Join(src.ids, " ") != want {
+		t.Errorf("读取的记录应为 %s，得到 %v", want, src.ids)
+	}
+}
+
+// TestDBSourceScanCancelled 查询中途取消时返回 ctx.Err()，由调用方按中断处理
+func TestDBSourceScanCancelled(t *testing.T) {
+	src, mock := newSourceMock(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	mock.ExpectQuery("FROM tbl_verify_content").WillReturnRows(sourceRows(1, 2)).WillDelayFor(time.Second)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	err := src.scan(ctx, 2, 0, func(*model.VerifyContent, []byte, error) {})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("应返回 context.Canceled，得到 %v", err)
+	}
+}
+
+// TestDBSourceScanOrder 只支持顺序读取，offset 为 0 时从头读取
+func TestDBSourceScanOrder(t *testing.T) {
+	src, mock := newSourceMock(t)
+	mock.ExpectQuery("FROM tbl_verify_content").WithArgs(2).WillReturnRows(sourceRows(1, 2))
+	mock.ExpectQuery(`ORDER BY id\s+LIMIT \?$`).WithArgs(2).WillReturnRows(sourceRows(1, 2))
+
+	if _, err := scanAll(t, src, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanAll(t, src, 2, 5); err == nil {
+		t.Fatal("跳跃的 offset 应返回错误")
+	}
+	if got, err := scanAll(t, src, 2, 0); err != nil || len(got) != 2 || got[0].id != 1 {
+		t.Fatalf("offset 为 0 时应从头读取，得到 %+v, %v", got, err)
+	}
+}
+
+// TestDBSourceCount 统计使用与读取相同的过滤条件
+func TestDBSourceCount(t *testing.T) {
+	src, mock := newSourceMock(t)
+	src.taskIDs, src.excludeTaskIDs = []string{"a", "b"}, []string{"c"}
+	query, _ := buildSourceCountQuery(src.taskIDs, src.excludeTaskIDs)
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("a", "b", "c").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	total, err := src.count(context.Background())
+	if err != nil || total != 42 {
+		t.Fatalf("应得到 42，得到 %d, %v", total, err)
+	}
+}
+
+// openMemoryDB 返回内存中的 duckdb 连接，测试结束时关闭
+func openMemoryDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// openSourceDB 返回内存中的 duckdb 连接，源表的结构与 genfixtures 生成的一致，时间列为字符串
+func openSourceDB(t *testing.T, rows [][]interface{}) *sql.DB {
+	t.Helper()
+	db := openMemoryDB(t)
+	if _, err := db.Exec(`CREATE TABLE ` + sourceTable + ` (
+	id BIGINT PRIMARY KEY,
+	taskId VARCHAR,
+	content VARCHAR,
+	created_at VARCHAR,
+	updated_at VARCHAR,
+	deleted_at VARCHAR
+)`); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if _, err := db.Exec("INSERT INTO "+sourceTable+" VALUES (?, ?, ?, ?, ?, ?)", row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// TestDBSourceTimestamps 源表中为 NULL 或无法解析的时间经 TRY_STRPTIME 读取为 NULL，
+// 处理结果中对应的时间为 nil，写入目标表后为 NULL 而不是 0001-01-01
+func TestDBSourceTimestamps(t *testing.T) {
+	const content = `{"data":{}}`
+	db := openSourceDB(t, [][]interface{}{
+		{1, "t1", content, "02/01/2024 03:04:05.000000", "03/01/2024 04:05:06.500000", nil},
+		{2, "t1", content, nil, nil, nil},
+		{3, "t1", content, "2024-01-02", "", "not a date"},
+		{4, "t1", content, "31/02/2024 00:00:00.000000", "02/01/2024 03:04:05", "02/01/2024 03:04:05.000000"},
+	})
+	src := &dbSource{db: db}
+	var contents []*model.VerifyContent
+	err := src.scan(context.Background(), 10, 0, func(content *model.VerifyContent, _ []byte, err error) {
+		if err != nil {
+			t.Fatalf("扫描失败: %v", err)
+		}
+		contents = append(contents, content)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 4 {
+		t.Fatalf("应读取 4 行，得到 %d", len(contents))
+	}
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := contents[0].CreatedAt; !got.Equal(created) {
+		t.Errorf("第 1 行 created_at: %v", got)
+	}
+	if got := contents[0].UpdatedAt; !got.Equal(time.Date(2024, 1, 3, 4, 5, 6, 500000000, time.UTC)) {
+		t.Errorf("第 1 行 updated_at: %v", got)
+	}
+	if contents[0].DeletedAt.Valid {
+		t.Errorf("第 1 行 deleted_at 应为 NULL: %v", contents[0].DeletedAt)
+	}
+	for _, c := range contents[1:3] {
+		if !c.CreatedAt.IsZero() || !c.UpdatedAt.IsZero() || c.DeletedAt.Valid {
+			t.Errorf("第 %d 行的时间应都为 NULL: %v %v %v", c.ID, c.CreatedAt, c.UpdatedAt, c.DeletedAt)
+		}
+	}
+	// 日期不存在、缺少小数秒都无法解析；格式正确的 deleted_at 照常读取
+	if c := contents[3]; !c.CreatedAt.IsZero() || !c.UpdatedAt.IsZero() || !c.DeletedAt.Valid || !c.DeletedAt.Time.Equal(created) {
+		t.Errorf("第 4 行: %v %v %v", c.CreatedAt, c.UpdatedAt, c.DeletedAt)
+	}
+
+	p := NewContentProcessor()
+	if _, err := db.Exec(buildCreateTableSQL("out")); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range contents {
+		processed := p.ProcessContent(c)
+		processed.ID = fmt.Sprint(c.ID) // 内容中没有 id，目标表的主键用源表 ID 代替
+		if (processed.SourceCreatedAt == nil) != c.CreatedAt.IsZero() || (processed.SourceUpdatedAt == nil) != c.UpdatedAt.IsZero() {
+			t.Errorf("第 %d 行的源时间应仅在源表为 NULL 时为 nil: %v %v", c.ID, processed.SourceCreatedAt, processed.SourceUpdatedAt)
+		}
+		values, err := processedValues(processed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(buildInsertSQL("out"), values...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var nullCreated, nullUpdated, zero int
+	if err := db.QueryRow(`SELECT
+	COUNT(*) FILTER (WHERE source_created_at IS NULL),
+	COUNT(*) FILTER (WHERE source_updated_at IS NULL),
+	COUNT(*) FILTER (WHERE year(source_created_at) = 1 OR year(source_updated_at) = 1)
+	FROM out`).Scan(&nullCreated, &nullUpdated, &zero); err != nil {
+		t.Fatal(err)
+	}
+	if nullCreated != 3 || nullUpdated != 3 || zero != 0 {
+		t.Errorf("目标表中应有 3 行 source_created_at、3 行 source_updated_at 为 NULL，且没有零时间，得到 %d %d %d", nullCreated, nullUpdated, zero)
+	}
+}
+
+// TestLoadContentJSONLimits 超出 JSON 限制的源内容不解析，作为失败行写入死信并说明原因
+func TestLoadContentJSONLimits(t *testing.T) {
+	s := NewMigrationService(MigrationOptions{Processor: ProcessorOptions{JSONLimits: model.JSONLimits{MaxSize: 64, MaxDepth: 4}}})
+	for name, raw := range map[string]string{
+		"too large": `{"data":{"replace_text":"` + strings.Repeat("字", 64) + `","checklist":[]}}`,
+		"too deep":  `{"data":{"checklist":[[[{"a":1}]]]}}`,
+	} {
+		ok, failure := s.loadContent(&model.VerifyContent{ID: 1}, []byte(raw))
+		if ok || failure == nil || failure.reason != "content 超出 JSON 限制" {
+			t.Errorf("%s: ok=%v failure=%v", name, ok, failure)
+		}
+	}
+	if ok, failure := s.loadContent(&model.VerifyContent{ID: 1}, []byte(`{"data":{"replace_text":"正文","checklist":[]}}`)); failure != nil {
+		t.Errorf("未超出限制: ok=%v failure=%v", ok, failure)
+	}
+}
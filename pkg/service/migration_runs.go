@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"content-verify-log/pkg/util"
+
+	"go.uber.org/zap"
+)
+
+// migrationRunsTable 记录每次迁移的运行日志，用于审计何时处理了哪些数据
+const migrationRunsTable = "migration_runs"
+
+// buildCreateMigrationRunsSQL 构造创建运行日志表的语句，表已存在时保留历史记录
+func buildCreateMigrationRunsSQL() string {
+	return `CREATE TABLE IF NOT EXISTS ` + migrationRunsTable + ` (
+	run_id TEXT PRIMARY KEY,
+	started_at TIMESTAMP,
+	finished_at TIMESTAMP,
+	source_filter TEXT,
+	processed BIGINT,
+	errors BIGINT,
+	skipped BIGINT,
+	tool_version TEXT,
+	settings TEXT,
+	status TEXT,
+	error TEXT
+)`
+}
+
+// migrationRunsAddedColumns 建表后新增的列，旧版本创建的表写入前补齐
+var migrationRunsAddedColumns = []columnDef{
+	{Name: "settings", Type: "TEXT"},
+	{Name: "status", Type: "TEXT"},
+	{Name: "error", Type: "TEXT"},
+}
+
+// 运行日志中一次迁移的结束状态
+const (
+	runStatusSucceeded   = "succeeded"   // 成功完成并替换了目标表
+	runStatusFailed      = "failed"      // 出错结束，目标表保持不变
+	runStatusInterrupted = "interrupted" // 被取消（SIGTERM/Ctrl+C），目标表保持不变
+	runStatusAborted     = "aborted"     // 失败行的比例超过 MaxErrorRate 而中止，目标表保持不变
+)
+
+// buildAlterMigrationRunsSQL 构造为旧版本创建的运行日志表补齐新增列的语句
+func buildAlterMigrationRunsSQL() []string {
+	stmts := make([]string, len(migrationRunsAddedColumns))
+	for i, col := range migrationRunsAddedColumns {
+		stmts[i] = fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", migrationRunsTable, col.Name, col.Type)
+	}
+	return stmts
+}
+
+// buildInsertMigrationRunSQL 构造写入一次运行记录的语句
+func buildInsertMigrationRunSQL() string {
+	return "INSERT INTO " + migrationRunsTable + " (run_id, started_at, finished_at, source_filter, processed, errors, skipped, tool_version, settings, status, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+}
+
+// sourceFilter 返回本次迁移的源数据范围，格式与清单的 source_filter 相同（包含和排除的任务 ID），迁移全部任务时为 NULL
+func sourceFilter(taskIDs, excludeTaskIDs []string) (sql.NullString, error) {
+	if len(taskIDs) == 0 && len(excludeTaskIDs) == 0 {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(ManifestFilter{TaskIDs: taskIDs, ExcludeTaskIDs: excludeTaskIDs})
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// recordRun 在运行日志表中写入本次迁移的记录，runErr 为迁移返回的错误，成功时为 nil；
+// 失败或中断时同样记录已完成部分的统计。写入失败只输出警告，不影响迁移的结果
+func (s *MigrationService) recordRun(ctx context.Context, duckDB *sql.DB, stats *migrationStats, runErr error) {
+	status := runStatusSucceeded
+	if runErr != nil {
+		switch {
+		case ctx.Err() != nil:
+			status = runStatusInterrupted
+		case errors.Is(runErr, ErrAborted):
+			status = runStatusAborted
+		default:
+			status = runStatusFailed
+		}
+	}
+	// 中断时 ctx 已取消，使用不可取消的上下文写入
+	if err := s.insertMigrationRun(context.WithoutCancel(ctx), duckDB, stats, status, runErr); err != nil {
+		zap.S().Warnf("写入运行日志表 %s 失败: %v", migrationRunsTable, err)
+	}
+}
+
+// insertMigrationRun 确保运行日志表存在（补齐新增列）并写入一条记录
+func (s *MigrationService) insertMigrationRun(ctx context.Context, duckDB *sql.DB, stats *migrationStats, status string, runErr error) error {
+	if _, err := duckDB.ExecContext(ctx, buildCreateMigrationRunsSQL()); err != nil {
+		return fmt.Errorf("创建运行日志表失败: %v", err)
+	}
+	for _, stmt := range buildAlterMigrationRunsSQL() {
+		if _, err := duckDB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("补齐运行日志表的列失败: %v", err)
+		}
+	}
+	filter, err := sourceFilter(s.opts.TaskIDs, s.opts.ExcludeTaskIDs)
+	if err != nil {
+		return fmt.Errorf("序列化任务 ID 失败: %v", err)
+	}
+	_, err = duckDB.ExecContext(ctx, buildInsertMigrationRunSQL(),
+		stats.runID,
+		stats.startTime,
+		time.Now(),
+		filter,
+		stats.processed,
+		stats.errors,
+		stats.skipped,
+		util.GetVersion().Version,
+		nullString(s.opts.Settings),
+		status,
+		errorString(runErr),
+	)
+	if err != nil {
+		return err
+	}
+	zap.S().Debugf("已写入运行日志 %s", stats.runID)
+	return nil
+}
+
+// errorString 返回错误信息，err 为 nil 时为 NULL
+func errorString(err error) sql.NullString {
+	if err == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: err.Error(), Valid: true}
+}
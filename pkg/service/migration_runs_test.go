@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// runsSourceContent 迁移运行日志测试使用的源内容，有一处可应用的修正
+const runsSourceContent = `{"data":{"replace_text":"公圆","checklist":[{"word":"公圆","position":0,"length":2,"suggest":["公园"]}]}}`
+
+// TestMigrationRunSettings 生效的配置写入 settings 列，未设置时为 NULL；旧版本创建的表写入前补齐该列
+func TestMigrationRunSettings(t *testing.T) {
+	ctx := context.Background()
+	db := openSourceDB(t, [][]interface{}{{1, "t1", runsSourceContent, nil, nil, nil}})
+	// 旧版本创建的运行日志表没有 settings 列
+	if _, err := db.Exec(`CREATE TABLE ` + migrationRunsTable + ` (
+	run_id TEXT PRIMARY KEY,
+	started_at TIMESTAMP,
+	finished_at TIMESTAMP,
+	source_filter TEXT,
+	processed BIGINT,
+	errors BIGINT,
+	skipped BIGINT,
+	tool_version TEXT
+)`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, settings := range []string{`{"batchSize":2,"workers":1}`, ""} {
+		s := NewMigrationServiceWithDB(MigrationOptions{Settings: settings}, db, db)
+		if err := s.MigrateToDuckDB(ctx, 2); err != nil {
+			t.Fatal(err)
+		}
+		var got sql.NullString
+		if err := db.QueryRow("SELECT settings FROM "+migrationRunsTable+" WHERE run_id = ?", s.RunSummary().RunID).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != nullString(settings) {
+			t.Errorf("settings 为 %+v，应为 %q", got, settings)
+		}
+	}
+}
+
+// TestMigrationRunStatus 成功、失败和中断的迁移都写入运行日志，记录结束状态和错误
+func TestMigrationRunStatus(t *testing.T) {
+	db := openSourceDB(t, [][]interface{}{{1, "t1", runsSourceContent, nil, nil, nil}})
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		noTable bool // 源库中没有源表，读取失败
+		status  string
+	}{
+		{name: "succeeded", ctx: context.Background(), status: runStatusSucceeded},
+		{name: "failed", ctx: context.Background(), noTable: true, status: runStatusFailed},
+		{name: "interrupted", ctx: cancelled, status: runStatusInterrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := db
+			if tt.noTable {
+				source = openMemoryDB(t)
+			}
+			s := NewMigrationServiceWithDB(MigrationOptions{}, source, db)
+			runErr := s.MigrateToDuckDB(tt.ctx, 2)
+			if (runErr != nil) != (tt.status != runStatusSucceeded) {
+				t.Fatalf("迁移返回 %v", runErr)
+			}
+			var status string
+			var errText sql.NullString
+			var processed int64
+			if err := db.QueryRow("SELECT status, error, processed FROM "+migrationRunsTable+" WHERE run_id = ?", s.RunSummary().RunID).Scan(&status, &errText, &processed); err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.status {
+				t.Errorf("status 为 %q，应为 %q", status, tt.status)
+			}
+			if runErr == nil && (errText.Valid || processed != 1) {
+				t.Errorf("成功时 error 应为 NULL、processed 为 1，得到 %+v %d", errText, processed)
+			}
+			if runErr != nil && errText.String != runErr.Error() {
+				t.Errorf("error 为 %+v，应为 %q", errText, runErr)
+			}
+		})
+	}
+}
+
+// TestMigrationRunSourceFilter source_filter 同时记录包含和排除的任务，迁移全部任务时为 NULL
+func TestMigrationRunSourceFilter(t *testing.T) {
+	db := openSourceDB(t, [][]interface{}{{1, "t1", runsSourceContent, nil, nil, nil}})
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    sql.NullString
+	}{
+		{name: "all"},
+		{name: "include", include: []string{"t1"}, want: nullString(`{"task_ids":["t1"]}`)},
+		{name: "exclude", exclude: []string{"x1", "x2"}, want: nullString(`{"exclude_task_ids":["x1","x2"]}`)},
+		{name: "both", include: []string{"t1"}, exclude: []string{"x1"}, want: nullString(`{"task_ids":["t1"],"exclude_task_ids":["x1"]}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMigrationServiceWithDB(MigrationOptions{TaskIDs: tt.include, ExcludeTaskIDs: tt.exclude}, db, db)
+			if err := s.MigrateToDuckDB(context.Background(), 2); err != nil {
+				t.Fatal(err)
+			}
+			var got sql.NullString
+			if err := db.QueryRow("SELECT source_filter FROM "+migrationRunsTable+" WHERE run_id = ?", s.RunSummary().RunID).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("source_filter 为 %+v，应为 %+v", got, tt.want)
+			}
+		})
+	}
+}
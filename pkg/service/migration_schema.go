@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// duckDBTypeAliases 列定义中的类型别名，information_schema 中按 DuckDB 的规范名称返回
+var duckDBTypeAliases = map[string]string{
+	"TEXT":   "VARCHAR",
+	"STRING": "VARCHAR",
+	"INT":    "INTEGER",
+	"BOOL":   "BOOLEAN",
+}
+
+// ErrTableNotFound 读取的表不存在，可用 errors.Is 判断
+var ErrTableNotFound = errors.New("表不存在")
+
+// requireTable 检查只读命令要读取的表是否存在，不存在时返回包装 ErrTableNotFound 的错误，
+// 说明期望的表名和可能的原因，而不是在查询时得到 DuckDB 的 Catalog Error
+func requireTable(ctx context.Context, duckDB *sql.DB, table string) error {
+	var count int
+	err := duckDB.QueryRowContext(ctx,
+		`SELECT count(*) FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name = ?`, table).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("查询表 %s 是否存在失败: %v", table, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: DuckDB 中没有表 %s，请检查表名（--table 或 migration.targetTable）是否正确，或先执行 migrate 生成该表", ErrTableNotFound, table)
+	}
+	return nil
+}
+
+// canonicalType 返回列类型（去掉 PRIMARY KEY 等约束）的规范名称
+func canonicalType(colType string) string {
+	name := strings.ToUpper(strings.Fields(colType)[0])
+	if alias, ok := duckDBTypeAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// ensureDuckDBTable 目标表不存在时创建；已存在时保留其中的记录，并校验列与当前版本一致
+func ensureDuckDBTable(ctx context.Context, duckDB *sql.DB, table string) error {
+	if _, err := duckDB.ExecContext(ctx, buildCreateTableIfNotExistsSQL(table)); err != nil {
+		return fmt.Errorf("创建表失败: %v", err)
+	}
+	return checkTableSchema(ctx, duckDB, table)
+}
+
+// checkTableSchema 校验已有表的列名、顺序和类型与 processedColumns 一致，允许多出设置运行标签时追加的 run_label 列
+// 旧版本创建的表列不同时直接插入只会得到难以理解的绑定错误，这里给出具体差异
+func checkTableSchema(ctx context.Context, duckDB *sql.DB, table string) error {
+	rows, err := duckDB.QueryContext(ctx,
+		`SELECT column_name, data_type FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ?
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return fmt.Errorf("读取表 %s 的列失败: %v", table, err)
+	}
+	defer rows.Close()
+
+	actual := make(map[string]string)
+	var order []string
+	for rows.Next() {
+		var name, dataType string
+		if err := rows.Scan(&name, &dataType); err != nil {
+			return fmt.Errorf("读取表 %s 的列失败: %v", table, err)
+		}
+		actual[name] = strings.ToUpper(dataType)
+		order = append(order, name)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("读取表 %s 的列失败: %v", table, err)
+	}
+
+	var problems []string
+	expected := make(map[string]bool, len(processedColumns))
+	for _, col := range processedColumns {
+		expected[col.Name] = true
+		dataType, ok := actual[col.Name]
+		if !ok {
+			problems = append(problems, "缺少列 "+col.Name)
+			continue
+		}
+		if want := canonicalType(col.Type); dataType != want {
+			problems = append(problems, fmt.Sprintf("列 %s 类型为 %s，应为 %s", col.Name, dataType, want))
+		}
+	}
+	for _, name := range order {
+		switch {
+		case expected[name]:
+		case name == runLabelColumn.Name:
+			// 设置了运行标签的迁移追加的列，写入时不指定该列，值为 NULL
+			if want := canonicalType(runLabelColumn.Type); actual[name] != want {
+				problems = append(problems, fmt.Sprintf("列 %s 类型为 %s，应为 %s", name, actual[name], want))
+			}
+		default:
+			problems = append(problems, "多出列 "+name)
+		}
+	}
+	// 插入语句按列名指定，顺序不同不影响写入，只在列完全一致时提示
+	if len(problems) == 0 && len(order) == len(processedColumns) {
+		for i, col := range processedColumns {
+			if order[i] != col.Name {
+				zap.S().Debugf("表 %s 的列顺序与当前版本不同（第 %d 列为 %s，应为 %s）", table, i+1, order[i], col.Name)
+				break
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("表 %s 是旧版本创建的，列与当前版本不一致: %s；请先执行完整的 migrate 重建该表（或用 --table 指定新表名）",
+			table, strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestEnsureDuckDBTable 目标表不存在时创建；已存在且列一致时保留记录；旧版本创建的表列不同时报出具体差异
+func TestEnsureDuckDBTable(t *testing.T) {
+	ctx := context.Background()
+	db := openMemoryDB(t)
+	if err := ensureDuckDBTable(ctx, db, "processed_content"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO processed_content (id) VALUES ('a')"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureDuckDBTable(ctx, db, "processed_content"); err != nil {
+		t.Fatalf("列一致的已有表应通过校验: %v", err)
+	}
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM processed_content").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("应保留已有记录，得到 %d, %v", n, err)
+	}
+	for _, stmt := range buildAddRunLabelSQL("processed_content", "v1") {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ensureDuckDBTable(ctx, db, "processed_content"); err != nil {
+		t.Fatalf("追加了 run_label 列的表应通过校验: %v", err)
+	}
+
+	// 旧版本的表：少了 content_hash，has_errors 类型不同，多出 legacy 列
+	if _, err := db.Exec(buildCreateTableSQL("old_content")); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"ALTER TABLE old_content DROP COLUMN content_hash",
+		"ALTER TABLE old_content ALTER COLUMN has_errors TYPE INTEGER",
+		"ALTER TABLE old_content ADD COLUMN legacy VARCHAR",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := ensureDuckDBTable(ctx, db, "old_content")
+	if err == nil {
+		t.Fatal("列不一致的已有表应返回错误")
+	}
+	for _, want := range []string{"缺少列 content_hash", "列 has_errors 类型为 INTEGER，应为 BOOLEAN", "多出列 legacy", "--table"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误中应包含 %q: %v", want, err)
+		}
+	}
+}
+
+// TestRequireTable 表不存在时返回包装 ErrTableNotFound 的错误
+func TestRequireTable(t *testing.T) {
+	ctx := context.Background()
+	db := openMemoryDB(t)
+	if err := requireTable(ctx, db, "processed_content"); !errors.Is(err, ErrTableNotFound) {
+		t.Fatalf("应返回 ErrTableNotFound，得到 %v", err)
+	}
+	if _, err := db.Exec(buildCreateTableSQL("processed_content")); err != nil {
+		t.Fatal(err)
+	}
+	if err := requireTable(ctx, db, "processed_content"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestReadMissingTable 读取命令的目标表不存在（如表名写错）时返回 ErrTableNotFound，错误中说明期望的表名并提示先执行 migrate
+func TestReadMissingTable(t *testing.T) {
+	ctx := context.Background()
+	db := openMemoryDB(t)
+	if _, err := db.Exec(buildCreateTableSQL(defaultTargetTable)); err != nil {
+		t.Fatal(err)
+	}
+
+	reads := map[string]func() error{
+		"count": func() error {
+			_, err := NewMigrationServiceWithDB(MigrationOptions{TargetTable: "processed_content_tset"}, nil, db).GetProcessedContentCount(ctx)
+			return err
+		},
+		"export count": func() error {
+			_, err := NewExportServiceWithDB(ExportOptions{}, db).Count(ctx, "processed_content_tset")
+			return err
+		},
+		"suspicious": func() error {
+			_, err := ListSuspiciousDocuments(ctx, db, "processed_content_tset", 0.5)
+			return err
+		},
+	}
+	for name, read := range reads {
+		err := read()
+		if !errors.Is(err, ErrTableNotFound) {
+			t.Errorf("%s: 应返回 ErrTableNotFound，得到 %v", name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), "processed_content_tset") || !strings.Contains(err.Error(), "migrate") {
+			t.Errorf("%s: 错误中应说明表名并提示执行 migrate: %v", name, err)
+		}
+	}
+
+	if n, err := NewMigrationServiceWithDB(MigrationOptions{}, nil, db).GetProcessedContentCount(ctx); err != nil || n != 0 {
+		t.Errorf("表存在时应正常读取，得到 %d, %v", n, err)
+	}
+}
@@ -3,274 +3,473 @@ package service
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/log"
 	"content-verify-log/pkg/model"
+	"content-verify-log/pkg/repository"
+	"content-verify-log/pkg/util"
 
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
 type MigrationService struct {
-	processor *ContentProcessor
+	processor   *ContentProcessor
+	opts        MigrationOptions
+	targetTable string
+
+	// 显式注入的源库和目标库，为 nil 时使用全局 DuckDB 连接
+	sourceDB *sql.DB
+	targetDB *sql.DB
+	// 由 Migrator 创建，源库或目标库为 nil 时也不使用全局连接
+	detached bool
+
+	// 逐行诊断日志的采样器，每次迁移重新创建
+	diagnostics *log.Sampler
+
+	// 最近一次迁移的统计，用于生成运行摘要
+	lastRun *migrationStats
+	// 最近一次成功迁移写入的表，用于生成清单
+	lastOutputs []sinkOutput
+
+	// 本次迁移的影子对比，未开启时为 nil
+	shadow *shadowCompare
 }
 
-func NewMigrationService() *MigrationService {
+// 逐行诊断日志的采样参数：每个原因先输出前 rowDiagnosticsFirst 次，之后每 rowDiagnosticsEvery 次输出一次，
+// 每隔 rowDiagnosticsInterval 输出一行各原因的次数
+const (
+	rowDiagnosticsFirst    = 20
+	rowDiagnosticsEvery    = 1000
+	rowDiagnosticsInterval = 30 * time.Second
+)
+
+func newRowDiagnostics() *log.Sampler {
+	return log.NewSampler(rowDiagnosticsFirst, rowDiagnosticsEvery, rowDiagnosticsInterval)
+}
+
+// MigrationOptions 迁移选项
+type MigrationOptions struct {
+	Processor       ProcessorOptions  // 内容处理选项
+	SkipContentHash bool              // 跳过源内容 SHA-256 计算
+	ContentHashNFC  bool              // 计算 content_hash 前做 Unicode NFC 规范化
+	OnlyErrors      bool              // 只写入实际应用了修正的记录（HasErrors 为 true）
+	TargetTable     string            // 目标表名，为空时使用默认表
+	RowDiagnostics  bool              // 输出逐行的跳过诊断日志
+	TaskIDs         []string          // 只迁移这些任务，为空表示全部
+	ExcludeTaskIDs  []string          // 不迁移这些任务，与 TaskIDs 同时设置时取两者的交集
+	Workers         int               // 并发处理的 worker 数，小于 1 时按 1 处理
+	MemoryBudget    int64             // 单批原文字节数上限，大于 0 时按文档大小自适应调整批量
+	QueueDepth      int               // 读取、处理、写入之间每个队列最多缓冲的批数，小于 1 时按 1 处理
+	IngestMode      string            // 写入方式 IngestInsert/IngestAppender/IngestCopy/IngestArrow，为空时逐行插入
+	DuplicateIDs    string            // 源数据中 ID 重复时的处理方式 DuplicateIDSkip/DuplicateIDUpsert，为空时跳过
+	ShardBy         string            // 分片方式，ShardByTask 时每个任务写入单独的 DuckDB 文件
+	ShardPath       string            // 分片文件路径模板，包含 {task} 占位符
+	PartitionBy     string            // 分区方式，PartitionByMonth 时按源记录创建时间的月份写入 <目标表>_YYYYMM
+	SourceEncoding  string            // 源 content 的编码，非 UTF-8 的行按该编码转换，为空或 UTF-8 时按 GB18030 尝试
+	RunLabel        string            // 运行标签，非空时目标表追加 run_label 列，所有行的值为该标签；为空时没有该列
+	TextDir         string            // 非空时不写目标表，每篇文章写一个 <id>.txt 到该目录；死信表和运行日志仍写入目标库
+	TextOriginal    bool              // 文本输出写原文而不是修改后的文章
+	TextOpenFiles   int               // 文本输出时同时打开的文件数上限，小于 1 时为 DefaultTextOpenFiles
+	SourceDir       string            // 从该目录下的 JSON 文件而不是源表读取，为空时读取源表
+	SourceGlob      string            // SourceDir 中参与迁移的文件名模式，为空时为 DefaultSourceGlob
+	Shadow          *ProcessorOptions // 影子处理器的选项，设置后迁移时用它处理同一批输入并与写入的结果逐字段比较，为 nil 时不对比
+	Progress        ProgressReporter  // 迁移进度的接收者，为 nil 时不报告进度
+	MaxErrorRate    float64           // 失败行占读取行数的比例上限，超过时以 ErrAborted 中止迁移、不替换目标表，0 表示不检查
+	Settings        string            // 生效的迁移配置（JSON，已隐藏密钥引用的值），写入 migration_runs 的 settings 列，为空时为 NULL
+}
+
+func NewMigrationService(opts MigrationOptions) *MigrationService {
+	return newMigrationService(opts, NewContentProcessorWithOptions(opts.Processor))
+}
+
+// newMigrationService 使用指定的内容处理器创建迁移服务，opts.Processor 不再使用
+func newMigrationService(opts MigrationOptions, processor *ContentProcessor) *MigrationService {
+	targetTable := opts.TargetTable
+	if targetTable == "" {
+		targetTable = defaultTargetTable
+	}
 	return &MigrationService{
-		processor: NewContentProcessor(),
+		processor:   processor,
+		opts:        opts,
+		targetTable: targetTable,
+		diagnostics: newRowDiagnostics(),
 	}
 }
 
-// MigrateToDuckDB 从 DuckDB 的 tbl_verify_content 表读取数据，处理后写入 processed_content 表
-func (s *MigrationService) MigrateToDuckDB(ctx context.Context, batchSize int) error {
-	// 创建目标 DuckDB 表
-	if err := s.createDuckDBTable(ctx); err != nil {
-		return fmt.Errorf("创建 DuckDB 表失败: %v", err)
+// NewMigrationServiceWithDB 使用指定的源库和目标库创建迁移服务，便于测试时传入内存数据库
+func NewMigrationServiceWithDB(opts MigrationOptions, sourceDB, targetDB *sql.DB) *MigrationService {
+	s := NewMigrationService(opts)
+	s.sourceDB = sourceDB
+	s.targetDB = targetDB
+	return s
+}
+
+// source 返回源库连接
+func (s *MigrationService) source(ctx context.Context) *sql.DB {
+	if s.sourceDB != nil || s.detached {
+		return s.sourceDB
 	}
+	return db.GetDuckDBWithContext(ctx)
+}
 
-	duckDB := db.GetDuckDBWithContext(ctx)
-	if duckDB == nil {
-		return fmt.Errorf("DuckDB 连接未初始化")
+// contentSource 返回本次迁移的源数据：设置了 SourceDir 时为目录中的文件，否则为源表
+func (s *MigrationService) contentSource(ctx context.Context) (contentSource, error) {
+	if s.opts.SourceDir != "" {
+		if len(s.opts.TaskIDs) > 0 || len(s.opts.ExcludeTaskIDs) > 0 {
+			return nil, fmt.Errorf("从目录迁移时不支持按任务过滤")
+		}
+		return newFileSource(s.opts.SourceDir, s.opts.SourceGlob)
+	}
+	sourceDB := s.source(ctx)
+	if sourceDB == nil {
+		return nil, fmt.Errorf("DuckDB 连接未初始化")
 	}
+	return &dbSource{db: sourceDB, taskIDs: s.opts.TaskIDs, excludeTaskIDs: s.opts.ExcludeTaskIDs}, nil
+}
 
-	startTime := time.Now()
-	offset := 0
-	processed := 0
-	errors := 0
+// target 返回目标库连接
+func (s *MigrationService) target(ctx context.Context) *sql.DB {
+	if s.targetDB != nil || s.detached {
+		return s.targetDB
+	}
+	return db.GetDuckDBWithContext(ctx)
+}
 
-	for {
-		// 批量查询
-		query := `SELECT id, taskId, content,
-			TRY_STRPTIME(created_at, '%%d/%%m/%%Y %%H:%%M:%%S.%%f') AS created_at,
-			TRY_STRPTIME(updated_at, '%%d/%%m/%%Y %%H:%%M:%%S.%%f') AS updated_at,
-			TRY_STRPTIME(deleted_at, '%%d/%%m/%%Y %%H:%%M:%%S.%%f') AS deleted_at
-			FROM tbl_verify_content
-			WHERE taskId = '430aa1b775c143e6bfcf1d5f78c115ce'
-			ORDER BY id
-			LIMIT ? OFFSET ?`
+// MigrateToDuckDB 从 DuckDB 的 tbl_verify_content 表（设置了 SourceDir 时为目录中的 JSON 文件）读取数据，处理后写入 processed_content 表
+func (s *MigrationService) MigrateToDuckDB(ctx context.Context, batchSize int) (err error) {
+	// batchSize 为 0 时 OFFSET 不前进，会无限读取空批次
+	if batchSize < 1 {
+		return fmt.Errorf("批量大小必须大于 0，当前为 %d", batchSize)
+	}
+	if err := s.validateIdentifiers(); err != nil {
+		return err
+	}
 
-		rows, err := duckDB.QueryContext(ctx, query, batchSize, offset)
-		if err != nil {
-			return fmt.Errorf("查询数据失败: %v", err)
-		}
+	src, err := s.contentSource(ctx)
+	if err != nil {
+		return err
+	}
+	targetDB := s.target(ctx)
+	if targetDB == nil {
+		return fmt.Errorf("DuckDB 连接未初始化")
+	}
+	stats := newMigrationStats()
+	s.lastRun = stats
+	s.lastOutputs = nil
+	s.shadow = nil
+	if s.opts.Shadow != nil {
+		s.shadow = newShadowCompare(*s.opts.Shadow)
+		stats.shadow = s.shadow
+	}
+	// 开始迁移后无论成功、失败还是中断都写入运行日志
+	defer func() {
+		s.recordRun(ctx, targetDB, stats, err)
+	}()
 
-		var contents []model.VerifyContent
-		for rows.Next() {
-			var content model.VerifyContent
-			var taskID sql.NullString
-			var contentJSON sql.NullString
-			var createdAt, updatedAt, deletedAt sql.NullTime
-
-			if err := rows.Scan(&content.ID, &taskID, &contentJSON, &createdAt, &updatedAt, &deletedAt); err != nil {
-				zap.S().Warnf("扫描记录失败: %v", err)
-				errors++
-				continue
-			}
-
-			if taskID.Valid {
-				content.TaskID = taskID.String
-			}
-			if createdAt.Valid {
-				content.CreatedAt = createdAt.Time
-			}
-			if updatedAt.Valid {
-				content.UpdatedAt = updatedAt.Time
-			}
-			if deletedAt.Valid {
-				content.DeletedAt = gorm.DeletedAt{Time: deletedAt.Time, Valid: true}
-			}
-
-			// contentJSON 必须有效且可解析
-			if !contentJSON.Valid {
-				zap.S().Debugf("文章 ID %d: content 为 NULL，跳过", content.ID)
-				continue
-			}
-			content.Content.Raw = contentJSON.String
-
-			var raw map[string]interface{}
-			if err := json.Unmarshal([]byte(contentJSON.String), &raw); err != nil {
-				zap.S().Debugf("文章 ID %d: content 不是合法 JSON，跳过。错误: %v", content.ID, err)
-				continue
-			}
-
-			dataIface, ok := raw["data"]
-			if !ok {
-				zap.S().Debugf("文章 ID %d: JSON 中没有 data 字段，跳过", content.ID)
-				continue
-			}
-
-			data, ok := dataIface.(map[string]interface{})
-			if !ok {
-				zap.S().Debugf("文章 ID %d: data 字段不是 map 类型，跳过", content.ID)
-				continue
-			}
-
-			// 检查两种格式
-			isOldFormat := false
-			isNewFormat := false
-
-			// 旧格式：checkresultjson 不为空
-			if _, hasCheckResultStr := data["checkresultstr"]; hasCheckResultStr {
-				if checkResultJSONRaw, hasCheckResultJSON := data["checkresultjson"]; hasCheckResultJSON {
-					switch v := checkResultJSONRaw.(type) {
-					case string:
-						var arr []interface{}
-						if err := json.Unmarshal([]byte(v), &arr); err == nil && len(arr) > 0 {
-							isOldFormat = true
-						} else {
-							zap.S().Debugf("文章 ID %d: checkresultjson 字符串为空或解析失败", content.ID)
-						}
-					case []interface{}:
-						if len(v) > 0 {
-							isOldFormat = true
-						} else {
-							zap.S().Debugf("文章 ID %d: checkresultjson 数组为空", content.ID)
-						}
-					default:
-						zap.S().Debugf("文章 ID %d: checkresultjson 类型未知，跳过", content.ID)
-					}
-				} else {
-					zap.S().Debugf("文章 ID %d: 缺少 checkresultjson 字段", content.ID)
-				}
-			}
-
-			// 新格式：checklist 不为空
-			if _, hasReplaceText := data["replace_text"]; hasReplaceText {
-				if checklistRaw, hasChecklist := data["checklist"]; hasChecklist {
-					switch v := checklistRaw.(type) {
-					case string:
-						var arr []interface{}
-						if err := json.Unmarshal([]byte(v), &arr); err == nil && len(arr) > 0 {
-							isNewFormat = true
-						} else {
-							zap.S().Debugf("文章 ID %d: checklist 字符串为空或解析失败", content.ID)
-						}
-					case []interface{}:
-						if len(v) > 0 {
-							isNewFormat = true
-						} else {
-							zap.S().Debugf("文章 ID %d: checklist 数组为空", content.ID)
-						}
-					default:
-						zap.S().Debugf("文章 ID %d: checklist 类型未知，跳过", content.ID)
-					}
-				} else {
-					zap.S().Debugf("文章 ID %d: 缺少 checklist 字段", content.ID)
-				}
-			}
-
-			if !isOldFormat && !isNewFormat {
-				zap.S().Debugf("文章 ID %d: 不符合任何已知格式，跳过", content.ID)
-				continue
-			}
-
-			contents = append(contents, content)
-		}
-		rows.Close()
+	dups, err := newDuplicateIDs(s.opts.DuplicateIDs)
+	if err != nil {
+		return err
+	}
+	stats.duplicates = dups
 
-		if len(contents) == 0 {
-			break
+	// 先写入临时表，全部成功后再原子替换正式表，避免读者看到未完成的数据
+	sink, err := s.openSink(ctx, targetDB, dups)
+	if err != nil {
+		return fmt.Errorf("创建写入目标失败: %v", err)
+	}
+	swapped := false
+	defer func() {
+		// 迁移失败时保留正式表不动，仅清理临时表
+		if !swapped {
+			sink.abort(ctx)
 		}
+	}()
 
-		for _, content := range contents {
-			if err := s.processAndInsert(ctx, &content); err != nil {
-				zap.S().Warnf("处理记录 ID %d 失败: %v", content.ID, err)
-				errors++
-				continue
-			}
-			processed++
+	// 本次迁移范围内的旧死信会在重新处理时重新判定；目录迁移的范围与源表无关，不清理旧死信，写入成功时逐条删除
+	if s.opts.SourceDir != "" {
+		err = createDeadLetterTable(ctx, targetDB)
+	} else {
+		err = clearDeadLetters(ctx, targetDB, s.opts.TaskIDs, s.opts.ExcludeTaskIDs)
+	}
+	if err != nil {
+		return fmt.Errorf("初始化死信表失败: %v", err)
+	}
+
+	s.diagnostics = newRowDiagnostics()
+	sizer := newBatchSizer(batchSize, s.opts.MemoryBudget)
+	stats.sizer = sizer
+	stats.queues = &queueStats{}
+
+	if s.opts.Progress != nil {
+		s.startProgress(ctx, src)
+	}
+	err = s.runPipeline(ctx, src, sizer, stats.queues, func(batch *processedBatch) error {
+		s.writeBatch(ctx, sink, batch, stats)
+		if s.opts.Progress != nil {
+			s.opts.Progress.Add(batch.scanned)
 		}
+		return s.checkErrorRate(stats, false)
+	})
+	// 进度在汇总日志之前结束，避免进度条与汇总交错
+	if s.opts.Progress != nil {
+		s.opts.Progress.Finish()
+	}
+	if err != nil {
+		return err
+	}
+	// 失败行过多时不替换目标表，保留上一次的结果
+	if err := s.checkErrorRate(stats, true); err != nil {
+		return err
+	}
 
-		offset += batchSize
+	if err := sink.commit(ctx); err != nil {
+		return fmt.Errorf("替换目标表失败: %v", err)
 	}
+	swapped = true
+	s.lastOutputs = sink.outputs()
 
-	zap.S().Infof("处理完成: 成功 %d 条, 失败 %d 条", processed, errors)
-	zap.S().Infof("耗时：%s", time.Since(startTime))
+	if s.shadow != nil {
+		s.recordShadowDiffs(ctx, targetDB, stats.runID)
+	}
+	stats.log()
+	s.logDiagnostics()
 	return nil
 }
 
-// createDuckDBTable 创建 DuckDB 表
-func (s *MigrationService) createDuckDBTable(ctx context.Context) error {
-	duckDB := db.GetDuckDBWithContext(ctx)
-	if duckDB == nil {
-		return fmt.Errorf("DuckDB 连接未初始化")
+// RunSummary 返回最近一次 MigrateToDuckDB 的运行摘要，迁移失败或中断时为已完成部分的统计；
+// 尚未开始迁移（如参数校验失败）时返回 nil
+func (s *MigrationService) RunSummary() *RunSummary {
+	if s.lastRun == nil {
+		return nil
 	}
+	summary := s.lastRun.summary()
+	summary.TargetTable = s.targetTable
+	summary.TaskIDs = s.opts.TaskIDs
+	summary.ExcludeTaskIDs = s.opts.ExcludeTaskIDs
+	summary.RunLabel = s.opts.RunLabel
+	return summary
+}
 
-	// 删除旧表（如果存在），确保使用正确的表结构
-	// 这样可以处理表结构变更的情况
-	_, err := duckDB.ExecContext(ctx, "DROP TABLE IF EXISTS processed_content_test")
-	if err != nil {
-		return fmt.Errorf("删除旧表失败: %v", err)
+// Manifest 返回最近一次成功迁移的输出清单，dbPath 为主库文件路径；尚未成功迁移时返回 nil
+func (s *MigrationService) Manifest(dbPath string) *Manifest {
+	if s.lastRun == nil || s.lastOutputs == nil {
+		return nil
 	}
+	m := newManifest(ManifestKindMigrate)
+	m.RunID = s.lastRun.runID
+	if len(s.opts.TaskIDs) > 0 || len(s.opts.ExcludeTaskIDs) > 0 {
+		m.SourceFilter = &ManifestFilter{TaskIDs: s.opts.TaskIDs, ExcludeTaskIDs: s.opts.ExcludeTaskIDs}
+	}
+	m.RunLabel = s.opts.RunLabel
+	m.Columns = processedManifestColumns()
+	if s.opts.RunLabel != "" {
+		m.Columns = append(m.Columns, ManifestColumn{Name: runLabelColumn.Name, Type: canonicalType(runLabelColumn.Type), Description: runLabelColumn.Desc})
+	}
+	m.Outputs = make([]ManifestOutput, 0, len(s.lastOutputs))
+	for _, out := range s.lastOutputs {
+		path := out.path
+		if path == "" {
+			path = dbPath
+		}
+		m.Outputs = append(m.Outputs, ManifestOutput{Table: out.table, Format: manifestFormatDuckDB, Path: path, Rows: out.rows})
+	}
+	return m
+}
 
-	createTableSQL := `
-		CREATE TABLE processed_content_test (
-			id TEXT PRIMARY KEY,
-			original_text TEXT,
-			modified_text TEXT,
-			pid TEXT,
-			error_reason TEXT
-		)
-	`
-
-	_, err = duckDB.ExecContext(ctx, createTableSQL)
-	if err != nil {
-		return fmt.Errorf("创建表失败: %v", err)
+// writeBatch 将一批处理结果写入 sink，并把读取阶段无法解析的行写入死信表
+func (s *MigrationService) writeBatch(ctx context.Context, sink processedSink, batch *processedBatch, stats *migrationStats) {
+	stats.errors += batch.scanErrors
+	stats.scanned += batch.scanned
+	stats.failed += batch.scanErrors + len(batch.deadRows)
+	for _, dead := range batch.deadRows {
+		s.recordDeadLetter(ctx, dead.content, dead.contentJSON, dead.reason, stats)
+	}
+	toWrite := make([]*model.ProcessedContent, 0, len(batch.results))
+	contents := make([]*model.VerifyContent, 0, len(batch.results))
+	for i, result := range batch.results {
+		stats.recordProcessed(result)
+
+		// 仅保留实际应用了修正的记录
+		if s.opts.OnlyErrors && !result.HasErrors {
+			stats.skipped++
+			continue
+		}
+		toWrite = append(toWrite, result)
+		contents = append(contents, &batch.contents[i])
 	}
 
-	zap.S().Debug("DuckDB 表创建成功")
-	return nil
+	failed := make(map[int]bool)
+	for _, failure := range sink.write(ctx, toWrite) {
+		failed[failure.index] = true
+		content, result := contents[failure.index], toWrite[failure.index]
+		if errors.Is(failure.err, errDuplicateID) {
+			s.debugRow("duplicate_id", "文章 ID %d: ID 与已写入的记录重复，跳过", content.ID)
+			continue
+		}
+		zap.S().Warnf("处理记录 ID %d 失败: %v", content.ID, failure.err)
+		stats.failed++
+		stats.recordWriteError(result)
+		s.recordDeadLetter(ctx, content, []byte(content.Content.Raw), fmt.Sprintf("写入失败: %v", failure.err), stats)
+	}
+	for i, result := range toWrite {
+		if failed[i] {
+			continue
+		}
+		stats.recordWritten(result)
+		// 目录迁移开始时不清理死信表，之前失败、这次写入成功的文件在此删除旧死信
+		if s.opts.SourceDir != "" {
+			s.removeDeadLetter(ctx, contents[i].ID)
+		}
+	}
 }
 
-// processAndInsert 处理单条记录并插入到 DuckDB
-func (s *MigrationService) processAndInsert(ctx context.Context, verifyContent *model.VerifyContent) error {
-	// 处理内容（即使处理失败也会返回结果，包含错误原因）
-	processed := s.processor.ProcessContent(verifyContent)
+// openSink 按分片和分区方式创建写入目标
+func (s *MigrationService) openSink(ctx context.Context, targetDB *sql.DB, dups *duplicateIDs) (processedSink, error) {
+	if s.opts.TextDir != "" {
+		if s.opts.ShardBy != ShardByNone || s.opts.PartitionBy != PartitionByNone {
+			return nil, fmt.Errorf("文本输出不能与分片或分区同时使用")
+		}
+		if s.opts.RunLabel != "" {
+			return nil, fmt.Errorf("文本输出不支持运行标签")
+		}
+		return openTextSink(s.opts.TextDir, s.opts.TextOriginal, s.opts.TextOpenFiles, dups)
+	}
+	switch s.opts.PartitionBy {
+	case PartitionByNone:
+	case PartitionByMonth:
+		if s.opts.ShardBy != ShardByNone {
+			return nil, fmt.Errorf("分区不能与分片同时使用")
+		}
+		mode, err := ingestMode(s.opts.IngestMode)
+		if err != nil {
+			return nil, err
+		}
+		return newPartitionSink(targetDB, s.targetTable, mode, s.opts.RunLabel, dups), nil
+	default:
+		return nil, fmt.Errorf("不支持的分区方式: %q", s.opts.PartitionBy)
+	}
+	switch s.opts.ShardBy {
+	case ShardByNone:
+		return openTableSink(ctx, targetDB, s.targetTable, s.opts.IngestMode, s.opts.RunLabel, dups)
+	case ShardByTask:
+		if !strings.Contains(s.opts.ShardPath, ShardPathPlaceholder) {
+			return nil, fmt.Errorf("分片路径 %q 缺少占位符 %s", s.opts.ShardPath, ShardPathPlaceholder)
+		}
+		return newShardSink(s.opts.ShardPath, s.targetTable, s.opts.IngestMode, s.opts.RunLabel, dups), nil
+	default:
+		return nil, fmt.Errorf("不支持的分片方式: %q", s.opts.ShardBy)
+	}
+}
 
-	// 使用源表的 ID 作为主键
-	processed.ID = fmt.Sprintf("%d", verifyContent.ID)
+// debugRow 按 reason 采样输出逐行诊断日志，未开启 RowDiagnostics 时不输出
+func (s *MigrationService) debugRow(reason, template string, args ...interface{}) {
+	if s.opts.RowDiagnostics {
+		s.diagnostics.Debugf(reason, template, args...)
+	}
+}
 
-	// 插入到 DuckDB
-	duckDB := db.GetDuckDBWithContext(ctx)
-	if duckDB == nil {
-		return fmt.Errorf("DuckDB 连接未初始化")
+// logDiagnostics 输出本次运行各诊断原因的累计次数
+func (s *MigrationService) logDiagnostics() {
+	if s.opts.RowDiagnostics {
+		s.diagnostics.LogTotals()
 	}
+}
 
-	insertSQL := `
-		INSERT INTO processed_content_test (id, original_text, modified_text, pid, error_reason)
-		VALUES (?, ?, ?, ?, ?)
-	`
+// validateIdentifiers 校验将拼接到 SQL 中的表名
+func (s *MigrationService) validateIdentifiers() error {
+	tables := []string{s.targetTable, stagingTableName(s.targetTable)}
+	if s.opts.PartitionBy != PartitionByNone {
+		// 后缀最长的分区表名合法时其余分区表名也合法
+		tables = append(tables, stagingTableName(partitionTableName(s.targetTable, nil)))
+	}
+	for _, table := range tables {
+		if _, err := util.SanitizeIdentifier(table); err != nil {
+			return fmt.Errorf("目标表名不合法: %v", err)
+		}
+	}
+	return nil
+}
 
-	_, err := duckDB.ExecContext(ctx, insertSQL,
-		processed.ID,
-		processed.OriginalText,
-		processed.ModifiedText,
-		processed.PID,
-		processed.ErrorReason,
-	)
+// processBatch 按 Workers 并发处理一批记录，结果顺序与输入一致；写入仍由调用方顺序执行
+// 开启影子对比时每条记录处理完成后同时交给影子处理器
+func (s *MigrationService) processBatch(ctx context.Context, contents []model.VerifyContent) []*model.ProcessedContent {
+	opts := BatchOptions{Workers: s.opts.Workers}
+	if s.shadow != nil {
+		opts.OnResult = func(i int, result *model.ProcessedContent, _ time.Duration) {
+			s.shadow.observe(&contents[i], result)
+		}
+	}
+	results, _ := s.processor.ProcessBatch(ctx, contentPointers(contents), opts)
+	return results
+}
 
-	if err != nil {
-		return fmt.Errorf("插入数据失败: %v", err)
+// contentPointers 返回指向 contents 各元素的指针
+func contentPointers(contents []model.VerifyContent) []*model.VerifyContent {
+	items := make([]*model.VerifyContent, len(contents))
+	for i := range contents {
+		items[i] = &contents[i]
 	}
+	return items
+}
 
-	return nil
+// processedContent 返回目标表的仓储，目标表不存在时返回 ErrTableNotFound
+func (s *MigrationService) processedContent(ctx context.Context) (*repository.ProcessedContentRepository, error) {
+	if err := s.validateIdentifiers(); err != nil {
+		return nil, err
+	}
+	duckDB := s.target(ctx)
+	if duckDB == nil {
+		return nil, fmt.Errorf("DuckDB 连接未初始化")
+	}
+	if err := requireTable(ctx, duckDB, s.targetTable); err != nil {
+		return nil, err
+	}
+	return repository.NewProcessedContentRepository(duckDB, s.targetTable), nil
 }
 
-// GetProcessedContentCount 获取已处理的内容数量
+// GetProcessedContentCount 获取目标表中已处理的内容数量
 func (s *MigrationService) GetProcessedContentCount(ctx context.Context) (int64, error) {
-	duckDB := db.GetDuckDBWithContext(ctx)
-	if duckDB == nil {
-		return 0, fmt.Errorf("DuckDB 连接未初始化")
+	repo, err := s.processedContent(ctx)
+	if err != nil {
+		return 0, err
 	}
+	return repo.Count(ctx)
+}
 
-	var count int64
-	err := duckDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM processed_content").Scan(&count)
+// GetCountsByErrorCode 按错误码统计目标表的记录数量，没有错误码的记录不计
+func (s *MigrationService) GetCountsByErrorCode(ctx context.Context) (map[string]int64, error) {
+	repo, err := s.processedContent(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("查询数量失败: %v", err)
+		return nil, err
 	}
+	return repo.CountByErrorCode(ctx)
+}
 
-	return count, nil
+// GetCountsByTask 按任务 ID 统计目标表的记录数量，没有任务 ID 的记录计入空字符串
+func (s *MigrationService) GetCountsByTask(ctx context.Context) (map[string]int64, error) {
+	repo, err := s.processedContent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.CountByTask(ctx)
+}
+
+// ListFailed 按 id 顺序分页列出目标表中有错误码的记录及其错误码和原因
+func (s *MigrationService) ListFailed(ctx context.Context, limit, offset int) ([]repository.FailedContent, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("limit 必须大于 0，当前为 %d", limit)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("offset 不能为负数，当前为 %d", offset)
+	}
+	repo, err := s.processedContent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.ListFailed(ctx, limit, offset)
 }
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"content-verify-log/pkg/model"
+	"content-verify-log/pkg/repository"
+)
+
+// targetIDs 返回目标表中按 id 排序的全部 id
+func targetIDs(t *testing.T, s *MigrationService) []string {
+	t.Helper()
+	rows, err := s.targetDB.Query("SELECT id FROM " + s.targetTable + " ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// TestMigrateExcludeTasks 排除的任务不出现在目标表中，与包含的任务同时设置时取两者的交集
+func TestMigrateExcludeTasks(t *testing.T) {
+	const content = `{"data":{"replace_text":"公圆","checklist":[{"word":"公圆","position":0,"length":2,"suggest":["公园"]}]}}`
+	db := openSourceDB(t, [][]interface{}{
+		{1, "t1", content, nil, nil, nil},
+		{2, "x1", content, nil, nil, nil},
+		{3, "t2", content, nil, nil, nil},
+		{4, "x2", content, nil, nil, nil},
+		{5, "t1", content, nil, nil, nil},
+	})
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{name: "exclude", exclude: []string{"x1", "x2"}, want: []string{"1", "3", "5"}},
+		{name: "include and exclude", include: []string{"t1", "x1"}, exclude: []string{"x1"}, want: []string{"1", "5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMigrationServiceWithDB(MigrationOptions{TaskIDs: tt.include, ExcludeTaskIDs: tt.exclude}, db, db)
+			if err := s.MigrateToDuckDB(context.Background(), 2); err != nil {
+				t.Fatal(err)
+			}
+			if got := targetIDs(t, s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("目标表中的 id 为 %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// allErrorCodes model 中定义的全部错误码
+var allErrorCodes = []string{
+	model.ErrCodeSchemaViolation,
+	model.ErrCodeNoExtractableText,
+	model.ErrCodeJSONLimitExceeded,
+	model.ErrCodeEncodingError,
+	model.ErrCodeTruncatedJSONSalvaged,
+	model.ErrCodeSuspiciousModification,
+	model.ErrCodeCancelled,
+}
+
+// openStatsFixture 返回写有统计用记录的目标表：第 i 个错误码有 i+1 条记录，另有 3 条没有错误码的记录；
+// 任务依次为 t1、t2 和空，id 为三位数字，按 id 排序即写入顺序
+func openStatsFixture(t *testing.T) (*MigrationService, []repository.FailedContent) {
+	t.Helper()
+	ctx := context.Background()
+	db := openMemoryDB(t)
+	dups, _ := newDuplicateIDs(DuplicateIDSkip)
+	sink, err := openTableSink(ctx, db, defaultTargetTable, IngestInsert, "", dups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := []string{"t1", "t2", ""}
+	var batch []*model.ProcessedContent
+	var failed []repository.FailedContent
+	add := func(code string) {
+		n := len(batch)
+		processed := &model.ProcessedContent{ID: fmt.Sprintf("%03d", n), PID: tasks[n%len(tasks)], ErrorCode: code}
+		if code != "" {
+			processed.ErrorReason = "原因 " + code
+			failed = append(failed, repository.FailedContent{ID: processed.ID, TaskID: processed.PID, ErrorCode: code, ErrorReason: processed.ErrorReason})
+		}
+		batch = append(batch, processed)
+	}
+	for i, code := range allErrorCodes {
+		for j := 0; j <= i; j++ {
+			add(code)
+		}
+	}
+	for j := 0; j < 3; j++ {
+		add("")
+	}
+	if failures := sink.write(ctx, batch); len(failures) > 0 {
+		t.Fatalf("写入失败: %v", failures)
+	}
+	if err := sink.commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return NewMigrationServiceWithDB(MigrationOptions{}, db, db), failed
+}
+
+// TestProcessedContentStats 按错误码和任务统计目标表，没有错误码的记录只计入总数和任务统计
+func TestProcessedContentStats(t *testing.T) {
+	ctx := context.Background()
+	s, failed := openStatsFixture(t)
+	total := int64(len(failed) + 3)
+
+	count, err := s.GetProcessedContentCount(ctx)
+	if err != nil || count != total {
+		t.Errorf("记录数为 %d %v，应为 %d", count, err, total)
+	}
+
+	byCode, err := s.GetCountsByErrorCode(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCodes := make(map[string]int64)
+	for i, code := range allErrorCodes {
+		wantCodes[code] = int64(i + 1)
+	}
+	if !reflect.DeepEqual(byCode, wantCodes) {
+		t.Errorf("按错误码统计为 %v，应为 %v", byCode, wantCodes)
+	}
+
+	byTask, err := s.GetCountsByTask(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTasks := map[string]int64{"t1": total/3 + 1, "t2": total / 3, "": total / 3}
+	if !reflect.DeepEqual(byTask, wantTasks) {
+		t.Errorf("按任务统计为 %v，应为 %v", byTask, wantTasks)
+	}
+
+	missing := NewMigrationServiceWithDB(MigrationOptions{TargetTable: "no_such_table"}, s.targetDB, s.targetDB)
+	if _, err := missing.GetCountsByErrorCode(ctx); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("目标表不存在时应返回 ErrTableNotFound，得到 %v", err)
+	}
+}
+
+// TestListFailed 按 id 顺序分页列出有错误码的记录，分页拼接后与全部失败记录一致；limit、offset 不合法时报错
+func TestListFailed(t *testing.T) {
+	ctx := context.Background()
+	s, failed := openStatsFixture(t)
+
+	var got []repository.FailedContent
+	for offset := 0; ; offset += 4 {
+		page, err := s.ListFailed(ctx, 4, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) > 4 {
+			t.Fatalf("offset %d: 一页 %d 条，超出 limit", offset, len(page))
+		}
+		if len(page) == 0 {
+			break
+		}
+		got = append(got, page...)
+	}
+	if !reflect.DeepEqual(got, failed) {
+		t.Errorf("分页结果为 %v，应为 %v", got, failed)
+	}
+	if page, err := s.ListFailed(ctx, 10, len(failed)); err != nil || len(page) != 0 {
+		t.Errorf("offset 超出记录数时应返回空，得到 %v %v", page, err)
+	}
+
+	for _, tt := range []struct{ limit, offset int }{{0, 0}, {-1, 0}, {1, -1}} {
+		if _, err := s.ListFailed(ctx, tt.limit, tt.offset); err == nil {
+			t.Errorf("limit=%d offset=%d 应返回错误", tt.limit, tt.offset)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"content-verify-log/pkg/model"
+
+	"go.uber.org/zap"
+)
+
+// shadowDiffsTable 影子对比不一致的记录样本
+const shadowDiffsTable = "shadow_diffs"
+
+// shadowSampleLimit 每次迁移最多记录的不一致样本数
+const shadowSampleLimit = 1000
+
+// shadowIgnoredFields 对比时忽略的字段：处理时间和工具版本每次运行都不同，与处理逻辑无关
+var shadowIgnoredFields = map[string]bool{
+	"processed_at": true,
+	"tool_version": true,
+}
+
+// shadowSample 一条不一致的记录
+type shadowSample struct {
+	id     string
+	fields []string
+}
+
+// shadowCompare 用影子处理器处理同一批输入，逐字段比较其结果与主处理器的结果
+// 只观察不写入：写入目标表的始终是主处理器的结果。处理阶段并发调用，统计加锁
+type shadowCompare struct {
+	processor *ContentProcessor
+
+	mu        sync.Mutex
+	compared  int
+	disagreed int
+	byField   map[string]int
+	samples   []shadowSample
+}
+
+func newShadowCompare(opts ProcessorOptions) *shadowCompare {
+	return &shadowCompare{
+		processor: NewContentProcessorWithOptions(opts),
+		byField:   make(map[string]int),
+	}
+}
+
+// observe 用影子处理器处理 verifyContent，记录与 primary 不一致的字段
+func (c *shadowCompare) observe(verifyContent *model.VerifyContent, primary *model.ProcessedContent) {
+	shadow := c.processor.ProcessContent(verifyContent)
+	shadow.ID = primary.ID
+	fields, err := diffFields(primary, shadow)
+	if err != nil {
+		zap.S().Warnf("影子对比记录 ID %s 失败: %v", primary.ID, err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.compared++
+	if len(fields) == 0 {
+		return
+	}
+	c.disagreed++
+	for _, field := range fields {
+		c.byField[field]++
+	}
+	if len(c.samples) < shadowSampleLimit {
+		c.samples = append(c.samples, shadowSample{id: primary.ID, fields: fields})
+	}
+}
+
+// diffFields 按 JSON 字段比较两个结果，返回按名称排序的不一致字段；details 等嵌套字段整体比较
+func diffFields(a, b *model.ProcessedContent) ([]string, error) {
+	left, err := jsonFields(a)
+	if err != nil {
+		return nil, err
+	}
+	right, err := jsonFields(b)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for name, lv := range left {
+		if shadowIgnoredFields[name] {
+			continue
+		}
+		if rv, ok := right[name]; !ok || !bytes.Equal(lv, rv) {
+			fields = append(fields, name)
+		}
+	}
+	for name := range right {
+		if _, ok := left[name]; !ok && !shadowIgnoredFields[name] {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// jsonFields 将结果序列化为 JSON 后按顶层字段拆分
+func jsonFields(processed *model.ProcessedContent) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(processed)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// ShadowSummary 影子对比的汇总
+type ShadowSummary struct {
+	Compared     int            `json:"compared"`      // 对比的记录数
+	Disagreed    int            `json:"disagreed"`     // 至少一个字段不一致的记录数
+	Rate         float64        `json:"rate"`          // Disagreed / Compared
+	FieldCounts  map[string]int `json:"field_counts"`  // 按字段统计的不一致记录数
+	SamplesTable string         `json:"samples_table"` // 记录不一致样本的表
+}
+
+// summary 返回截至目前的对比汇总
+func (c *shadowCompare) summary() *ShadowSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	summary := &ShadowSummary{
+		Compared:     c.compared,
+		Disagreed:    c.disagreed,
+		FieldCounts:  make(map[string]int, len(c.byField)),
+		SamplesTable: shadowDiffsTable,
+	}
+	if c.compared > 0 {
+		summary.Rate = float64(c.disagreed) / float64(c.compared)
+	}
+	for field, count := range c.byField {
+		summary.FieldCounts[field] = count
+	}
+	return summary
+}
+
+// log 输出对比汇总，字段按不一致次数降序
+func (c *shadowCompare) log() {
+	summary := c.summary()
+	zap.S().Infof("影子对比: 比较 %d 条, 不一致 %d 条 (%.2f%%)", summary.Compared, summary.Disagreed, summary.Rate*100)
+	fields := make([]string, 0, len(summary.FieldCounts))
+	for field := range summary.FieldCounts {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if summary.FieldCounts[fields[i]] != summary.FieldCounts[fields[j]] {
+			return summary.FieldCounts[fields[i]] > summary.FieldCounts[fields[j]]
+		}
+		return fields[i] < fields[j]
+	})
+	for _, field := range fields {
+		zap.S().Infof("  字段 %s: %d 条", field, summary.FieldCounts[field])
+	}
+	if summary.Disagreed > shadowSampleLimit {
+		zap.S().Infof("不一致样本只记录前 %d 条", shadowSampleLimit)
+	}
+}
+
+// buildCreateShadowDiffsSQL 构造创建影子对比样本表的语句，表已存在时保留历史记录
+func buildCreateShadowDiffsSQL() string {
+	return `CREATE TABLE IF NOT EXISTS ` + shadowDiffsTable + ` (
+	run_id TEXT,
+	id TEXT,
+	fields TEXT
+)`
+}
+
+// recordShadowDiffs 将不一致样本写入样本表，run_id 与 migration_runs 一致；写入失败只输出警告
+func (s *MigrationService) recordShadowDiffs(ctx context.Context, duckDB *sql.DB, runID string) {
+	if err := insertShadowDiffs(ctx, duckDB, runID, s.shadow); err != nil {
+		zap.S().Warnf("写入影子对比样本表 %s 失败: %v", shadowDiffsTable, err)
+	}
+}
+
+// insertShadowDiffs 确保样本表存在，在一个事务中写入本次迁移的样本
+func insertShadowDiffs(ctx context.Context, duckDB *sql.DB, runID string, c *shadowCompare) error {
+	if _, err := duckDB.ExecContext(ctx, buildCreateShadowDiffsSQL()); err != nil {
+		return fmt.Errorf("创建样本表失败: %v", err)
+	}
+	c.mu.Lock()
+	samples := c.samples
+	c.mu.Unlock()
+	if len(samples) == 0 {
+		return nil
+	}
+
+	tx, err := duckDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO "+shadowDiffsTable+" (run_id, id, fields) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, sample := range samples {
+		if _, err := stmt.ExecContext(ctx, runID, sample.id, strings.Join(sample.fields, ",")); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
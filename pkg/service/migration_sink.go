@@ -0,0 +1,524 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"content-verify-log/pkg/db"
+	"content-verify-log/pkg/model"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+	"go.uber.org/zap"
+)
+
+// 分片方式
+const (
+	ShardByNone = ""     // 不分片，全部写入同一个库
+	ShardByTask = "task" // 按任务 ID 写入不同的 DuckDB 文件
+)
+
+// 分区方式
+const (
+	PartitionByNone  = ""      // 不分区，全部写入目标表
+	PartitionByMonth = "month" // 按源记录创建时间的月份写入 <目标表>_YYYYMM
+)
+
+// partitionUnknown 源记录没有创建时间时的分区后缀
+const partitionUnknown = "unknown"
+
+// ShardPathPlaceholder 分片路径模板中任务 ID 的占位符
+const ShardPathPlaceholder = "{task}"
+
+// 源数据中 ID 重复（如多个来源合并后有相同 ID）、写入目标表违反主键约束时的处理方式
+const (
+	DuplicateIDSkip   = "skip"   // 保留先写入的记录，跳过后来的同 ID 记录并计数
+	DuplicateIDUpsert = "upsert" // 后来的记录替换先写入的同 ID 记录
+)
+
+// errDuplicateID 按 DuplicateIDSkip 跳过的行的写入错误，可用 errors.Is 判断，这些行不写入死信表
+var errDuplicateID = errors.New("ID 与已写入的记录重复")
+
+// duplicateIDs ID 重复时的处理方式和计数，同一次迁移的各写入目标共用
+type duplicateIDs struct {
+	policy   string
+	skipped  int // 按 DuplicateIDSkip 跳过的行数
+	replaced int // 按 DuplicateIDUpsert 替换了先写入记录的行数
+}
+
+// newDuplicateIDs 校验处理方式，为空时使用 DuplicateIDSkip
+func newDuplicateIDs(policy string) (*duplicateIDs, error) {
+	switch policy {
+	case DuplicateIDSkip, DuplicateIDUpsert:
+	case "":
+		policy = DuplicateIDSkip
+	default:
+		return nil, fmt.Errorf("不支持的 ID 重复处理方式: %q", policy)
+	}
+	return &duplicateIDs{policy: policy}, nil
+}
+
+// isDuplicateKey 判断写入错误是否为主键重复
+func isDuplicateKey(err error) bool {
+	var e *duckdb.Error
+	return errors.As(err, &e) && e.Type == duckdb.ErrorTypeConstraint && strings.Contains(e.Msg, "Duplicate key")
+}
+
+// processedSink 处理结果的写入目标
+// 先写入临时表，全部成功后 commit 原子替换正式表；失败时 abort 只清理临时表，正式表保持不变
+type processedSink interface {
+	// write 写入一批结果，返回写入失败的行，由调用方写入死信表
+	write(ctx context.Context, batch []*model.ProcessedContent) []writeFailure
+	commit(ctx context.Context) error
+	abort(ctx context.Context)
+	// outputs 返回写入的各张表，提交成功后调用
+	outputs() []sinkOutput
+}
+
+// sinkOutput 写入目标中的一张表
+type sinkOutput struct {
+	path  string // 表所在的 DuckDB 文件，为空表示主库
+	table string
+	rows  int64
+}
+
+// writeFailure 写入失败的一行，index 为该行在批中的下标
+type writeFailure struct {
+	index int
+	err   error
+}
+
+// tableSink 写入单个库中的目标表
+type tableSink struct {
+	duckDB  *sql.DB
+	table   string
+	staging string
+	mode    string // 写入方式 IngestInsert/IngestAppender/IngestCopy/IngestArrow
+	label   string // 运行标签，非空时提交前追加 run_label 列
+	dups    *duplicateIDs
+	rows    int64
+}
+
+// ingestMode 校验写入方式，为空时返回 IngestInsert
+func ingestMode(mode string) (string, error) {
+	switch mode {
+	case IngestInsert, IngestAppender, IngestCopy, IngestArrow:
+		return mode, nil
+	case "":
+		return IngestInsert, nil
+	default:
+		return "", fmt.Errorf("不支持的写入方式: %q", mode)
+	}
+}
+
+func openTableSink(ctx context.Context, duckDB *sql.DB, table, mode, label string, dups *duplicateIDs) (*tableSink, error) {
+	mode, err := ingestMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	sink := &tableSink{duckDB: duckDB, table: table, staging: stagingTableName(table), mode: mode, label: label, dups: dups}
+	if err := createDuckDBTable(ctx, duckDB, sink.staging); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// write 按写入方式写入一批结果；批量写入被整批拒绝（如主键冲突、数据不合法）时回退为逐行插入，
+// 只有逐行插入也失败的行计为失败，与 insert 方式的结果一致
+func (t *tableSink) write(ctx context.Context, batch []*model.ProcessedContent) []writeFailure {
+	if len(batch) == 0 {
+		return nil
+	}
+	var err error
+	switch t.mode {
+	case IngestAppender:
+		err = appendBatch(ctx, t.duckDB, t.staging, batch)
+	case IngestCopy:
+		err = copyBatch(ctx, t.duckDB, t.staging, batch)
+	case IngestArrow:
+		err = arrowBatch(ctx, t.duckDB, t.staging, batch)
+	default:
+		return t.insertEach(ctx, batch)
+	}
+	if err == nil {
+		t.rows += int64(len(batch))
+		return nil
+	}
+	if ctx.Err() != nil {
+		return failAll(batch, err)
+	}
+	zap.S().Warnf("批量写入 %d 条失败，改为逐行插入: %v", len(batch), err)
+	return t.insertEach(ctx, batch)
+}
+
+// insertEach 逐行插入，返回失败的行；ID 与已写入的记录重复的行按 dups 的处理方式跳过或替换
+func (t *tableSink) insertEach(ctx context.Context, batch []*model.ProcessedContent) []writeFailure {
+	var failures []writeFailure
+	for i, processed := range batch {
+		err := insertProcessed(ctx, t.duckDB, t.staging, processed)
+		if err == nil {
+			t.rows++
+			continue
+		}
+		if isDuplicateKey(err) {
+			if err = t.resolveDuplicate(ctx, processed); err == nil {
+				continue
+			}
+		}
+		failures = append(failures, writeFailure{index: i, err: err})
+	}
+	return failures
+}
+
+// resolveDuplicate 处理 ID 与已写入的记录重复的行：skip 时返回 errDuplicateID，upsert 时替换已写入的记录
+func (t *tableSink) resolveDuplicate(ctx context.Context, processed *model.ProcessedContent) error {
+	if t.dups.policy != DuplicateIDUpsert {
+		t.dups.skipped++
+		return errDuplicateID
+	}
+	values, err := processedValues(processed)
+	if err != nil {
+		return err
+	}
+	if _, err := t.duckDB.ExecContext(ctx, buildUpsertSQL(t.staging), values...); err != nil {
+		return fmt.Errorf("替换同 ID 记录失败: %v", err)
+	}
+	t.dups.replaced++
+	return nil
+}
+
+// failAll 将整批记为失败
+func failAll(batch []*model.ProcessedContent, err error) []writeFailure {
+	failures := make([]writeFailure, len(batch))
+	for i := range batch {
+		failures[i] = writeFailure{index: i, err: err}
+	}
+	return failures
+}
+
+func (t *tableSink) commit(ctx context.Context) error {
+	if t.label != "" {
+		for _, stmt := range buildAddRunLabelSQL(t.staging, t.label) {
+			if _, err := t.duckDB.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("追加运行标签列失败: %v", err)
+			}
+		}
+	}
+	return swapTable(ctx, t.duckDB, t.staging, t.table)
+}
+
+func (t *tableSink) abort(ctx context.Context) {
+	// ctx 可能已取消，使用不可取消的上下文清理
+	if _, err := t.duckDB.ExecContext(context.WithoutCancel(ctx), buildDropTableSQL(t.staging)); err != nil {
+		zap.S().Warnf("清理临时表 %s 失败: %v", t.staging, err)
+	}
+}
+
+func (t *tableSink) outputs() []sinkOutput {
+	return []sinkOutput{{table: t.table, rows: t.rows}}
+}
+
+// taskShard 一个任务对应的 DuckDB 文件
+type taskShard struct {
+	path   string
+	duckDB *sql.DB
+	sink   *tableSink
+}
+
+// shardSink 按任务 ID 将结果写入不同的 DuckDB 文件，文件在首次写入该任务时打开
+type shardSink struct {
+	pathTemplate string
+	table        string
+	mode         string
+	label        string
+	dups         *duplicateIDs
+	shards       map[string]*taskShard
+}
+
+func newShardSink(pathTemplate, table, mode, label string, dups *duplicateIDs) *shardSink {
+	return &shardSink{pathTemplate: pathTemplate, table: table, mode: mode, label: label, dups: dups, shards: make(map[string]*taskShard)}
+}
+
+// write 按任务拆分批次，分别写入各自的分片
+func (s *shardSink) write(ctx context.Context, batch []*model.ProcessedContent) []writeFailure {
+	var taskIDs []string
+	groups := make(map[string][]int)
+	for i, processed := range batch {
+		if _, ok := groups[processed.PID]; !ok {
+			taskIDs = append(taskIDs, processed.PID)
+		}
+		groups[processed.PID] = append(groups[processed.PID], i)
+	}
+
+	var failures []writeFailure
+	for _, taskID := range taskIDs {
+		indexes := groups[taskID]
+		shard, err := s.shard(ctx, taskID)
+		if err != nil {
+			for _, i := range indexes {
+				failures = append(failures, writeFailure{index: i, err: err})
+			}
+			continue
+		}
+		group := make([]*model.ProcessedContent, len(indexes))
+		for j, i := range indexes {
+			group[j] = batch[i]
+		}
+		for _, failure := range shard.sink.write(ctx, group) {
+			failures = append(failures, writeFailure{index: indexes[failure.index], err: failure.err})
+		}
+	}
+	return failures
+}
+
+// shard 返回任务对应的分片，不存在时打开文件并创建临时表
+func (s *shardSink) shard(ctx context.Context, taskID string) (*taskShard, error) {
+	if shard, ok := s.shards[taskID]; ok {
+		return shard, nil
+	}
+	path := shardPath(s.pathTemplate, taskID)
+	duckDB, err := db.OpenDuckDB(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开分片 %s 失败: %v", path, err)
+	}
+	sink, err := openTableSink(ctx, duckDB, s.table, s.mode, s.label, s.dups)
+	if err != nil {
+		_ = duckDB.Close()
+		return nil, fmt.Errorf("分片 %s 创建表失败: %v", path, err)
+	}
+	shard := &taskShard{path: path, duckDB: duckDB, sink: sink}
+	s.shards[taskID] = shard
+	return shard, nil
+}
+
+// commit 逐个替换各分片的正式表并关闭文件，输出每个分片的写入数量
+func (s *shardSink) commit(ctx context.Context) error {
+	var failed []string
+	for _, taskID := range s.taskIDs() {
+		shard := s.shards[taskID]
+		if err := shard.sink.commit(ctx); err != nil {
+			zap.S().Warnf("分片 %s 替换目标表失败: %v", shard.path, err)
+			shard.sink.abort(ctx)
+			failed = append(failed, shard.path)
+		} else {
+			zap.S().Infof("分片 %s (任务 %s): 写入 %d 条", shard.path, taskID, shard.sink.rows)
+		}
+		if err := shard.duckDB.Close(); err != nil {
+			zap.S().Warnf("关闭分片 %s 失败: %v", shard.path, err)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个分片替换失败: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (s *shardSink) abort(ctx context.Context) {
+	for _, taskID := range s.taskIDs() {
+		shard := s.shards[taskID]
+		shard.sink.abort(ctx)
+		if err := shard.duckDB.Close(); err != nil {
+			zap.S().Warnf("关闭分片 %s 失败: %v", shard.path, err)
+		}
+	}
+}
+
+func (s *shardSink) outputs() []sinkOutput {
+	outputs := make([]sinkOutput, 0, len(s.shards))
+	for _, taskID := range s.taskIDs() {
+		shard := s.shards[taskID]
+		outputs = append(outputs, sinkOutput{path: shard.path, table: shard.sink.table, rows: shard.sink.rows})
+	}
+	return outputs
+}
+
+// taskIDs 返回排序后的任务 ID，保证输出顺序稳定
+func (s *shardSink) taskIDs() []string {
+	ids := make([]string, 0, len(s.shards))
+	for id := range s.shards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// partitionSink 按源记录创建时间的月份将结果写入同一个库中的不同表，表在首次写入该月份时创建
+type partitionSink struct {
+	duckDB *sql.DB
+	table  string
+	mode   string
+	label  string
+	dups   *duplicateIDs
+	parts  map[string]*tableSink
+}
+
+func newPartitionSink(duckDB *sql.DB, table, mode, label string, dups *duplicateIDs) *partitionSink {
+	return &partitionSink{duckDB: duckDB, table: table, mode: mode, label: label, dups: dups, parts: make(map[string]*tableSink)}
+}
+
+// partitionTableName 返回创建时间 t 所在月份的分区表名，t 为 nil 时为 <table>_unknown
+func partitionTableName(table string, t *time.Time) string {
+	if t == nil {
+		return table + "_" + partitionUnknown
+	}
+	return table + "_" + t.UTC().Format("200601")
+}
+
+// write 按分区拆分批次，分别写入各自的临时表
+func (p *partitionSink) write(ctx context.Context, batch []*model.ProcessedContent) []writeFailure {
+	var names []string
+	groups := make(map[string][]int)
+	for i, processed := range batch {
+		name := partitionTableName(p.table, processed.SourceCreatedAt)
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], i)
+	}
+
+	var failures []writeFailure
+	for _, name := range names {
+		indexes := groups[name]
+		sink, err := p.partition(ctx, name)
+		if err != nil {
+			for _, i := range indexes {
+				failures = append(failures, writeFailure{index: i, err: err})
+			}
+			continue
+		}
+		group := make([]*model.ProcessedContent, len(indexes))
+		for j, i := range indexes {
+			group[j] = batch[i]
+		}
+		for _, failure := range sink.write(ctx, group) {
+			failures = append(failures, writeFailure{index: indexes[failure.index], err: failure.err})
+		}
+	}
+	return failures
+}
+
+// partition 返回分区表对应的写入目标，不存在时创建临时表
+func (p *partitionSink) partition(ctx context.Context, name string) (*tableSink, error) {
+	if sink, ok := p.parts[name]; ok {
+		return sink, nil
+	}
+	sink, err := openTableSink(ctx, p.duckDB, name, p.mode, p.label, p.dups)
+	if err != nil {
+		return nil, fmt.Errorf("分区 %s 创建表失败: %v", name, err)
+	}
+	p.parts[name] = sink
+	return sink, nil
+}
+
+// commit 逐个替换各分区的正式表，输出每个分区的写入数量；本次没有写入的月份的分区表保持不变
+func (p *partitionSink) commit(ctx context.Context) error {
+	var failed []string
+	for _, name := range p.names() {
+		sink := p.parts[name]
+		if err := sink.commit(ctx); err != nil {
+			zap.S().Warnf("分区 %s 替换目标表失败: %v", name, err)
+			sink.abort(ctx)
+			failed = append(failed, name)
+			continue
+		}
+		zap.S().Infof("分区 %s: 写入 %d 条", name, sink.rows)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个分区替换失败: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (p *partitionSink) abort(ctx context.Context) {
+	for _, name := range p.names() {
+		p.parts[name].abort(ctx)
+	}
+}
+
+func (p *partitionSink) outputs() []sinkOutput {
+	outputs := make([]sinkOutput, 0, len(p.parts))
+	for _, name := range p.names() {
+		outputs = append(outputs, sinkOutput{table: name, rows: p.parts[name].rows})
+	}
+	return outputs
+}
+
+// names 返回排序后的分区表名，保证输出顺序稳定
+func (p *partitionSink) names() []string {
+	names := make([]string, 0, len(p.parts))
+	for name := range p.parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var unsafePathCharRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// shardPath 用任务 ID 替换路径模板中的占位符，任务 ID 中不适合出现在文件名中的字符替换为 _
+func shardPath(template, taskID string) string {
+	name := unsafePathCharRegex.ReplaceAllString(taskID, "_")
+	if name == "" || strings.Trim(name, ".") == "" {
+		name = "unknown"
+	}
+	return strings.ReplaceAll(template, ShardPathPlaceholder, name)
+}
+
+// createDuckDBTable 创建 DuckDB 表
+func createDuckDBTable(ctx context.Context, duckDB *sql.DB, table string) error {
+	// 删除旧表（如果存在），确保使用正确的表结构
+	// 这样可以处理表结构变更的情况
+	_, err := duckDB.ExecContext(ctx, buildDropTableSQL(table))
+	if err != nil {
+		return fmt.Errorf("删除旧表失败: %v", err)
+	}
+
+	_, err = duckDB.ExecContext(ctx, buildCreateTableSQL(table))
+	if err != nil {
+		return fmt.Errorf("创建表失败: %v", err)
+	}
+
+	zap.S().Debugf("DuckDB 表 %s 创建成功", table)
+	return nil
+}
+
+// swapTable 在一个事务中删除正式表并将临时表重命名为正式表
+func swapTable(ctx context.Context, duckDB *sql.DB, from, to string) error {
+	tx, err := duckDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, stmt := range buildSwapTableSQL(from, to) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("执行 %q 失败: %v", stmt, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+
+	zap.S().Debugf("DuckDB 表 %s 已替换为 %s", to, from)
+	return nil
+}
+
+// insertProcessed 将处理结果插入到 DuckDB，保留驱动的错误以便判断是否为主键重复
+func insertProcessed(ctx context.Context, duckDB *sql.DB, table string, processed *model.ProcessedContent) error {
+	values, err := processedValues(processed)
+	if err != nil {
+		return err
+	}
+
+	if _, err := duckDB.ExecContext(ctx, buildInsertSQL(table), values...); err != nil {
+		return fmt.Errorf("插入数据失败: %w", err)
+	}
+	return nil
+}
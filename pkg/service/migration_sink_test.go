@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// processedRow 只含 ID 和修改后文章的处理结果
+func processedRow(id, text string) *model.ProcessedContent {
+	return &model.ProcessedContent{ID: id, ModifiedText: text, ModifiedTextValid: true}
+}
+
+// TestTableSinkDuplicateIDs ID 与已写入的记录重复时（跨批或同一批内）按处理方式跳过或替换，
+// 不计为普通的写入失败；按批写入的批次含重复 ID 时回退为逐行插入，结果与逐行插入一致
+func TestTableSinkDuplicateIDs(t *testing.T) {
+	tests := []struct {
+		policy   string
+		want     map[string]string
+		failures int
+		skipped  int
+		replaced int
+	}{
+		{policy: DuplicateIDSkip, want: map[string]string{"1": "甲", "2": "乙", "3": "丁"}, failures: 2, skipped: 2},
+		{policy: DuplicateIDUpsert, want: map[string]string{"1": "戊", "2": "乙", "3": "丁"}, replaced: 2},
+	}
+	for _, mode := range []string{IngestInsert, IngestAppender, IngestCopy, IngestArrow} {
+		for _, tt := range tests {
+			t.Run(mode+"/"+tt.policy, func(t *testing.T) {
+				ctx := context.Background()
+				db := openMemoryDB(t)
+				dups, err := newDuplicateIDs(tt.policy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sink, err := openTableSink(ctx, db, "out", mode, "", dups)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var failures []writeFailure
+				failures = append(failures, sink.write(ctx, []*model.ProcessedContent{processedRow("1", "甲"), processedRow("2", "乙")})...)
+				failures = append(failures, sink.write(ctx, []*model.ProcessedContent{processedRow("1", "丙"), processedRow("3", "丁"), processedRow("1", "戊")})...)
+				if len(failures) != tt.failures {
+					t.Fatalf("失败 %d 行，应为 %d: %v", len(failures), tt.failures, failures)
+				}
+				for _, f := range failures {
+					if !errors.Is(f.err, errDuplicateID) {
+						t.Errorf("第 %d 行的错误应为 errDuplicateID: %v", f.index, f.err)
+					}
+				}
+				if dups.skipped != tt.skipped || dups.replaced != tt.replaced {
+					t.Errorf("skipped=%d replaced=%d，应为 %d 和 %d", dups.skipped, dups.replaced, tt.skipped, tt.replaced)
+				}
+				if err := sink.commit(ctx); err != nil {
+					t.Fatal(err)
+				}
+
+				rows, err := db.Query("SELECT id, modified_text FROM out")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer rows.Close()
+				got := make(map[string]string)
+				for rows.Next() {
+					var id, text string
+					if err := rows.Scan(&id, &text); err != nil {
+						t.Fatal(err)
+					}
+					got[id] = text
+				}
+				if err := rows.Err(); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("目标表为 %v，应为 %v", got, tt.want)
+				}
+				if outputs := sink.outputs(); outputs[0].rows != 3 {
+					t.Errorf("写入行数为 %d，应为 3", outputs[0].rows)
+				}
+			})
+		}
+	}
+}
+
+// TestNullTextColumns 写入目标表后：处理成功时 error_reason 为 NULL；原文、修改后的文章只在处理于生成前失败时为 NULL，
+// 文档本身没有文字时为空字符串；各写入方式一致
+func TestNullTextColumns(t *testing.T) {
+	null := sql.NullString{}
+	text := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	p := NewContentProcessor()
+	tests := []struct {
+		id        string
+		processed *model.ProcessedContent
+		original  sql.NullString
+		modified  sql.NullString
+		reason    bool // error_reason 不为 NULL
+	}{
+		{
+			id:        "ok",
+			processed: processJSON(t, p, `{"data":{"replace_text":"公圆","checklist":[{"word":"公圆","position":0,"length":2,"suggest":["公园"]}]}}`),
+			original:  text("公圆"), modified: text("公园"),
+		},
+		{
+			id:        "no text",
+			processed: processJSON(t, p, `{"data":{"replace_text":"<p></p>","checklist":[{"word":"公圆","position":0,"length":2,"suggest":["公园"]}]}}`),
+			original:  text(""), modified: text(""), reason: true,
+		},
+		{
+			id:        "no corrections",
+			processed: processJSON(t, p, `{"data":{"checkresultstr":"公圆","checkresultjson":""}}`),
+			original:  text("公圆"), modified: null, reason: true,
+		},
+		{
+			id:        "empty content",
+			processed: p.ProcessContent(&model.VerifyContent{}),
+			original:  null, modified: null, reason: true,
+		},
+	}
+	for _, mode := range []string{IngestInsert, IngestAppender, IngestCopy, IngestArrow} {
+		t.Run(mode, func(t *testing.T) {
+			ctx := context.Background()
+			db := openMemoryDB(t)
+			dups, _ := newDuplicateIDs(DuplicateIDSkip)
+			sink, err := openTableSink(ctx, db, "out", mode, "", dups)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var batch []*model.ProcessedContent
+			for _, tt := range tests {
+				tt.processed.ID = tt.id
+				batch = append(batch, tt.processed)
+			}
+			if failures := sink.write(ctx, batch); len(failures) > 0 {
+				t.Fatalf("写入失败: %v", failures)
+			}
+			if err := sink.commit(ctx); err != nil {
+				t.Fatal(err)
+			}
+			for _, tt := range tests {
+				var original, modified, reason sql.NullString
+				if err := db.QueryRow("SELECT original_text, modified_text, error_reason FROM out WHERE id = ?", tt.id).Scan(&original, &modified, &reason); err != nil {
+					t.Fatal(err)
+				}
+				if original != tt.original || modified != tt.modified {
+					t.Errorf("%s: original_text=%+v modified_text=%+v，应为 %+v 和 %+v", tt.id, original, modified, tt.original, tt.modified)
+				}
+				if reason.Valid != tt.reason {
+					t.Errorf("%s: error_reason=%+v，不为 NULL 应为 %v", tt.id, reason, tt.reason)
+				}
+			}
+		})
+	}
+}
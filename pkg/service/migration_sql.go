@@ -0,0 +1,287 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"content-verify-log/pkg/model"
+)
+
+// sourceTable 源数据表
+const sourceTable = "tbl_verify_content"
+
+// defaultTargetTable 默认的目标表
+const defaultTargetTable = "processed_content_test"
+
+// columnDef 目标表的列定义
+type columnDef struct {
+	Name string // 列名
+	Type string // 列类型（含约束）
+	Desc string // 列说明，用于 SQL 预览中标注参数
+}
+
+// processedColumns 目标表的列，顺序与 processedValues 返回的参数一一对应
+var processedColumns = []columnDef{
+	{Name: "id", Type: "TEXT PRIMARY KEY", Desc: "源表 ID"},
+	{Name: "original_text", Type: "TEXT", Desc: "原文，提取原文前处理失败时为 NULL"},
+	{Name: "modified_text", Type: "TEXT", Desc: "修改后的文章，未能生成时为 NULL"},
+	{Name: "pid", Type: "TEXT", Desc: "任务 ID"},
+	{Name: "error_reason", Type: "TEXT", Desc: "错误原因，处理成功时为 NULL"},
+	{Name: "source_format", Type: "TEXT", Desc: "源数据格式"},
+	{Name: "error_code", Type: "TEXT", Desc: "错误码"},
+	{Name: "validation_error", Type: "TEXT", Desc: "输入校验违例"},
+	{Name: "details", Type: "TEXT", Desc: "错误明细 JSON"},
+	{Name: "raw_size", Type: "BIGINT", Desc: "源内容字节数"},
+	{Name: "content_hash", Type: "TEXT", Desc: "源内容 SHA-256"},
+	{Name: "converted_encoding", Type: "TEXT", Desc: "源内容不是 UTF-8 时解析前转换自的编码"},
+	{Name: "has_errors", Type: "BOOLEAN", Desc: "是否实际应用了修正"},
+	{Name: "correction_count", Type: "INTEGER", Desc: "实际应用的修正数量"},
+	{Name: "conflict_count", Type: "INTEGER", Desc: "同区间冲突未被选中的修正数量"},
+	{Name: "level_counts", Type: "TEXT", Desc: "按错误级别统计的明细数量 JSON"},
+	{Name: "markers_stripped", Type: "BOOLEAN", Desc: "移除错误标记是否改变了文本"},
+	{Name: "html_stripped", Type: "BOOLEAN", Desc: "清洗 HTML 是否改变了文本"},
+	{Name: "needs_review", Type: "BOOLEAN", Desc: "是否需要人工复核"},
+	{Name: "processed_at", Type: "TIMESTAMP", Desc: "处理时间"},
+	{Name: "tool_version", Type: "TEXT", Desc: "处理工具版本"},
+	{Name: "source_created_at", Type: "TIMESTAMP", Desc: "源记录创建时间"},
+	{Name: "source_updated_at", Type: "TIMESTAMP", Desc: "源记录更新时间"},
+}
+
+// runLabelColumn 设置运行标签时追加到目标表末尾的列，所有行的值相同，不设置时表中没有该列
+var runLabelColumn = columnDef{Name: "run_label", Type: "TEXT", Desc: "运行标签"}
+
+// processedValues 返回写入目标表的参数，顺序与 processedColumns 一致
+func processedValues(processed *model.ProcessedContent) ([]interface{}, error) {
+	// 错误明细以 JSON 存储
+	details, err := json.Marshal(processed.Details)
+	if err != nil {
+		return nil, fmt.Errorf("序列化错误明细失败: %v", err)
+	}
+	// 没有错误明细时写入 NULL
+	var levelCounts sql.NullString
+	if processed.LevelCounts != nil {
+		b, err := json.Marshal(processed.LevelCounts)
+		if err != nil {
+			return nil, fmt.Errorf("序列化级别统计失败: %v", err)
+		}
+		levelCounts = sql.NullString{String: string(b), Valid: true}
+	}
+	return []interface{}{
+		processed.ID,
+		sql.NullString{String: processed.OriginalText, Valid: processed.OriginalTextValid},
+		sql.NullString{String: processed.ModifiedText, Valid: processed.ModifiedTextValid},
+		processed.PID,
+		nullString(processed.ErrorReason),
+		nullString(processed.SourceFormat),
+		nullString(processed.ErrorCode),
+		nullString(processed.ValidationError),
+		string(details),
+		processed.RawSize,
+		nullString(processed.ContentHash),
+		nullString(processed.ConvertedEncoding),
+		processed.HasErrors,
+		processed.CorrectionCount,
+		processed.ConflictCount,
+		levelCounts,
+		processed.MarkersStripped,
+		processed.HTMLStripped,
+		nullBool(processed.NeedsReview),
+		nullTime(processed.ProcessedAt),
+		nullString(processed.ToolVersion),
+		nullTime(processed.SourceCreatedAt),
+		nullTime(processed.SourceUpdatedAt),
+	}, nil
+}
+
+// nullString 将空字符串写入为 NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullBool 将可空布尔值转换为 sql.NullBool，nil 写入为 NULL
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+// nullTime 将可空时间转换为 sql.NullTime，nil 写入为 NULL
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// sourceWhere 构造按任务过滤源表的条件（以换行开头）：只包含 taskIDs 中的任务（为空时不限），
+// 并排除 excludeTaskIDs 中的任务；两者都为空时返回空。参数先是包含的任务 ID，后是排除的任务 ID
+func sourceWhere(taskIDs, excludeTaskIDs []string) (string, []interface{}) {
+	args := make([]interface{}, 0, len(taskIDs)+len(excludeTaskIDs)+2)
+	var conds []string
+	if len(taskIDs) > 0 {
+		cond, in := inClause("taskId", taskIDs)
+		conds = append(conds, cond)
+		args = append(args, in...)
+	}
+	if len(excludeTaskIDs) > 0 {
+		cond, in := notInClause("taskId", excludeTaskIDs)
+		conds = append(conds, cond)
+		args = append(args, in...)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return "\n\t\t\tWHERE " + strings.Join(conds, " AND "), args
+}
+
+// buildSourceCountQuery 构造统计本次迁移范围内源表行数的查询，用于显示进度
+func buildSourceCountQuery(taskIDs, excludeTaskIDs []string) (string, []interface{}) {
+	where, args := sourceWhere(taskIDs, excludeTaskIDs)
+	return "SELECT COUNT(*) FROM " + sourceTable + where, args
+}
+
+// buildSourceQuery 构造分批读取源表的查询，taskIDs 为空时读取全部任务，excludeTaskIDs 中的任务不读取
+// 按 ID 游标分批：after 为 true 时只读取 ID 大于上一批最后一条记录的行，不用 OFFSET 重新跳过已读的行。
+// 返回的参数只包含任务 ID，调用方需在其后追加上一批最后的 ID（after 为 true 时）和 LIMIT
+func buildSourceQuery(taskIDs, excludeTaskIDs []string, after bool) (string, []interface{}) {
+	where, args := sourceWhere(taskIDs, excludeTaskIDs)
+	if after {
+		if where == "" {
+			where = "\n\t\t\tWHERE id > ?"
+		} else {
+			where += " AND id > ?"
+		}
+	}
+	return `SELECT id, taskId, content,
+			TRY_STRPTIME(created_at, '%d/%m/%Y %H:%M:%S.%f') AS created_at,
+			TRY_STRPTIME(updated_at, '%d/%m/%Y %H:%M:%S.%f') AS updated_at,
+			TRY_STRPTIME(deleted_at, '%d/%m/%Y %H:%M:%S.%f') AS deleted_at
+			FROM ` + sourceTable + where + `
+			ORDER BY id
+			LIMIT ?`, args
+}
+
+// stagingTableName 返回迁移过程中写入的临时表名
+func stagingTableName(table string) string {
+	return table + "_new"
+}
+
+// buildSwapTableSQL 构造用临时表替换正式表的语句，需在同一事务中执行
+func buildSwapTableSQL(from, to string) []string {
+	return []string{
+		"DROP TABLE IF EXISTS " + to,
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", from, to),
+	}
+}
+
+// buildAddRunLabelSQL 构造为表追加 run_label 列并将已有行填为 label 的语句；
+// 之后写入的行（如重试死信）不带标签，因此填充后去掉默认值
+func buildAddRunLabelSQL(table, label string) []string {
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s DEFAULT %s", table, runLabelColumn.Name, runLabelColumn.Type, quoteLiteral(label)),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, runLabelColumn.Name),
+	}
+}
+
+// buildDropTableSQL 构造删除目标表的语句
+func buildDropTableSQL(table string) string {
+	return "DROP TABLE IF EXISTS " + table
+}
+
+// buildCreateTableSQL 构造创建目标表的语句
+func buildCreateTableSQL(table string) string {
+	defs := make([]string, 0, len(processedColumns))
+	for _, col := range processedColumns {
+		defs = append(defs, fmt.Sprintf("\t%s %s", col.Name, col.Type))
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n)", table, strings.Join(defs, ",\n"))
+}
+
+// buildCreateTableIfNotExistsSQL 构造创建目标表的语句，表已存在时保留其中的记录
+func buildCreateTableIfNotExistsSQL(table string) string {
+	return strings.Replace(buildCreateTableSQL(table), "CREATE TABLE", "CREATE TABLE IF NOT EXISTS", 1)
+}
+
+// buildInsertSQL 构造写入目标表的参数化语句
+func buildInsertSQL(table string) string {
+	names := make([]string, 0, len(processedColumns))
+	placeholders := make([]string, 0, len(processedColumns))
+	for _, col := range processedColumns {
+		names = append(names, col.Name)
+		placeholders = append(placeholders, "?")
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s)\nVALUES (%s)", table, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+}
+
+// buildUpsertSQL 构造写入目标表的参数化语句，ID 已存在时替换该记录
+func buildUpsertSQL(table string) string {
+	return strings.Replace(buildInsertSQL(table), "INSERT INTO", "INSERT OR REPLACE INTO", 1)
+}
+
+// ExplainSQL 返回迁移将要执行的 SQL（不执行），并标注占位符对应的取值
+func (s *MigrationService) ExplainSQL(batchSize int) (string, error) {
+	if err := s.validateIdentifiers(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	b.WriteString("-- 读取源数据（每批执行一次，第一批不带 id > ? 条件）\n")
+	query, args := buildSourceQuery(s.opts.TaskIDs, s.opts.ExcludeTaskIDs, true)
+	b.WriteString(query)
+	b.WriteString(";\n")
+	b.WriteString("-- 参数:")
+	for i, arg := range args {
+		if i < len(s.opts.TaskIDs) {
+			fmt.Fprintf(&b, " ?%d = 任务 ID (%v),", i+1, arg)
+		} else {
+			fmt.Fprintf(&b, " ?%d = 排除的任务 ID (%v),", i+1, arg)
+		}
+	}
+	fmt.Fprintf(&b, " ?%d = 上一批最后一条记录的 ID, ?%d = 批量大小 (%d)\n\n", len(args)+1, len(args)+2, batchSize)
+
+	buildTable := stagingTableName(s.targetTable)
+	b.WriteString("-- 创建临时表（迁移开始时执行一次）\n")
+	b.WriteString(buildDropTableSQL(buildTable))
+	b.WriteString(";\n")
+	b.WriteString(buildCreateTableSQL(buildTable))
+	b.WriteString(";\n\n")
+
+	b.WriteString("-- 写入处理结果（每条记录执行一次）\n")
+	b.WriteString(buildInsertSQL(buildTable))
+	b.WriteString(";\n")
+	b.WriteString("-- 参数:")
+	for i, col := range processedColumns {
+		fmt.Fprintf(&b, " ?%d = %s", i+1, col.Desc)
+		if i < len(processedColumns)-1 {
+			b.WriteString(",")
+		}
+	}
+	b.WriteString("\n\n")
+
+	if s.opts.DuplicateIDs == DuplicateIDUpsert {
+		b.WriteString("-- ID 与已写入的记录重复时替换该记录（每条重复记录执行一次，参数同上）\n")
+		b.WriteString(buildUpsertSQL(buildTable))
+		b.WriteString(";\n\n")
+	}
+
+	if s.opts.RunLabel != "" {
+		b.WriteString("-- 追加运行标签列（迁移成功后、替换正式表前执行）\n")
+		for _, stmt := range buildAddRunLabelSQL(buildTable, s.opts.RunLabel) {
+			b.WriteString(stmt)
+			b.WriteString(";\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("-- 替换正式表（迁移成功后在同一事务中执行）\n")
+	for _, stmt := range buildSwapTableSQL(buildTable, s.targetTable) {
+		b.WriteString(stmt)
+		b.WriteString(";\n")
+	}
+	return b.String(), nil
+}
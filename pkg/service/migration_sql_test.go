@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// injectionTaskIDs 带引号、分号和注释的任务 ID
+var injectionTaskIDs = []string{
+	"t1' OR '1'='1",
+	"t2'; DROP TABLE tbl_verify_content; --",
+	`t3" /* x */`,
+	"t4 -- x",
+}
+
+// TestSourceWhereInjection 任务 ID 只作为参数传递，不拼接到 SQL 中：条件中只有占位符，参数按原样依次传递
+func TestSourceWhereInjection(t *testing.T) {
+	where, args := sourceWhere(injectionTaskIDs[:2], injectionTaskIDs[2:])
+	if want := "\n\t\t\tWHERE taskId IN (?, ?) AND taskId NOT IN (?, ?)"; where != want {
+		t.Errorf("条件为 %q，应为 %q", where, want)
+	}
+	for _, s := range []string{"'", ";", "--", "/*", "DROP"} {
+		if strings.Contains(where, s) {
+			t.Errorf("条件中不应出现 %q: %q", s, where)
+		}
+	}
+	want := []interface{}{injectionTaskIDs[0], injectionTaskIDs[1], injectionTaskIDs[2], injectionTaskIDs[3]}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("参数为 %q，应为 %q", args, want)
+	}
+
+	cond, in := inClause("taskId", injectionTaskIDs)
+	if cond != "taskId IN (?, ?, ?, ?)" || !reflect.DeepEqual(in, want) {
+		t.Errorf("inClause: %q %q", cond, in)
+	}
+	cond, in = notInClause("taskId", injectionTaskIDs[:1])
+	if cond != "taskId NOT IN (?)" || !reflect.DeepEqual(in, want[:1]) {
+		t.Errorf("notInClause: %q %q", cond, in)
+	}
+}
+
+// TestSourceWhereInjectionQuery 在 duckdb 中执行：注入的任务 ID 按字面值比较，不会改变查询或删除源表
+func TestSourceWhereInjectionQuery(t *testing.T) {
+	db := openSourceDB(t, [][]interface{}{
+		{1, "t1", `{}`, nil, nil, nil},
+		{2, injectionTaskIDs[1], `{}`, nil, nil, nil},
+	})
+	query, args := buildSourceCountQuery(injectionTaskIDs, nil)
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("应只匹配任务 ID 与注入字符串完全相同的 1 行，得到 %d", n)
+	}
+	query, args = buildSourceCountQuery(nil, injectionTaskIDs)
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("排除注入的任务 ID 后应剩 1 行，得到 %d", n)
+	}
+}
+
+// TestValidateIdentifiers 目标表名及由其派生的临时表、分区表名都需要合法，带引号、分号、注释的表名被拒绝
+func TestValidateIdentifiers(t *testing.T) {
+	for _, table := range []string{
+		"processed_content; DROP TABLE tbl_verify_content",
+		"processed_content--",
+		"processed_content/**/",
+		`processed_content"`,
+		"processed_content'",
+		"processed content",
+		"",
+	} {
+		s := &MigrationService{targetTable: table}
+		if err := s.validateIdentifiers(); err == nil {
+			t.Errorf("表名 %q 应被拒绝", table)
+		}
+	}
+
+	s := &MigrationService{targetTable: "processed_content"}
+	if err := s.validateIdentifiers(); err != nil {
+		t.Errorf("合法的表名被拒绝: %v", err)
+	}
+	// 临时表名不超长，但分区临时表名超长
+	long := strings.Repeat("t", 59)
+	s = &MigrationService{targetTable: long}
+	if err := s.validateIdentifiers(); err != nil {
+		t.Errorf("长度为 59 的表名应合法: %v", err)
+	}
+	s.opts.PartitionBy = PartitionByMonth
+	if err := s.validateIdentifiers(); err == nil {
+		t.Errorf("分区表名 %s 超长，应被拒绝", stagingTableName(partitionTableName(long, nil)))
+	}
+}
+
+// TestBuildSourceQueryExclude 排除的任务生成 NOT IN 条件，与包含的任务、ID 游标用 AND 组合；
+// 参数依次为包含的任务、排除的任务，调用方再追加上一批最后的 ID 和 LIMIT
+func TestBuildSourceQueryExclude(t *testing.T) {
+	tests := []struct {
+		name      string
+		include   []string
+		exclude   []string
+		after     bool
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{name: "exclude", exclude: []string{"x1", "x2"}, wantWhere: "WHERE taskId NOT IN (?, ?)", wantArgs: []interface{}{"x1", "x2"}},
+		{name: "exclude after", exclude: []string{"x1"}, after: true, wantWhere: "WHERE taskId NOT IN (?) AND id > ?", wantArgs: []interface{}{"x1"}},
+		{name: "include and exclude", include: []string{"t1"}, exclude: []string{"x1", "x2"}, after: true,
+			wantWhere: "WHERE taskId IN (?) AND taskId NOT IN (?, ?) AND id > ?", wantArgs: []interface{}{"t1", "x1", "x2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := buildSourceQuery(tt.include, tt.exclude, tt.after)
+			if !strings.Contains(query, "\n\t\t\t"+tt.wantWhere+"\n") {
+				t.Errorf("查询中没有条件 %q:\n%s", tt.wantWhere, query)
+			}
+			if !strings.HasSuffix(query, "ORDER BY id\n\t\t\tLIMIT ?") {
+				t.Errorf("查询应以 ORDER BY id LIMIT ? 结尾:\n%s", query)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("参数为 %q，应为 %q", args, tt.wantArgs)
+			}
+			count, countArgs := buildSourceCountQuery(tt.include, tt.exclude)
+			if want := strings.TrimSuffix(tt.wantWhere, " AND id > ?"); !strings.HasSuffix(count, want) || !reflect.DeepEqual(countArgs, tt.wantArgs) {
+				t.Errorf("统计查询 %q %q 应使用相同的条件 %q", count, countArgs, want)
+			}
+		})
+	}
+}
+
+// TestDBSourceExclude 第二批的参数顺序为包含的任务、排除的任务、上一批最后的 ID、LIMIT；排除的任务不出现在结果中
+func TestDBSourceExclude(t *testing.T) {
+	db := openSourceDB(t, [][]interface{}{
+		{1, "t1", `{}`, nil, nil, nil},
+		{2, "x1", `{}`, nil, nil, nil},
+		{3, "t2", `{}`, nil, nil, nil},
+		{4, "t1", `{}`, nil, nil, nil},
+		{5, "x2", `{}`, nil, nil, nil},
+		{6, "t2", `{}`, nil, nil, nil},
+	})
+	src := &dbSource{db: db, taskIDs: []string{"t1", "x1"}, excludeTaskIDs: []string{"x1", "x2"}}
+	var ids []uint
+	for offset := 0; ; offset++ {
+		n := 0
+		err := src.scan(context.Background(), 1, offset, func(content *model.VerifyContent, _ []byte, err error) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, content.ID)
+			n++
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(ids, []uint{1, 4}) {
+		t.Errorf("应只读取任务 t1 的行 [1 4]，得到 %v", ids)
+	}
+
+	mockSrc, mock := newSourceMock(t)
+	mockSrc.taskIDs, mockSrc.excludeTaskIDs = []string{"t1"}, []string{"x1"}
+	first, _ := buildSourceQuery(mockSrc.taskIDs, mockSrc.excludeTaskIDs, false)
+	next, _ := buildSourceQuery(mockSrc.taskIDs, mockSrc.excludeTaskIDs, true)
+	mock.ExpectQuery(regexp.QuoteMeta(first)).WithArgs("t1", "x1", 2).WillReturnRows(sourceRows(1, 2))
+	mock.ExpectQuery(regexp.QuoteMeta(next)).WithArgs("t1", "x1", 2, 2).WillReturnRows(sourceRows(3, 3))
+	if _, err := scanAll(t, mockSrc, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanAll(t, mockSrc, 2, 2); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"content-verify-log/pkg/model"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// formatUnknown 未能识别格式（如 JSON 解析失败）时的统计键
+const formatUnknown = "unknown"
+
+// migrationStats 迁移过程中的统计
+type migrationStats struct {
+	runID     string
+	startTime time.Time
+
+	processed int // 成功写入
+	errors    int // 扫描或写入失败
+	skipped   int // 按 --only-errors 过滤未写入
+	filtered  int // 按错误类型过滤未应用的修正数
+	lowLevel  int // 错误级别低于 MinErrorLevel 未应用的修正数
+	dupCorr   int // 与前面某项完全相同、去重后未应用的修正数
+	noText    int // 内容非空但清洗后没有可提取文本
+	recovered int // 位置不一致、在附近查找到错误词后应用的修正数
+	converted int // 源内容不是 UTF-8、转换编码后解析的记录数
+
+	deadLettered int // 写入死信表的记录数
+	scanned      int // 从源读取的行数，包括跳过和无法解析的行
+	failed       int // 扫描失败、无法解析或写入失败的行数，与 scanned 之比用于 MaxErrorRate 检查
+
+	duplicates *duplicateIDs // ID 重复而跳过或替换的记录数
+
+	sizer  *batchSizer    // 开启自适应批量时输出批量范围
+	queues *queueStats    // 流水线队列深度
+	shadow *shadowCompare // 开启影子对比时输出不一致统计
+
+	byFormat   map[string]*formatStats
+	errorCodes map[string]int // 按 error_code 统计的处理结果数（无论是否写入）
+	markers    map[string]*markerCount
+}
+
+// markerCount 按 span class 统计的错误标记数量
+type markerCount struct {
+	removed int // 作为错误标记移除
+	kept    int // jdt_ 类但未移除
+}
+
+// formatStats 按源数据格式的统计
+type formatStats struct {
+	processed int // 成功写入
+	errors    int // 写入失败
+	noErrors  int // 成功写入但没有应用任何修正
+}
+
+func newMigrationStats() *migrationStats {
+	return &migrationStats{
+		runID:      uuid.NewString(),
+		startTime:  time.Now(),
+		byFormat:   make(map[string]*formatStats),
+		errorCodes: make(map[string]int),
+		markers:    make(map[string]*markerCount),
+	}
+}
+
+// marker 返回指定 class 的错误标记统计，不存在时创建
+func (m *migrationStats) marker(class string) *markerCount {
+	mc, ok := m.markers[class]
+	if !ok {
+		mc = &markerCount{}
+		m.markers[class] = mc
+	}
+	return mc
+}
+
+// format 返回指定格式的统计，不存在时创建
+func (m *migrationStats) format(sourceFormat string) *formatStats {
+	if sourceFormat == "" {
+		sourceFormat = formatUnknown
+	}
+	fs, ok := m.byFormat[sourceFormat]
+	if !ok {
+		fs = &formatStats{}
+		m.byFormat[sourceFormat] = fs
+	}
+	return fs
+}
+
+// recordProcessed 记录处理结果（无论是否写入）
+func (m *migrationStats) recordProcessed(result *model.ProcessedContent) {
+	m.filtered += result.FilteredCount
+	m.lowLevel += result.BelowLevelCount
+	m.dupCorr += result.DuplicateCount
+	if result.ErrorCode != "" {
+		m.errorCodes[result.ErrorCode]++
+	}
+	if result.ErrorCode == model.ErrCodeNoExtractableText {
+		m.noText++
+	}
+	if result.ConvertedEncoding != "" {
+		m.converted++
+	}
+	for i := range result.Details {
+		if result.Details[i].Recovered {
+			m.recovered++
+		}
+	}
+	for class, n := range result.RemovedMarkers {
+		m.marker(class).removed += n
+	}
+	for class, n := range result.KeptMarkers {
+		m.marker(class).kept += n
+	}
+}
+
+// recordWritten 记录写入成功
+func (m *migrationStats) recordWritten(result *model.ProcessedContent) {
+	m.processed++
+	fs := m.format(result.SourceFormat)
+	fs.processed++
+	if !result.HasErrors {
+		fs.noErrors++
+	}
+}
+
+// recordWriteError 记录写入失败
+func (m *migrationStats) recordWriteError(result *model.ProcessedContent) {
+	m.errors++
+	m.format(result.SourceFormat).errors++
+}
+
+// log 输出最终汇总
+func (m *migrationStats) log() {
+	zap.S().Infof("处理完成: 成功 %d 条, 失败 %d 条", m.processed, m.errors)
+
+	formats := make([]string, 0, len(m.byFormat))
+	for f := range m.byFormat {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	for _, f := range formats {
+		fs := m.byFormat[f]
+		zap.S().Infof("  格式 %s: 成功 %d 条 (其中无修正 %d 条), 失败 %d 条", f, fs.processed, fs.noErrors, fs.errors)
+	}
+
+	classes := make([]string, 0, len(m.markers))
+	for class := range m.markers {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		mc := m.markers[class]
+		zap.S().Infof("  错误标记 class %s: 移除 %d 处, 保留 %d 处", class, mc.removed, mc.kept)
+	}
+
+	if m.skipped > 0 {
+		zap.S().Infof("没有应用任何修正而未写入: %d 条", m.skipped)
+	}
+	if m.noText > 0 {
+		zap.S().Infof("内容非空但清洗后没有可提取文本: %d 条", m.noText)
+	}
+	if m.converted > 0 {
+		zap.S().Infof("源内容不是 UTF-8、转换编码后解析: %d 条", m.converted)
+	}
+	if m.filtered > 0 {
+		zap.S().Infof("按错误类型过滤未应用的修正: %d 处", m.filtered)
+	}
+	if m.lowLevel > 0 {
+		zap.S().Infof("错误级别低于下限未应用的修正: %d 处", m.lowLevel)
+	}
+	if m.dupCorr > 0 {
+		zap.S().Infof("重复列出、去重后未应用的修正: %d 处", m.dupCorr)
+	}
+	if n := m.errorCodes[model.ErrCodeSuspiciousModification]; n > 0 {
+		zap.S().Warnf("修改后长度异常（%s）: %d 条", model.ErrCodeSuspiciousModification, n)
+	}
+	if n := m.errorCodes[model.ErrCodeTruncatedJSONSalvaged]; n > 0 {
+		zap.S().Warnf("JSON 不完整、提取已知字段处理（%s）: %d 条", model.ErrCodeTruncatedJSONSalvaged, n)
+	}
+	if m.recovered > 0 {
+		zap.S().Infof("位置不一致、在附近查找到错误词后应用的修正: %d 处", m.recovered)
+	}
+	if m.sizer != nil && m.sizer.adaptive() {
+		zap.S().Infof("自适应批量: 最小 %d 条, 最大 %d 条, 最终 %d 条", m.sizer.minSeen, m.sizer.maxSeen, m.sizer.next())
+	}
+	if m.queues != nil {
+		m.queues.log()
+	}
+	if m.shadow != nil {
+		m.shadow.log()
+	}
+	if m.duplicates != nil && m.duplicates.skipped+m.duplicates.replaced > 0 {
+		zap.S().Warnf("ID 与已写入的记录重复（%s）: 跳过 %d 条, 替换 %d 条", m.duplicates.policy, m.duplicates.skipped, m.duplicates.replaced)
+	}
+	if m.deadLettered > 0 {
+		zap.S().Infof("无法处理、已写入死信表 %s: %d 条", deadLetterTable, m.deadLettered)
+	}
+	zap.S().Infof("耗时：%s", time.Since(m.startTime))
+}
+
+// 运行摘要中列出的错误码数量
+const topErrorCodes = 5
+
+// RunSummary 一次迁移的运行摘要，用于结束通知
+type RunSummary struct {
+	RunID          string    `json:"run_id"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	DurationMS     int64     `json:"duration_ms"`
+	TargetTable    string    `json:"target_table"`
+	TaskIDs        []string  `json:"task_ids,omitempty"`
+	ExcludeTaskIDs []string  `json:"exclude_task_ids,omitempty"`
+	RunLabel       string    `json:"run_label,omitempty"`
+	Processed      int       `json:"processed"`     // 成功写入
+	Errors         int       `json:"errors"`        // 扫描或写入失败
+	Skipped        int       `json:"skipped"`       // 按 onlyErrors 过滤未写入
+	DeadLettered   int       `json:"dead_lettered"` // 写入死信表
+	DuplicateIDs   int       `json:"duplicate_ids"` // ID 与已写入的记录重复而跳过或替换
+
+	TopErrorCodes []ErrorCodeCount `json:"top_error_codes"` // 数量最多的错误码，按数量降序
+
+	Shadow *ShadowSummary `json:"shadow,omitempty"` // 影子对比的汇总，未开启时省略
+}
+
+// ErrorCodeCount 一个错误码的记录数
+type ErrorCodeCount struct {
+	Code  string `json:"code"`
+	Count int    `json:"count"`
+}
+
+// summary 返回截至目前的运行摘要，中断或失败时为已完成部分的统计
+func (m *migrationStats) summary() *RunSummary {
+	finished := time.Now()
+	codes := make([]ErrorCodeCount, 0, len(m.errorCodes))
+	for code, count := range m.errorCodes {
+		codes = append(codes, ErrorCodeCount{Code: code, Count: count})
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if codes[i].Count != codes[j].Count {
+			return codes[i].Count > codes[j].Count
+		}
+		return codes[i].Code < codes[j].Code
+	})
+	if len(codes) > topErrorCodes {
+		codes = codes[:topErrorCodes]
+	}
+	var shadow *ShadowSummary
+	if m.shadow != nil {
+		shadow = m.shadow.summary()
+	}
+	var duplicates int
+	if m.duplicates != nil {
+		duplicates = m.duplicates.skipped + m.duplicates.replaced
+	}
+	return &RunSummary{
+		RunID:         m.runID,
+		StartedAt:     m.startTime,
+		FinishedAt:    finished,
+		DurationMS:    finished.Sub(m.startTime).Milliseconds(),
+		Processed:     m.processed,
+		Errors:        m.errors,
+		Skipped:       m.skipped,
+		DeadLettered:  m.deadLettered,
+		DuplicateIDs:  duplicates,
+		TopErrorCodes: codes,
+		Shadow:        shadow,
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"content-verify-log/pkg/model"
+
+	"go.uber.org/zap"
+)
+
+// DefaultTextOpenFiles 文本输出时默认同时打开的文件数
+const DefaultTextOpenFiles = 16
+
+// textSink 每篇文章写一个 <id>.txt，供全文检索等只需要纯文本的下游使用
+// 先写入同级的临时目录，commit 时替换输出目录；abort 时删除临时目录，原输出目录保持不变
+type textSink struct {
+	dir       string
+	staging   string
+	original  bool // 写原文而不是修改后的文章
+	openFiles int  // 同时打开的文件数上限
+	dups      *duplicateIDs
+	names     map[string]string // ID -> 分配的文件名
+	owners    map[string]string // 小写的文件名 -> 使用该文件名的 ID
+	written   map[string]bool   // 本次已写入文件的 ID
+	rows      int64
+	noText    int // 处理在生成文本之前失败、没有写文件的行数
+}
+
+func openTextSink(dir string, original bool, openFiles int, dups *duplicateIDs) (*textSink, error) {
+	dir = filepath.Clean(dir)
+	staging := dir + ".staging"
+	// 上次中断残留的临时目录不再有用
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, fmt.Errorf("清理临时目录 %s 失败: %v", staging, err)
+	}
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return nil, fmt.Errorf("创建临时目录 %s 失败: %v", staging, err)
+	}
+	if openFiles < 1 {
+		openFiles = DefaultTextOpenFiles
+	}
+	return &textSink{
+		dir: dir, staging: staging, original: original, openFiles: openFiles, dups: dups,
+		names: make(map[string]string), owners: make(map[string]string), written: make(map[string]bool),
+	}, nil
+}
+
+// textFileName 返回 ID 对应的文件名，ID 中不适合出现在文件名中的字符替换为 _，保留大小写；
+// suffix 非空时以 - 连接在 ID 之后
+func textFileName(id, suffix string) string {
+	name := unsafePathCharRegex.ReplaceAllString(id, "_")
+	if name == "" || strings.Trim(name, ".") == "" {
+		name = "unknown"
+	}
+	if suffix != "" {
+		name += "-" + suffix
+	}
+	return name + ".txt"
+}
+
+// fileName 返回 ID 对应的文件名，首次出现时分配。不同的 ID 替换字符后相同，或只有大小写不同
+// （在不区分大小写的文件系统上是同一个文件）时，后出现的 ID 追加其 SHA-256 的前 8 位十六进制，
+// 仍冲突时追加完整的哈希；同一 ID 始终对应同一个文件
+func (t *textSink) fileName(id string) string {
+	if name, ok := t.names[id]; ok {
+		return name
+	}
+	sum := sha256.Sum256([]byte(id))
+	hash := hex.EncodeToString(sum[:])
+	name := textFileName(id, "")
+	for _, suffix := range []string{hash[:8], hash} {
+		if _, taken := t.owners[strings.ToLower(name)]; !taken {
+			break
+		}
+		name = textFileName(id, suffix)
+	}
+	t.names[id] = name
+	t.owners[strings.ToLower(name)] = id
+	return name
+}
+
+// write 按 openFiles 并发写入一批结果。ID 与本次已写入的重复时按 dups 的处理方式跳过或覆盖；
+// 同一批中有重复时先确定每个 ID 最终写入哪一行，再并发写入，避免同时写同一个文件
+func (t *textSink) write(ctx context.Context, batch []*model.ProcessedContent) []writeFailure {
+	var failures []writeFailure
+	var ids []string
+	owner := make(map[string]int) // ID -> 最终写入该 ID 文件的行
+	for i, processed := range batch {
+		valid := processed.ModifiedTextValid
+		if t.original {
+			valid = processed.OriginalTextValid
+		}
+		if !valid {
+			t.noText++
+			continue
+		}
+		id := processed.ID
+		_, inBatch := owner[id]
+		if inBatch || t.written[id] {
+			if t.dups.policy != DuplicateIDUpsert {
+				t.dups.skipped++
+				failures = append(failures, writeFailure{index: i, err: errDuplicateID})
+				continue
+			}
+			t.dups.replaced++
+		}
+		if !inBatch {
+			ids = append(ids, id)
+		}
+		owner[id] = i
+	}
+
+	// 文件名按 ID 在批内出现的顺序分配，与并发写入的完成顺序无关
+	names := make([]string, len(ids))
+	for j, id := range ids {
+		names[j] = t.fileName(id)
+	}
+	errs := make([]error, len(ids))
+	forEachParallel(len(ids), t.openFiles, func(j int) {
+		if err := ctx.Err(); err != nil {
+			errs[j] = err
+			return
+		}
+		processed := batch[owner[ids[j]]]
+		text := processed.ModifiedText
+		if t.original {
+			text = processed.OriginalText
+		}
+		if err := writeTextFile(filepath.Join(t.staging, names[j]), text); err != nil {
+			errs[j] = fmt.Errorf("写入文本文件失败: %v", err)
+		}
+	})
+
+	for j, id := range ids {
+		if errs[j] != nil {
+			failures = append(failures, writeFailure{index: owner[id], err: errs[j]})
+			continue
+		}
+		if !t.written[id] {
+			t.written[id] = true
+			t.rows++
+		}
+	}
+	return failures
+}
+
+// writeTextFile 先写临时文件再重命名，upsert 覆盖已写入的文件失败时原文件保持完整
+func writeTextFile(path, text string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text), 0644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// commit 用临时目录替换输出目录：原输出目录先改名为 <dir>.old，替换成功后删除
+func (t *textSink) commit(context.Context) error {
+	old := t.dir + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return fmt.Errorf("清理目录 %s 失败: %v", old, err)
+	}
+	if err := os.Rename(t.dir, old); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("移走原输出目录失败: %v", err)
+	}
+	if err := os.Rename(t.staging, t.dir); err != nil {
+		// 尽量恢复原输出目录
+		_ = os.Rename(old, t.dir)
+		return fmt.Errorf("替换输出目录失败: %v", err)
+	}
+	if err := os.RemoveAll(old); err != nil {
+		zap.S().Warnf("删除原输出目录 %s 失败: %v", old, err)
+	}
+	zap.S().Infof("文本输出 %s: 写入 %d 个文件", t.dir, t.rows)
+	if t.noText > 0 {
+		zap.S().Warnf("%d 条记录处理失败、没有生成文本，未写入文件", t.noText)
+	}
+	return nil
+}
+
+func (t *textSink) abort(context.Context) {
+	if err := os.RemoveAll(t.staging); err != nil {
+		zap.S().Warnf("清理临时目录 %s 失败: %v", t.staging, err)
+	}
+}
+
+// outputs 文本文件不是表，不计入清单
+func (t *textSink) outputs() []sinkOutput { return nil }
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// TestTextFileName ID 中不适合出现在文件名中的字符替换为 _，保留大小写，后缀以 - 连接
+func TestTextFileName(t *testing.T) {
+	tests := []struct {
+		id, suffix, want string
+	}{
+		{id: "Abc-1.x", want: "Abc-1.x.txt"},
+		{id: "a/b\\c", want: "a_b_c.txt"},
+		{id: "任务", want: "__.txt"},
+		{id: "", want: "unknown.txt"},
+		{id: "..", want: "unknown.txt"},
+		{id: "A", suffix: "1234abcd", want: "A-1234abcd.txt"},
+	}
+	for _, tt := range tests {
+		if got := textFileName(tt.id, tt.suffix); got != tt.want {
+			t.Errorf("%q %q: 得到 %q，应为 %q", tt.id, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+// readTextDir 返回目录中的全部文件名和内容
+func readTextDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[e.Name()] = string(b)
+	}
+	return files
+}
+
+// TestTextSinkDuplicates 完全相同的 ID 按处理方式跳过或覆盖，跨批和同一批内一致
+func TestTextSinkDuplicates(t *testing.T) {
+	tests := []struct {
+		policy   string
+		want     map[string]string
+		failures int
+	}{
+		{policy: DuplicateIDSkip, want: map[string]string{"A.txt": "甲", "b.txt": "乙"}, failures: 2},
+		{policy: DuplicateIDUpsert, want: map[string]string{"A.txt": "丁", "b.txt": "乙"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			ctx := context.Background()
+			dir := filepath.Join(t.TempDir(), "text")
+			dups, _ := newDuplicateIDs(tt.policy)
+			sink, err := openTextSink(dir, false, 2, dups)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var failures []writeFailure
+			failures = append(failures, sink.write(ctx, []*model.ProcessedContent{processedRow("A", "甲"), processedRow("b", "乙")})...)
+			failures = append(failures, sink.write(ctx, []*model.ProcessedContent{processedRow("A", "丙"), processedRow("A", "丁")})...)
+			if len(failures) != tt.failures {
+				t.Errorf("失败 %d 行，应为 %d: %v", len(failures), tt.failures, failures)
+			}
+			if dups.skipped+dups.replaced != 2 {
+				t.Errorf("skipped=%d replaced=%d，应共计 2", dups.skipped, dups.replaced)
+			}
+			if err := sink.commit(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if got := readTextDir(t, dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("输出目录为 %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// idSuffix 返回 ID 的 SHA-256 前 8 位十六进制，即文件名冲突时追加的后缀
+func idSuffix(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// TestTextSinkNameCollisions 不同的 ID 替换字符后相同或只有大小写不同时都写入，后出现的 ID 追加哈希后缀，
+// 不计为重复；同一 ID 再次出现时覆盖同一个文件，两次运行的文件名相同
+func TestTextSinkNameCollisions(t *testing.T) {
+	ctx := context.Background()
+	want := map[string]string{
+		"A.txt":                           "甲",
+		"a-" + idSuffix("a") + ".txt":     "丁",
+		"a_b.txt":                         "丙",
+		"a_b-" + idSuffix("a_b") + ".txt": "戊",
+	}
+	var runs []map[string]string
+	for range 2 {
+		dir := filepath.Join(t.TempDir(), "text")
+		dups, _ := newDuplicateIDs(DuplicateIDUpsert)
+		sink, err := openTextSink(dir, false, 2, dups)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var failures []writeFailure
+		failures = append(failures, sink.write(ctx, []*model.ProcessedContent{processedRow("A", "甲"), processedRow("a", "乙"), processedRow("a/b", "丙")})...)
+		failures = append(failures, sink.write(ctx, []*model.ProcessedContent{processedRow("a", "丁"), processedRow("a_b", "戊")})...)
+		if len(failures) > 0 {
+			t.Fatalf("写入失败: %v", failures)
+		}
+		if dups.skipped != 0 || dups.replaced != 1 || sink.rows != 4 {
+			t.Errorf("skipped=%d replaced=%d rows=%d，应为 0、1、4", dups.skipped, dups.replaced, sink.rows)
+		}
+		if err := sink.commit(ctx); err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, readTextDir(t, dir))
+	}
+	for _, got := range runs {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("输出目录为 %v，应为 %v", got, want)
+		}
+	}
+}
+
+// TestTextSinkFailedOverwrite upsert 覆盖已写入的文件失败时原文件保持完整，该行计为失败
+func TestTextSinkFailedOverwrite(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "text")
+	dups, _ := newDuplicateIDs(DuplicateIDUpsert)
+	sink, err := openTextSink(dir, false, 1, dups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures := sink.write(ctx, []*model.ProcessedContent{processedRow("a", "原来的文章")}); len(failures) > 0 {
+		t.Fatal(failures)
+	}
+	// 临时文件的位置被非空目录占用，写入失败
+	if err := os.MkdirAll(filepath.Join(sink.staging, "a.txt.tmp", "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if failures := sink.write(ctx, []*model.ProcessedContent{processedRow("a", "新的文章")}); len(failures) != 1 {
+		t.Fatalf("覆盖应失败，得到 %v", failures)
+	}
+	b, err := os.ReadFile(filepath.Join(sink.staging, "a.txt"))
+	if err != nil || string(b) != "原来的文章" {
+		t.Errorf("原文件应保持完整，得到 %q %v", b, err)
+	}
+	if sink.rows != 1 {
+		t.Errorf("写入的文件数为 %d，应为 1", sink.rows)
+	}
+}
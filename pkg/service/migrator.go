@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Migrator 不依赖全局连接的迁移入口，用于在其他 Go 程序中嵌入处理逻辑。
+// 源库、目标库和内容处理器都由调用方创建后显式传入（连接可用 db.Open 或 sql.Open 打开，由调用方负责关闭），
+// 由它创建的迁移服务不会读写 db 包中的全局连接；命令行仍使用 db.InitDuckDB 和 NewMigrationService。
+// Migrator 本身没有可变状态，可以并发使用，但同时运行的迁移应写入不同的目标表
+type Migrator struct {
+	source    *sql.DB
+	target    *sql.DB
+	processor *ContentProcessor
+}
+
+// NewMigrator 创建迁移入口。target 为写入结果、死信表和运行日志的库，不能为 nil；
+// source 为 tbl_verify_content 所在的库，只从目录迁移时可以为 nil；processor 为 nil 时使用默认选项
+func NewMigrator(source, target *sql.DB, processor *ContentProcessor) (*Migrator, error) {
+	if target == nil {
+		return nil, errors.New("目标库连接不能为空")
+	}
+	if processor == nil {
+		processor = NewContentProcessor()
+	}
+	return &Migrator{source: source, target: target, processor: processor}, nil
+}
+
+// Processor 返回迁移使用的内容处理器，可直接用它处理单条记录
+func (m *Migrator) Processor() *ContentProcessor {
+	return m.processor
+}
+
+// Service 按 opts 创建使用这些连接和处理器的迁移服务，用于重试死信、统计等 Migrate 以外的操作；
+// opts.Processor 不使用，处理选项以 NewMigrator 传入的处理器为准
+func (m *Migrator) Service(opts MigrationOptions) *MigrationService {
+	s := newMigrationService(opts, m.processor)
+	s.sourceDB = m.source
+	s.targetDB = m.target
+	s.detached = true
+	return s
+}
+
+// Migrate 按 opts 执行一次迁移，返回运行摘要；迁移失败或中断时同时返回已完成部分的摘要（尚未开始时为 nil）
+func (m *Migrator) Migrate(ctx context.Context, opts MigrationOptions, batchSize int) (*RunSummary, error) {
+	s := m.Service(opts)
+	err := s.MigrateToDuckDB(ctx, batchSize)
+	return s.RunSummary(), err
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"slices"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// PositionMapper 将源数据中的位置和长度换算为工作文本中的 rune 区间
+// 不同来源的位置单位不一致（旧格式的 pos 是字节、新格式的 position 是字符，也有前端按 UTF-16 计算的数据），
+// 换算统一经过该接口，不在各处手工转换
+type PositionMapper interface {
+	// Len 返回 s 按该单位计算的长度，源数据没有给出长度时（旧格式）用错误词计算
+	Len(s string) int
+	// RuneRange 将以该单位表示的位置 pos 和长度 length 换算为 runes 中的区间 [start, end)，
+	// 位置或长度为负、落在字符中间、超出文本时 ok 为 false
+	RuneRange(runes []rune, pos, length int) (start, end int, ok bool)
+}
+
+var (
+	// RunePositions 按字符（rune）计算位置，新格式的默认单位
+	RunePositions PositionMapper = runeMapper{}
+	// BytePositions 按 UTF-8 字节计算位置，旧格式的默认单位
+	BytePositions PositionMapper = unitMapper{unitLen: utf8.RuneLen}
+	// UTF16Positions 按 UTF-16 码元计算位置，与 JavaScript 的字符串下标一致
+	UTF16Positions PositionMapper = unitMapper{unitLen: utf16.RuneLen}
+)
+
+// runeMapper 位置即 rune 下标，无需换算
+type runeMapper struct{}
+
+func (runeMapper) Len(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+func (runeMapper) RuneRange(runes []rune, pos, length int) (int, int, bool) {
+	end := pos + length
+	if pos < 0 || length < 0 || end > len(runes) {
+		return 0, 0, false
+	}
+	return pos, end, true
+}
+
+// unitMapper 每个 rune 占若干单位的位置，按 unitLen 累加换算
+type unitMapper struct {
+	unitLen func(r rune) int
+}
+
+func (m unitMapper) Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += m.width(r)
+	}
+	return n
+}
+
+// width 返回 r 占用的单位数，无效 rune 按替换字符计算
+func (m unitMapper) width(r rune) int {
+	if w := m.unitLen(r); w > 0 {
+		return w
+	}
+	return m.unitLen(utf8.RuneError)
+}
+
+func (m unitMapper) RuneRange(runes []rune, pos, length int) (int, int, bool) {
+	if pos < 0 || length < 0 {
+		return 0, 0, false
+	}
+	start := -1
+	units := 0
+	// i 取到 len(runes)，使文本末尾也是合法的边界
+	for i := 0; i <= len(runes); i++ {
+		if units == pos {
+			start = i
+		}
+		if start >= 0 && units == pos+length {
+			return start, i, true
+		}
+		if i == len(runes) || units > pos+length {
+			break
+		}
+		units += m.width(runes[i])
+	}
+	return 0, 0, false
+}
+
+// runeRanges 返回在 runes 上按 m 换算区间的函数，结果与 m.RuneRange(runes, ...) 相同
+// 按单位换算时首次调用预先计算每个 rune 之前的单位数，之后二分查找，同一文本上多次换算时不必每次从头累加
+func runeRanges(m PositionMapper, runes []rune) func(pos, length int) (int, int, bool) {
+	um, ok := m.(unitMapper)
+	if !ok {
+		return func(pos, length int) (int, int, bool) { return m.RuneRange(runes, pos, length) }
+	}
+	var units []int // units[i] 为 runes[:i] 的单位数，严格递增
+	return func(pos, length int) (int, int, bool) {
+		if pos < 0 || length < 0 {
+			return 0, 0, false
+		}
+		if units == nil {
+			units = make([]int, len(runes)+1)
+			for i, r := range runes {
+				units[i+1] = units[i] + um.width(r)
+			}
+		}
+		start, found := slices.BinarySearch(units, pos)
+		if !found {
+			return 0, 0, false
+		}
+		end, found := slices.BinarySearch(units[start:], pos+length)
+		if !found {
+			return 0, 0, false
+		}
+		return start, start + end, true
+	}
+}
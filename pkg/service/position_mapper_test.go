@@ -0,0 +1,20 @@
+package service
+
+import "testing"
+
+// TestRuneRanges 预先计算单位数的换算与逐次累加的 RuneRange 结果一致，包括落在字符中间、越界和文本末尾
+func TestRuneRanges(t *testing.T) {
+	runes := []rune("a我😀b")
+	for _, m := range []PositionMapper{RunePositions, BytePositions, UTF16Positions} {
+		ranges := runeRanges(m, runes)
+		for pos := -1; pos <= 12; pos++ {
+			for length := -1; length <= 12; length++ {
+				start, end, ok := m.RuneRange(runes, pos, length)
+				gotStart, gotEnd, gotOK := ranges(pos, length)
+				if gotStart != start || gotEnd != end || gotOK != ok {
+					t.Errorf("%T pos=%d length=%d: 得到 (%d, %d, %v)，应为 (%d, %d, %v)", m, pos, length, gotStart, gotEnd, gotOK, start, end, ok)
+				}
+			}
+		}
+	}
+}
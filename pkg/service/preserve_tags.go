@@ -0,0 +1,125 @@
+package service
+
+import (
+	"html"
+	"strings"
+)
+
+// preserveSet 返回需要原样保留的标签名集合（小写），未设置时为 nil
+func preserveSet(tags []string) map[string]bool {
+	if len(tags) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[strings.ToLower(tag)] = true
+	}
+	return set
+}
+
+// tagName 返回标签 <name ...> 或 </name> 的小写标签名，不是开始或结束标签（如注释、<!DOCTYPE>、"< b>"）时返回空
+func tagName(tag string) string {
+	s := strings.TrimPrefix(tag[1:], "/")
+	n := 0
+	for n < len(s) && (isASCIILetter(s[n]) || (n > 0 && (s[n] == '-' || (s[n] >= '0' && s[n] <= '9')))) {
+		n++
+	}
+	if n == 0 || n == len(s) {
+		return ""
+	}
+	switch s[n] {
+	case '>', '/', ' ', '\t', '\n', '\r', '\f':
+		return strings.ToLower(s[:n])
+	}
+	return ""
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// stripHTMLPreserving 清洗 HTML 用于存储，preserveTags 中的标签（开始和结束标签）原样保留
+// 与 stripHTML 先整体解码实体再移除标签不同，这里先在源文本上识别标签：保留的标签不经过实体解码，
+// 其余文字解码后按 stripHTML 的方式移除其中的标签，因此 &lt;sup&gt; 之类编码后的文字不会被当作保留的标签
+func (p *ContentProcessor) stripHTMLPreserving(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	// 链接的开始和结束标签可能被保留的标签隔开，整个文本共用一个 tagRenderer
+	r := p.newTagRenderer()
+	// pending 为两个保留标签之间的文字（已去掉其中的标签），写入前统一解码
+	var pending strings.Builder
+	flush := func() {
+		if pending.Len() > 0 {
+			b.WriteString(stripTags(html.UnescapeString(pending.String()), r))
+			pending.Reset()
+		}
+	}
+
+	rest := text
+	// 与 stripHTML 一致：出现过未闭合的注释后不再按注释查找
+	unterminated := false
+	for {
+		next := strings.IndexByte(rest, '<')
+		if next < 0 {
+			pending.WriteString(rest)
+			break
+		}
+		pending.WriteString(rest[:next])
+		rest = rest[next:]
+
+		end := -1
+		comment := false
+		if !unterminated && strings.HasPrefix(rest, commentOpen) {
+			if end = commentEnd(rest); end < 0 {
+				unterminated = true
+			}
+			comment = end >= 0
+		}
+		if end < 0 {
+			end = strings.IndexByte(rest, '>')
+		}
+		if end < 0 {
+			// 没有闭合的 '>'，不构成标签，原样保留
+			pending.WriteString(rest)
+			break
+		}
+		tag := rest[:end+1]
+		rest = rest[end+1:]
+		if comment {
+			continue
+		}
+		if p.preserve[tagName(tag)] {
+			flush()
+			b.WriteString(tag)
+		} else if rendered := r.render(html.UnescapeString(tag)); rendered != "" {
+			flush()
+			b.WriteString(rendered)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// removePreservedTags 从存储的文本中移除保留的标签，得到与明细位置对应的纯文本
+func (p *ContentProcessor) removePreservedTags(text string) string {
+	if len(p.preserve) == 0 || !strings.Contains(text, "<") {
+		return text
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); {
+		if text[i] == '<' {
+			// 标签内不能再出现 '<'，解码得到的 "<" 不会与之后的标签连成一个
+			if end := strings.IndexAny(text[i+1:], "<>"); end >= 0 && text[i+1+end] == '>' {
+				tag := text[i : i+end+2]
+				if p.preserve[tagName(tag)] {
+					i += len(tag)
+					continue
+				}
+			}
+		}
+		b.WriteByte(text[i])
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package service
+
+import "testing"
+
+// TestStripHTMLPreserving 保留的标签（开始和结束标签）原样保留，其余标签照常移除；
+// 编码后的 &lt;sup&gt; 是文字而不是标签，解码后按普通文字中的标签处理，不会被当作保留的标签
+func TestStripHTMLPreserving(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "basic", in: "<p>第<sup>1</sup>条</p>", want: "第<sup>1</sup>条"},
+		{name: "case insensitive", in: "第<SUP>1</SUP>条", want: "第<SUP>1</SUP>条"},
+		{name: "attributes", in: `第<sup class="n">1</sup>条`, want: `第<sup class="n">1</sup>条`},
+		{name: "preserved inside stripped", in: "<span>甲<del>乙</del>丙</span>", want: "甲<del>乙</del>丙"},
+		{name: "stripped inside preserved", in: "<del>甲<b>乙</b></del>", want: "<del>甲乙</del>"},
+		{name: "similar name", in: "<supx>1</supx><s>2</s>", want: "12"},
+		{name: "entities around", in: "a&amp;b<sub>2</sub>&lt;c&gt;", want: "a&b<sub>2</sub>"},
+		{name: "encoded tag", in: "&lt;sup&gt;1&lt;/sup&gt;", want: "1"},
+		{name: "comment", in: "<sup>1</sup><!-- <sup>2</sup> -->", want: "<sup>1</sup>"},
+	}
+	p := NewContentProcessor(WithPreserveTags("sup", "sub", "del"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.stripStoredHTML(tt.in)
+			if got != tt.want {
+				t.Fatalf("得到 %q，应为 %q", got, tt.want)
+			}
+			// 去掉保留的标签后与不保留任何标签时清洗的结果一致，明细中的位置基于该文本
+			if plain, want := p.removePreservedTags(got), NewContentProcessor().stripHTML(tt.in); plain != want {
+				t.Errorf("去掉保留的标签后为 %q，应为 %q", plain, want)
+			}
+		})
+	}
+}
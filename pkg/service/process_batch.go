@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"content-verify-log/pkg/model"
+)
+
+// BatchOptions ProcessBatch 的选项
+type BatchOptions struct {
+	Workers int // 并发处理的 worker 数，小于 1 时按 1 处理
+	// OnResult 每条记录处理完成后在处理它的 goroutine 中调用，took 为处理耗时；因取消未处理的记录不调用。
+	// 可能被多个 goroutine 同时调用，需自行保证并发安全，为 nil 时不调用
+	OnResult func(i int, result *model.ProcessedContent, took time.Duration)
+}
+
+// BatchStats 一批记录的处理汇总
+type BatchStats struct {
+	Total       int            // 输入的记录数
+	Processed   int            // 已处理的记录数，不含因取消未处理的
+	Cancelled   int            // 因 ctx 取消未处理的记录数
+	WithErrors  int            // 实际应用了修正的记录数
+	Corrections int            // 应用的修正总数
+	ErrorCodes  map[string]int // 按 error_code 统计的已处理记录数，没有错误码的不计
+	Elapsed     time.Duration  // 整批的耗时
+}
+
+// ProcessBatch 用至多 opts.Workers 个 goroutine 处理一批记录，结果顺序与输入一致，ID 取源记录的 ID。
+// ctx 取消后尚未开始的记录不再处理，结果的 error_code 为 CANCELLED；已开始的记录照常处理完
+func (p *ContentProcessor) ProcessBatch(ctx context.Context, items []*model.VerifyContent, opts BatchOptions) ([]*model.ProcessedContent, BatchStats) {
+	start := time.Now()
+	results := make([]*model.ProcessedContent, len(items))
+	forEachParallel(len(items), opts.Workers, func(i int) {
+		if ctx.Err() != nil {
+			results[i] = cancelledResult(ctx, items[i])
+			return
+		}
+		t := time.Now()
+		result := p.ProcessContent(items[i])
+		// 使用源表的 ID 作为主键
+		result.ID = strconv.FormatUint(uint64(items[i].ID), 10)
+		results[i] = result
+		if opts.OnResult != nil {
+			opts.OnResult(i, result, time.Since(t))
+		}
+	})
+
+	stats := BatchStats{Total: len(items), ErrorCodes: make(map[string]int), Elapsed: time.Since(start)}
+	for _, result := range results {
+		if result.ErrorCode == model.ErrCodeCancelled {
+			stats.Cancelled++
+			continue
+		}
+		stats.Processed++
+		stats.Corrections += result.CorrectionCount
+		if result.HasErrors {
+			stats.WithErrors++
+		}
+		if result.ErrorCode != "" {
+			stats.ErrorCodes[result.ErrorCode]++
+		}
+	}
+	return results, stats
+}
+
+// cancelledResult 返回因取消未处理的记录的结果
+func cancelledResult(ctx context.Context, item *model.VerifyContent) *model.ProcessedContent {
+	return &model.ProcessedContent{
+		ID:          strconv.FormatUint(uint64(item.ID), 10),
+		PID:         item.TaskID,
+		ErrorCode:   model.ErrCodeCancelled,
+		ErrorReason: fmt.Sprintf("处理前已取消: %v", context.Cause(ctx)),
+		RawSize:     item.Content.Size(),
+		ToolVersion: toolVersion,
+	}
+}
+
+// forEachParallel 用至多 workers 个 goroutine 对 [0, n) 的每个下标调用 fn，全部完成后返回
+func forEachParallel(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
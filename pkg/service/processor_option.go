@@ -0,0 +1,204 @@
+package service
+
+import (
+	"content-verify-log/config"
+	"content-verify-log/pkg/model"
+	"content-verify-log/pkg/util"
+)
+
+// Option 内容处理器的可选配置，传给 NewContentProcessor
+type Option func(*ProcessorOptions)
+
+// WithOptions 整体设置处理选项，之后的 Option 在此基础上继续修改
+func WithOptions(opts ProcessorOptions) Option {
+	return func(o *ProcessorOptions) {
+		*o = opts
+	}
+}
+
+// WithIncludeTypes 仅应用这些错误类型的修正
+// 复制后再追加，避免修改 WithOptions 传入的切片
+func WithIncludeTypes(typeIDs ...int) Option {
+	return func(o *ProcessorOptions) {
+		o.IncludeTypes = append(append([]int(nil), o.IncludeTypes...), typeIDs...)
+	}
+}
+
+// WithExcludeTypes 不应用这些错误类型的修正，优先于 WithIncludeTypes
+func WithExcludeTypes(typeIDs ...int) Option {
+	return func(o *ProcessorOptions) {
+		o.ExcludeTypes = append(append([]int(nil), o.ExcludeTypes...), typeIDs...)
+	}
+}
+
+// WithMinErrorLevel 只应用错误级别不低于 level 的修正
+func WithMinErrorLevel(level int) Option {
+	return func(o *ProcessorOptions) {
+		o.MinErrorLevel = level
+	}
+}
+
+// WithDedupCorrections 位置、错误词和建议词都相同的重复修正项只应用第一项
+func WithDedupCorrections() Option {
+	return func(o *ProcessorOptions) {
+		o.DedupCorrections = true
+	}
+}
+
+// WithValidateInput 处理前校验输入格式，违例记为 SCHEMA_VIOLATION
+func WithValidateInput() Option {
+	return func(o *ProcessorOptions) {
+		o.ValidateInput = true
+	}
+}
+
+// WithNormalizeText 清洗 HTML 后统一换行符并移除零宽字符
+func WithNormalizeText() Option {
+	return func(o *ProcessorOptions) {
+		o.NormalizeText = true
+	}
+}
+
+// WithNormalizeQuotes 设置引号规范化方式 QuotesOff/QuotesMatch/QuotesAll
+func WithNormalizeQuotes(mode string) Option {
+	return func(o *ProcessorOptions) {
+		o.NormalizeQuotes = mode
+	}
+}
+
+// WithKeepHTML 存储的原文和修改后的文章保留 HTML，只移除错误标记
+func WithKeepHTML() Option {
+	return func(o *ProcessorOptions) {
+		o.KeepHTML = true
+	}
+}
+
+// WithPreserveTags 清洗 HTML 用于存储时原样保留这些标签
+func WithPreserveTags(tags ...string) Option {
+	return func(o *ProcessorOptions) {
+		o.PreserveTags = tags
+	}
+}
+
+// WithImageAltText 清洗 HTML 时将图片替换为 "[图: alt]"
+func WithImageAltText() Option {
+	return func(o *ProcessorOptions) {
+		o.ImageAltText = true
+	}
+}
+
+// WithLinkURLs 清洗 HTML 时在链接文字后附上链接地址
+func WithLinkURLs() Option {
+	return func(o *ProcessorOptions) {
+		o.LinkURLs = true
+	}
+}
+
+// WithMarkerClasses 设置新格式中作为错误标记移除的 span class，prefix 不为空时 class 以其开头的 span 同样移除，
+// keep 中的 class 不移除
+func WithMarkerClasses(classes []string, prefix string, keep []string) Option {
+	return func(o *ProcessorOptions) {
+		o.MarkerClasses = classes
+		o.MarkerClassPrefix = prefix
+		o.KeepMarkerClasses = keep
+	}
+}
+
+// WithContextWindow 为已应用的修正提取前后各 window 个字符的上下文，0 表示不提取
+func WithContextWindow(window int) Option {
+	return func(o *ProcessorOptions) {
+		o.ContextWindow = window
+	}
+}
+
+// WithLengthRatio 修正后检查修改后的文章与原文的长度之比，超出 [min, max] 时记为 SUSPICIOUS_MODIFICATION，0 表示不检查该侧
+// keepOriginal 为 true 时超出范围的记录保留原文
+func WithLengthRatio(min, max float64, keepOriginal bool) Option {
+	return func(o *ProcessorOptions) {
+		o.LengthRatio = LengthRatioCheck{Min: min, Max: max, KeepOriginal: keepOriginal}
+	}
+}
+
+// WithNeedsReview 为结果设置 NeedsReview：跳过的修正占比超过 skipRatio，或有错误明细却未修改原文时需要复核
+func WithNeedsReview(skipRatio float64) Option {
+	return func(o *ProcessorOptions) {
+		o.TagNeedsReview = true
+		o.ReviewSkipRatio = skipRatio
+	}
+}
+
+// WithJSONLimits 设置解析源内容前检查的大小和嵌套层数限制
+func WithJSONLimits(limits model.JSONLimits) Option {
+	return func(o *ProcessorOptions) {
+		o.JSONLimits = limits
+	}
+}
+
+// WithSalvageTruncated 源内容不是合法 JSON 时从中提取已知字段尽量处理
+func WithSalvageTruncated() Option {
+	return func(o *ProcessorOptions) {
+		o.SalvageTruncated = true
+	}
+}
+
+// WithSearchWindow 设置位置与错误词不一致时的查找窗口（字符数），0 表示不查找
+func WithSearchWindow(window int) Option {
+	return func(o *ProcessorOptions) {
+		o.SearchWindow = window
+	}
+}
+
+// WithPositionFormat 设置新格式 position 的表示方式 PositionFormatOffset/PositionFormatLineCol
+func WithPositionFormat(format string) Option {
+	return func(o *ProcessorOptions) {
+		o.PositionFormat = format
+	}
+}
+
+// WithOldPositions 设置旧格式 pos 的单位，默认按字节
+func WithOldPositions(m PositionMapper) Option {
+	return func(o *ProcessorOptions) {
+		o.OldPositions = m
+	}
+}
+
+// WithNewPositions 设置新格式 position/length 的单位，默认按字符
+func WithNewPositions(m PositionMapper) Option {
+	return func(o *ProcessorOptions) {
+		o.NewPositions = m
+	}
+}
+
+// ProcessorOptionsFromConfig 将迁移配置中的处理选项转换为处理器选项，配置需已通过校验
+func ProcessorOptionsFromConfig(cfg *config.MigrationConfig) ProcessorOptions {
+	// 已在配置校验时检查过格式
+	maxJSONSize, _ := util.ParseByteSize(cfg.MaxJSONSize)
+	return ProcessorOptions{
+		IncludeTypes:      cfg.IncludeTypes,
+		ExcludeTypes:      cfg.ExcludeTypes,
+		MinErrorLevel:     cfg.MinErrorLevel,
+		DedupCorrections:  cfg.DedupCorrections,
+		ValidateInput:     cfg.ValidateInput,
+		NormalizeText:     cfg.NormalizeText,
+		NormalizeQuotes:   cfg.NormalizeQuotes,
+		KeepHTML:          cfg.KeepHTML,
+		PreserveTags:      cfg.PreserveTags,
+		ImageAltText:      cfg.ImageAltText,
+		LinkURLs:          cfg.LinkURLs,
+		MarkerClasses:     cfg.MarkerClasses,
+		MarkerClassPrefix: cfg.MarkerClassPrefix,
+		KeepMarkerClasses: cfg.KeepMarkerClasses,
+		SearchWindow:      cfg.SearchWindow,
+		ContextWindow:     cfg.ContextWindow,
+		PositionFormat:    cfg.PositionFormat,
+		LengthRatio: LengthRatioCheck{
+			Min:          cfg.MinLengthRatio,
+			Max:          cfg.MaxLengthRatio,
+			KeepOriginal: cfg.KeepOriginalOnSuspicious,
+		},
+		TagNeedsReview:   cfg.TagNeedsReview,
+		ReviewSkipRatio:  cfg.ReviewSkipRatio,
+		JSONLimits:       model.JSONLimits{MaxSize: maxJSONSize, MaxDepth: cfg.MaxJSONDepth},
+		SalvageTruncated: cfg.SalvageTruncated,
+	}
+}
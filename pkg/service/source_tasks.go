@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// TaskRowCount 源表中一个任务的记录数
+type TaskRowCount struct {
+	TaskID string // 任务 ID，源表中为 NULL 时为空
+	Valid  bool   // 任务 ID 是否非 NULL
+	Rows   int64  // 记录数
+}
+
+// buildTaskCountsQuery 构造按任务统计源表记录数的查询，按记录数降序排列
+// includeDeleted 为 false 时排除 deleted_at 能解析为时间的软删除记录，与迁移读取时的解析方式一致
+func buildTaskCountsQuery(includeDeleted bool) string {
+	where := ""
+	if !includeDeleted {
+		where = "\n\t\tWHERE TRY_STRPTIME(deleted_at, '%d/%m/%Y %H:%M:%S.%f') IS NULL"
+	}
+	return `SELECT taskId, COUNT(*)
+		FROM ` + sourceTable + where + `
+		GROUP BY taskId
+		ORDER BY 2 DESC, 1`
+}
+
+// ListSourceTasks 统计源表中每个任务的记录数，用于选择 --task-id 和了解数据分布
+func ListSourceTasks(ctx context.Context, duckDB *sql.DB, includeDeleted bool) ([]TaskRowCount, error) {
+	if duckDB == nil {
+		return nil, fmt.Errorf("DuckDB 连接未初始化")
+	}
+	rows, err := duckDB.QueryContext(ctx, buildTaskCountsQuery(includeDeleted))
+	if err != nil {
+		return nil, fmt.Errorf("统计源表 %s 的任务失败: %v", sourceTable, err)
+	}
+	defer rows.Close()
+
+	var tasks []TaskRowCount
+	for rows.Next() {
+		var taskID sql.NullString
+		var task TaskRowCount
+		if err := rows.Scan(&taskID, &task.Rows); err != nil {
+			return nil, fmt.Errorf("读取任务统计失败: %v", err)
+		}
+		task.TaskID, task.Valid = taskID.String, taskID.Valid
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取任务统计失败: %v", err)
+	}
+	return tasks, nil
+}
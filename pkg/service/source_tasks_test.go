@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestListSourceTasks 默认排除软删除的记录，includeDeleted 时一并统计；NULL 的任务 ID 单独计数
+func TestListSourceTasks(t *testing.T) {
+	for _, includeDeleted := range []bool{false, true} {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := buildTaskCountsQuery(includeDeleted)
+		if got := strings.Contains(query, "deleted_at"); got == includeDeleted {
+			t.Errorf("includeDeleted=%v: 查询是否过滤 deleted_at 不符: %s", includeDeleted, query)
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WillReturnRows(sqlmock.NewRows([]string{"taskId", "count"}).AddRow("t1", 3).AddRow(nil, 1))
+
+		tasks, err := ListSourceTasks(context.Background(), db, includeDeleted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []TaskRowCount{{TaskID: "t1", Valid: true, Rows: 3}, {Rows: 1}}
+		if len(tasks) != len(want) || tasks[0] != want[0] || tasks[1] != want[1] {
+			t.Errorf("includeDeleted=%v: 得到 %+v", includeDeleted, tasks)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import "sort"
+
+// spanCandidate 参与同区间冲突判断的修正项
+type spanCandidate struct {
+	index      int // 在源列表中的下标
+	pos        int // 源数据中的位置
+	length     int // 替换长度，与 pos 的单位一致
+	word       string
+	suggestion string
+	level      int
+	typeID     int
+}
+
+// spanConflicts 找出替换区间 [pos, pos+length) 和错误词都相同、建议词却不同的修正项，每个区间只保留一项，返回其余项的下标；
+// 区间相同而错误词不同的项通常是位置有偏差，仍按原有逻辑在附近查找或记为重叠。
+// 保留的优先级：级别高的优先，其次错误类型 ID 小的，再次源列表中靠前的；建议词都相同的重复项不算冲突，由 duplicateCandidates 去重或按原有逻辑处理
+func spanConflicts(candidates []spanCandidate) map[int]bool {
+	type span struct {
+		pos, length int
+		word        string
+	}
+	groups := make(map[span][]spanCandidate)
+	for _, c := range candidates {
+		key := span{c.pos, c.length, c.word}
+		groups[key] = append(groups[key], c)
+	}
+	var losers map[int]bool
+	for _, group := range groups {
+		if len(group) < 2 || !differentSuggestions(group) {
+			continue
+		}
+		sort.Slice(group, func(a, b int) bool {
+			if group[a].level != group[b].level {
+				return group[a].level > group[b].level
+			}
+			if group[a].typeID != group[b].typeID {
+				return group[a].typeID < group[b].typeID
+			}
+			return group[a].index < group[b].index
+		})
+		if losers == nil {
+			losers = make(map[int]bool)
+		}
+		for _, c := range group[1:] {
+			losers[c.index] = true
+		}
+	}
+	return losers
+}
+
+// differentSuggestions 判断同一区间的修正项中是否有不同的建议词
+func differentSuggestions(group []spanCandidate) bool {
+	for _, c := range group[1:] {
+		if c.suggestion != group[0].suggestion {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicateCandidates 找出位置、长度、错误词和建议词都与源列表中更靠前的某项相同的修正项，返回其下标；每组只保留最靠前的一项
+func duplicateCandidates(candidates []spanCandidate) map[int]bool {
+	type key struct {
+		pos, length      int
+		word, suggestion string
+	}
+	seen := make(map[key]bool, len(candidates))
+	var duplicates map[int]bool
+	for _, c := range candidates {
+		k := key{c.pos, c.length, c.word, c.suggestion}
+		if !seen[k] {
+			seen[k] = true
+			continue
+		}
+		if duplicates == nil {
+			duplicates = make(map[int]bool)
+		}
+		duplicates[c.index] = true
+	}
+	return duplicates
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// TestSpanConflicts 同一区间、同一错误词的建议词不同时只保留一项：级别高的优先，其次错误类型 ID 小的，再次靠前的
+func TestSpanConflicts(t *testing.T) {
+	c := func(index, level, typeID int, suggestion string) spanCandidate {
+		return spanCandidate{index: index, pos: 3, length: 2, word: "公圆", suggestion: suggestion, level: level, typeID: typeID}
+	}
+	tests := []struct {
+		name       string
+		candidates []spanCandidate
+		losers     map[int]bool
+	}{
+		{name: "higher level", candidates: []spanCandidate{c(0, 1, 1, "公园"), c(1, 2, 9, "***")}, losers: map[int]bool{0: true}},
+		{name: "lower type", candidates: []spanCandidate{c(0, 1, 7, "公园"), c(1, 1, 3, "***")}, losers: map[int]bool{0: true}},
+		{name: "first", candidates: []spanCandidate{c(0, 1, 3, "公园"), c(1, 1, 3, "***"), c(2, 1, 3, "花园")}, losers: map[int]bool{1: true, 2: true}},
+		{name: "same suggestion", candidates: []spanCandidate{c(0, 1, 3, "公园"), c(1, 2, 3, "公园")}},
+		{
+			name: "different word",
+			candidates: []spanCandidate{
+				c(0, 1, 3, "公园"),
+				{index: 1, pos: 3, length: 2, word: "园玩", suggestion: "***", level: 2},
+			},
+		},
+		{
+			name: "different span",
+			candidates: []spanCandidate{
+				c(0, 1, 3, "公园"),
+				{index: 1, pos: 3, length: 1, word: "公圆", suggestion: "***", level: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spanConflicts(tt.candidates); !reflect.DeepEqual(got, tt.losers) {
+				t.Errorf("得到 %v，应为 %v", got, tt.losers)
+			}
+		})
+	}
+}
+
+// TestSpanConflictResolution 两种格式中同一区间的冲突项按优先级应用其一，其余记为 conflict 并计入 conflict_count
+func TestSpanConflictResolution(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{
+			name: "old",
+			raw: `{"data":{"checkresultstr":"我们去公圆玩","checkresultjson":"[` +
+				`{\"errtype\":5,\"errword\":\"公圆\",\"pos\":9,\"level\":1,\"corword\":[\"花园\"]},` +
+				`{\"errtype\":2,\"errword\":\"公圆\",\"pos\":9,\"level\":1,\"corword\":[\"公园\"]},` +
+				`{\"errtype\":1,\"errword\":\"公圆\",\"pos\":9,\"level\":0,\"corword\":[\"**\"]}]"}}`,
+		},
+		{
+			name: "new",
+			raw: `{"data":{"replace_text":"我们去公圆玩","checklist":[` +
+				`{"word":"公圆","position":3,"length":2,"suggest":["花园"],"type":{"id":5},"um_error_level":1},` +
+				`{"word":"公圆","position":3,"length":2,"suggest":["公园"],"type":{"id":2},"um_error_level":1},` +
+				`{"word":"公圆","position":3,"length":2,"suggest":["**"],"type":{"id":1},"um_error_level":0}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processJSON(t, NewContentProcessor(), tt.raw)
+			if result.ModifiedText != "我们去公园玩" {
+				t.Errorf("修改后的文章为 %q，应应用级别最高、错误类型 ID 最小的公园", result.ModifiedText)
+			}
+			if result.CorrectionCount != 1 || result.ConflictCount != 2 {
+				t.Errorf("correction_count=%d conflict_count=%d，应为 1 和 2", result.CorrectionCount, result.ConflictCount)
+			}
+			for _, d := range result.Details {
+				if !d.Applied() && d.SkipReason != model.SkipReasonConflict {
+					t.Errorf("%v 未应用的原因为 %q，应为 conflict", d.Suggestions, d.SkipReason)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"content-verify-log/pkg/model"
+	"content-verify-log/pkg/util"
+
+	"go.uber.org/zap"
+)
+
+// reviewCounts 统计已应用和跳过的修正数量；因错误类型或级别过滤、去重而未应用的项是配置的结果，不计为跳过
+func reviewCounts(details []model.ErrorDetail) (applied, skipped int) {
+	for i := range details {
+		switch {
+		case details[i].Applied():
+			applied++
+		case !filteredByConfig(details[i].SkipReason) && details[i].SkipReason != model.SkipReasonDuplicate:
+			skipped++
+		}
+	}
+	return applied, skipped
+}
+
+// filteredByConfig 判断跳过原因是否为按配置过滤（错误类型、级别）
+func filteredByConfig(reason string) bool {
+	return reason == model.SkipReasonFiltered || reason == model.SkipReasonBelowLevel
+}
+
+// skipRatio 返回跳过的修正占（已应用 + 跳过）的比例，两者都为 0 时为 0
+func skipRatio(applied, skipped int) float64 {
+	if applied+skipped == 0 {
+		return 0
+	}
+	return float64(skipped) / float64(applied+skipped)
+}
+
+// dominantSkipReason 返回出现次数最多的跳过原因（不含 filtered、below_level、duplicate），次数相同时取字典序较小的，没有跳过的项时为空
+func dominantSkipReason(details []model.ErrorDetail) string {
+	counts := make(map[string]int)
+	for i := range details {
+		detail := &details[i]
+		if detail.Applied() || filteredByConfig(detail.SkipReason) || detail.SkipReason == model.SkipReasonDuplicate {
+			continue
+		}
+		counts[detail.SkipReason]++
+	}
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if counts[reasons[i]] != counts[reasons[j]] {
+			return counts[reasons[i]] > counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	if len(reasons) == 0 {
+		return ""
+	}
+	return reasons[0]
+}
+
+// needsReview 判断记录是否需要人工复核：跳过比例超过 threshold，
+// 或有（未被过滤的）错误明细但修改后的文章与原文完全相同
+func needsReview(details []model.ErrorDetail, unchanged bool, threshold float64) bool {
+	applied, skipped := reviewCounts(details)
+	if applied+skipped == 0 {
+		return false
+	}
+	return skipRatio(applied, skipped) > threshold || unchanged
+}
+
+// tagNeedsReview 按 ReviewSkipRatio 为结果设置 NeedsReview
+func (p *ContentProcessor) tagNeedsReview(result *model.ProcessedContent) {
+	review := needsReview(result.Details, result.ModifiedText == result.OriginalText, p.opts.ReviewSkipRatio)
+	result.NeedsReview = &review
+}
+
+// SuspiciousDocument 需要人工复核的记录
+type SuspiciousDocument struct {
+	ID                 string  `json:"id"`
+	TaskID             string  `json:"task_id"`
+	Applied            int     `json:"applied"`              // 已应用的修正数量
+	Skipped            int     `json:"skipped"`              // 跳过的修正数量（不含被错误类型过滤的项）
+	SkipRatio          float64 `json:"skip_ratio"`           // Skipped / (Applied + Skipped)
+	DominantSkipReason string  `json:"dominant_skip_reason"` // 出现次数最多的跳过原因
+	Unchanged          bool    `json:"unchanged"`            // 修改后的文章与原文相同
+}
+
+// ListSuspiciousDocuments 按 id 顺序扫描目标表，返回跳过比例超过 threshold 或有错误明细却未修改原文的记录
+// 判断条件与迁移时的 needs_review 标记一致，不依赖迁移时是否开启了标记
+func ListSuspiciousDocuments(ctx context.Context, duckDB *sql.DB, table string, threshold float64) ([]SuspiciousDocument, error) {
+	if duckDB == nil {
+		return nil, fmt.Errorf("DuckDB 连接未初始化")
+	}
+	if table == "" {
+		table = defaultTargetTable
+	}
+	if _, err := util.SanitizeIdentifier(table); err != nil {
+		return nil, fmt.Errorf("表名不合法: %v", err)
+	}
+	if err := requireTable(ctx, duckDB, table); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT id, pid, details, original_text IS NOT DISTINCT FROM modified_text FROM %s ORDER BY id", table)
+	zap.S().Debugf("查询需复核记录 SQL: %s", query)
+	rows, err := duckDB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("查询表 %s 失败: %v", table, err)
+	}
+	defer rows.Close()
+
+	var docs []SuspiciousDocument
+	for rows.Next() {
+		var id string
+		var pid, details sql.NullString
+		var unchanged bool
+		if err := rows.Scan(&id, &pid, &details, &unchanged); err != nil {
+			return nil, fmt.Errorf("读取记录失败: %v", err)
+		}
+		if !details.Valid || details.String == "" {
+			continue
+		}
+		var parsed []model.ErrorDetail
+		if err := json.Unmarshal([]byte(details.String), &parsed); err != nil {
+			zap.S().Warnf("记录 ID %s 的错误明细不是合法 JSON，已跳过: %v", id, err)
+			continue
+		}
+		if !needsReview(parsed, unchanged, threshold) {
+			continue
+		}
+		applied, skipped := reviewCounts(parsed)
+		docs = append(docs, SuspiciousDocument{
+			ID:                 id,
+			TaskID:             pid.String,
+			Applied:            applied,
+			Skipped:            skipped,
+			SkipRatio:          skipRatio(applied, skipped),
+			DominantSkipReason: dominantSkipReason(parsed),
+			Unchanged:          unchanged,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取记录失败: %v", err)
+	}
+	return docs, nil
+}
+
+// TableStats 目标表的概要统计
+type TableStats struct {
+	Rows            int64 `json:"rows"`             // 记录数
+	WithCorrections int64 `json:"with_corrections"` // 实际应用了修正的记录数
+	WithErrorCode   int64 `json:"with_error_code"`  // 有错误码（处理失败或长度异常）的记录数
+	NeedsReview     int64 `json:"needs_review"`     // 迁移时标记为需要复核的记录数，未开启标记时为 0
+}
+
+// GetTableStats 统计目标表的记录数、应用了修正和有错误码的记录数
+func GetTableStats(ctx context.Context, duckDB *sql.DB, table string) (*TableStats, error) {
+	if duckDB == nil {
+		return nil, fmt.Errorf("DuckDB 连接未初始化")
+	}
+	if table == "" {
+		table = defaultTargetTable
+	}
+	if _, err := util.SanitizeIdentifier(table); err != nil {
+		return nil, fmt.Errorf("表名不合法: %v", err)
+	}
+	if err := requireTable(ctx, duckDB, table); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`SELECT count(*), count(*) FILTER (WHERE has_errors), count(error_code), count(*) FILTER (WHERE needs_review) FROM %s`, table)
+	var stats TableStats
+	if err := duckDB.QueryRowContext(ctx, query).Scan(&stats.Rows, &stats.WithCorrections, &stats.WithErrorCode, &stats.NeedsReview); err != nil {
+		return nil, fmt.Errorf("统计表 %s 失败: %v", table, err)
+	}
+	return &stats, nil
+}
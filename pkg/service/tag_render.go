@@ -0,0 +1,125 @@
+package service
+
+import (
+	"strings"
+)
+
+// tagRenderer 清洗 HTML 时将部分标签替换为文字：图片替换为替代文字，链接在文字后附上地址
+// 链接地址要等到 </a> 才输出，因此每次清洗使用一个新的 tagRenderer
+type tagRenderer struct {
+	imageAlt bool
+	linkURL  bool
+	href     string // 尚未闭合的链接的地址，不输出时为空
+}
+
+// newTagRenderer 按选项创建 tagRenderer，两项都未开启时返回 nil
+func (p *ContentProcessor) newTagRenderer() *tagRenderer {
+	if !p.opts.ImageAltText && !p.opts.LinkURLs {
+		return nil
+	}
+	return &tagRenderer{imageAlt: p.opts.ImageAltText, linkURL: p.opts.LinkURLs}
+}
+
+// render 返回已解码的标签 tag 替换成的文字，不需要替换时返回空；r 为 nil 时总是返回空
+func (r *tagRenderer) render(tag string) string {
+	if r == nil {
+		return ""
+	}
+	closing := strings.HasPrefix(tag, "</")
+	switch tagName(tag) {
+	case "img":
+		if !r.imageAlt || closing {
+			return ""
+		}
+		if alt := strings.TrimSpace(tagAttr(tag, "alt")); alt != "" {
+			return "[图: " + alt + "]"
+		}
+	case "a":
+		if !r.linkURL {
+			return ""
+		}
+		if closing {
+			href := r.href
+			r.href = ""
+			if href != "" {
+				return " (" + href + ")"
+			}
+			return ""
+		}
+		r.href = ""
+		if href := strings.TrimSpace(tagAttr(tag, "href")); renderableURL(href) {
+			r.href = href
+		}
+	}
+	return ""
+}
+
+// renderableURL 判断链接地址是否输出：空地址、页内锚点和 javascript:、vbscript:、data: 地址不输出
+func renderableURL(href string) bool {
+	if href == "" || strings.HasPrefix(href, "#") {
+		return false
+	}
+	// 浏览器解析协议时忽略其中的空白和控制字符，如 "java\tscript:"
+	scheme := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, href)
+	scheme = strings.ToLower(scheme)
+	for _, unsafe := range []string{"javascript:", "vbscript:", "data:"} {
+		if strings.HasPrefix(scheme, unsafe) {
+			return false
+		}
+	}
+	return true
+}
+
+// tagAttr 返回标签 tag 中属性 name（小写）的值，属性不存在或没有值时返回空
+func tagAttr(tag, name string) string {
+	s := strings.TrimSuffix(tag[1:], ">")
+	// 跳过标签名
+	i := strings.IndexAny(s, " \t\n\r\f/")
+	if i < 0 {
+		return ""
+	}
+	s = s[i:]
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f/")
+		if s == "" {
+			return ""
+		}
+		n := strings.IndexAny(s, " \t\n\r\f/=")
+		if n < 0 {
+			n = len(s)
+		}
+		attr := strings.ToLower(s[:n])
+		s = strings.TrimLeft(s[n:], " \t\n\r\f")
+		if !strings.HasPrefix(s, "=") {
+			// 没有值的属性，如 <img hidden>
+			if attr == name {
+				return ""
+			}
+			continue
+		}
+		s = strings.TrimLeft(s[1:], " \t\n\r\f")
+		var value string
+		if s != "" && (s[0] == '"' || s[0] == '\'') {
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(s, " \t\n\r\f")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		if attr == name {
+			return value
+		}
+	}
+}
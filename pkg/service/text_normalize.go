@@ -0,0 +1,160 @@
+package service
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"content-verify-log/pkg/model"
+)
+
+// lineEndingAndZeroWidthReplacer 统一换行符为 \n，并移除零宽字符
+// 注意 \r\n 必须排在 \r 之前，NewReplacer 在同一位置按参数顺序匹配
+var lineEndingAndZeroWidthReplacer = strings.NewReplacer(
+	"\r\n", "\n",
+	"\r", "\n",
+	"\u200b", "", // ZERO WIDTH SPACE
+	"\u200c", "", // ZERO WIDTH NON-JOINER
+	"\u200d", "", // ZERO WIDTH JOINER
+	"\u2060", "", // WORD JOINER
+	"\ufeff", "", // ZERO WIDTH NO-BREAK SPACE / BOM
+)
+
+// isZeroWidth 判断 r 是否为 normalizeText 移除的零宽字符
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+		return true
+	}
+	return false
+}
+
+// normalizeText 规范化换行符并移除零宽字符
+func normalizeText(text string) string {
+	return lineEndingAndZeroWidthReplacer.Replace(text)
+}
+
+// 引号规范化方式
+const (
+	QuotesOff   = "off"   // 不规范化
+	QuotesMatch = "match" // 只在比较错误词时忽略引号样式，存储的文本保持原样
+	QuotesAll   = "all"   // 比较时忽略引号样式，存储的原文和修改后的文章也统一为直引号
+)
+
+// normalizeQuotes 将引号统一为直引号，逐字符替换，不改变字符数和位置
+func normalizeQuotes(text string) string {
+	return strings.Map(quoteRune, text)
+}
+
+// matchQuotes 返回比较错误词时是否忽略引号样式
+func (p *ContentProcessor) matchQuotes() bool {
+	return p.opts.NormalizeQuotes == QuotesMatch || p.opts.NormalizeQuotes == QuotesAll
+}
+
+// sameWord 判断原文中的文本与错误词是否一致，开启引号规范化时忽略引号样式
+func (p *ContentProcessor) sameWord(text, word string) bool {
+	if text == word {
+		return true
+	}
+	return p.matchQuotes() && normalizeQuotes(text) == normalizeQuotes(word)
+}
+
+// matchRunes 返回用于比较的文本，开启引号规范化时为规范化后的副本（与 runes 等长、位置一一对应）
+func (p *ContentProcessor) matchRunes(runes []rune) []rune {
+	if !p.matchQuotes() {
+		return runes
+	}
+	normalized := make([]rune, len(runes))
+	for i, r := range runes {
+		normalized[i] = quoteRune(r)
+	}
+	return normalized
+}
+
+// quoteRune 将弯引号及全角、低位引号统一为直引号
+func quoteRune(r rune) rune {
+	switch r {
+	case '\u201c', '\u201d', '\u201e', '\u201f', '\u301d', '\u301e', '\uff02': // “ ” „ ‟ 〝 〞 ＂
+		return '"'
+	case '\u2018', '\u2019', '\u201a', '\u201b', '\uff07': // ‘ ’ ‚ ‛ ＇
+		return '\''
+	}
+	return r
+}
+
+// indexWord 返回 word 在 s 中第一次出现的字节区间 [start, end)，找不到时 start 为 -1；
+// 开启引号规范化时忽略引号样式，区间对应 s 中的原始文本
+func (p *ContentProcessor) indexWord(s, word string) (start, end int) {
+	if !p.matchQuotes() {
+		idx := strings.Index(s, word)
+		if idx < 0 {
+			return -1, -1
+		}
+		return idx, idx + len(word)
+	}
+	ns, nw := normalizeQuotes(s), normalizeQuotes(word)
+	idx := strings.Index(ns, nw)
+	if idx < 0 {
+		return -1, -1
+	}
+	// 规范化逐字符替换，按字符数映射回原文的字节位置
+	start = runeByteOffset(s, utf8.RuneCountInString(ns[:idx]))
+	end = start + runeByteOffset(s[start:], utf8.RuneCountInString(nw))
+	return start, end
+}
+
+// runeByteOffset 返回 s 中第 n 个字符的字节位置，n 超出字符数时返回 len(s)
+func runeByteOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
+
+// cleanSource 清洗源文本：移除错误标记得到 unmarked（保留原文 HTML，供定位修正使用），
+// 再生成用于存储的文本 stored（开启 KeepHTML 时不清洗 HTML），并记录每个步骤是否实际修改了文本
+func (p *ContentProcessor) cleanSource(source, flag string, result *model.ProcessedContent) (unmarked, stored string) {
+	unmarked = p.stripErrorMarkersCounting(source, flag, result)
+	result.MarkersStripped = unmarked != source
+	stored = unmarked
+	if !p.opts.KeepHTML {
+		stored = p.stripStoredHTML(unmarked)
+		result.HTMLStripped = stored != unmarked
+	}
+	stored = p.normalizeStored(stored)
+	return unmarked, stored
+}
+
+// toStoredText 生成用于存储的文本：开启 KeepHTML 时保留 HTML，否则清洗为纯文本（保留 PreserveTags 中的标签）
+func (p *ContentProcessor) toStoredText(text string) string {
+	if p.opts.KeepHTML {
+		return p.normalizeStored(text)
+	}
+	return p.normalizeStored(p.stripStoredHTML(text))
+}
+
+// stripStoredHTML 清洗 HTML 用于存储，设置了 PreserveTags 时保留其中的标签
+func (p *ContentProcessor) stripStoredHTML(text string) string {
+	if p.preserve == nil {
+		return p.stripHTML(text)
+	}
+	return p.stripHTMLPreserving(text)
+}
+
+// toPlainText 生成纯文本：清洗 HTML 后按选项做规范化
+func (p *ContentProcessor) toPlainText(text string) string {
+	return p.normalizeStored(p.stripHTML(text))
+}
+
+// normalizeStored 按选项规范化用于存储的文本
+func (p *ContentProcessor) normalizeStored(text string) string {
+	if p.opts.NormalizeText {
+		text = normalizeText(text)
+	}
+	if p.opts.NormalizeQuotes == QuotesAll {
+		text = normalizeQuotes(text)
+	}
+	return text
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// TestCleanSourceStripped 分别记录移除错误标记和清洗 HTML 是否实际修改了文本
+func TestCleanSourceStripped(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		source  string
+		markers bool
+		html    bool
+		plain   string
+	}{
+		{name: "new neither", flag: model.SourceFormatNew, source: "我们的正文", plain: "我们的正文"},
+		{name: "new markers", flag: model.SourceFormatNew, source: `我<span class="jdt_umold">门</span>的正文`, markers: true, plain: "我门的正文"},
+		{name: "new html", flag: model.SourceFormatNew, source: "<p>我们的正文</p>", html: true, plain: "我们的正文"},
+		{name: "new entity", flag: model.SourceFormatNew, source: "我们&amp;正文", html: true, plain: "我们&正文"},
+		{name: "new both", flag: model.SourceFormatNew, source: `<p>我<span class="jdt_umold">门</span>的正文</p>`, markers: true, html: true, plain: "我门的正文"},
+		{name: "old neither", flag: model.SourceFormatOld, source: "我们的正文", plain: "我们的正文"},
+		{name: "old markers", flag: model.SourceFormatOld, source: `我<span style="background-color:yellow;">门</span>的正文`, markers: true, plain: "我门的正文"},
+		{name: "old html", flag: model.SourceFormatOld, source: "<p>我们的正文</p>", html: true, plain: "我们的正文"},
+		{name: "old both", flag: model.SourceFormatOld, source: `<p>我<span style="background-color:yellow;">门</span>的正文</p>`, markers: true, html: true, plain: "我门的正文"},
+		// 没有错误标记 class 的 span 是原文的 HTML，只在清洗 HTML 时移除
+		{name: "unknown marker", flag: model.SourceFormatNew, source: `我<span class="other">门</span>`, html: true, plain: "我门"},
+	}
+	p := NewContentProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.ProcessedContent{}
+			_, plain := p.cleanSource(tt.source, tt.flag, result)
+			if plain != tt.plain {
+				t.Errorf("纯文本为 %q，应为 %q", plain, tt.plain)
+			}
+			if result.MarkersStripped != tt.markers || result.HTMLStripped != tt.html {
+				t.Errorf("markers_stripped=%v html_stripped=%v，应为 %v %v", result.MarkersStripped, result.HTMLStripped, tt.markers, tt.html)
+			}
+		})
+	}
+}
+
+// TestNormalizeQuotes 原文与错误词的引号样式不一致时，match 和 all 忽略引号样式匹配错误词；
+// match 不改变存储的文本，all 将存储的原文和修改后的文章统一为直引号
+func TestNormalizeQuotes(t *testing.T) {
+	// 原文为弯引号，错误词为直引号；旧格式按字节位置，位置不一致时在全文中查找
+	newFormat := `{"data":{"replace_text":"他说“我门”走了","checklist":[{"word":"\"我门\"","position":2,"length":4,"suggest":["“我们”"]}]}}`
+	oldFormat := `{"data":{"checkresultstr":"他说“我门”走了","checkresultjson":"[{\"errword\":\"\\\"我门\\\"\",\"pos\":1,\"corword\":[\"“我们”\"]}]"}}`
+
+	tests := []struct {
+		mode         string
+		wantOriginal string
+		wantModified string
+	}{
+		{mode: QuotesOff, wantOriginal: "他说“我门”走了", wantModified: "他说“我门”走了"},
+		{mode: QuotesMatch, wantOriginal: "他说“我门”走了", wantModified: "他说“我们”走了"},
+		{mode: QuotesAll, wantOriginal: `他说"我门"走了`, wantModified: `他说"我们"走了`},
+	}
+	for _, raw := range []string{newFormat, oldFormat} {
+		for _, tt := range tests {
+			result := processJSON(t, NewContentProcessor(WithNormalizeQuotes(tt.mode)), raw)
+			if result.OriginalText != tt.wantOriginal || result.ModifiedText != tt.wantModified {
+				t.Errorf("%s %.20s: 原文 %q、修改后 %q，应为 %q、%q", tt.mode, raw, result.OriginalText, result.ModifiedText, tt.wantOriginal, tt.wantModified)
+			}
+		}
+	}
+
+	if got := normalizeQuotes("“a” ‘b’ „c‟ 〝d〞 ＂e＂ ＇f＇"); got != `"a" 'b' "c" "d" "e" 'f'` {
+		t.Errorf("normalizeQuotes 得到 %q", got)
+	}
+}
+
+// TestNormalizeTextPositions 开启 NormalizeText 时明细的位置和上下文基于规范化后存储的原文：
+// 零宽字符（含实体）不计数，\r\n 按一个字符计算
+func TestNormalizeTextPositions(t *testing.T) {
+	// 源文本为 a、三个 U+200B、b、\r\n、我门走、&#8203;、了；旧格式 pos 为字节偏移
+	const text = `a\u200b\u200b\u200bb\r\n我门走&#8203;了`
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "new", raw: `{"data":{"replace_text":"` + text + `","checklist":[{"word":"我门","position":7,"length":2,"suggest":["我们"]}]}}`},
+		{name: "old", raw: `{"data":{"checkresultstr":"` + text + `","checkresultjson":[{"errword":"我门","pos":13,"corword":["我们"]}]}}`},
+		{name: "old unknown pos", raw: `{"data":{"checkresultstr":"` + text + `","checkresultjson":[{"errword":"我门","pos":-1,"corword":["我们"]}]}}`},
+	}
+	p := NewContentProcessor(WithNormalizeText(), WithContextWindow(2))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processJSON(t, p, tt.raw)
+			if result.OriginalText != "ab\n我门走了" || result.ModifiedText != "ab\n我们走了" {
+				t.Fatalf("原文 %q，修改后 %q", result.OriginalText, result.ModifiedText)
+			}
+			if len(result.Details) != 1 || !result.Details[0].Applied() {
+				t.Fatalf("修正应被应用: %+v", result.Details)
+			}
+			detail := result.Details[0]
+			if detail.Position != 3 || detail.Context != "b\n我门走了" {
+				t.Errorf("position=%d context=%q，应为 3 和 %q", detail.Position, detail.Context, "b\n我门走了")
+			}
+			if runes := []rune(result.OriginalText); string(runes[detail.Position:detail.Position+2]) != detail.Word {
+				t.Errorf("原文中位置 %d 处不是错误词 %q", detail.Position, detail.Word)
+			}
+		})
+	}
+
+	// 未开启时位置基于未规范化的文本
+	result := processJSON(t, NewContentProcessor(), tests[0].raw)
+	if len(result.Details) != 1 || result.Details[0].Position != 7 {
+		t.Errorf("未开启 NormalizeText 时位置应为 7: %+v", result.Details)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// salvageKeyRegex 匹配截断的 JSON 中已知字段的键，键前不能是反斜杠，避免匹配到字符串值中转义的引号
+var salvageKeyRegex = regexp.MustCompile(`(?:^|[^\\])"(checkresultstr|checkresultjson|replace_text|checklist)"\s*:\s*`)
+
+// salvageTextKeys 正文字段，值被截断时保留截断前的部分
+var salvageTextKeys = map[string]bool{"checkresultstr": true, "replace_text": true}
+
+// incompleteUnicodeRegex 匹配字符串末尾可能被截断的 \uXXXX 转义
+var incompleteUnicodeRegex = regexp.MustCompile(`u[0-9a-fA-F]{0,3}$`)
+
+// salvageTruncated 从无法解析的（通常是因存储长度限制被截断的）JSON 中提取已知字段，组成 data 对象：
+// 各字段取第一次出现的完整值；正文字段（checkresultstr/replace_text）的字符串被截断时取截断前的部分，
+// 修正列表（checkresultjson/checklist）不完整时不提取。没有提取到正文字段时返回 false
+func salvageTruncated(raw string) (map[string]interface{}, bool) {
+	dataObj := make(map[string]interface{})
+	for _, m := range salvageKeyRegex.FindAllStringSubmatchIndex(raw, -1) {
+		key := raw[m[2]:m[3]]
+		if _, ok := dataObj[key]; ok {
+			continue
+		}
+		rest := raw[m[1]:]
+		var value interface{}
+		if err := json.NewDecoder(strings.NewReader(rest)).Decode(&value); err == nil {
+			dataObj[key] = value
+			continue
+		}
+		if salvageTextKeys[key] {
+			if text, ok := truncatedString(rest); ok {
+				dataObj[key] = text
+			}
+		}
+	}
+	_, hasOld := dataObj["checkresultstr"].(string)
+	_, hasNew := dataObj["replace_text"].(string)
+	return dataObj, hasOld || hasNew
+}
+
+// truncatedString 解码没有结束引号的 JSON 字符串，去掉末尾的空白和不完整的转义；
+// s 不是以引号开始，或中间有非法内容（说明不是截断造成的）时返回 false
+func truncatedString(s string) (string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", false
+	}
+	body := strings.TrimRight(s[1:], " \t\r\n")
+	// 前面是奇数个反斜杠的 u 是被截断的 \uXXXX 转义
+	if loc := incompleteUnicodeRegex.FindStringIndex(body); loc != nil && oddBackslashes(body[:loc[0]]) {
+		body = body[:loc[0]]
+	}
+	// 末尾奇数个反斜杠说明转义字符本身被截断
+	if oddBackslashes(body) {
+		body = body[:len(body)-1]
+	}
+	var text string
+	if err := json.Unmarshal([]byte(`"`+body+`"`), &text); err != nil {
+		return "", false
+	}
+	return text, true
+}
+
+// oddBackslashes 判断 s 末尾是否有奇数个连续的反斜杠
+func oddBackslashes(s string) bool {
+	return (len(s)-len(strings.TrimRight(s, `\`)))%2 == 1
+}
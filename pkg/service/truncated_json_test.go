@@ -0,0 +1,121 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"content-verify-log/pkg/model"
+)
+
+// TestSalvageTruncated 从截断的 JSON 中提取已知字段：正文字段取截断前的部分，不完整的修正列表不提取
+func TestSalvageTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want map[string]interface{}
+		ok   bool
+	}{
+		{
+			name: "text cut",
+			raw:  `{"data":{"replace_text":"今天天气  `,
+			want: map[string]interface{}{"replace_text": "今天天气"},
+			ok:   true,
+		},
+		{
+			name: "unicode escape cut",
+			raw:  `{"data":{"checkresultstr":"公园\u4e`,
+			want: map[string]interface{}{"checkresultstr": "公园"},
+			ok:   true,
+		},
+		{
+			name: "escape cut",
+			raw:  `{"data":{"checkresultstr":"a\"b\`,
+			want: map[string]interface{}{"checkresultstr": `a"b`},
+			ok:   true,
+		},
+		{
+			name: "complete list",
+			raw:  `{"data":{"checkresultstr":"公圆","checkresultjson":"[]","extra":"x`,
+			want: map[string]interface{}{"checkresultstr": "公圆", "checkresultjson": "[]"},
+			ok:   true,
+		},
+		{
+			name: "list cut",
+			raw:  `{"data":{"replace_text":"公圆","checklist":[{"word":"公圆","posi`,
+			want: map[string]interface{}{"replace_text": "公圆"},
+			ok:   true,
+		},
+		{
+			name: "first value",
+			raw:  `{"data":{"replace_text":"甲","replace_text":"乙"`,
+			want: map[string]interface{}{"replace_text": "甲"},
+			ok:   true,
+		},
+		{
+			name: "escaped key in value",
+			raw:  `{"data":{"title":"\"replace_text\": \"假","checklist":[]`,
+			want: map[string]interface{}{"checklist": []interface{}{}},
+		},
+		{
+			name: "no text field",
+			raw:  `{"data":{"checklist":[{"word":"公`,
+			want: map[string]interface{}{},
+		},
+		{
+			name: "not a string",
+			raw:  `{"data":{"replace_text":123`,
+			want: map[string]interface{}{"replace_text": float64(123)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := salvageTruncated(tt.raw)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %v %v，应为 %v %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// TestProcessTruncated 开启 SalvageTruncated 时截断的内容照常处理并记为 TRUNCATED_JSON_SALVAGED，
+// 关闭时写入死信表；提取不到正文字段时仍写入死信表
+func TestProcessTruncated(t *testing.T) {
+	const complete = `{"data":{"checkresultstr":"我们去公圆玩","checkresultjson":"[{\"errword\":\"公圆\",\"pos\":9,\"corword\":[\"公园\"]}]"`
+	truncated := complete + `,"extra":"被截`
+
+	s := NewMigrationService(MigrationOptions{})
+	content := &model.VerifyContent{ID: 1}
+	if ok, failure := s.loadContent(content, []byte(truncated)); ok || failure == nil || failure.reason != "content 不是合法 JSON" {
+		t.Errorf("关闭时应写入死信表: ok=%v failure=%v", ok, failure)
+	}
+
+	s = NewMigrationService(MigrationOptions{Processor: ProcessorOptions{SalvageTruncated: true}})
+	content = &model.VerifyContent{ID: 1}
+	ok, failure := s.loadContent(content, []byte(truncated))
+	if !ok || failure != nil {
+		t.Fatalf("开启时应照常处理: ok=%v failure=%v", ok, failure)
+	}
+	result := s.processor.ProcessContent(content)
+	if result.ErrorCode != model.ErrCodeTruncatedJSONSalvaged || !strings.HasPrefix(result.ErrorReason, "从截断的 JSON 中提取字段处理") {
+		t.Errorf("error_code=%q error_reason=%q", result.ErrorCode, result.ErrorReason)
+	}
+	if result.OriginalText != "我们去公圆玩" || result.ModifiedText != "我们去公园玩" || result.CorrectionCount != 1 {
+		t.Errorf("完整的修正列表应照常应用: %q -> %q，correction_count=%d", result.OriginalText, result.ModifiedText, result.CorrectionCount)
+	}
+
+	// 修正列表不完整时只保留原文
+	content = &model.VerifyContent{ID: 2}
+	if ok, _ := s.loadContent(content, []byte(`{"data":{"replace_text":"我们去公圆玩","checklist":[{"word":"公圆","posi`)); !ok {
+		t.Fatal("提取到正文字段时应照常处理")
+	}
+	result = s.processor.ProcessContent(content)
+	if result.ErrorCode != model.ErrCodeTruncatedJSONSalvaged || result.ModifiedText != "我们去公圆玩" || result.CorrectionCount != 0 {
+		t.Errorf("error_code=%q modified_text=%q correction_count=%d", result.ErrorCode, result.ModifiedText, result.CorrectionCount)
+	}
+
+	content = &model.VerifyContent{ID: 3}
+	if ok, failure := s.loadContent(content, []byte(`{"data":{"checklist":[`)); ok || failure == nil {
+		t.Errorf("没有正文字段时应写入死信表: ok=%v failure=%v", ok, failure)
+	}
+}
@@ -2,25 +2,40 @@ package signals
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+
+	"go.uber.org/zap"
 )
 
 var onlyOneSignalHandler = make(chan struct{})
 var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGINT}
 
+// ErrShutdownSignal 收到退出信号时上下文的取消原因，可用 errors.Is(context.Cause(ctx), ErrShutdownSignal) 判断
+var ErrShutdownSignal = errors.New("收到退出信号")
+
+// ForceExitCode 第二次收到退出信号时的退出码（128 + SIGINT）
+const ForceExitCode = 130
+
+// SetupSignalHandler 返回收到第一个 SIGINT/SIGTERM 时取消的上下文，取消原因包装 ErrShutdownSignal
+// 优雅退出卡住（如 DuckDB 刷写大量 WAL、源查询挂起）时，第二个信号直接以 ForceExitCode 退出
 func SetupSignalHandler() context.Context {
 	close(onlyOneSignalHandler)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancelCause(context.Background())
 
 	c := make(chan os.Signal, 2)
 	signal.Notify(c, shutdownSignals...)
 	go func() {
-		<-c
-		cancel()
-		<-c
-		os.Exit(1)
+		sig := <-c
+		zap.S().Warnf("收到信号 %s，正在退出，再次按 Ctrl+C 强制退出", sig)
+		cancel(fmt.Errorf("%w: %s", ErrShutdownSignal, sig))
+		sig = <-c
+		zap.S().Errorf("再次收到信号 %s，强制退出", sig)
+		_ = zap.L().Sync()
+		os.Exit(ForceExitCode)
 	}()
 	return ctx
 }
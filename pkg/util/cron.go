@@ -0,0 +1,171 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CronSchedule 标准 5 字段 cron 表达式（分 时 日 月 周），按本地时区计算
+// 每个字段支持 *、数字、a-b 范围、/n 步长和逗号分隔的列表；周的 0 和 7 都表示周日
+type CronSchedule struct {
+	expr string
+
+	minutes, hours, days, months, weekdays uint64 // 每个字段允许的取值位图
+
+	// 日和周都不以 * 开头时，与 cron 一致，满足任一即可
+	anyDay, anyWeekday bool
+}
+
+// cronField 字段的名称和取值范围
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"分钟", 0, 59},
+	{"小时", 0, 23},
+	{"日", 1, 31},
+	{"月", 1, 12},
+	{"周", 0, 7},
+}
+
+// cronSearchYears 查找下一次触发时间的最大年数，超过时认为表达式永远不会触发（如 2 月 30 日）
+const cronSearchYears = 5
+
+// ParseCronSchedule 解析 cron 表达式，如 "0 2 * * *" 表示每天 2:00
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, errors.Errorf("cron 表达式 %q 应为 5 个字段（分 时 日 月 周），实际为 %d 个", expr, len(fields))
+	}
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "cron 表达式 %q", expr)
+		}
+		bits[i] = b
+	}
+	s := &CronSchedule{
+		expr:       expr,
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}
+	// 7 与 0 同为周日
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+	if s.Next(time.Now()).IsZero() {
+		return nil, errors.Errorf("cron 表达式 %q 在 %d 年内不会触发", expr, cronSearchYears)
+	}
+	return s, nil
+}
+
+// parseCronField 解析一个字段，返回允许的取值位图
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, errors.Errorf("%s字段的步长 %q 不合法", f.name, stepPart)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			a, b, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseCronValue(a, f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseCronValue(b, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, errors.Errorf("%s字段的范围 %q 不合法", f.name, rangePart)
+			}
+		default:
+			v, err := parseCronValue(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// 与 cron 一致，单个数字带步长（如 5/15）表示从该值到最大值
+			if !hasStep {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue 解析字段中的一个数字并检查范围
+func parseCronValue(s string, f cronField) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Errorf("%s字段的值 %q 不是数字", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, errors.Errorf("%s字段的值 %d 超出范围 [%d, %d]", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String 返回原始表达式
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+// Next 返回 t 之后（不含 t 所在的分钟）的下一次触发时间，找不到时返回零值
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 判断日期是否满足日和周字段；两者都有限制时满足任一即可
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
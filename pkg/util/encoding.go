@@ -0,0 +1,75 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// 支持的源内容编码
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingGBK     = "gbk"
+	EncodingGB18030 = "gb18030"
+)
+
+// replacementChar 解码器遇到无法解码的字节时输出的替换字符
+var replacementChar = []byte(string(utf8.RuneError))
+
+// lookupEncoding 返回编码名对应的解码器和规范的编码名，UTF-8 返回 nil
+func lookupEncoding(name string) (encoding.Encoding, string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", EncodingUTF8, "utf8":
+		return nil, EncodingUTF8, nil
+	case EncodingGBK:
+		return simplifiedchinese.GBK, EncodingGBK, nil
+	case EncodingGB18030:
+		return simplifiedchinese.GB18030, EncodingGB18030, nil
+	default:
+		return nil, "", errors.Errorf("不支持的编码 %q，可选 utf-8/gbk/gb18030", name)
+	}
+}
+
+// ValidateEncoding 校验编码名
+func ValidateEncoding(name string) error {
+	_, _, err := lookupEncoding(name)
+	return err
+}
+
+// SniffToUTF8 将非 UTF-8 的内容转换为 UTF-8，返回转换后的内容和转换前的编码名：
+// name 为 gbk/gb18030 时按该编码转换，为 UTF-8 时按 GB18030（兼容 GBK）尝试。
+// 已经是合法 UTF-8 的内容原样返回、编码名为空，因此同一批数据中 UTF-8 与旧编码的行可以混合存在
+func SniffToUTF8(b []byte, name string) ([]byte, string, error) {
+	enc, canonical, err := lookupEncoding(name)
+	if err != nil {
+		return nil, "", err
+	}
+	if utf8.Valid(b) {
+		return b, "", nil
+	}
+	if enc == nil {
+		enc, canonical = simplifiedchinese.GB18030, EncodingGB18030
+	}
+	decoded, err := decodeStrict(b, enc, canonical)
+	if err != nil {
+		return nil, "", err
+	}
+	return decoded, canonical, nil
+}
+
+// decodeStrict 按 enc 解码。解码器遇到无法解码的字节时输出替换字符而不报错，
+// 结果中替换字符比原文多时说明内容不是该编码，返回错误
+func decodeStrict(b []byte, enc encoding.Encoding, name string) ([]byte, error) {
+	decoded, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "按 %s 解码失败", name)
+	}
+	if bytes.Count(decoded, replacementChar) > bytes.Count(b, replacementChar) {
+		return nil, errors.Errorf("按 %s 解码失败: 含无法解码的字节", name)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// TestSniffToUTF8 GBK 编码的内容按指定编码或默认的 GB18030 转换为 UTF-8，合法 UTF-8 原样返回
+func TestSniffToUTF8(t *testing.T) {
+	const text = `{"data":{"replace_text":"我们的正文"}}`
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		in       []byte
+		encoding string
+		want     string
+		wantName string
+	}{
+		{name: "gbk", in: gbk, encoding: "gbk", want: text, wantName: EncodingGBK},
+		{name: "gbk as gb18030", in: gbk, encoding: "GB18030", want: text, wantName: EncodingGB18030},
+		{name: "gbk sniffed", in: gbk, encoding: "utf-8", want: text, wantName: EncodingGB18030},
+		{name: "utf-8", in: []byte(text), encoding: "gbk", want: text},
+		{name: "default", in: []byte(text), want: text},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name, err := SniffToUTF8(tt.in, tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want || name != tt.wantName {
+				t.Errorf("得到 %q (%q)，应为 %q (%q)", got, name, tt.want, tt.wantName)
+			}
+		})
+	}
+
+	if _, _, err := SniffToUTF8(gbk, "latin1"); err == nil {
+		t.Error("不支持的编码应返回错误")
+	}
+	if _, _, err := SniffToUTF8(append(bytes.Clone(gbk), 0xff), "gbk"); err == nil {
+		t.Error("含无法解码的字节时应返回错误")
+	}
+}
+
+// TestValidateEncoding 编码名不区分大小写，空值按 UTF-8 处理
+func TestValidateEncoding(t *testing.T) {
+	for _, name := range []string{"", "utf-8", "UTF8", "gbk", " GB18030 "} {
+		if err := ValidateEncoding(name); err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+	}
+	for _, name := range []string{"big5", "latin1", "utf-16"} {
+		if err := ValidateEncoding(name); err == nil {
+			t.Errorf("%q 应返回错误", name)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 对象存储地址的协议
+const (
+	ObjectSchemeS3  = "s3"
+	ObjectSchemeOSS = "oss"
+)
+
+// ObjectURL 对象存储地址 s3://bucket/prefix 或 oss://bucket/prefix，bucket 为空时（s3:///prefix）使用配置的默认 bucket
+type ObjectURL struct {
+	Scheme string
+	Bucket string
+	Prefix string // 不含首尾的 /
+}
+
+// String 返回 scheme://bucket/key 形式的地址
+func (u ObjectURL) String() string {
+	return u.Scheme + "://" + u.Bucket + "/" + u.Prefix
+}
+
+// IsObjectURL 判断 s 是否为对象存储地址
+func IsObjectURL(s string) bool {
+	scheme, _, ok := strings.Cut(s, "://")
+	if !ok {
+		return false
+	}
+	scheme = strings.ToLower(scheme)
+	return scheme == ObjectSchemeS3 || scheme == ObjectSchemeOSS
+}
+
+// ParseObjectURL 解析对象存储地址，s 不是 s3:// 或 oss:// 地址时返回错误
+func ParseObjectURL(s string) (*ObjectURL, error) {
+	if !IsObjectURL(s) {
+		return nil, errors.Errorf("%q 不是对象存储地址，应为 s3://bucket/prefix 或 oss://bucket/prefix", s)
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "对象存储地址 %q 不合法", s)
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil || u.Port() != "" {
+		return nil, errors.Errorf("对象存储地址 %q 不合法，应为 s3://bucket/prefix 或 oss://bucket/prefix", s)
+	}
+	return &ObjectURL{
+		Scheme: strings.ToLower(u.Scheme),
+		Bucket: u.Host,
+		Prefix: strings.Trim(u.Path, "/"),
+	}, nil
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PeakRSS 返回进程的峰值常驻内存（字节），读取 /proc/self/status 的 VmHWM；
+// 非 Linux 系统或读取失败时返回 false
+func PeakRSS() (int64, bool) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmHWM:") {
+			continue
+		}
+		// 格式为 "VmHWM:	  123456 kB"
+		fields := strings.Fields(strings.TrimPrefix(line, "VmHWM:"))
+		if len(fields) == 0 {
+			return 0, false
+		}
+		kb, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return kb * 1024, true
+	}
+	return 0, false
+}
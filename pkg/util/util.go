@@ -1,6 +1,9 @@
 package util
 
 import (
+	"regexp"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cast"
 )
@@ -16,3 +19,46 @@ func IsValidPort[T int | int32 | uint | uint32 | uint64 | int64 | string](port T
 	}
 	return errors.Errorf("%d不是一个合格的[0-65535]端口", p)
 }
+
+// maxIdentifierLength 动态 SQL 标识符的最大长度（与 PostgreSQL/DuckDB 常见上限一致）
+const maxIdentifierLength = 63
+
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SanitizeIdentifier 校验动态拼接到 SQL 中的标识符（表名、列名、后缀等）
+// 只允许字母、数字和下划线，且不能以数字开头，长度不超过 63
+func SanitizeIdentifier(s string) (string, error) {
+	if s == "" {
+		return "", errors.New("标识符不能为空")
+	}
+	if len(s) > maxIdentifierLength {
+		return "", errors.Errorf("标识符 %q 超过最大长度 %d", s, maxIdentifierLength)
+	}
+	if !identifierRegex.MatchString(s) {
+		return "", errors.Errorf("标识符 %q 不合法：只允许字母、数字和下划线，且不能以数字开头", s)
+	}
+	return s, nil
+}
+
+var byteSizeRegex = regexp.MustCompile(`(?i)^\s*(\d+)\s*(b|kb|k|mb|m|gb|g)?\s*$`)
+
+// ParseByteSize 解析 256MB、1g、4096 这类大小，单位按 1024 进制，无单位时为字节
+func ParseByteSize(s string) (int64, error) {
+	m := byteSizeRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, errors.Errorf("大小 %q 不合法，示例: 512KB、256MB、1GB", s)
+	}
+	n, err := cast.ToInt64E(m[1])
+	if err != nil {
+		return 0, errors.Wrapf(err, "大小 %q 不合法", s)
+	}
+	switch strings.ToLower(m[2]) {
+	case "kb", "k":
+		n <<= 10
+	case "mb", "m":
+		n <<= 20
+	case "gb", "g":
+		n <<= 30
+	}
+	return n, nil
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSanitizeIdentifier 只接受字母、数字和下划线组成、不以数字开头且不超长的标识符，
+// 带引号、分号、注释、空白等的注入尝试都被拒绝
+func TestSanitizeIdentifier(t *testing.T) {
+	for _, s := range []string{"processed_content", "_tmp", "T2024_01", strings.Repeat("a", maxIdentifierLength)} {
+		if got, err := SanitizeIdentifier(s); err != nil || got != s {
+			t.Errorf("%q 应合法，得到 %q, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{
+		"",
+		"1table",
+		strings.Repeat("a", maxIdentifierLength+1),
+		"t; DROP TABLE tbl_verify_content",
+		"t;--",
+		"t--",
+		"t/*x*/",
+		`t"`,
+		`"t"`,
+		"t'",
+		"t' OR '1'='1",
+		"t`",
+		"t x",
+		"t\n",
+		"t.u",
+		"表",
+		"t\x00",
+	} {
+		if _, err := SanitizeIdentifier(s); err == nil {
+			t.Errorf("%q 应被拒绝", s)
+		}
+	}
+}